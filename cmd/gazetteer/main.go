@@ -0,0 +1,120 @@
+package main
+
+import (
+	"archive/zip"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/config"
+	"github.com/hackclub/geocoder/internal/database"
+	"github.com/hackclub/geocoder/internal/gazetteer"
+)
+
+func main() {
+	var (
+		placesPath    = flag.String("places", "", "GeoNames places dump, e.g. cities500.zip or allCountries.zip")
+		admin1Path    = flag.String("admin1", "", "GeoNames admin1CodesASCII.txt")
+		postalPath    = flag.String("postal", "", "GeoNames postal code dump, e.g. zip/allCountries.zip or zip/US.zip")
+		namesPath     = flag.String("alternate-names", "", "GeoNames alternateNamesV2.zip, for city names in other languages")
+		minPopulation = flag.Int64("min-population", 0, "Skip places with fewer inhabitants")
+	)
+	flag.Parse()
+
+	if *placesPath == "" && *admin1Path == "" && *postalPath == "" && *namesPath == "" {
+		log.Fatal("Usage: go run cmd/gazetteer/main.go [-places file] [-admin1 file] [-postal file] [-alternate-names file] [-min-population n]")
+	}
+
+	cfg := config.Load()
+
+	db, err := database.New(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	importer := gazetteer.NewImporter(db.GetDB())
+
+	if *admin1Path != "" {
+		run("admin1 divisions", *admin1Path, importer.ImportAdmin1)
+	}
+	if *placesPath != "" {
+		run("places", *placesPath, func(r io.Reader) (int, error) {
+			return importer.ImportPlaces(r, *minPopulation)
+		})
+	}
+	// Importing places clears their localized names, so these are loaded afterwards
+	if *namesPath != "" {
+		run("localized names", *namesPath, importer.ImportAlternateNames)
+	}
+	if *postalPath != "" {
+		run("postal codes", *postalPath, importer.ImportPostalCodes)
+	}
+}
+
+func run(what, filePath string, importFile func(io.Reader) (int, error)) {
+	r, err := open(filePath)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", filePath, err)
+	}
+	defer r.Close()
+
+	log.Printf("Importing %s from %s...", what, filePath)
+	count, err := importFile(r)
+	if err != nil {
+		log.Fatalf("Failed to import %s: %v", what, err)
+	}
+	log.Printf("Imported %d %s", count, what)
+}
+
+// open reads a dump either as is or, as GeoNames distributes most of them, from inside a zip archive
+func open(filePath string) (io.ReadCloser, error) {
+	if !strings.HasSuffix(strings.ToLower(filePath), ".zip") {
+		return os.Open(filePath)
+	}
+
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	// Archives are named after the dump inside them; alternateNamesV2.zip also holds iso-languagecodes.txt
+	var dump *zip.File
+	for _, file := range archive.File {
+		if !strings.HasSuffix(file.Name, ".txt") || strings.EqualFold(path.Base(file.Name), "readme.txt") {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSuffix(path.Base(file.Name), ".txt"), strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))) {
+			dump = file
+			break
+		}
+		if dump == nil {
+			dump = file
+		}
+	}
+	if dump == nil {
+		archive.Close()
+		return nil, fmt.Errorf("no .txt file in %s", filePath)
+	}
+
+	r, err := dump.Open()
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return &zipEntry{ReadCloser: r, archive: archive}, nil
+}
+
+// zipEntry closes the archive along with the entry read from it
+type zipEntry struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+func (z *zipEntry) Close() error {
+	z.ReadCloser.Close()
+	return z.archive.Close()
+}
@@ -19,6 +19,8 @@ import (
 	"github.com/hackclub/geocoder/internal/geoip"
 	"github.com/hackclub/geocoder/internal/middleware"
 	"github.com/hackclub/geocoder/internal/migrations"
+	"github.com/hackclub/geocoder/internal/offline"
+	"github.com/hackclub/geocoder/internal/redact"
 )
 
 func main() {
@@ -54,28 +56,101 @@ func main() {
 
 	// Initialize cache service
 	cacheService := cache.NewService(db, cfg.MaxAddressCacheSize, cfg.MaxIPCacheSize)
+	cacheService.SetPlaceCacheTTL(time.Duration(cfg.PlaceCacheTTLHours) * time.Hour)
 
 	// Initialize handlers
 	handlers := api.NewHandlers(db, geocodeClient, geoipClient, cacheService)
 
+	// Country lookups work from embedded boundaries, which a more detailed file can replace; state and
+	// city lookups need data files
+	if cfg.OfflineCountriesPath != "" || cfg.OfflineAdmin1Path != "" || cfg.OfflineCitiesPath != "" {
+		offlineGeocoder, err := offline.New(cfg.OfflineCountriesPath, cfg.OfflineAdmin1Path, cfg.OfflineCitiesPath)
+		if err != nil {
+			log.Fatalf("Failed to load offline geocoding data: %v", err)
+		}
+		handlers.SetOfflineGeocoder(offlineGeocoder)
+	}
+
+	// Redact query texts and client IPs in the activity log and dashboard
+	logRedaction, err := redact.ParseMode(cfg.ActivityLogRedaction)
+	if err != nil {
+		log.Fatalf("Invalid ACTIVITY_LOG_REDACTION: %v", err)
+	}
+	broadcastRedaction, err := redact.ParseMode(cfg.ActivityBroadcastRedaction)
+	if err != nil {
+		log.Fatalf("Invalid ACTIVITY_BROADCAST_REDACTION: %v", err)
+	}
+	if cfg.ActivityLogHashSecret == "" && (logRedaction == redact.Hashed || broadcastRedaction == redact.Hashed) {
+		log.Println("ACTIVITY_LOG_HASH_SECRET is not set: hashed activity can't be erased after a restart")
+	}
+	handlers.SetActivityRedaction(
+		redact.New(logRedaction, cfg.ActivityLogHashSecret),
+		redact.New(broadcastRedaction, cfg.ActivityLogHashSecret),
+	)
+
+	// Delete activity past its retention period
+	if cfg.ActivityLogRetentionHours > 0 {
+		retention := time.Duration(cfg.ActivityLogRetentionHours) * time.Hour
+		go func() {
+			ticker := time.NewTicker(5 * time.Minute)
+			defer ticker.Stop()
+			for ; ; <-ticker.C {
+				if _, err := db.DeleteActivityBefore(time.Now().Add(-retention)); err != nil {
+					log.Printf("Failed to prune activity log: %v", err)
+				}
+			}
+		}()
+	}
+
 	// Initialize rate limiter
 	rateLimiter := middleware.NewRateLimiter()
 	rateLimiter.Cleanup() // Start cleanup goroutine
 
+	// Only proxies in this list may set the caller's address through forwarding headers
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// Set up routes
 	router := mux.NewRouter()
 
 	// Apply CORS middleware to all routes
 	router.Use(middleware.CORS())
 
+	// Resolve the caller's IP address once for logging and /v1/geoip/me
+	router.Use(middleware.ClientIP(trustedProxies))
+
 	// API v1 routes (with authentication and rate limiting)
 	v1 := router.PathPrefix("/v1").Subrouter()
 	v1.Use(middleware.APIKeyAuth(db))
 	v1.Use(rateLimiter.RateLimit())
-	v1.HandleFunc("/geocode", handlers.HandleGeocode).Methods("GET")
-	v1.HandleFunc("/geocode_structured", handlers.HandleGeocodeStructured).Methods("GET")
-	v1.HandleFunc("/reverse_geocode", handlers.HandleReverseGeocode).Methods("GET")
-	v1.HandleFunc("/geoip", handlers.HandleGeoIP).Methods("GET")
+	v1.HandleFunc("/geocode", handlers.HandleGeocode).Methods("GET", "POST")
+	v1.HandleFunc("/geocode_structured", handlers.HandleGeocodeStructured).Methods("GET", "POST")
+	v1.HandleFunc("/reverse_geocode", handlers.HandleReverseGeocode).Methods("GET", "POST")
+	v1.HandleFunc("/geoip", handlers.HandleGeoIP).Methods("GET", "POST")
+	v1.HandleFunc("/geoip/me", handlers.HandleGeoIPMe).Methods("GET", "POST")
+	v1.HandleFunc("/asn/{asn}", handlers.HandleASN).Methods("GET")
+	v1.HandleFunc("/place/{place_id}", handlers.HandlePlace).Methods("GET")
+	v1.HandleFunc("/consistency", handlers.HandleConsistency).Methods("GET", "POST")
+	v1.HandleFunc("/geofences", handlers.HandleGeofences).Methods("GET", "POST")
+	v1.HandleFunc("/geofences/{id}", handlers.HandleGeofence).Methods("GET", "PUT", "DELETE")
+	v1.HandleFunc("/geofence/check", handlers.HandleGeofenceCheck).Methods("GET", "POST")
+	v1.HandleFunc("/collections", handlers.HandleCollections).Methods("GET", "POST")
+	v1.HandleFunc("/collections/{id}", handlers.HandleCollection).Methods("GET", "DELETE")
+	v1.HandleFunc("/collections/{id}/places", handlers.HandleCollectionPlaces).Methods("GET", "POST")
+	v1.HandleFunc("/collections/{id}/places/{place_id}", handlers.HandleCollectionPlace).Methods("DELETE")
+	v1.HandleFunc("/collections/{id}/import", handlers.HandleCollectionImport).Methods("POST")
+	v1.HandleFunc("/collections/{id}/nearby", handlers.HandleCollectionNearby).Methods("GET", "POST")
+	v1.HandleFunc("/validate_address", handlers.HandleValidateAddress).Methods("GET", "POST")
+	v1.HandleFunc("/countries", handlers.HandleCountries).Methods("GET")
+	v1.HandleFunc("/countries/{code}/subdivisions", handlers.HandleCountrySubdivisions).Methods("GET")
+	v1.HandleFunc("/postal_code", handlers.HandlePostalCode).Methods("GET", "POST")
+	v1.HandleFunc("/parse_address", handlers.HandleParseAddress).Methods("GET", "POST")
+	v1.HandleFunc("/format_address", handlers.HandleFormatAddress).Methods("GET", "POST")
+	v1.HandleFunc("/encode", handlers.HandleEncode).Methods("GET", "POST")
+	v1.HandleFunc("/decode", handlers.HandleDecode).Methods("GET", "POST")
+	v1.HandleFunc("/convert", handlers.HandleConvert).Methods("GET", "POST")
 
 	// Admin routes (with basic auth)
 	admin := router.PathPrefix("/admin").Subrouter()
@@ -83,10 +158,19 @@ func main() {
 	admin.HandleFunc("/dashboard", handlers.HandleAdminDashboard).Methods("GET")
 	admin.HandleFunc("/keys", handlers.HandleAdminKeys).Methods("GET", "POST")
 	admin.HandleFunc("/keys/{key_id}/rate-limit", handlers.HandleUpdateAPIKeyRateLimit).Methods("PUT")
+	admin.HandleFunc("/keys/{key_id}/auth", handlers.HandleUpdateAPIKeyAuth).Methods("PUT")
+	admin.HandleFunc("/keys/{key_id}/cache", handlers.HandleUpdateAPIKeyCache).Methods("PUT")
+	admin.HandleFunc("/keys/{key_id}/privacy", handlers.HandleUpdateAPIKeyPrivacy).Methods("PUT")
 	admin.HandleFunc("/keys/{key_id}", handlers.HandleDeactivateAPIKey).Methods("DELETE")
+	admin.HandleFunc("/keys/{key_id}/geofences", handlers.HandleAdminKeyGeofences).Methods("POST")
+	admin.HandleFunc("/geofences", handlers.HandleAdminGeofences).Methods("GET")
+	admin.HandleFunc("/geofences/{id}", handlers.HandleAdminGeofence).Methods("GET", "PUT", "DELETE")
+	admin.HandleFunc("/overrides", handlers.HandleAdminOverrides).Methods("GET", "POST")
+	admin.HandleFunc("/overrides/{id}", handlers.HandleAdminOverride).Methods("GET", "PUT", "DELETE")
 	admin.HandleFunc("/stats", handlers.HandleAdminStats).Methods("GET")
 	admin.HandleFunc("/activity", handlers.HandleAdminActivity).Methods("GET")
 	admin.HandleFunc("/usage-summary", handlers.HandleUsageSummary).Methods("GET")
+	admin.HandleFunc("/erase", handlers.HandleAdminErase).Methods("POST")
 	admin.HandleFunc("/ws", handlers.HandleWebSocket)
 
 	// Redirect /admin to /admin/dashboard
@@ -4,14 +4,18 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/hackclub/geocoder/internal/addressformat"
 	"github.com/hackclub/geocoder/internal/api"
 	"github.com/hackclub/geocoder/internal/cache"
 	"github.com/hackclub/geocoder/internal/database"
@@ -218,6 +222,175 @@ func TestIntegration_GeocodeStructuredEndpoint(t *testing.T) {
 	}
 }
 
+// Test the address validation endpoint
+func TestIntegration_ValidateAddressEndpoint(t *testing.T) {
+	db := &mockIntegrationDB{}
+	db.init()
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+	handlers := api.NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	rateLimiter := middleware.NewRateLimiter()
+
+	// Create API key for testing
+	testAPIKey := "test_live_sk_123456789"
+	keyHash := database.HashAPIKey(testAPIKey)
+	testKey := &models.APIKey{
+		ID:                 "test-key-id",
+		KeyHash:            keyHash,
+		Name:               "Test Key",
+		IsActive:           true,
+		RateLimitPerSecond: 10,
+	}
+	db.apiKeys[keyHash] = testKey
+
+	router := mux.NewRouter()
+	v1 := router.PathPrefix("/v1").Subrouter()
+	v1.Use(middleware.APIKeyAuth(db))
+	v1.Use(rateLimiter.RateLimit())
+	v1.HandleFunc("/validate_address", handlers.HandleValidateAddress).Methods("GET")
+
+	// Test empty address (all fields empty)
+	req := httptest.NewRequest("GET", "/v1/validate_address?key="+testAPIKey, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for empty address, got %d", w.Code)
+	}
+
+	// Test valid structured address (will fail because geocoding client not configured)
+	req = httptest.NewRequest("GET", "/v1/validate_address?address_line_1=123+Main+St&city=San+Francisco&state=CA&country=USA&key="+testAPIKey, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 for unconfigured geocoding client, got %d", w.Code)
+	}
+
+	// Test cached geocoding result is validated without an external call
+	cachedAddress := models.StructuredAddress{AddressLine1: "1600 Amphitheatre Parkway", City: "Mountain View", Country: "US"}
+	_ = cacheService.SetStandardGeocodeResult(addressformat.Query(cachedAddress), &models.GeocodeAPIResponse{
+		Lat:     37.4224764,
+		Lng:     -122.0842499,
+		Backend: "google_maps_platform_geocoding",
+		RawBackendResponse: &geocoding.GeocodeResponse{
+			Status: "OK",
+			Results: []geocoding.GeocodeResult{{
+				Geometry: geocoding.GeocodeGeometry{LocationType: "ROOFTOP"},
+				Types:    []string{"street_address"},
+				AddressComponents: []geocoding.AddressComponent{
+					{LongName: "1600", ShortName: "1600", Types: []string{"street_number"}},
+					{LongName: "Amphitheatre Parkway", ShortName: "Amphitheatre Pkwy", Types: []string{"route"}},
+					{LongName: "Mountain View", ShortName: "Mountain View", Types: []string{"locality"}},
+					{LongName: "United States", ShortName: "US", Types: []string{"country"}},
+				},
+			}},
+		},
+	})
+
+	req = httptest.NewRequest("GET", "/v1/validate_address?address_line_1=1600+Amphitheatre+Parkway&city=Mountain+View&country=US&key="+testAPIKey, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for cached address, got %d", w.Code)
+	}
+
+	var validation models.AddressValidationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &validation); err != nil {
+		t.Fatalf("Failed to parse validation response: %v", err)
+	}
+
+	if !validation.Valid || validation.Granularity != "rooftop" {
+		t.Errorf("Expected valid rooftop verdict, got valid=%v granularity=%s", validation.Valid, validation.Granularity)
+	}
+}
+
+// Test GeoJSON, CSV and gzip output on a cached geocoding result
+func TestIntegration_OutputFormats(t *testing.T) {
+	db := &mockIntegrationDB{}
+	db.init()
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+	handlers := api.NewHandlers(db, geocodeClient, geoipClient, cacheService)
+
+	testAPIKey := "test_live_sk_123456789"
+	keyHash := database.HashAPIKey(testAPIKey)
+	db.apiKeys[keyHash] = &models.APIKey{
+		ID:                 "test-key-id",
+		KeyHash:            keyHash,
+		Name:               "Test Key",
+		IsActive:           true,
+		RateLimitPerSecond: 10,
+	}
+
+	router := mux.NewRouter()
+	v1 := router.PathPrefix("/v1").Subrouter()
+	v1.Use(middleware.APIKeyAuth(db))
+	v1.HandleFunc("/geocode", handlers.HandleGeocode).Methods("GET", "POST")
+
+	_ = cacheService.SetStandardGeocodeResult("1600 Amphitheatre Parkway", &models.GeocodeAPIResponse{
+		Lat:              37.4224764,
+		Lng:              -122.0842499,
+		FormattedAddress: "1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA",
+		Backend:          "google_maps_platform_geocoding",
+	})
+
+	// Test GeoJSON via the Accept header, gzip-compressed
+	req := httptest.NewRequest("GET", "/v1/geocode?address=1600+Amphitheatre+Parkway&key="+testAPIKey, nil)
+	req.Header.Set("Accept", "application/geo+json")
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/geo+json" {
+		t.Errorf("Expected Content-Type application/geo+json, got %s", contentType)
+	}
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("Expected gzip Content-Encoding")
+	}
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Failed to open gzip body: %v", err)
+	}
+	var feature struct {
+		Type     string `json:"type"`
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+	}
+	if err := json.NewDecoder(gz).Decode(&feature); err != nil {
+		t.Fatalf("Failed to parse GeoJSON response: %v", err)
+	}
+	if feature.Type != "Feature" || len(feature.Geometry.Coordinates) != 2 || feature.Geometry.Coordinates[0] != -122.0842499 {
+		t.Errorf("Expected a Point feature at [lng, lat], got %+v", feature)
+	}
+
+	// Test CSV via the format parameter in a JSON body
+	body := bytes.NewBufferString(`{"address": "1600 Amphitheatre Parkway", "format": "csv"}`)
+	req = httptest.NewRequest("POST", "/v1/geocode?key="+testAPIKey, body)
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Expected CSV Content-Type, got %s", w.Header().Get("Content-Type"))
+	}
+	header := strings.SplitN(w.Body.String(), "\n", 2)[0]
+	if !strings.HasPrefix(header, "lat,lng,formatted_address,") || !strings.Contains(header, ",admin_hierarchy.city,") {
+		t.Errorf("Expected CSV header row with flattened columns, got %q", header)
+	}
+}
+
 // Test IP geolocation endpoint
 func TestIntegration_GeoIPEndpoint(t *testing.T) {
 	db := &mockIntegrationDB{}
@@ -285,6 +458,83 @@ func TestIntegration_GeoIPEndpoint(t *testing.T) {
 	}
 }
 
+// Test caller IP geolocation behind a trusted proxy
+func TestIntegration_GeoIPMeEndpoint(t *testing.T) {
+	db := &mockIntegrationDB{}
+	db.init()
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+	handlers := api.NewHandlers(db, geocodeClient, geoipClient, cacheService)
+
+	testAPIKey := "test_live_sk_123456789"
+	keyHash := database.HashAPIKey(testAPIKey)
+	db.apiKeys[keyHash] = &models.APIKey{
+		ID:                 "test-key-id",
+		KeyHash:            keyHash,
+		Name:               "Test Key",
+		IsActive:           true,
+		RateLimitPerSecond: 10,
+	}
+
+	trustedProxies, err := middleware.ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.Use(middleware.ClientIP(trustedProxies))
+	v1 := router.PathPrefix("/v1").Subrouter()
+	v1.Use(middleware.APIKeyAuth(db))
+	v1.HandleFunc("/geoip/me", handlers.HandleGeoIPMe).Methods("GET", "POST")
+
+	// Seed the cache so no external call is made. Documentation ranges such as 198.51.100.0/24 would be
+	// answered as bogons without reaching it.
+	_ = cacheService.SetStandardIPResult("73.12.34.56", &models.GeoIPAPIResponse{
+		IP:          "73.12.34.56",
+		City:        "Burlington",
+		Region:      "Vermont",
+		CountryCode: "US",
+		Backend:     "ipinfo_api",
+	})
+
+	// The load balancer's own address is replaced by the visitor's
+	req := httptest.NewRequest("GET", "/v1/geoip/me?key="+testAPIKey, nil)
+	req.RemoteAddr = "10.0.0.5:43210"
+	req.Header.Set("X-Forwarded-For", "203.0.113.99, 73.12.34.56")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response models.GeoIPAPIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse IP response: %v", err)
+	}
+	if response.IP != "73.12.34.56" || response.City != "Burlington" {
+		t.Errorf("Expected the forwarded visitor's location, got %+v", response)
+	}
+
+	// Forwarding headers from an untrusted peer are ignored
+	req = httptest.NewRequest("GET", "/v1/geoip/me?key="+testAPIKey, nil)
+	req.RemoteAddr = "73.12.34.56:43210"
+	req.Header.Set("X-Forwarded-For", "8.8.8.8")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse IP response: %v", err)
+	}
+	if response.IP != "73.12.34.56" {
+		t.Errorf("Expected the connecting address, got %s", response.IP)
+	}
+}
+
 // Test reverse geocoding endpoint  
 func TestIntegration_ReverseGeocodeEndpoint(t *testing.T) {
 	db := &mockIntegrationDB{}
@@ -638,7 +888,7 @@ func TestIntegration_CORS(t *testing.T) {
 	expectedHeaders := map[string]string{
 		"Access-Control-Allow-Origin":  "*",
 		"Access-Control-Allow-Methods": "GET, POST, PUT, DELETE, OPTIONS",
-		"Access-Control-Allow-Headers": "Content-Type, Authorization",
+		"Access-Control-Allow-Headers": "Content-Type, Authorization, X-API-Key",
 	}
 
 	for header, expected := range expectedHeaders {
@@ -791,6 +1041,39 @@ func (m *mockIntegrationDB) UpdateAPIKeyRateLimit(keyID string, rateLimitPerSeco
 	return fmt.Errorf("key not found")
 }
 
+func (m *mockIntegrationDB) UpdateAPIKeyRequireHeaderAuth(keyID string, requireHeaderAuth bool) error {
+	m.init()
+	for _, key := range m.apiKeys {
+		if key.ID == keyID {
+			key.RequireHeaderAuth = requireHeaderAuth
+			return nil
+		}
+	}
+	return fmt.Errorf("key not found")
+}
+
+func (m *mockIntegrationDB) UpdateAPIKeyCacheControl(keyID string, allowCacheControl bool) error {
+	m.init()
+	for _, key := range m.apiKeys {
+		if key.ID == keyID {
+			key.AllowCacheControl = allowCacheControl
+			return nil
+		}
+	}
+	return fmt.Errorf("key not found")
+}
+
+func (m *mockIntegrationDB) UpdateAPIKeyPrivacyPolicy(keyID string, policy models.PrivacyPolicy) error {
+	m.init()
+	for _, key := range m.apiKeys {
+		if key.ID == keyID {
+			key.Privacy = policy
+			return nil
+		}
+	}
+	return fmt.Errorf("key not found")
+}
+
 func (m *mockIntegrationDB) DeactivateAPIKey(keyID string) error {
 	m.init()
 	for _, key := range m.apiKeys {
@@ -892,6 +1175,111 @@ func (m *mockIntegrationDB) UpdateCostTracking(date time.Time, geocodeRequests,
 	return nil
 }
 
+func (m *mockIntegrationDB) UpdateOfflineCostTracking(date time.Time, offlineRequests int) error {
+	return nil
+}
+
+func (m *mockIntegrationDB) GetASNCache(asn int) (*models.ASNCache, error) {
+	return nil, fmt.Errorf("cache not found")
+}
+
+func (m *mockIntegrationDB) SetASNCache(asn int, responseData string) error {
+	return nil
+}
+
+func (m *mockIntegrationDB) GetPlaceCache(placeID, language string, maxAge time.Duration) (*models.PlaceCache, error) {
+	return nil, fmt.Errorf("cache not found")
+}
+
+func (m *mockIntegrationDB) SetPlaceCache(placeID, language, responseData string, maxAge time.Duration) error {
+	return nil
+}
+
+func (m *mockIntegrationDB) FindGazetteerPlaces(name, countryCode string, limit int) ([]models.GazetteerPlace, error) {
+	return nil, nil
+}
+
+func (m *mockIntegrationDB) FindGazetteerPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error) {
+	return nil, nil
+}
+
+func (m *mockIntegrationDB) FindGazetteerLocalizedName(geonameID int64, name, countryCode string, languages []string) (string, error) {
+	return "", nil
+}
+
+func (m *mockIntegrationDB) CreateGeofence(apiKeyID, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	return &models.Geofence{ID: "fence-id", APIKeyID: apiKeyID, Collection: collection, Name: name, Geometry: geometry, RadiusM: radiusM, Properties: properties}, nil
+}
+
+func (m *mockIntegrationDB) GetGeofences(apiKeyID, collection string) ([]models.Geofence, error) {
+	return []models.Geofence{}, nil
+}
+
+func (m *mockIntegrationDB) GetGeofence(id string) (*models.Geofence, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockIntegrationDB) UpdateGeofence(id, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockIntegrationDB) DeleteGeofence(id string) error {
+	return sql.ErrNoRows
+}
+
+func (m *mockIntegrationDB) CreateGeocodeOverride(override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	override.ID = "override-id"
+	return &override, nil
+}
+
+func (m *mockIntegrationDB) GetGeocodeOverrides() ([]models.GeocodeOverride, error) {
+	return []models.GeocodeOverride{}, nil
+}
+
+func (m *mockIntegrationDB) GetGeocodeOverride(id string) (*models.GeocodeOverride, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockIntegrationDB) UpdateGeocodeOverride(id string, override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockIntegrationDB) DeleteGeocodeOverride(id string) error {
+	return sql.ErrNoRows
+}
+
+func (m *mockIntegrationDB) CreatePlaceCollection(apiKeyID, name string) (*models.PlaceCollection, error) {
+	return &models.PlaceCollection{ID: "collection-id", APIKeyID: apiKeyID, Name: name}, nil
+}
+
+func (m *mockIntegrationDB) GetPlaceCollections(apiKeyID string) ([]models.PlaceCollection, error) {
+	return []models.PlaceCollection{}, nil
+}
+
+func (m *mockIntegrationDB) GetPlaceCollection(id string) (*models.PlaceCollection, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockIntegrationDB) DeletePlaceCollection(id string) error {
+	return sql.ErrNoRows
+}
+
+func (m *mockIntegrationDB) AddCollectionPlaces(collectionID string, places []models.CollectionPlace) ([]models.CollectionPlace, error) {
+	return places, nil
+}
+
+func (m *mockIntegrationDB) GetCollectionPlaces(collectionID string) ([]models.CollectionPlace, error) {
+	return []models.CollectionPlace{}, nil
+}
+
+func (m *mockIntegrationDB) DeleteCollectionPlace(collectionID, placeID string) error {
+	return sql.ErrNoRows
+}
+
+func (m *mockIntegrationDB) FindCollectionPlacesInBox(collectionID string, minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error) {
+	return []models.CollectionPlace{}, nil
+}
+
 func (m *mockIntegrationDB) LogActivity(apiKeyName, endpoint, queryText string, resultCount, responseTimeMs int, apiSource string, cacheHit bool, ipAddress, userAgent string) error {
 	return nil
 }
@@ -900,6 +1288,32 @@ func (m *mockIntegrationDB) GetRecentActivity() ([]models.ActivityLog, error) {
 	return []models.ActivityLog{}, nil
 }
 
+func (m *mockIntegrationDB) DeleteActivityBefore(cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockIntegrationDB) DeleteActivityMatching(values []string) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockIntegrationDB) DeleteAddressCache(queryHash, queryText string) (int64, error) {
+	m.init()
+	if _, ok := m.addressCache[queryHash]; ok {
+		delete(m.addressCache, queryHash)
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m *mockIntegrationDB) DeleteIPCache(ipAddress string) (int64, error) {
+	m.init()
+	if _, ok := m.ipCache[ipAddress]; ok {
+		delete(m.ipCache, ipAddress)
+		return 1, nil
+	}
+	return 0, nil
+}
+
 func (m *mockIntegrationDB) GetAPIKeyUsageSummary(page, pageSize int) (*models.UsageSummaryResponse, error) {
 	return &models.UsageSummaryResponse{
 		APIKeys:    []models.APIKeyUsageSummary{},
@@ -0,0 +1,188 @@
+// Package addressformat lays out structured addresses the way each country writes them, with per-country
+// templates modelled on OpenCage's address-formatting data.
+package addressformat
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/hackclub/geocoder/internal/iso3166"
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/postalcode"
+)
+
+// template is a country's address layout. Lines name fields as {address_line_1}, {address_line_2},
+// {city}, {state}, {postal_code} and {country}; lines whose fields are all empty are dropped.
+type template struct {
+	lines []string
+	// native is the layout used when the address is written in the country's own script, e.g. Japanese
+	// addresses run from the prefecture down to the building
+	native []string
+	// stateCode writes states as their ISO 3166-2 code, e.g. "IL" rather than "Illinois"
+	stateCode bool
+}
+
+var (
+	// defaultTemplate is used for countries without their own template, and when the country is unknown
+	defaultTemplate = template{lines: []string{"{address_line_1}", "{address_line_2}", "{city}, {state} {postal_code}", "{country}"}}
+
+	usTemplate      = template{lines: []string{"{address_line_1}", "{address_line_2}", "{city}, {state} {postal_code}", "{country}"}, stateCode: true}
+	caTemplate      = template{lines: []string{"{address_line_1}", "{address_line_2}", "{city} {state} {postal_code}", "{country}"}, stateCode: true}
+	ukTemplate      = template{lines: []string{"{address_line_1}", "{address_line_2}", "{city}", "{postal_code}", "{country}"}}
+	irelandTemplate = template{lines: []string{"{address_line_1}", "{address_line_2}", "{city}", "{state}", "{postal_code}", "{country}"}}
+	// europeTemplate puts the postal code before the city, as most of continental Europe does
+	europeTemplate = template{lines: []string{"{address_line_1}", "{address_line_2}", "{postal_code} {city}", "{country}"}}
+	italyTemplate  = template{lines: []string{"{address_line_1}", "{address_line_2}", "{postal_code} {city} {state}", "{country}"}}
+	brazilTemplate = template{lines: []string{"{address_line_1}", "{address_line_2}", "{city} - {state}", "{postal_code}", "{country}"}, stateCode: true}
+	mexicoTemplate = template{lines: []string{"{address_line_1}", "{address_line_2}", "{postal_code} {city}, {state}", "{country}"}}
+	russiaTemplate = template{lines: []string{"{address_line_1}", "{address_line_2}", "{city}", "{state}", "{postal_code}", "{country}"}}
+	indiaTemplate  = template{lines: []string{"{address_line_1}", "{address_line_2}", "{city} - {postal_code}", "{state}", "{country}"}}
+	japanTemplate  = template{
+		lines:  []string{"{address_line_1}", "{address_line_2}", "{city}, {state} {postal_code}", "{country}"},
+		native: []string{"〒{postal_code}", "{state}{city}{address_line_1}", "{address_line_2}"},
+	}
+	chinaTemplate = template{
+		lines:  []string{"{address_line_1}", "{address_line_2}", "{city}, {state} {postal_code}", "{country}"},
+		native: []string{"{postal_code}", "{state}{city}{address_line_1}", "{address_line_2}"},
+	}
+	koreaTemplate = template{
+		lines:  []string{"{address_line_1}", "{address_line_2}", "{city}, {state} {postal_code}", "{country}"},
+		native: []string{"{state} {city} {address_line_1}", "{address_line_2}", "{postal_code}"},
+	}
+)
+
+// templates maps ISO 3166-1 alpha-2 codes to their layouts
+var templates = map[string]template{
+	"US": usTemplate, "PR": usTemplate, "GU": usTemplate, "VI": usTemplate, "AS": usTemplate, "MP": usTemplate,
+	"CA": caTemplate, "AU": caTemplate,
+	"GB": ukTemplate, "IM": ukTemplate, "JE": ukTemplate, "GG": ukTemplate, "NZ": ukTemplate,
+	"IE": irelandTemplate,
+	"AD": europeTemplate, "AT": europeTemplate, "BE": europeTemplate, "BG": europeTemplate, "CH": europeTemplate,
+	"CY": europeTemplate, "CZ": europeTemplate, "DE": europeTemplate, "DK": europeTemplate, "EE": europeTemplate,
+	"ES": europeTemplate, "FI": europeTemplate, "FR": europeTemplate, "GR": europeTemplate, "HR": europeTemplate,
+	"HU": europeTemplate, "IS": europeTemplate, "LI": europeTemplate, "LT": europeTemplate, "LU": europeTemplate,
+	"LV": europeTemplate, "MC": europeTemplate, "MT": europeTemplate, "NL": europeTemplate, "NO": europeTemplate,
+	"PL": europeTemplate, "PT": europeTemplate, "RO": europeTemplate, "RS": europeTemplate, "SE": europeTemplate,
+	"SI": europeTemplate, "SK": europeTemplate, "SM": europeTemplate, "VA": europeTemplate, "AR": europeTemplate,
+	"IL": europeTemplate, "TR": europeTemplate,
+	"IT": italyTemplate,
+	"BR": brazilTemplate,
+	"MX": mexicoTemplate,
+	"RU": russiaTemplate, "UA": russiaTemplate, "BY": russiaTemplate, "KZ": russiaTemplate,
+	"IN": indiaTemplate,
+	"JP": japanTemplate,
+	"CN": chinaTemplate, "TW": chinaTemplate,
+	"KR": koreaTemplate,
+}
+
+var fieldPattern = regexp.MustCompile(`\{(address_line_1|address_line_2|city|state|postal_code|country)\}`)
+
+// Lines lays out an address for a postal label, one element per line. The country is written in
+// capitals, as the UPU recommends for international mail, and is left off addresses in the country's
+// own script, which are domestic.
+func Lines(address models.StructuredAddress) []string {
+	countryLine := strings.ToUpper(address.Country)
+	if country, ok := iso3166.FindCountryInAddress(address.Country); ok {
+		countryLine = strings.ToUpper(country.Name)
+	}
+	return render(address, countryLine)
+}
+
+// Label returns the address laid out for a postal label, with lines separated by newlines
+func Label(address models.StructuredAddress) string {
+	return strings.Join(Lines(address), "\n")
+}
+
+// Query returns the address as a single comma-separated line in the country's order, for geocoding and
+// as the cache key. The country is kept as the caller wrote it.
+func Query(address models.StructuredAddress) string {
+	lines := render(address, address.Country)
+	// Native-script layouts leave the country off, but geocoding still needs it
+	if country := clean(address.Country); country != "" && (len(lines) == 0 || lines[len(lines)-1] != country) {
+		lines = append(lines, country)
+	}
+	return strings.Join(lines, ", ")
+}
+
+// CountryCode returns the ISO 3166-1 alpha-2 code of the address's country, or "" if it isn't recognized
+func CountryCode(address models.StructuredAddress) string {
+	country, ok := iso3166.FindCountryInAddress(address.Country)
+	if !ok {
+		return ""
+	}
+	return country.Alpha2
+}
+
+func render(address models.StructuredAddress, countryLine string) []string {
+	countryCode := CountryCode(address)
+	tmpl, ok := templates[countryCode]
+	if !ok {
+		tmpl = defaultTemplate
+	}
+
+	lines := tmpl.lines
+	if tmpl.native != nil && isNativeScript(address) {
+		lines = tmpl.native
+	}
+
+	fields := map[string]string{
+		"address_line_1": clean(address.AddressLine1),
+		"address_line_2": clean(address.AddressLine2),
+		"city":           clean(address.City),
+		"state":          clean(address.State),
+		"postal_code":    clean(address.PostalCode),
+		"country":        clean(countryLine),
+	}
+	if countryCode != "" {
+		if tmpl.stateCode {
+			if subdivision, ok := iso3166.FindSubdivision(countryCode, fields["state"]); ok {
+				fields["state"] = strings.TrimPrefix(subdivision.Code, subdivision.CountryCode+"-")
+			}
+		}
+		if code, err := postalcode.Normalize(countryCode, fields["postal_code"]); err == nil {
+			fields["postal_code"] = code
+		}
+	}
+
+	var result []string
+	for _, line := range lines {
+		filled := false
+		rendered := fieldPattern.ReplaceAllStringFunc(line, func(field string) string {
+			value := fields[strings.Trim(field, "{}")]
+			if value != "" {
+				filled = true
+			}
+			return value
+		})
+		if rendered = tidy(rendered); filled && rendered != "" {
+			result = append(result, rendered)
+		}
+	}
+	return result
+}
+
+// tidy cleans up the separators left around empty fields, e.g. "Springfield,  62701" or ", IL 62701"
+func tidy(line string) string {
+	line = strings.Join(strings.Fields(line), " ")
+	line = strings.ReplaceAll(line, " ,", ",")
+	line = strings.ReplaceAll(line, ",,", ",")
+	line = strings.ReplaceAll(line, "- -", "-")
+	return strings.Trim(line, " ,-")
+}
+
+func clean(value string) string {
+	return strings.Trim(strings.Join(strings.Fields(value), " "), ",")
+}
+
+// isNativeScript reports whether an address is written in Chinese, Japanese or Korean script
+func isNativeScript(address models.StructuredAddress) bool {
+	for _, value := range []string{address.AddressLine1, address.City, address.State} {
+		for _, r := range value {
+			if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package addressformat
+
+import (
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  models.StructuredAddress
+		expected string
+	}{
+		{
+			name:     "US with state name and ZIP+4",
+			address:  models.StructuredAddress{AddressLine1: "123 Main St", AddressLine2: "Apt 4B", City: "Springfield", State: "Illinois", PostalCode: "627011234", Country: "USA"},
+			expected: "123 Main St\nApt 4B\nSpringfield, IL 62701-1234\nUNITED STATES",
+		},
+		{
+			name:     "UK",
+			address:  models.StructuredAddress{AddressLine1: "10 Downing Street", City: "London", PostalCode: "sw1a2aa", Country: "UK"},
+			expected: "10 Downing Street\nLondon\nSW1A 2AA\nUNITED KINGDOM",
+		},
+		{
+			name:     "Germany",
+			address:  models.StructuredAddress{AddressLine1: "Unter den Linden 77", City: "Berlin", State: "Berlin", PostalCode: "10117", Country: "DE"},
+			expected: "Unter den Linden 77\n10117 Berlin\nGERMANY",
+		},
+		{
+			name:     "Canada",
+			address:  models.StructuredAddress{AddressLine1: "24 Sussex Dr", City: "Ottawa", State: "Ontario", PostalCode: "K1M1M4", Country: "Canada"},
+			expected: "24 Sussex Dr\nOttawa ON K1M 1M4\nCANADA",
+		},
+		{
+			name:     "Japan in Latin script",
+			address:  models.StructuredAddress{AddressLine1: "1-1 Chiyoda", City: "Chiyoda-ku", State: "Tokyo", PostalCode: "1008111", Country: "JP"},
+			expected: "1-1 Chiyoda\nChiyoda-ku, Tokyo 100-8111\nJAPAN",
+		},
+		{
+			name:     "Japan in Japanese script",
+			address:  models.StructuredAddress{AddressLine1: "千代田1-1", City: "千代田区", State: "東京都", PostalCode: "100-8111", Country: "JP"},
+			expected: "〒100-8111\n東京都千代田区千代田1-1",
+		},
+		{
+			name:     "Missing fields",
+			address:  models.StructuredAddress{City: "Springfield", PostalCode: "62701", Country: "US"},
+			expected: "Springfield, 62701\nUNITED STATES",
+		},
+		{
+			name:     "Unknown country",
+			address:  models.StructuredAddress{AddressLine1: "1 High St", City: "Springfield", Country: "Atlantis"},
+			expected: "1 High St\nSpringfield\nATLANTIS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Label(tt.address); got != tt.expected {
+				t.Errorf("Label() =\n%s\nexpected\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		address  models.StructuredAddress
+		expected string
+	}{
+		{
+			models.StructuredAddress{AddressLine1: "1600 Amphitheatre Parkway", City: "Mountain View", State: "CA", PostalCode: "94043", Country: "USA"},
+			"1600 Amphitheatre Parkway, Mountain View, CA 94043, USA",
+		},
+		{
+			models.StructuredAddress{AddressLine1: "Unter den Linden 77", City: "Berlin", PostalCode: "10117", Country: "Germany"},
+			"Unter den Linden 77, 10117 Berlin, Germany",
+		},
+		{
+			models.StructuredAddress{AddressLine1: "千代田1-1", City: "千代田区", State: "東京都", Country: "JP"},
+			"東京都千代田区千代田1-1, JP",
+		},
+		{
+			models.StructuredAddress{City: "Paris"},
+			"Paris",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := Query(tt.address); got != tt.expected {
+			t.Errorf("Query(%+v) = %q, expected %q", tt.address, got, tt.expected)
+		}
+	}
+}
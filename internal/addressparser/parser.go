@@ -0,0 +1,637 @@
+// Package addressparser splits free-text addresses into structured fields using the layouts of US,
+// Canadian, UK and continental European addresses, without calling any geocoding backend.
+package addressparser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/iso3166"
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/postalcode"
+)
+
+// Address layouts reported in ParsedAddressResponse.Format
+const (
+	FormatUS      = "us"
+	FormatCA      = "ca"
+	FormatUK      = "uk"
+	FormatEU      = "eu"
+	FormatOther   = "other"
+	FormatUnknown = "unknown"
+)
+
+const (
+	confidenceValidated  = 0.95 // Checked against ISO 3166 or the country's postal code format
+	confidenceLayout     = 0.9  // Where the layout puts it, e.g. the city before a state and ZIP code
+	confidenceInferred   = 0.8  // Implied by another field, e.g. the country of a Canadian postal code
+	confidenceSplit      = 0.7  // Split from a street on the same line
+	confidencePositional = 0.6  // Only known from which line it's on
+	confidenceGuess      = 0.4
+)
+
+var (
+	usZIPPattern      = regexp.MustCompile(`^\d{5}(?:-\d{4})?$`)
+	caPostalPattern   = regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z] ?\d[ABCEGHJ-NPRSTV-Z]\d$`)
+	ukPostalPattern   = regexp.MustCompile(`^(?:[A-Z]{1,2}\d[A-Z\d]?|GIR) ?\d[A-Z]{2}$`)
+	euPrefixedPattern = regexp.MustCompile(`^([A-Z]{1,2})-(\d{4,5})$`)
+	euDigitsPattern   = regexp.MustCompile(`^\d{4,5}$`)
+	plPostalPattern   = regexp.MustCompile(`^\d{2}-\d{3}$`)
+	ptPostalPattern   = regexp.MustCompile(`^\d{4}-\d{3}$`)
+	nlLettersPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+
+	// unitPattern matches a line that is only a unit, e.g. "Apt 4B", "Suite 200" or "#12"
+	unitPattern = regexp.MustCompile(`(?i)^(?:(?:apt|apartment|suite|ste|unit|flat|floor|fl|room|rm|bldg|building|dept)\.?\s*#?\s*(?:[A-Z]?\d[A-Z0-9-]*|[A-Z])|#\s*[A-Z0-9-]+)$`)
+	// trailingUnitPattern splits a unit off the end of a street line, e.g. "123 Main St Apt 4B"
+	trailingUnitPattern = regexp.MustCompile(`(?i)^(.*\d.*?)\s+((?:apt|apartment|suite|ste|unit|flat|floor|fl|room|rm|bldg|building)\.?\s*#?\s*(?:[A-Z]?\d[A-Z0-9-]*|[A-Z])|#\s*[A-Z0-9-]+)$`)
+)
+
+// streetSuffixes are street types written as their own word, lower case and without a trailing period
+var streetSuffixes = map[string]bool{
+	"street": true, "st": true, "avenue": true, "ave": true, "av": true, "road": true, "rd": true,
+	"boulevard": true, "blvd": true, "drive": true, "dr": true, "lane": true, "ln": true, "way": true,
+	"court": true, "ct": true, "place": true, "pl": true, "parkway": true, "pkwy": true, "highway": true,
+	"hwy": true, "terrace": true, "ter": true, "circle": true, "cir": true, "square": true, "sq": true,
+	"crescent": true, "cres": true, "close": true, "row": true, "mews": true, "gardens": true, "trail": true,
+	"rue": true, "calle": true, "via": true, "straße": true, "strasse": true, "str": true, "weg": true,
+	"gasse": true, "straat": true, "laan": true, "plein": true,
+}
+
+// compoundSuffixes are street types written as part of the name, e.g. "Hauptstraße" or "Kalverstraat"
+var compoundSuffixes = []string{"straße", "strasse", "str", "weg", "gasse", "straat", "laan", "gracht", "plein", "gatan", "vägen", "vej"}
+
+// unitDesignators start a unit written after the street on a single line
+var unitDesignators = map[string]bool{
+	"apt": true, "apartment": true, "suite": true, "ste": true, "unit": true, "flat": true, "floor": true,
+	"fl": true, "room": true, "rm": true, "bldg": true, "building": true,
+}
+
+// prefixCountries maps the vehicle registration prefixes written before European postal codes, as in
+// "D-10117 Berlin", to countries
+var prefixCountries = map[string]string{
+	"A": "AT", "B": "BE", "CH": "CH", "D": "DE", "DK": "DK", "E": "ES", "F": "FR", "FI": "FI", "I": "IT",
+	"L": "LU", "N": "NO", "NL": "NL", "P": "PT", "PL": "PL", "S": "SE",
+}
+
+// ukCountries use UK postcodes
+var ukCountries = map[string]bool{"GB": true, "IM": true, "JE": true, "GG": true}
+
+// euCountries are the European countries that write the postal code before the city
+var euCountries = map[string]bool{
+	"AD": true, "AT": true, "BE": true, "BG": true, "CH": true, "CY": true, "CZ": true, "DE": true, "DK": true,
+	"EE": true, "ES": true, "FI": true, "FR": true, "GR": true, "HR": true, "HU": true, "IE": true, "IS": true,
+	"IT": true, "LI": true, "LT": true, "LU": true, "LV": true, "MC": true, "MT": true, "NL": true, "NO": true,
+	"PL": true, "PT": true, "RO": true, "SE": true, "SI": true, "SK": true, "SM": true, "VA": true,
+}
+
+// locality is the city, state and postal code line of an address
+type locality struct {
+	street     string // Words before the locality on single-line input, e.g. "123 Main St"
+	city       string
+	state      string
+	postalCode string
+	country    string
+	format     string
+	validated  bool // The postal code matched the country's format
+}
+
+type matcher func(words []string, country string) (locality, bool)
+
+// Parse splits a free-text address into structured fields. Fields it can't find are left empty with
+// zero confidence; it never fails.
+func Parse(text string) *models.ParsedAddressResponse {
+	result := &models.ParsedAddressResponse{}
+	segments, country := takeCountry(splitSegments(text))
+	if country != "" {
+		result.Country = country
+		result.Confidence.Country = confidenceValidated
+	}
+
+	layout := ""
+	if n := len(segments); n > 0 {
+		var streetLines []string
+		if loc, ok := findLocality(segments[n-1], country); ok {
+			streetLines = applyLocality(result, loc, segments[:n-1])
+			layout = loc.format
+		} else {
+			streetLines = applyTrailingLines(result, segments)
+		}
+		setStreet(result, streetLines)
+	}
+
+	result.Format = formatOf(result.Country, layout)
+	return result
+}
+
+// splitSegments splits an address into its lines, treating commas and semicolons as line breaks
+func splitSegments(text string) []string {
+	var segments []string
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == ',' || r == ';'
+	}) {
+		if part = strings.Join(strings.Fields(part), " "); part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+// takeCountry removes a trailing country, either on its own line or as the last words of the final one,
+// as in "75001 Paris France"
+func takeCountry(segments []string) ([]string, string) {
+	n := len(segments)
+	if n == 0 {
+		return segments, ""
+	}
+
+	last := segments[n-1]
+	if !containsDigit(last) {
+		if country, ok := iso3166.FindCountryInAddress(last); ok {
+			// "CA", "DE" and "Georgia" are US states as well as countries: they're only countries after a
+			// European postal code line such as "10117 Berlin"
+			if !isNorthAmericanSubdivision(last) || (n > 1 && startsWithEUPostalCode(segments[n-2])) {
+				return segments[:n-1], country.Alpha2
+			}
+			return segments, ""
+		}
+	}
+	if isNorthAmericanSubdivision(last) {
+		return segments, "" // e.g. "New Jersey", not Jersey
+	}
+
+	words := strings.Fields(last)
+	for k := min(3, len(words)-1); k >= 1; k-- {
+		candidate := strings.Join(words[len(words)-k:], " ")
+		// Two-letter words are too often states, and three-letter ones only count as alpha-3 codes
+		if len(candidate) < 3 || containsDigit(candidate) || (len(candidate) == 3 && candidate != strings.ToUpper(candidate)) {
+			continue
+		}
+		if isNorthAmericanSubdivision(candidate) {
+			continue
+		}
+		if country, ok := iso3166.FindCountryInAddress(candidate); ok {
+			rest := append(append([]string{}, segments[:n-1]...), strings.Join(words[:len(words)-k], " "))
+			return rest, country.Alpha2
+		}
+	}
+	return segments, ""
+}
+
+// findLocality matches a line against the locality layouts used in a country, or all of them if the
+// country is unknown
+func findLocality(segment, country string) (locality, bool) {
+	var matchers []matcher
+	switch {
+	case country == "":
+		matchers = []matcher{matchUS, matchCA, matchUK, matchEU}
+	case country == "US" || country == "PR":
+		matchers = []matcher{matchUS}
+	case country == "CA":
+		matchers = []matcher{matchCA}
+	case ukCountries[country]:
+		matchers = []matcher{matchUK}
+	default:
+		matchers = []matcher{matchEU, matchTrailing}
+	}
+
+	words := strings.Fields(segment)
+	for _, match := range matchers {
+		if loc, ok := match(words, country); ok {
+			return loc, true
+		}
+	}
+	return locality{}, false
+}
+
+// matchUS matches "<city> <state> <ZIP>". Without a known country the state is required, since a bare
+// five-digit code could be from anywhere.
+func matchUS(words []string, country string) (locality, bool) {
+	if len(words) == 0 || !usZIPPattern.MatchString(words[len(words)-1]) {
+		return locality{}, false
+	}
+	state, rest, ok := takeState(words[:len(words)-1], "US", false)
+	if !ok && country == "" {
+		return locality{}, false
+	}
+	if country == "" {
+		country = "US"
+	}
+
+	loc := locality{state: state, postalCode: words[len(words)-1], country: country, format: FormatUS, validated: true}
+	loc.street, loc.city = splitStreet(rest)
+	return loc, true
+}
+
+// matchCA matches "<city> <province> <A1A 1A1>"
+func matchCA(words []string, country string) (locality, bool) {
+	code, rest, ok := takeTrailingCode(words, caPostalPattern)
+	if !ok {
+		return locality{}, false
+	}
+	normalized, err := postalcode.Normalize("CA", code)
+	if err != nil {
+		return locality{}, false
+	}
+	state, rest, _ := takeState(rest, "CA", false)
+
+	loc := locality{state: state, postalCode: normalized, country: "CA", format: FormatCA, validated: true}
+	loc.street, loc.city = splitStreet(rest)
+	return loc, true
+}
+
+// matchUK matches "<town> <postcode>", with the postcode's space optional
+func matchUK(words []string, country string) (locality, bool) {
+	code, rest, ok := takeTrailingCode(words, ukPostalPattern)
+	if !ok {
+		return locality{}, false
+	}
+	if country == "" {
+		country = "GB"
+	}
+	normalized, err := postalcode.Normalize("GB", code)
+	if err != nil {
+		return locality{}, false
+	}
+
+	loc := locality{postalCode: normalized, country: country, format: FormatUK, validated: true}
+	loc.street, loc.city = splitStreet(rest)
+	return loc, true
+}
+
+// matchEU matches the continental "<postal code> <city>", which may follow the street on a single line
+// as in "Unter den Linden 77 10117 Berlin"
+func matchEU(words []string, country string) (locality, bool) {
+	for i := len(words) - 2; i >= 0; i-- {
+		code, implied, size := euPostalCode(words, i)
+		if size == 0 || i+size >= len(words) {
+			continue
+		}
+		city := words[i+size:]
+		if containsDigit(city[0]) || hasStreetSuffix(strings.Join(city, " ")) {
+			continue // "1600 Amphitheatre Parkway" is a street, not a postal code and city
+		}
+		street := strings.Join(words[:i], " ")
+		if street != "" && !containsDigit(street) {
+			continue // A code mid-line has to follow a house number
+		}
+
+		loc := locality{street: street, city: strings.Join(city, " "), postalCode: code, country: country, format: FormatEU}
+		if loc.country == "" {
+			loc.country = implied
+		}
+		if loc.country != "" {
+			normalized, err := postalcode.Normalize(loc.country, code)
+			if err != nil {
+				continue
+			}
+			loc.postalCode, loc.validated = normalized, true
+		}
+		return loc, true
+	}
+	return locality{}, false
+}
+
+// matchTrailing matches "<city> <state> <postal code>" for other countries with a known code format,
+// such as Australia's "Sydney NSW 2000"
+func matchTrailing(words []string, country string) (locality, bool) {
+	for size := min(2, len(words)); size >= 1; size-- {
+		code := strings.Join(words[len(words)-size:], " ")
+		if !containsDigit(code) {
+			continue
+		}
+		normalized, err := postalcode.Normalize(country, code)
+		if err != nil {
+			continue
+		}
+		state, rest, _ := takeState(words[:len(words)-size], country, false)
+
+		loc := locality{state: state, postalCode: normalized, country: country, format: FormatOther, validated: true}
+		loc.street, loc.city = splitStreet(rest)
+		return loc, true
+	}
+	return locality{}, false
+}
+
+// euPostalCode reads a postal code written before the city at words[i], returning it, the country its
+// layout implies, if any, and how many words it spans
+func euPostalCode(words []string, i int) (string, string, int) {
+	word := strings.ToUpper(words[i])
+	next := ""
+	if i+1 < len(words) {
+		next = words[i+1]
+	}
+
+	switch {
+	case euPrefixedPattern.MatchString(word):
+		match := euPrefixedPattern.FindStringSubmatch(word)
+		if country, ok := prefixCountries[match[1]]; ok {
+			return match[2], country, 1
+		}
+	case plPostalPattern.MatchString(word):
+		return word, "PL", 1
+	case ptPostalPattern.MatchString(word):
+		return word, "PT", 1
+	case len(word) == 4 && euDigitsPattern.MatchString(word) && nlLettersPattern.MatchString(next):
+		return word + " " + next, "NL", 2
+	case len(word) == 3 && isDigits(word) && len(next) == 2 && isDigits(next):
+		return word + " " + next, "", 2 // Sweden, Czechia, Slovakia and Greece
+	case euDigitsPattern.MatchString(word):
+		return word, "", 1
+	}
+	return "", "", 0
+}
+
+// takeTrailingCode removes a postal code from the end of a line, trying it as two words then as one
+func takeTrailingCode(words []string, pattern *regexp.Regexp) (string, []string, bool) {
+	for size := min(2, len(words)); size >= 1; size-- {
+		code := strings.ToUpper(strings.Join(words[len(words)-size:], " "))
+		if pattern.MatchString(code) {
+			return code, words[:len(words)-size], true
+		}
+	}
+	return "", words, false
+}
+
+// takeState removes a state or province, as a code or name of up to three words, from the end of a line.
+// In strict mode codes must be written in capitals, so "in" and "me" aren't read as Indiana and Maine.
+func takeState(words []string, country string, strict bool) (string, []string, bool) {
+	for k := min(3, len(words)); k >= 1; k-- {
+		candidate := strings.Join(words[len(words)-k:], " ")
+		if containsDigit(candidate) || (strict && len(candidate) <= 3 && candidate != strings.ToUpper(candidate)) {
+			continue
+		}
+		if _, ok := iso3166.FindSubdivision(country, candidate); ok {
+			return candidate, words[:len(words)-k], true
+		}
+	}
+	return "", words, false
+}
+
+// splitStreet separates the street from the city on single-line input such as "123 Main St Springfield",
+// after the last street suffix or, failing that, the last word with a digit. Words without a house number
+// are all city, so "Port St Lucie" stays whole.
+func splitStreet(words []string) (string, string) {
+	if !containsDigit(strings.Join(words, " ")) {
+		return "", strings.Join(words, " ")
+	}
+
+	end := -1
+	for i, word := range words {
+		if i > 0 && streetSuffixes[normalizeWord(word)] {
+			end = i
+		}
+	}
+	if end >= 0 {
+		// Keep a unit written after the street with it, e.g. "Apt 4"
+		if end+2 < len(words) && unitDesignators[normalizeWord(words[end+1])] {
+			end += 2
+		} else if end+1 < len(words) && strings.HasPrefix(words[end+1], "#") {
+			end++
+		}
+	} else {
+		for i, word := range words {
+			if containsDigit(word) {
+				end = i
+			}
+		}
+	}
+	return strings.Join(words[:end+1], " "), strings.Join(words[end+1:], " ")
+}
+
+// applyLocality fills in the fields found on the locality line and returns the street lines before it.
+// A postal code on its own line, as in "London, SW1A 1AA", takes the state and city from the lines above.
+func applyLocality(result *models.ParsedAddressResponse, loc locality, before []string) []string {
+	result.PostalCode = loc.postalCode
+	result.Confidence.PostalCode = confidenceSplit
+	if loc.validated {
+		result.Confidence.PostalCode = confidenceValidated
+	}
+	if result.Country == "" && loc.country != "" {
+		result.Country = loc.country
+		result.Confidence.Country = confidenceInferred
+	}
+	if loc.state != "" {
+		result.State = loc.state
+		result.Confidence.State = confidenceValidated
+	}
+
+	switch {
+	case loc.city != "" && loc.street != "":
+		result.City = loc.city
+		result.Confidence.City = confidenceSplit
+	case loc.city != "":
+		result.City = loc.city
+		result.Confidence.City = confidenceLayout
+	case loc.street == "":
+		if n := len(before); n > 0 && result.State == "" && result.Country != "" && isState(result.Country, before[n-1]) {
+			result.State = before[n-1]
+			result.Confidence.State = confidenceValidated
+			before = before[:n-1]
+		}
+		if n := len(before); n > 0 && isPlaceName(before[n-1]) {
+			result.City = before[n-1]
+			result.Confidence.City = confidenceInferred
+			before = before[:n-1]
+		}
+	}
+
+	if loc.street != "" {
+		return append(append([]string{}, before...), loc.street)
+	}
+	return before
+}
+
+// applyTrailingLines handles addresses without a postal code, where the last line is a city, a state
+// or both, as in "Mountain View, CA" or "Springfield IL"
+func applyTrailingLines(result *models.ParsedAddressResponse, segments []string) []string {
+	n := len(segments)
+	last := segments[n-1]
+	words := strings.Fields(last)
+
+	// States are only looked for in the US and Canada, where they're part of every address
+	var stateCountries []string
+	switch result.Country {
+	case "":
+		stateCountries = []string{"US", "CA"}
+	case "US", "CA":
+		stateCountries = []string{result.Country}
+	}
+
+	for _, country := range stateCountries {
+		state, rest, ok := takeState(words, country, true)
+		if !ok {
+			continue
+		}
+		result.State = state
+		result.Confidence.State = confidenceValidated
+		if result.Country == "" {
+			result.Country = country
+			result.Confidence.Country = confidencePositional
+		}
+
+		before := segments[:n-1]
+		if len(rest) > 0 {
+			street, city := splitStreet(rest)
+			result.City = city
+			result.Confidence.City = confidenceSplit
+			if street == "" {
+				return before
+			}
+			return append(append([]string{}, before...), street)
+		}
+		if n := len(before); n > 0 && isPlaceName(before[n-1]) {
+			result.City = before[n-1]
+			result.Confidence.City = confidenceInferred
+			before = before[:n-1]
+		}
+		return before
+	}
+
+	if isPlaceName(last) && (n > 1 || result.Country != "") {
+		result.City = last
+		result.Confidence.City = confidencePositional
+		return segments[:n-1]
+	}
+	if n == 1 {
+		street, city := splitStreet(words)
+		if city != "" {
+			result.City = city
+			result.Confidence.City = confidenceGuess
+		}
+		if street == "" {
+			return nil
+		}
+		return []string{street}
+	}
+	return segments
+}
+
+// setStreet assigns the remaining lines: the first with a house number is address_line_1, and units and
+// anything else, such as a building or company name, are joined into address_line_2
+func setStreet(result *models.ParsedAddressResponse, lines []string) {
+	var units, others []string
+	for _, line := range lines {
+		if unitPattern.MatchString(line) {
+			units = append(units, line)
+		} else if line != "" {
+			others = append(others, line)
+		}
+	}
+	if len(others) > 0 {
+		main := 0
+		for i, line := range others {
+			if containsDigit(line) {
+				main = i
+				break
+			}
+		}
+
+		line1 := others[main]
+		if match := trailingUnitPattern.FindStringSubmatch(line1); match != nil {
+			line1 = match[1]
+			units = append([]string{match[2]}, units...)
+		}
+		result.AddressLine1 = line1
+		result.Confidence.AddressLine1 = streetConfidence(line1)
+		others = append(others[:main:main], others[main+1:]...)
+	}
+
+	if len(units)+len(others) > 0 {
+		result.AddressLine2 = strings.Join(append(units, others...), ", ")
+		result.Confidence.AddressLine2 = confidencePositional
+		if len(others) == 0 {
+			result.Confidence.AddressLine2 = confidenceLayout
+		}
+	}
+}
+
+// streetConfidence rates how much a line looks like a street address
+func streetConfidence(line string) float64 {
+	switch {
+	case containsDigit(line) && hasStreetSuffix(line):
+		return confidenceLayout
+	case containsDigit(line):
+		return confidenceSplit
+	default:
+		return confidenceGuess
+	}
+}
+
+// formatOf names the address layout from the country, or from the layout matched when it's unknown
+func formatOf(country, layout string) string {
+	switch {
+	case country == "US" || country == "PR":
+		return FormatUS
+	case country == "CA":
+		return FormatCA
+	case ukCountries[country]:
+		return FormatUK
+	case euCountries[country]:
+		return FormatEU
+	case country != "":
+		return FormatOther
+	case layout != "":
+		return layout
+	default:
+		return FormatUnknown
+	}
+}
+
+func startsWithEUPostalCode(segment string) bool {
+	loc, ok := matchEU(strings.Fields(segment), "")
+	return ok && loc.street == ""
+}
+
+func isNorthAmericanSubdivision(value string) bool {
+	for _, country := range []string{"US", "CA"} {
+		if _, ok := iso3166.FindSubdivision(country, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isState(country, value string) bool {
+	if containsDigit(value) {
+		return false
+	}
+	_, ok := iso3166.FindSubdivision(country, value)
+	return ok
+}
+
+// isPlaceName reports whether a line could be a city: no house number and not a unit
+func isPlaceName(value string) bool {
+	return !containsDigit(value) && !unitPattern.MatchString(value)
+}
+
+func hasStreetSuffix(line string) bool {
+	for _, word := range strings.Fields(line) {
+		word = normalizeWord(word)
+		if streetSuffixes[word] {
+			return true
+		}
+		for _, suffix := range compoundSuffixes {
+			if strings.HasSuffix(word, suffix) && len(word) > len(suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalizeWord(word string) string {
+	return strings.TrimRight(strings.ToLower(word), ".,")
+}
+
+func containsDigit(value string) bool {
+	return strings.ContainsAny(value, "0123456789")
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
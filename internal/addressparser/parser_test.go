@@ -0,0 +1,145 @@
+package addressparser
+
+import (
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected models.ParsedAddressResponse
+	}{
+		{
+			name:  "US multi-line with unit",
+			input: "123 Main St Apt 4B\nSpringfield, IL 62701\nUSA",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "123 Main St", AddressLine2: "Apt 4B", City: "Springfield", State: "IL",
+				PostalCode: "62701", Country: "US", Format: FormatUS,
+			},
+		},
+		{
+			name:  "US without country",
+			input: "1600 Amphitheatre Parkway, Mountain View, CA 94043",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "1600 Amphitheatre Parkway", City: "Mountain View", State: "CA",
+				PostalCode: "94043", Country: "US", Format: FormatUS,
+			},
+		},
+		{
+			name:  "US single line",
+			input: "350 5th Ave Suite 3300 New York NY 10118",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "350 5th Ave", AddressLine2: "Suite 3300", City: "New York", State: "NY",
+				PostalCode: "10118", Country: "US", Format: FormatUS,
+			},
+		},
+		{
+			name:  "US without postal code",
+			input: "1600 Amphitheatre Parkway, Mountain View, CA",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "1600 Amphitheatre Parkway", City: "Mountain View", State: "CA",
+				Country: "US", Format: FormatUS,
+			},
+		},
+		{
+			name:  "Canada",
+			input: "24 Sussex Dr, Ottawa ON k1m1m4, Canada",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "24 Sussex Dr", City: "Ottawa", State: "ON", PostalCode: "K1M 1M4",
+				Country: "CA", Format: FormatCA,
+			},
+		},
+		{
+			name:  "UK postcode on its own line",
+			input: "Flat 3\n10 Downing Street\nLondon\nSW1A 2AA",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "10 Downing Street", AddressLine2: "Flat 3", City: "London", PostalCode: "SW1A 2AA",
+				Country: "GB", Format: FormatUK,
+			},
+		},
+		{
+			name:  "Germany",
+			input: "Unter den Linden 77, 10117 Berlin, Germany",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "Unter den Linden 77", City: "Berlin", PostalCode: "10117", Country: "DE", Format: FormatEU,
+			},
+		},
+		{
+			name:  "Germany single line with prefix",
+			input: "Hauptstraße 5 D-80331 München",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "Hauptstraße 5", City: "München", PostalCode: "80331", Country: "DE", Format: FormatEU,
+			},
+		},
+		{
+			name:  "Netherlands",
+			input: "Dam 1, 1012 JS Amsterdam",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "Dam 1", City: "Amsterdam", PostalCode: "1012 JS", Country: "NL", Format: FormatEU,
+			},
+		},
+		{
+			name:  "France with a two-letter country",
+			input: "8 Rue de Rivoli; 75001 Paris; FR",
+			expected: models.ParsedAddressResponse{
+				AddressLine1: "8 Rue de Rivoli", City: "Paris", PostalCode: "75001", Country: "FR", Format: FormatEU,
+			},
+		},
+		{
+			name:     "City and country",
+			input:    "Paris, France",
+			expected: models.ParsedAddressResponse{City: "Paris", Country: "FR", Format: FormatEU},
+		},
+		{
+			name:     "Georgia is a state",
+			input:    "Atlanta, Georgia",
+			expected: models.ParsedAddressResponse{City: "Atlanta", State: "Georgia", Country: "US", Format: FormatUS},
+		},
+		{
+			name:     "Empty",
+			input:    " ,\n ",
+			expected: models.ParsedAddressResponse{Format: FormatUnknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := *Parse(tt.input)
+			got.Confidence = models.AddressFieldConfidence{}
+			if got != tt.expected {
+				t.Errorf("Parse(%q) =\n%+v\nexpected\n%+v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParse_Confidence(t *testing.T) {
+	explicit := Parse("123 Main St, Springfield, IL 62701, USA").Confidence
+	if explicit.Country != confidenceValidated || explicit.State != confidenceValidated || explicit.PostalCode != confidenceValidated {
+		t.Errorf("Expected validated country, state and postal code, got %+v", explicit)
+	}
+	if explicit.AddressLine1 != confidenceLayout || explicit.AddressLine2 != 0 {
+		t.Errorf("Expected a confident street and no second line, got %+v", explicit)
+	}
+
+	inferred := Parse("123 Main St, Springfield, IL 62701").Confidence
+	if inferred.Country >= explicit.Country {
+		t.Errorf("Expected an inferred country to be less confident than an explicit one, got %v", inferred.Country)
+	}
+
+	guessed := Parse("Springfield").Confidence
+	if guessed.City >= inferred.City {
+		t.Errorf("Expected a lone city to be a guess, got %v", guessed.City)
+	}
+}
+
+func TestParse_ToStructuredAddress(t *testing.T) {
+	structured := Parse("123 Main St, Springfield, IL 62701").ToStructuredAddress()
+	expected := models.StructuredAddress{AddressLine1: "123 Main St", City: "Springfield", State: "IL", PostalCode: "62701", Country: "US"}
+	if structured != expected {
+		t.Errorf("Unexpected structured address %+v", structured)
+	}
+}
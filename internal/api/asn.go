@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hackclub/geocoder/internal/geoip"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// v1/asn/{asn} endpoint
+func (h *Handlers) HandleASN(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	asn, err := geoip.ParseASN(mux.Vars(r)["asn"])
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ASN", "ASN must be a number such as 15169 or AS15169")
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	queryText := "AS" + strconv.Itoa(asn)
+
+	var result *models.ASNAPIResponse
+	var cacheHit bool
+	if apiReq.cache.reads() {
+		result, cacheHit = h.cacheService.GetASNResult(asn)
+	}
+	if !cacheHit {
+		if apiErr := apiReq.cache.fetch(); apiErr != nil {
+			h.writeAPIError(w, apiErr)
+			return
+		}
+		result, err = h.geoipClient.GetASNInfoToStandardFormat(asn)
+		switch {
+		case err == nil && apiReq.cache.writes():
+			_ = h.cacheService.SetASNResult(asn, result)
+		case err == nil:
+		case errors.Is(err, geoip.ErrTokenRequired):
+			// Without a token only the built-in hosting list can answer
+			hosting, found := geoip.HostingASNResponse(asn)
+			if !found {
+				h.writeErrorResponse(w, http.StatusServiceUnavailable, "EXTERNAL_API_ERROR", "ASN lookups need an IPinfo token (IPINFO_API_KEY)")
+				return
+			}
+			h.logLocalRequest(r, apiKey, "v1/asn", queryText, 1, startTime)
+			h.writeResponse(w, r, apiReq, hosting)
+			return
+		case errors.Is(err, geoip.ErrASNNotFound):
+			h.writeErrorResponse(w, http.StatusNotFound, "NO_RESULTS", fmt.Sprintf("No record of %s", queryText))
+			return
+		default:
+			h.writeErrorResponse(w, http.StatusBadGateway, "EXTERNAL_API_ERROR", fmt.Sprintf("Failed to get ASN info: %v", err))
+			return
+		}
+	}
+
+	apiReq.cache.record(cacheHit)
+	h.logIPinfoRequest(r, apiKey, "v1/asn", queryText, 1, cacheHit, startTime)
+
+	h.writeResponse(w, r, apiReq, result)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// cacheMode is how a request may use the caches, set with the cache parameter
+type cacheMode string
+
+const (
+	// cacheDefault answers from the cache when it can and caches provider results
+	cacheDefault cacheMode = "default"
+	// cacheRefresh skips the cache and replaces its entry with a fresh provider result
+	cacheRefresh cacheMode = "refresh"
+	// cacheBypass skips the cache and leaves it untouched
+	cacheBypass cacheMode = "bypass"
+	// cacheOnly answers from the cache and never calls a paid provider
+	cacheOnly cacheMode = "only"
+)
+
+// cacheHeader reports whether a response came from the cache: HIT when every lookup behind it
+// did, MISS when any went to a provider. Responses that needed no cache, such as offline or
+// gazetteer answers, don't carry it.
+const cacheHeader = "X-Cache"
+
+// cacheUse is a request's cache mode, and records whether its lookups were answered from the cache.
+// A nil cacheUse is the default mode.
+type cacheUse struct {
+	mode   cacheMode
+	hits   int
+	misses int
+}
+
+// parseCacheMode reads the cache parameter. refresh and bypass cost money, so they need a key
+// allowed to send them. It writes the error response itself and returns false when the mode is
+// invalid or not allowed.
+func (h *Handlers) parseCacheMode(w http.ResponseWriter, r *http.Request, params url.Values) (*cacheUse, bool) {
+	mode := cacheMode(params.Get("cache"))
+	switch mode {
+	case "":
+		mode = cacheDefault
+	case cacheDefault, cacheOnly:
+	case cacheRefresh, cacheBypass:
+		apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+		if ok && !apiKey.AllowCacheControl {
+			h.writeErrorResponse(w, http.StatusForbidden, "CACHE_MODE_NOT_ALLOWED", fmt.Sprintf("This API key may not use cache=%s", mode))
+			return nil, false
+		}
+	default:
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "cache must be default, refresh, bypass or only")
+		return nil, false
+	}
+	return &cacheUse{mode: mode}, true
+}
+
+// reads reports whether lookups may be answered from the cache
+func (c *cacheUse) reads() bool {
+	return c == nil || c.mode == cacheDefault || c.mode == cacheOnly
+}
+
+// writes reports whether provider results may be cached
+func (c *cacheUse) writes() bool {
+	return c == nil || c.mode == cacheDefault || c.mode == cacheRefresh
+}
+
+// fetch returns the error for a cache miss in cache=only mode, or nil when the provider may be called
+func (c *cacheUse) fetch() *apiError {
+	if c != nil && c.mode == cacheOnly {
+		return &apiError{http.StatusNotFound, "CACHE_MISS", "No cached result, and cache=only never calls the provider", nil}
+	}
+	return nil
+}
+
+// record counts a lookup as answered from the cache or not
+func (c *cacheUse) record(hit bool) {
+	if c == nil {
+		return
+	}
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// setHeader sets the cache header for the lookups recorded
+func (c *cacheUse) setHeader(w http.ResponseWriter) {
+	switch {
+	case c == nil || c.hits+c.misses == 0:
+	case c.misses > 0:
+		w.Header().Set(cacheHeader, "MISS")
+	default:
+		w.Header().Set(cacheHeader, "HIT")
+	}
+}
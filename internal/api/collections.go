@@ -0,0 +1,393 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hackclub/geocoder/internal/collections"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// maxCollectionUploadBytes fits MaxPlacesPerUpload places with generous properties
+const maxCollectionUploadBytes = 5 << 20
+
+// v1/collections endpoint, which lists (GET) or creates (POST) the API key's place collections
+func (h *Handlers) HandleCollections(w http.ResponseWriter, r *http.Request) {
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		list, err := h.db.GetPlaceCollections(apiKey.ID)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve collections")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		h.writeJSONResponse(w, models.PlaceCollectionListResponse{Collections: list})
+	case "POST":
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)).Decode(&req); err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON")
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || len(req.Name) > 255 {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "name is required and must be at most 255 characters")
+			return
+		}
+
+		collection, err := h.db.CreatePlaceCollection(apiKey.ID, req.Name)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to create collection")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		h.writeJSONResponse(w, collection)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// v1/collections/{id} endpoint, which reads or deletes one of the API key's place collections
+func (h *Handlers) HandleCollection(w http.ResponseWriter, r *http.Request) {
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+	collection, ok := h.findCollection(w, mux.Vars(r)["id"], apiKey.ID)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		h.writeJSONResponse(w, collection)
+	case "DELETE":
+		if err := h.db.DeletePlaceCollection(collection.ID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to delete collection")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// v1/collections/{id}/places endpoint, which lists (GET) or adds (POST) a collection's places.
+// Places without coordinates are geocoded through the cache.
+func (h *Handlers) HandleCollectionPlaces(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+	collection, ok := h.findCollection(w, mux.Vars(r)["id"], apiKey.ID)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		places, err := h.db.GetCollectionPlaces(collection.ID)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve places")
+			return
+		}
+		result := &models.CollectionPlaceListResponse{Places: places}
+		applyPrivacy(r, result)
+		w.Header().Set("Content-Type", "application/json")
+		h.writeJSONResponse(w, result)
+	case "POST":
+		var req struct {
+			Places []collections.PlaceInput `json:"places"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCollectionUploadBytes)).Decode(&req); err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON")
+			return
+		}
+		if len(req.Places) == 0 || len(req.Places) > collections.MaxPlacesPerUpload {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("places must list between 1 and %d places", collections.MaxPlacesPerUpload))
+			return
+		}
+
+		var rows []collections.Row
+		var rowErrors []models.CollectionImportError
+		for i, place := range req.Places {
+			if err := place.Validate(); err != nil {
+				rowErrors = append(rowErrors, models.CollectionImportError{Row: i + 1, Message: err.Error()})
+				continue
+			}
+			rows = append(rows, collections.Row{Number: i + 1, Place: place})
+		}
+		h.addCollectionPlaces(w, r, apiKey, collection, rows, rowErrors, "v1/collections/places", startTime)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// v1/collections/{id}/import endpoint, which adds places from a CSV file with a header row
+func (h *Handlers) HandleCollectionImport(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+	collection, ok := h.findCollection(w, mux.Vars(r)["id"], apiKey.ID)
+	if !ok {
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "text/csv") && !strings.HasPrefix(contentType, "text/plain") {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("unsupported content type %q, expected text/csv", contentType))
+		return
+	}
+
+	rows, rowErrors, err := collections.ParseCSV(http.MaxBytesReader(w, r.Body, maxCollectionUploadBytes))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	h.addCollectionPlaces(w, r, apiKey, collection, rows, rowErrors, "v1/collections/import", startTime)
+}
+
+// addCollectionPlaces geocodes the rows without coordinates and stores every row that has a
+// location. Rows that fail are reported alongside the places added.
+func (h *Handlers) addCollectionPlaces(w http.ResponseWriter, r *http.Request, apiKey *models.APIKey, collection *models.PlaceCollection, rows []collections.Row, rowErrors []models.CollectionImportError, endpoint string, startTime time.Time) {
+	if collection.PlaceCount+len(rows) > collections.MaxPlacesPerCollection {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("A collection holds at most %d places; this one has %d", collections.MaxPlacesPerCollection, collection.PlaceCount))
+		return
+	}
+
+	// region and language bias geocoding the same way as /v1/geocode
+	opts, err := parseGeocodeOptions(r.URL.Query())
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	cache, ok := h.parseCacheMode(w, r, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	places := make([]models.CollectionPlace, 0, len(rows))
+	for _, row := range rows {
+		place := models.CollectionPlace{Name: row.Place.Name, Properties: row.Place.Properties}
+		if row.Place.HasCoordinates() {
+			place.Lat, place.Lng = *row.Place.Lat, *row.Place.Lng
+		} else {
+			geocoded, cacheHit, apiErr := h.resolveGeocode(cache, row.Place.Address, opts)
+			if apiErr != nil {
+				rowErrors = append(rowErrors, models.CollectionImportError{Row: row.Number, Message: apiErr.message})
+				continue
+			}
+			if cacheHit {
+				h.trackGeocodeCost(sourceCache)
+			} else {
+				h.trackGeocodeCost(sourceGoogle)
+			}
+			place.Address, place.FormattedAddress = row.Place.Address, geocoded.FormattedAddress
+			place.Lat, place.Lng = geocoded.Lat, geocoded.Lng
+		}
+		places = append(places, place)
+	}
+
+	sort.Slice(rowErrors, func(i, j int) bool {
+		return rowErrors[i].Row < rowErrors[j].Row
+	})
+	if len(places) == 0 {
+		message := "No places to add"
+		if len(rowErrors) > 0 {
+			message = fmt.Sprintf("No places could be added; row %d: %s", rowErrors[0].Row, rowErrors[0].Message)
+		}
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", message)
+		return
+	}
+
+	added, err := h.db.AddCollectionPlaces(collection.ID, places)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to store places")
+		return
+	}
+
+	// Geocoding was billed per place above, so the upload itself is logged as local work
+	h.logLocalRequest(r, apiKey, endpoint, fmt.Sprintf("%s (%d places)", collection.Name, len(rows)), len(added), startTime)
+
+	result := &models.CollectionImportResponse{
+		Added:  len(added),
+		Places: added,
+		Errors: append([]models.CollectionImportError{}, rowErrors...),
+	}
+	applyPrivacy(r, result)
+
+	cache.setHeader(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.writeJSONResponse(w, result)
+}
+
+// v1/collections/{id}/places/{place_id} endpoint, which removes a place from a collection
+func (h *Handlers) HandleCollectionPlace(w http.ResponseWriter, r *http.Request) {
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+	collection, ok := h.findCollection(w, mux.Vars(r)["id"], apiKey.ID)
+	if !ok {
+		return
+	}
+
+	placeID := mux.Vars(r)["place_id"]
+	if !uuidPattern.MatchString(placeID) {
+		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Place not found")
+		return
+	}
+
+	err := h.db.DeleteCollectionPlace(collection.ID, placeID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Place not found")
+	case err != nil:
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to delete place")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// v1/collections/{id}/nearby endpoint, which returns a collection's places nearest a point or an
+// address, optionally within a radius in metres
+func (h *Handlers) HandleCollectionNearby(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	hasCoordinates := params.Get("lat") != "" || params.Get("lng") != ""
+	address := params.Get("address")
+	if hasCoordinates == (address != "") {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Provide either lat and lng, or address")
+		return
+	}
+
+	var lat, lng float64
+	var err error
+	if hasCoordinates {
+		lat, lng, err = parseCoordinates(params.Get("lat"), params.Get("lng"))
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", err.Error())
+			return
+		}
+	}
+
+	var radiusM float64
+	if value := params.Get("radius"); value != "" {
+		radiusM, err = strconv.ParseFloat(value, 64)
+		if err != nil || radiusM <= 0 || radiusM > 20000*1000 {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "radius must be a distance in metres, at most 20,000,000")
+			return
+		}
+	}
+
+	limit := collections.DefaultLimit
+	if value := params.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > collections.MaxLimit {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("limit must be between 1 and %d", collections.MaxLimit))
+			return
+		}
+	}
+
+	opts, err := parseGeocodeOptions(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+	collection, ok := h.findCollection(w, mux.Vars(r)["id"], apiKey.ID)
+	if !ok {
+		return
+	}
+
+	result := &models.NearbyResponse{Source: "coordinates", Lat: lat, Lng: lng, RadiusM: radiusM}
+	source := geocodeSource("")
+	if address != "" {
+		// Addresses go through the same cache as /v1/geocode
+		geocoded, cacheHit, apiErr := h.resolveGeocode(apiReq.cache, address, opts)
+		if apiErr != nil {
+			h.writeAPIError(w, apiErr)
+			return
+		}
+		result.Source = "address"
+		result.Lat, result.Lng = geocoded.Lat, geocoded.Lng
+		source = sourceGoogle
+		if cacheHit {
+			source = sourceCache
+		}
+	}
+
+	find := func(minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error) {
+		return h.db.FindCollectionPlacesInBox(collection.ID, minLat, minLng, maxLat, maxLng)
+	}
+	result.Places, err = collections.Nearby(find, result.Lat, result.Lng, radiusM, limit)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to search places")
+		return
+	}
+
+	if address != "" {
+		h.logGeocodeRequest(r, apiKey, "v1/collections/nearby", address, len(result.Places), source, startTime)
+	} else {
+		h.logLocalRequest(r, apiKey, "v1/collections/nearby", fmt.Sprintf("%f,%f", lat, lng), len(result.Places), startTime)
+	}
+
+	h.writeResponse(w, r, apiReq, result)
+}
+
+// findCollection loads a place collection, writing a 404 when it doesn't exist or belongs to
+// another key
+func (h *Handlers) findCollection(w http.ResponseWriter, id, ownerID string) (*models.PlaceCollection, bool) {
+	if !uuidPattern.MatchString(id) {
+		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Collection not found")
+		return nil, false
+	}
+
+	collection, err := h.db.GetPlaceCollection(id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows), err == nil && collection.APIKeyID != ownerID:
+		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Collection not found")
+		return nil, false
+	case err != nil:
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve collection")
+		return nil, false
+	}
+	return collection, true
+}
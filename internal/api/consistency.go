@@ -0,0 +1,114 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/hackclub/geocoder/internal/consistency"
+	"github.com/hackclub/geocoder/internal/geoip"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// v1/consistency endpoint, which checks an address against the location of an IP address
+func (h *Handlers) HandleConsistency(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	address := params.Get("address")
+	if address == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ADDRESS", "Address parameter is required")
+		return
+	}
+
+	ip := params.Get("ip")
+	if ip == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_IP", "IP parameter is required")
+		return
+	}
+	if net.ParseIP(ip) == nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_IP", "Invalid IP address format")
+		return
+	}
+
+	opts, err := parseGeocodeOptions(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	// Both sides go through the same caches as /v1/geocode and /v1/geoip
+	geocodeResult, geocodeHit, apiErr := h.resolveGeocode(apiReq.cache, address, opts)
+	if apiErr != nil {
+		h.writeAPIError(w, apiErr)
+		return
+	}
+	geocodeSrc := sourceCache
+	if !geocodeHit {
+		geocodeSrc = sourceGoogle
+	}
+
+	ipResult, bogon := geoip.BogonResponse(ip)
+	ipSource, ipHit := "local", false
+	if !bogon {
+		ipResult, ipHit, apiErr = h.resolveGeoIP(apiReq.cache, ip)
+		if apiErr != nil {
+			h.writeAPIError(w, apiErr)
+			return
+		}
+		ipSource = "ipinfo"
+		if ipHit {
+			ipSource = "cache"
+		}
+	}
+
+	result := consistency.Check(geocodeResult, ipResult)
+
+	responseTime := int(time.Since(startTime).Milliseconds())
+	// A private IP answered locally costs nothing, so it counts as a hit like a cached lookup
+	cacheHit := geocodeHit && (ipHit || bogon)
+	apiSource := string(geocodeSrc) + "+" + ipSource
+	if cacheHit {
+		apiSource = "cache"
+	}
+	queryText := address + " / " + ip
+
+	_ = h.db.LogUsage(apiKey.ID, "v1/consistency", cacheHit, responseTime)
+	h.logActivity(apiKey.Name, "v1/consistency", queryText, 1, responseTime, apiSource, cacheHit, middleware.GetClientIP(r), r.UserAgent())
+
+	h.broadcastActivity(&models.ActivityLog{
+		Timestamp:      time.Now(),
+		APIKeyName:     apiKey.Name,
+		Endpoint:       "v1/consistency",
+		QueryText:      queryText,
+		ResultCount:    1,
+		ResponseTimeMs: responseTime,
+		APISource:      apiSource,
+		CacheHit:       cacheHit,
+		IPAddress:      middleware.GetClientIP(r),
+		UserAgent:      r.UserAgent(),
+	})
+
+	// Each side is billed as its own lookup would be
+	h.trackGeocodeCost(geocodeSrc)
+	if bogon {
+		today := time.Now().Truncate(24 * time.Hour)
+		_ = h.db.UpdateOfflineCostTracking(today, 1)
+	} else {
+		h.trackIPinfoCost(ipHit)
+	}
+	h.broadcastStats()
+
+	h.writeResponse(w, r, apiReq, result)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hackclub/geocoder/internal/crs"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// v1/convert endpoint, which converts a point between WGS84, UTM, MGRS and Web Mercator
+func (h *Handlers) HandleConvert(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	lat, lng, source, err := parseLocation(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", err.Error())
+		return
+	}
+
+	targets, explicit, err := parseConvertTargets(params.Get("to"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	mgrsPrecision := crs.DefaultMGRSPrecision
+	if value := params.Get("mgrs_precision"); value != "" {
+		mgrsPrecision, err = strconv.Atoi(value)
+		if err != nil || mgrsPrecision < 1 || mgrsPrecision > crs.MaxMGRSPrecision {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("mgrs_precision must be between 1 and %d", crs.MaxMGRSPrecision))
+			return
+		}
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	result := &models.ConvertResponse{Lat: roundTo(lat, 1e8), Lng: roundTo(lng, 1e8), Source: source}
+	for _, target := range targets {
+		switch target {
+		case crs.UTM:
+			var c crs.UTMCoordinate
+			if c, err = crs.ToUTM(lat, lng); err == nil {
+				hemisphere := "S"
+				if c.North {
+					hemisphere = "N"
+				}
+				result.UTM = &models.UTMCoordinate{
+					Zone:       c.Zone,
+					Hemisphere: hemisphere,
+					Band:       string(c.Band),
+					Easting:    roundTo(c.Easting, 1e3),
+					Northing:   roundTo(c.Northing, 1e3),
+				}
+			}
+		case crs.MGRS:
+			result.MGRS, err = crs.ToMGRS(lat, lng, mgrsPrecision)
+		case crs.EPSG3857:
+			var x, y float64
+			if x, y, err = crs.ToWebMercator(lat, lng); err == nil {
+				result.EPSG3857 = &models.WebMercatorCoordinate{X: roundTo(x, 1e3), Y: roundTo(y, 1e3)}
+			}
+		}
+		// Systems that don't reach the point are left out, unless they were asked for by name
+		if errors.Is(err, crs.ErrOutOfRange) && !explicit {
+			err = nil
+		}
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", err.Error())
+			return
+		}
+	}
+
+	h.logLocalRequest(r, apiKey, "v1/convert", fmt.Sprintf("%f,%f", lat, lng), 1, startTime)
+
+	h.writeResponse(w, r, apiReq, result)
+}
+
+// parseConvertTargets reads the comma-separated systems to convert to, reporting whether any were
+// named. WGS84 is always returned, so naming it adds nothing.
+func parseConvertTargets(value string) ([]string, bool, error) {
+	var targets []string
+	for _, name := range strings.Split(value, ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "", crs.WGS84:
+		case crs.UTM, crs.MGRS, crs.EPSG3857:
+			targets = append(targets, name)
+		default:
+			return nil, false, fmt.Errorf("unknown coordinate system %q: use wgs84, utm, mgrs or epsg3857", name)
+		}
+	}
+	if strings.TrimSpace(value) == "" {
+		return []string{crs.UTM, crs.MGRS, crs.EPSG3857}, false, nil
+	}
+	return targets, true, nil
+}
+
+// roundTo rounds to a number of steps per unit, e.g. 1e3 for millimetres from metres
+func roundTo(value, steps float64) float64 {
+	return math.Round(value*steps) / steps
+}
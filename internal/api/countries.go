@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hackclub/geocoder/internal/iso3166"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// v1/countries endpoint
+func (h *Handlers) HandleCountries(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	language, err := parseLanguage(apiReq.params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	countries := iso3166.Countries()
+	for i := range countries {
+		countries[i] = iso3166.LocalizeCountry(countries[i], language)
+	}
+
+	h.logLocalRequest(r, apiKey, "v1/countries", "", len(countries), startTime)
+
+	h.writeResponse(w, r, apiReq, countries)
+}
+
+// v1/countries/{code}/subdivisions endpoint
+func (h *Handlers) HandleCountrySubdivisions(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	language, err := parseLanguage(apiReq.params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	code := mux.Vars(r)["code"]
+	subdivisions, found := iso3166.Subdivisions(code)
+	if !found {
+		h.writeErrorResponse(w, http.StatusNotFound, "INVALID_COUNTRY", "Unknown country code; use an ISO 3166-1 alpha-2, alpha-3 or numeric code")
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	for i := range subdivisions {
+		subdivisions[i] = iso3166.LocalizeSubdivision(subdivisions[i], language)
+	}
+
+	h.logLocalRequest(r, apiKey, "v1/subdivisions", code, len(subdivisions), startTime)
+
+	h.writeResponse(w, r, apiReq, subdivisions)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hackclub/geocoder/internal/geoencode"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// v1/encode endpoint, which converts coordinates to geohashes, Plus Codes and H3 cells
+func (h *Handlers) HandleEncode(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	lat, lng, err := parseCoordinates(params.Get("lat"), params.Get("lng"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", err.Error())
+		return
+	}
+
+	specs, err := parseEncodings(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ENCODING", err.Error())
+		return
+	}
+	if len(specs) == 0 {
+		specs = geoencode.DefaultSpecs()
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	encodings, err := geoencode.Encode(lat, lng, specs)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ENCODING", err.Error())
+		return
+	}
+	result := &models.EncodeResponse{Lat: lat, Lng: lng, Encodings: *encodings}
+
+	h.logLocalRequest(r, apiKey, "v1/encode", fmt.Sprintf("%f,%f", lat, lng), 1, startTime)
+
+	h.writeResponse(w, r, apiReq, result)
+}
+
+// v1/decode endpoint, which returns the cell a geohash, Plus Code or H3 index stands for
+func (h *Handlers) HandleDecode(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	code := strings.TrimSpace(params.Get("code"))
+	if code == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_CODE", "Code parameter is required")
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	// The type is detected from the code unless given
+	result, err := geoencode.Decode(code, strings.ToLower(params.Get("type")))
+	switch {
+	case errors.Is(err, geoencode.ErrUnknownFormat):
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ENCODING", err.Error())
+		return
+	case err != nil:
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_CODE", err.Error())
+		return
+	}
+
+	h.logLocalRequest(r, apiKey, "v1/decode", code, 1, startTime)
+
+	h.writeResponse(w, r, apiReq, result)
+}
+
+// parseEncodings reads the optional encodings parameter, e.g. "geohash:7,h3". Errors are worded
+// for an INVALID_ENCODING response.
+func parseEncodings(params url.Values) ([]geoencode.Spec, error) {
+	return geoencode.ParseSpecs(params.Get("encodings"))
+}
+
+// encodeLocation returns a result's coordinates in the formats asked for, or nil when none were
+// asked for or the result has no location
+func encodeLocation(lat, lng float64, specs []geoencode.Spec) *models.Encodings {
+	if len(specs) == 0 || (lat == 0 && lng == 0) {
+		return nil
+	}
+	// The specs were checked when they were parsed, so encoding can't fail
+	encodings, err := geoencode.Encode(lat, lng, specs)
+	if err != nil {
+		return nil
+	}
+	return encodings
+}
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats supported by v1 endpoints
+const (
+	formatJSON    = "json"
+	formatGeoJSON = "geojson"
+	formatCSV     = "csv"
+)
+
+// responseEncoder writes a successful response body in one output format
+type responseEncoder interface {
+	ContentType() string
+	Encode(w io.Writer, data interface{}) error
+}
+
+// newResponseEncoder returns the encoder for a format, or nil if the format is unknown
+func newResponseEncoder(format string, compact bool) responseEncoder {
+	switch format {
+	case formatJSON:
+		return jsonEncoder{compact: compact}
+	case formatGeoJSON:
+		return geoJSONEncoder{compact: compact}
+	case formatCSV:
+		return csvEncoder{}
+	}
+	return nil
+}
+
+// acceptedMediaTypes maps Accept header media types to output formats
+var acceptedMediaTypes = map[string]string{
+	"application/json":     formatJSON,
+	"application/geo+json": formatGeoJSON,
+	"text/csv":             formatCSV,
+	"*/*":                  formatJSON,
+	"application/*":        formatJSON,
+}
+
+// negotiateFormat picks the output format from an explicit format parameter, falling back to the
+// Accept header and then JSON. Only an unknown explicit format is an error.
+func negotiateFormat(format, accept string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if newResponseEncoder(format, false) == nil {
+			return "", fmt.Errorf("unsupported format %q, expected json, geojson or csv", format)
+		}
+		return format, nil
+	}
+
+	bestFormat, bestQuality := formatJSON, 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, quality := parseMediaRange(mediaRange)
+		if candidate, ok := acceptedMediaTypes[mediaType]; ok && quality > bestQuality {
+			bestFormat, bestQuality = candidate, quality
+		}
+	}
+
+	return bestFormat, nil
+}
+
+// parseMediaRange splits an Accept header entry such as "text/csv;q=0.8" into its type and quality
+func parseMediaRange(mediaRange string) (string, float64) {
+	parts := strings.Split(mediaRange, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(parts[0]))
+	quality := 1.0
+	for _, param := range parts[1:] {
+		if name, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(name) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				quality = q
+			}
+		}
+	}
+	return mediaType, quality
+}
+
+// acceptsGzip reports whether the client accepts a gzip-compressed response
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, quality := parseMediaRange(coding)
+		if name == "gzip" && quality > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeEncoded writes data with the given encoder, gzip-compressing it when the client accepts it
+func writeEncoded(w http.ResponseWriter, r *http.Request, encoder responseEncoder, data interface{}) error {
+	w.Header().Set("Content-Type", encoder.ContentType())
+	w.Header().Add("Vary", "Accept, Accept-Encoding")
+
+	if !acceptsGzip(r) {
+		return encoder.Encode(w, data)
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	gz := gzip.NewWriter(w)
+	if err := encoder.Encode(gz, data); err != nil {
+		gz.Close()
+		return err
+	}
+	return gz.Close()
+}
+
+// jsonEncoder writes JSON, indented unless compact output was requested
+type jsonEncoder struct {
+	compact bool
+}
+
+func (e jsonEncoder) ContentType() string { return "application/json" }
+
+func (e jsonEncoder) Encode(w io.Writer, data interface{}) error {
+	encoder := json.NewEncoder(w)
+	if !e.compact {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(data)
+}
+
+// itemLister is implemented by responses that wrap a list, such as nearby places, so that GeoJSON and
+// CSV output has a feature or row per item instead of a single one holding an array
+type itemLister interface {
+	ListItems() interface{}
+}
+
+// listRecords returns the items of a list response, or ok=false for a single record
+func listRecords(data interface{}) (reflect.Value, bool) {
+	if lister, ok := data.(itemLister); ok {
+		data = lister.ListItems()
+	}
+	value := reflect.Indirect(reflect.ValueOf(data))
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		return value, true
+	}
+	return value, false
+}
+
+// geoJSONEncoder writes a response with lat/lng as a GeoJSON Feature, and a list of them as a FeatureCollection
+type geoJSONEncoder struct {
+	compact bool
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *geoJSONGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+func (e geoJSONEncoder) ContentType() string { return "application/geo+json" }
+
+func (e geoJSONEncoder) Encode(w io.Writer, data interface{}) error {
+	var output interface{}
+
+	if value, ok := listRecords(data); ok {
+		collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+		for i := 0; i < value.Len(); i++ {
+			feature, err := toGeoJSONFeature(value.Index(i).Interface())
+			if err != nil {
+				return err
+			}
+			collection.Features = append(collection.Features, feature)
+		}
+		output = collection
+	} else {
+		feature, err := toGeoJSONFeature(data)
+		if err != nil {
+			return err
+		}
+		output = feature
+	}
+
+	return jsonEncoder{compact: e.compact}.Encode(w, output)
+}
+
+// toGeoJSONFeature turns a response into a Feature, using its lat/lng as a Point and every other
+// field except the raw backend response as properties
+func toGeoJSONFeature(record interface{}) (geoJSONFeature, error) {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return geoJSONFeature{}, err
+	}
+
+	properties := map[string]interface{}{}
+	if err := json.Unmarshal(encoded, &properties); err != nil {
+		return geoJSONFeature{}, fmt.Errorf("response cannot be represented as a GeoJSON feature: %w", err)
+	}
+	delete(properties, "raw_backend_response")
+
+	feature := geoJSONFeature{Type: "Feature", Properties: properties}
+
+	lat, latOK := properties["lat"].(float64)
+	lng, lngOK := properties["lng"].(float64)
+	if latOK && lngOK {
+		feature.Geometry = &geoJSONGeometry{Type: "Point", Coordinates: []float64{lng, lat}}
+		delete(properties, "lat")
+		delete(properties, "lng")
+	}
+
+	return feature, nil
+}
+
+// csvEncoder writes a header row followed by one row per response, flattening nested objects
+// into dotted column names such as "components.city.status"
+type csvEncoder struct{}
+
+func (e csvEncoder) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (e csvEncoder) Encode(w io.Writer, data interface{}) error {
+	var records []reflect.Value
+	if value, ok := listRecords(data); ok {
+		for i := 0; i < value.Len(); i++ {
+			records = append(records, value.Index(i))
+		}
+	} else {
+		records = append(records, value)
+	}
+
+	var columns []string
+	seen := map[string]bool{}
+	rows := make([]map[string]string, 0, len(records))
+	for _, record := range records {
+		row := map[string]string{}
+		var recordColumns []string
+		flattenValue("", record, &recordColumns, row)
+		for _, column := range recordColumns {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		line := make([]string, len(columns))
+		for i, column := range columns {
+			line[i] = row[column]
+		}
+		if err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// flattenValue walks a response in field order, recording each leaf under its dotted JSON name
+func flattenValue(name string, value reflect.Value, columns *[]string, row map[string]string) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			// Keep the columns of absent nested objects so every row has the same shape
+			if value.Kind() == reflect.Ptr && value.Type().Elem().Kind() == reflect.Struct && value.Type().Elem() != timeType {
+				flattenValue(name, reflect.New(value.Type().Elem()).Elem(), columns, map[string]string{})
+			} else if name != "" {
+				*columns = append(*columns, name)
+			}
+			return
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Struct && value.Type() != timeType {
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tagName := strings.Split(field.Tag.Get("json"), ",")[0]
+			if tagName == "-" || tagName == "raw_backend_response" {
+				continue
+			}
+			// Embedded structs are inlined, as encoding/json does
+			if field.Anonymous && tagName == "" {
+				flattenValue(name, value.Field(i), columns, row)
+				continue
+			}
+			if tagName == "" {
+				tagName = field.Name
+			}
+			if name != "" {
+				tagName = name + "." + tagName
+			}
+			flattenValue(tagName, value.Field(i), columns, row)
+		}
+		return
+	}
+
+	*columns = append(*columns, name)
+	switch value.Kind() {
+	case reflect.String:
+		row[name] = value.String()
+	case reflect.Bool:
+		row[name] = strconv.FormatBool(value.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		row[name] = strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		row[name] = strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		row[name] = strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	default:
+		// Times, maps and slices keep their JSON representation inside a single cell
+		if encoded, err := json.Marshal(value.Interface()); err == nil {
+			row[name] = strings.Trim(string(encoded), `"`)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hackclub/geocoder/internal/addressformat"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// v1/format_address endpoint
+func (h *Handlers) HandleFormatAddress(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	structuredAddr := parseStructuredAddress(params)
+	if structuredAddr.IsEmpty() {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ADDRESS", "At least one address field is required")
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	lines := addressformat.Lines(structuredAddr)
+	result := &models.FormattedAddressResponse{
+		FormattedAddress: addressformat.Label(structuredAddr),
+		Lines:            lines,
+		CountryCode:      addressformat.CountryCode(structuredAddr),
+	}
+
+	h.logLocalRequest(r, apiKey, "v1/format_address", addressformat.Query(structuredAddr), 1, startTime)
+
+	h.writeResponse(w, r, apiReq, result)
+}
@@ -0,0 +1,312 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hackclub/geocoder/internal/geofence"
+	"github.com/hackclub/geocoder/internal/geoip"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// maxGeofenceBodyBytes is larger than other request bodies, since fences can be detailed borders
+const maxGeofenceBodyBytes = 5 << 20
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// v1/geofences endpoint, which lists (GET) or uploads (POST) the API key's geofences
+func (h *Handlers) HandleGeofences(w http.ResponseWriter, r *http.Request) {
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		h.listGeofences(w, apiKey.ID, r.URL.Query().Get("collection"))
+	case "POST":
+		h.createGeofences(w, r, apiKey.ID)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// v1/geofences/{id} endpoint, which reads, replaces or deletes one of the API key's geofences
+func (h *Handlers) HandleGeofence(w http.ResponseWriter, r *http.Request) {
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	// Other keys' fences are reported as missing rather than forbidden, so IDs can't be probed
+	fence, ok := h.findGeofence(w, mux.Vars(r)["id"], apiKey.ID)
+	if !ok {
+		return
+	}
+	h.serveGeofence(w, r, fence)
+}
+
+// HandleAdminGeofences lists every key's geofences, optionally filtered by api_key_id and collection
+func (h *Handlers) HandleAdminGeofences(w http.ResponseWriter, r *http.Request) {
+	keyID := r.URL.Query().Get("api_key_id")
+	if keyID != "" && !uuidPattern.MatchString(keyID) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "api_key_id must be an API key ID")
+		return
+	}
+	h.listGeofences(w, keyID, r.URL.Query().Get("collection"))
+}
+
+// HandleAdminKeyGeofences uploads geofences on behalf of an API key
+func (h *Handlers) HandleAdminKeyGeofences(w http.ResponseWriter, r *http.Request) {
+	keyID := mux.Vars(r)["key_id"]
+	if !uuidPattern.MatchString(keyID) {
+		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "API key not found")
+		return
+	}
+	h.createGeofences(w, r, keyID)
+}
+
+// HandleAdminGeofence reads, replaces or deletes any geofence
+func (h *Handlers) HandleAdminGeofence(w http.ResponseWriter, r *http.Request) {
+	fence, ok := h.findGeofence(w, mux.Vars(r)["id"], "")
+	if !ok {
+		return
+	}
+	h.serveGeofence(w, r, fence)
+}
+
+func (h *Handlers) serveGeofence(w http.ResponseWriter, r *http.Request, fence *models.Geofence) {
+	switch r.Method {
+	case "GET":
+		w.Header().Set("Content-Type", "application/json")
+		h.writeJSONResponse(w, fence)
+	case "PUT":
+		h.updateGeofence(w, r, fence)
+	case "DELETE":
+		if err := h.db.DeleteGeofence(fence.ID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to delete geofence")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// findGeofence loads a geofence, writing a 404 when it doesn't exist or belongs to a key other than
+// ownerID. An empty ownerID matches any key.
+func (h *Handlers) findGeofence(w http.ResponseWriter, id, ownerID string) (*models.Geofence, bool) {
+	if !uuidPattern.MatchString(id) {
+		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Geofence not found")
+		return nil, false
+	}
+
+	fence, err := h.db.GetGeofence(id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows), err == nil && ownerID != "" && fence.APIKeyID != ownerID:
+		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Geofence not found")
+		return nil, false
+	case err != nil:
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve geofence")
+		return nil, false
+	}
+	return fence, true
+}
+
+func (h *Handlers) listGeofences(w http.ResponseWriter, keyID, collection string) {
+	fences, err := h.db.GetGeofences(keyID, collection)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve geofences")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeJSONResponse(w, models.GeofenceListResponse{Geofences: fences})
+}
+
+// createGeofences stores every feature of an uploaded GeoJSON Feature or FeatureCollection. All of
+// them are validated before any is stored.
+func (h *Handlers) createGeofences(w http.ResponseWriter, r *http.Request, keyID string) {
+	inputs, ok := h.readGeofenceUpload(w, r, r.URL.Query().Get("collection"))
+	if !ok {
+		return
+	}
+
+	created := make([]models.Geofence, 0, len(inputs))
+	for _, input := range inputs {
+		fence, err := h.db.CreateGeofence(keyID, input.Collection, input.Name, input.Geometry, input.Properties, input.RadiusM)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", fmt.Sprintf("Failed to store geofence; %d of %d were stored", len(created), len(inputs)))
+			return
+		}
+		created = append(created, *fence)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	h.writeJSONResponse(w, models.GeofenceListResponse{Geofences: created})
+}
+
+// updateGeofence replaces a geofence with a single uploaded feature, keeping its collection unless
+// the feature names another
+func (h *Handlers) updateGeofence(w http.ResponseWriter, r *http.Request, fence *models.Geofence) {
+	inputs, ok := h.readGeofenceUpload(w, r, fence.Collection)
+	if !ok {
+		return
+	}
+	if len(inputs) != 1 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_GEOFENCE", "A geofence is replaced by exactly one feature")
+		return
+	}
+
+	input := inputs[0]
+	updated, err := h.db.UpdateGeofence(fence.ID, input.Collection, input.Name, input.Geometry, input.Properties, input.RadiusM)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to update geofence")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeJSONResponse(w, updated)
+}
+
+func (h *Handlers) readGeofenceUpload(w http.ResponseWriter, r *http.Request, collection string) ([]geofence.Input, bool) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "application/json") && !strings.HasPrefix(contentType, "application/geo+json") {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("unsupported content type %q, expected application/geo+json", contentType))
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxGeofenceBodyBytes))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("Failed to read request body: %v", err))
+		return nil, false
+	}
+
+	inputs, err := geofence.ParseFeatures(body, collection)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_GEOFENCE", err.Error())
+		return nil, false
+	}
+	return inputs, true
+}
+
+// v1/geofence/check endpoint, which reports the API key's geofences containing a point, an address or an IP address
+func (h *Handlers) HandleGeofenceCheck(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	hasCoordinates := params.Get("lat") != "" || params.Get("lng") != ""
+	address, ip := params.Get("address"), params.Get("ip")
+
+	given := 0
+	for _, present := range []bool{hasCoordinates, address != "", ip != ""} {
+		if present {
+			given++
+		}
+	}
+	if given != 1 {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Provide exactly one of lat and lng, address or ip")
+		return
+	}
+
+	var lat, lng float64
+	var err error
+	if hasCoordinates {
+		lat, lng, err = parseCoordinates(params.Get("lat"), params.Get("lng"))
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", err.Error())
+			return
+		}
+	}
+	if ip != "" && net.ParseIP(ip) == nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_IP", "Invalid IP address format")
+		return
+	}
+
+	opts, err := parseGeocodeOptions(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	// Load the fences first, so a database failure doesn't waste an upstream lookup
+	fences, err := h.db.GetGeofences(apiKey.ID, params.Get("collection"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve geofences")
+		return
+	}
+
+	result := &models.GeofenceCheckResponse{Checked: len(fences)}
+	switch {
+	case hasCoordinates:
+		result.Source = "coordinates"
+		result.Lat, result.Lng = lat, lng
+	case address != "":
+		// Addresses and IP addresses go through the same caches as /v1/geocode and /v1/geoip
+		geocoded, cacheHit, apiErr := h.resolveGeocode(apiReq.cache, address, opts)
+		if apiErr != nil {
+			h.writeAPIError(w, apiErr)
+			return
+		}
+		result.Source = "address"
+		result.Lat, result.Lng = geocoded.Lat, geocoded.Lng
+		defer func() {
+			source := sourceCache
+			if !cacheHit {
+				source = sourceGoogle
+			}
+			h.logGeocodeRequest(r, apiKey, "v1/geofence/check", address, len(result.Matches), source, startTime)
+		}()
+	default:
+		if _, bogon := geoip.BogonReason(net.ParseIP(ip)); bogon {
+			h.writeErrorResponse(w, http.StatusNotFound, "NO_RESULTS", "Private and reserved IP addresses have no location")
+			return
+		}
+		located, cacheHit, apiErr := h.resolveGeoIP(apiReq.cache, ip)
+		if apiErr != nil {
+			h.writeAPIError(w, apiErr)
+			return
+		}
+		if located.Lat == 0 && located.Lng == 0 {
+			h.writeErrorResponse(w, http.StatusNotFound, "NO_RESULTS", "No location found for this IP address")
+			return
+		}
+		result.Source = "ip"
+		result.Lat, result.Lng = located.Lat, located.Lng
+		defer func() {
+			h.logIPinfoRequest(r, apiKey, "v1/geofence/check", ip, len(result.Matches), cacheHit, startTime)
+		}()
+	}
+
+	result.Matches = geofence.Check(fences, result.Lat, result.Lng)
+	result.Inside = len(result.Matches) > 0
+
+	if hasCoordinates {
+		h.logLocalRequest(r, apiKey, "v1/geofence/check", fmt.Sprintf("%f,%f", lat, lng), len(result.Matches), startTime)
+	}
+
+	h.writeResponse(w, r, apiReq, result)
+}
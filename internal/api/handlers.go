@@ -8,38 +8,54 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 
+	"github.com/hackclub/geocoder/internal/addressformat"
 	"github.com/hackclub/geocoder/internal/cache"
 	"github.com/hackclub/geocoder/internal/database"
+	"github.com/hackclub/geocoder/internal/gazetteer"
 	"github.com/hackclub/geocoder/internal/geocoding"
 	"github.com/hackclub/geocoder/internal/geoip"
 	"github.com/hackclub/geocoder/internal/middleware"
 	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/offline"
+	"github.com/hackclub/geocoder/internal/overrides"
+	"github.com/hackclub/geocoder/internal/privacy"
+	"github.com/hackclub/geocoder/internal/redact"
 )
 
 type Handlers struct {
-	db            database.DatabaseInterface
-	geocodeClient *geocoding.Client
-	geoipClient   *geoip.Client
-	cacheService  *cache.CacheService
-	wsClients     map[*websocket.Conn]bool
-	wsBroadcast   chan models.WebSocketMessage
-	upgrader      websocket.Upgrader
+	db              database.DatabaseInterface
+	geocodeClient   *geocoding.Client
+	geoipClient     *geoip.Client
+	cacheService    *cache.CacheService
+	offlineGeocoder *offline.Geocoder
+	gazetteer       *gazetteer.Resolver
+	overrides       *overrides.Resolver
+	logRedactor     *redact.Redactor
+	wsRedactor      *redact.Redactor
+	wsClients       map[*websocket.Conn]bool
+	wsBroadcast     chan models.WebSocketMessage
+	upgrader        websocket.Upgrader
 }
 
 func NewHandlers(db database.DatabaseInterface, geocodeClient *geocoding.Client, geoipClient *geoip.Client, cacheService *cache.CacheService) *Handlers {
 	h := &Handlers{
-		db:            db,
-		geocodeClient: geocodeClient,
-		geoipClient:   geoipClient,
-		cacheService:  cacheService,
-		wsClients:     make(map[*websocket.Conn]bool),
-		wsBroadcast:   make(chan models.WebSocketMessage, 100),
+		db:              db,
+		geocodeClient:   geocodeClient,
+		geoipClient:     geoipClient,
+		cacheService:    cacheService,
+		offlineGeocoder: offline.Default(),
+		gazetteer:       gazetteer.NewResolver(db),
+		overrides:       overrides.NewResolver(db),
+		wsClients:       make(map[*websocket.Conn]bool),
+		wsBroadcast:     make(chan models.WebSocketMessage, 100),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // Allow all origins for demo
@@ -53,44 +69,60 @@ func NewHandlers(db database.DatabaseInterface, geocodeClient *geocoding.Client,
 	return h
 }
 
+// SetOfflineGeocoder replaces the default country-only offline geocoder, e.g. with one that also has
+// state and city data loaded
+func (h *Handlers) SetOfflineGeocoder(geocoder *offline.Geocoder) {
+	h.offlineGeocoder = geocoder
+}
+
+// SetActivityRedaction sets how query texts and IP addresses are redacted in the stored activity log
+// and in dashboard updates. Nil redactors keep everything, which is the default.
+func (h *Handlers) SetActivityRedaction(stored, broadcast *redact.Redactor) {
+	h.logRedactor = stored
+	h.wsRedactor = broadcast
+}
+
 // v1/geocode endpoint
 func (h *Handlers) HandleGeocode(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
-	address := r.URL.Query().Get("address")
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	address := params.Get("address")
 	if address == "" {
 		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ADDRESS", "Address parameter is required")
 		return
 	}
 
+	opts, err := parseGeocodeOptions(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	encodings, err := parseEncodings(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ENCODING", err.Error())
+		return
+	}
+
 	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
 	if !ok {
 		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
 		return
 	}
 
-	// Check cache first
-	cached, cacheHit := h.cacheService.GetStandardGeocodeResult(address)
-	var result *models.GeocodeAPIResponse
-	var err error
-
-	if cacheHit {
-		result = cached
-	} else {
-		// Make external API call
-		if !h.geocodeClient.IsConfigured() {
-			h.writeErrorResponse(w, http.StatusServiceUnavailable, "EXTERNAL_API_ERROR", "Google Geocoding API not configured")
-			return
-		}
-
-		result, err = h.geocodeClient.GeocodeToStandardFormat(address)
-		if err != nil {
-			h.writeErrorResponse(w, http.StatusBadGateway, "EXTERNAL_API_ERROR", fmt.Sprintf("Failed to geocode address: %v", err))
-			return
-		}
-
-		// Cache the result
-		_ = h.cacheService.SetStandardGeocodeResult(address, result)
+	query, simple := gazetteer.ParseQuery(address)
+	result, source, apiErr := h.resolveForwardGeocode(apiReq.cache, address, query, simple, opts)
+	if apiErr != nil {
+		h.writeAPIError(w, apiErr)
+		return
 	}
+	cacheHit := source == sourceCache
+	apiSource := string(source)
 
 	responseTime := int(time.Since(startTime).Milliseconds())
 
@@ -98,15 +130,11 @@ func (h *Handlers) HandleGeocode(w http.ResponseWriter, r *http.Request) {
 	_ = h.db.LogUsage(apiKey.ID, "v1/geocode", cacheHit, responseTime)
 
 	// Log activity
-	apiSource := "cache"
-	if !cacheHit {
-		apiSource = "google"
-	}
 	resultCount := 1 // Standard format always returns 1 result when successful
 	if result.Lat == 0 && result.Lng == 0 {
 		resultCount = 0 // No valid coordinates found
 	}
-	_ = h.db.LogActivity(apiKey.Name, "v1/geocode", address, resultCount, responseTime, apiSource, cacheHit, extractIP(r.RemoteAddr), r.UserAgent())
+	h.logActivity(apiKey.Name, "v1/geocode", address, resultCount, responseTime, apiSource, cacheHit, middleware.GetClientIP(r), r.UserAgent())
 
 	// Broadcast activity update
 	activity := &models.ActivityLog{
@@ -118,23 +146,17 @@ func (h *Handlers) HandleGeocode(w http.ResponseWriter, r *http.Request) {
 		ResponseTimeMs: responseTime,
 		APISource:      apiSource,
 		CacheHit:       cacheHit,
-		IPAddress:      extractIP(r.RemoteAddr),
+		IPAddress:      middleware.GetClientIP(r),
 		UserAgent:      r.UserAgent(),
 	}
 	h.broadcastActivity(activity)
 
 	// Update cost tracking
-	if !cacheHit {
-		today := time.Now().Truncate(24 * time.Hour)
-		_ = h.db.UpdateCostTracking(today, 1, 0, 0, 0, 0.005) // $0.005 per Google API call
-	} else {
-		today := time.Now().Truncate(24 * time.Hour)
-		_ = h.db.UpdateCostTracking(today, 0, 1, 0, 0, 0)
-	}
+	h.trackGeocodeCost(source)
 
 	// Send WebSocket update if there are results
 	if result.Lat != 0 || result.Lng != 0 {
-		h.broadcastUpdate(models.WebSocketMessage{
+		h.broadcastLocation(apiKey, models.WebSocketMessage{
 			Type:      "geocode_request",
 			Lat:       result.Lat,
 			Lng:       result.Lng,
@@ -148,62 +170,56 @@ func (h *Handlers) HandleGeocode(w http.ResponseWriter, r *http.Request) {
 	// Broadcast updated stats
 	h.broadcastStats()
 
-	w.Header().Set("Content-Type", "application/json")
-	h.writeJSONResponse(w, result)
+	result.Encodings = encodeLocation(result.Lat, result.Lng, encodings)
+	h.writeResponse(w, r, apiReq, result)
 }
 
 // v1/geocode_structured endpoint
 func (h *Handlers) HandleGeocodeStructured(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
-	
-	// Parse structured address from query parameters
-	structuredAddr := models.StructuredAddress{
-		AddressLine1: r.URL.Query().Get("address_line_1"),
-		AddressLine2: r.URL.Query().Get("address_line_2"),
-		City:         r.URL.Query().Get("city"),
-		State:        r.URL.Query().Get("state"),
-		PostalCode:   r.URL.Query().Get("postal_code"),
-		Country:      r.URL.Query().Get("country"),
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
 	}
+	params := apiReq.params
+
+	// Parse structured address from request parameters
+	structuredAddr := parseStructuredAddress(params)
 
 	// Validate that at least one field is provided
-	if structuredAddr.AddressLine1 == "" && structuredAddr.AddressLine2 == "" && structuredAddr.City == "" && structuredAddr.State == "" && structuredAddr.PostalCode == "" && structuredAddr.Country == "" {
+	if structuredAddr.IsEmpty() {
 		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ADDRESS", "At least one address field is required")
 		return
 	}
 
+	opts, err := parseGeocodeOptions(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	encodings, err := parseEncodings(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ENCODING", err.Error())
+		return
+	}
+
 	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
 	if !ok {
 		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
 		return
 	}
 
-	// Convert structured address to formatted string for caching and geocoding
-	address := structuredAddr.ToFormattedString()
-
-	// Check cache first
-	cached, cacheHit := h.cacheService.GetStandardGeocodeResult(address)
-	var result *models.GeocodeAPIResponse
-	var err error
-
-	if cacheHit {
-		result = cached
-	} else {
-		// Make external API call
-		if !h.geocodeClient.IsConfigured() {
-			h.writeErrorResponse(w, http.StatusServiceUnavailable, "EXTERNAL_API_ERROR", "Google Geocoding API not configured")
-			return
-		}
-
-		result, err = h.geocodeClient.GeocodeToStandardFormat(address)
-		if err != nil {
-			h.writeErrorResponse(w, http.StatusBadGateway, "EXTERNAL_API_ERROR", fmt.Sprintf("Failed to geocode address: %v", err))
-			return
-		}
+	// Lay the address out in its country's order for caching and geocoding
+	address := addressformat.Query(structuredAddr)
 
-		// Cache the result
-		_ = h.cacheService.SetStandardGeocodeResult(address, result)
+	query, simple := gazetteer.QueryFromStructured(structuredAddr)
+	result, source, apiErr := h.resolveForwardGeocode(apiReq.cache, address, query, simple, opts)
+	if apiErr != nil {
+		h.writeAPIError(w, apiErr)
+		return
 	}
+	cacheHit := source == sourceCache
 
 	responseTime := int(time.Since(startTime).Milliseconds())
 
@@ -218,31 +234,22 @@ func (h *Handlers) HandleGeocodeStructured(w http.ResponseWriter, r *http.Reques
 		QueryText:      address,
 		ResultCount:    1,
 		ResponseTimeMs: responseTime,
-		APISource:      "google",
+		APISource:      string(source),
 		CacheHit:       cacheHit,
-		IPAddress:      extractIP(r.RemoteAddr),
+		IPAddress:      middleware.GetClientIP(r),
 		UserAgent:      r.UserAgent(),
 	}
-	if cacheHit {
-		activity.APISource = "cache"
-	}
 	if result.Lat == 0 && result.Lng == 0 {
 		activity.ResultCount = 0
 	}
 	h.broadcastActivity(activity)
 
 	// Update cost tracking
-	if !cacheHit {
-		today := time.Now().Truncate(24 * time.Hour)
-		_ = h.db.UpdateCostTracking(today, 1, 0, 0, 0, 0.005) // $0.005 per Google API call
-	} else {
-		today := time.Now().Truncate(24 * time.Hour)
-		_ = h.db.UpdateCostTracking(today, 0, 1, 0, 0, 0)
-	}
+	h.trackGeocodeCost(source)
 
 	// Send WebSocket update if there are results
 	if result.Lat != 0 || result.Lng != 0 {
-		h.broadcastUpdate(models.WebSocketMessage{
+		h.broadcastLocation(apiKey, models.WebSocketMessage{
 			Type:      "geocode_request",
 			Lat:       result.Lat,
 			Lng:       result.Lng,
@@ -256,41 +263,44 @@ func (h *Handlers) HandleGeocodeStructured(w http.ResponseWriter, r *http.Reques
 	// Broadcast updated stats
 	h.broadcastStats()
 
-	w.Header().Set("Content-Type", "application/json")
-	h.writeJSONResponse(w, result)
+	result.Encodings = encodeLocation(result.Lat, result.Lng, encodings)
+	h.writeResponse(w, r, apiReq, result)
 }
 
 // v1/reverse-geocode endpoint
 func (h *Handlers) HandleReverseGeocode(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
-	latStr := r.URL.Query().Get("lat")
-	lngStr := r.URL.Query().Get("lng")
-	
-	if latStr == "" || lngStr == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", "Both lat and lng parameters are required")
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
 		return
 	}
+	params := apiReq.params
 
-	// Parse coordinates
-	lat, err := strconv.ParseFloat(latStr, 64)
+	// The point may also be given in UTM, MGRS or Web Mercator
+	lat, lng, _, err := parseLocation(params)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", "Invalid latitude value")
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", err.Error())
 		return
 	}
-	
-	lng, err := strconv.ParseFloat(lngStr, 64)
+
+	language, err := parseLanguage(params)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", "Invalid longitude value")
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
 		return
 	}
 
-	// Validate coordinate ranges
-	if lat < -90 || lat > 90 {
-		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", "Latitude must be between -90 and 90")
-		return
+	var precision offline.Precision
+	if value := params.Get("precision"); value != "" {
+		precision, err = offline.ParsePrecision(value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
 	}
-	if lng < -180 || lng > 180 {
-		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COORDINATES", "Longitude must be between -180 and 180")
+
+	encodings, err := parseEncodings(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ENCODING", err.Error())
 		return
 	}
 
@@ -300,28 +310,47 @@ func (h *Handlers) HandleReverseGeocode(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Coarse lookups are answered from offline boundaries without touching the cache or Google
+	if precision != "" {
+		h.serveOfflineReverseGeocode(w, r, apiReq, startTime, apiKey, lat, lng, precision, language, encodings)
+		return
+	}
+
 	// Check cache first
-	cached, cacheHit := h.cacheService.GetStandardReverseGeocodeResult(lat, lng)
+	var cached *models.ReverseGeocodeAPIResponse
+	var cacheHit bool
+	if apiReq.cache.reads() {
+		cached, cacheHit = h.cacheService.GetStandardReverseGeocodeResultWithLanguage(lat, lng, language)
+	}
 	var result *models.ReverseGeocodeAPIResponse
 	
 	if cacheHit {
 		result = cached
+		geocoding.BackfillAdminHierarchy(&result.AdminHierarchy, result.RawBackendResponse)
 	} else {
+		if apiErr := apiReq.cache.fetch(); apiErr != nil {
+			h.writeAPIError(w, apiErr)
+			return
+		}
+
 		// Make external API call
 		if !h.geocodeClient.IsConfigured() {
 			h.writeErrorResponse(w, http.StatusServiceUnavailable, "EXTERNAL_API_ERROR", "Google Geocoding API not configured")
 			return
 		}
 
-		result, err = h.geocodeClient.ReverseGeocodeToStandardFormat(lat, lng)
+		result, err = h.geocodeClient.ReverseGeocodeToStandardFormatWithLanguage(lat, lng, language)
 		if err != nil {
 			h.writeErrorResponse(w, http.StatusBadGateway, "EXTERNAL_API_ERROR", fmt.Sprintf("Failed to reverse geocode coordinates: %v", err))
 			return
 		}
 
 		// Cache the result
-		_ = h.cacheService.SetStandardReverseGeocodeResult(lat, lng, result)
+		if apiReq.cache.writes() {
+			_ = h.cacheService.SetStandardReverseGeocodeResultWithLanguage(lat, lng, language, result)
+		}
 	}
+	apiReq.cache.record(cacheHit)
 
 	responseTime := int(time.Since(startTime).Milliseconds())
 
@@ -335,7 +364,7 @@ func (h *Handlers) HandleReverseGeocode(w http.ResponseWriter, r *http.Request)
 	}
 	resultCount := 1 // Standard format always returns 1 result when successful
 	queryText := fmt.Sprintf("%f,%f", lat, lng)
-	_ = h.db.LogActivity(apiKey.Name, "v1/reverse_geocode", queryText, resultCount, responseTime, apiSource, cacheHit, extractIP(r.RemoteAddr), r.UserAgent())
+	h.logActivity(apiKey.Name, "v1/reverse_geocode", queryText, resultCount, responseTime, apiSource, cacheHit, middleware.GetClientIP(r), r.UserAgent())
 
 	// Broadcast activity update
 	activity := &models.ActivityLog{
@@ -347,7 +376,7 @@ func (h *Handlers) HandleReverseGeocode(w http.ResponseWriter, r *http.Request)
 		ResponseTimeMs: responseTime,
 		APISource:      apiSource,
 		CacheHit:       cacheHit,
-		IPAddress:      extractIP(r.RemoteAddr),
+		IPAddress:      middleware.GetClientIP(r),
 		UserAgent:      r.UserAgent(),
 	}
 	h.broadcastActivity(activity)
@@ -362,7 +391,7 @@ func (h *Handlers) HandleReverseGeocode(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Send WebSocket update
-	h.broadcastUpdate(models.WebSocketMessage{
+	h.broadcastLocation(apiKey, models.WebSocketMessage{
 		Type:      "reverse_geocode_request",
 		Lat:       lat,
 		Lng:       lng,
@@ -375,14 +404,20 @@ func (h *Handlers) HandleReverseGeocode(w http.ResponseWriter, r *http.Request)
 	// Broadcast updated stats
 	h.broadcastStats()
 
-	w.Header().Set("Content-Type", "application/json")
-	h.writeJSONResponse(w, result)
+	result.Encodings = encodeLocation(result.Lat, result.Lng, encodings)
+	h.writeResponse(w, r, apiReq, result)
 }
 
 // v1/geoip endpoint
 func (h *Handlers) HandleGeoIP(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
-	ip := r.URL.Query().Get("ip")
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	ip := params.Get("ip")
 	if ip == "" {
 		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_IP", "IP parameter is required")
 		return
@@ -394,89 +429,90 @@ func (h *Handlers) HandleGeoIP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.serveGeoIP(w, r, apiReq, startTime, ip, "v1/geoip")
+}
+
+// v1/geoip/me endpoint, which geolocates the caller
+func (h *Handlers) HandleGeoIPMe(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ip := middleware.GetClientIP(r)
+	if net.ParseIP(ip) == nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_IP", "Could not determine the caller's IP address")
+		return
+	}
+
+	h.serveGeoIP(w, r, apiReq, startTime, ip, "v1/geoip/me")
+}
+
+// serveGeoIP looks up an already validated IP address and writes the response
+func (h *Handlers) serveGeoIP(w http.ResponseWriter, r *http.Request, apiReq *v1Request, startTime time.Time, ip, endpoint string) {
+	language, err := parseLanguage(apiReq.params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	encodings, err := parseEncodings(apiReq.params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ENCODING", err.Error())
+		return
+	}
+
 	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
 	if !ok {
 		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
 		return
 	}
 
-	// Check cache first
-	cached, cacheHit := h.cacheService.GetStandardIPResult(ip)
-	var result *models.GeoIPAPIResponse
-	var err error
+	// Private and reserved addresses have no location, so answer them here instead of spending IPinfo quota
+	if bogon, ok := geoip.BogonResponse(ip); ok {
+		// The reason goes in the source so the dashboard can tell a private address from an empty lookup
+		h.logLocalSource(r, apiKey, endpoint, ip, 0, fmt.Sprintf("bogon (%s)", bogon.BogonReason), startTime)
 
-	if cacheHit {
-		result = cached
-	} else {
-		// Make external API call
-		result, err = h.geoipClient.GetIPInfoToStandardFormat(ip)
-		if err != nil {
-			h.writeErrorResponse(w, http.StatusBadGateway, "EXTERNAL_API_ERROR", fmt.Sprintf("Failed to get IP info: %v", err))
-			return
-		}
+		today := time.Now().Truncate(24 * time.Hour)
+		_ = h.db.UpdateOfflineCostTracking(today, 1)
 
-		// Cache the result
-		_ = h.cacheService.SetStandardIPResult(ip, result)
+		h.writeResponse(w, r, apiReq, bogon)
+		return
 	}
 
-	responseTime := int(time.Since(startTime).Milliseconds())
+	result, cacheHit, apiErr := h.resolveGeoIP(apiReq.cache, ip)
+	if apiErr != nil {
+		h.writeAPIError(w, apiErr)
+		return
+	}
 
-	// Log usage
-	_ = h.db.LogUsage(apiKey.ID, "v1/geoip", cacheHit, responseTime)
+	// IPinfo only answers in English, so the cache holds English names and they are translated on the
+	// way out. The language doesn't need to be part of the cache key.
+	geoip.LocalizeResponse(result, language)
+	result.City = h.gazetteer.LocalizeCity(0, result.City, result.CountryCode, language)
 
-	// Log activity
-	apiSource := "cache"
-	if !cacheHit {
-		apiSource = "ipinfo"
-	}
 	resultCount := 0
 	if result.City != "" || result.Region != "" || result.CountryCode != "" {
 		resultCount = 1
 	}
-	_ = h.db.LogActivity(apiKey.Name, "v1/geoip", ip, resultCount, responseTime, apiSource, cacheHit, extractIP(r.RemoteAddr), r.UserAgent())
-
-	// Broadcast activity update
-	activity := &models.ActivityLog{
-		Timestamp:      time.Now(),
-		APIKeyName:     apiKey.Name,
-		Endpoint:       "v1/geoip",
-		QueryText:      ip,
-		ResultCount:    resultCount,
-		ResponseTimeMs: responseTime,
-		APISource:      apiSource,
-		CacheHit:       cacheHit,
-		IPAddress:      extractIP(r.RemoteAddr),
-		UserAgent:      r.UserAgent(),
-	}
-	h.broadcastActivity(activity)
-
-	// Update cost tracking
-	if !cacheHit {
-		today := time.Now().Truncate(24 * time.Hour)
-		_ = h.db.UpdateCostTracking(today, 0, 0, 1, 0, 0.001) // $0.001 per IPinfo API call
-	} else {
-		today := time.Now().Truncate(24 * time.Hour)
-		_ = h.db.UpdateCostTracking(today, 0, 0, 0, 1, 0)
-	}
+	h.logIPinfoRequest(r, apiKey, endpoint, ip, resultCount, cacheHit, startTime)
 
 	// Send WebSocket update if location is available
 	if result.Lat != 0 || result.Lng != 0 {
-		h.broadcastUpdate(models.WebSocketMessage{
+		h.broadcastLocation(apiKey, models.WebSocketMessage{
 			Type:      "geoip_request",
 			Lat:       result.Lat,
 			Lng:       result.Lng,
 			CacheHit:  cacheHit,
-			Endpoint:  "v1/geoip",
+			Endpoint:  endpoint,
 			IP:        ip,
 			Timestamp: time.Now(),
 		})
 	}
 
-	// Broadcast updated stats
-	h.broadcastStats()
-
-	w.Header().Set("Content-Type", "application/json")
-	h.writeJSONResponse(w, result)
+	result.Encodings = encodeLocation(result.Lat, result.Lng, encodings)
+	h.writeResponse(w, r, apiReq, result)
 }
 
 // Health check endpoint
@@ -630,6 +666,68 @@ func (h *Handlers) HandleUpdateAPIKeyRateLimit(w http.ResponseWriter, r *http.Re
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *Handlers) HandleUpdateAPIKeyAuth(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	keyID := vars["key_id"]
+
+	var req models.UpdateAuthSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON")
+		return
+	}
+
+	err := h.db.UpdateAPIKeyRequireHeaderAuth(keyID, req.RequireHeaderAuth)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to update authentication settings")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handlers) HandleUpdateAPIKeyCache(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	keyID := vars["key_id"]
+
+	var req models.UpdateCacheSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON")
+		return
+	}
+
+	err := h.db.UpdateAPIKeyCacheControl(keyID, req.AllowCacheControl)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to update cache settings")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handlers) HandleUpdateAPIKeyPrivacy(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	keyID := vars["key_id"]
+
+	var policy models.PrivacyPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON")
+		return
+	}
+
+	if err := privacy.Validate(policy); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	err := h.db.UpdateAPIKeyPrivacyPolicy(keyID, policy)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to update privacy policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func (h *Handlers) HandleDeactivateAPIKey(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	keyID := vars["key_id"]
@@ -668,6 +766,61 @@ func (h *Handlers) HandleAdminActivity(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, activities)
 }
 
+// HandleAdminErase removes every trace of an address or IP address: activity entries that mention it
+// as sent or as its hash, and its cached lookups. Truncated and city-level entries no longer identify
+// it and are left alone.
+func (h *Handlers) HandleAdminErase(w http.ResponseWriter, r *http.Request) {
+	var req models.EraseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON")
+		return
+	}
+
+	address := strings.TrimSpace(req.Address)
+	ipAddress := strings.TrimSpace(req.IP)
+	if (address == "") == (ipAddress == "") {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Give exactly one of address or ip")
+		return
+	}
+
+	var matches []string
+	if address != "" {
+		matches = []string{address, h.logRedactor.Hash(address)}
+	} else {
+		ip := net.ParseIP(ipAddress)
+		if ip == nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid IP address")
+			return
+		}
+		// Match the address as sent as well as in canonical form, e.g. for IPv6
+		matches = []string{ipAddress, ip.String(), h.logRedactor.Hash(ipAddress)}
+		ipAddress = ip.String()
+	}
+
+	var result models.EraseResponse
+	var err error
+	result.ActivityEntries, err = h.db.DeleteActivityMatching(matches)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to erase activity")
+		return
+	}
+
+	if address != "" {
+		result.CacheEntries, err = h.cacheService.EraseAddress(address)
+	} else {
+		result.CacheEntries, err = h.cacheService.EraseIP(ipAddress)
+	}
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to erase cached lookups")
+		return
+	}
+
+	log.Printf("Erased %d activity entries and %d cached lookups", result.ActivityEntries, result.CacheEntries)
+
+	w.Header().Set("Content-Type", "application/json")
+	h.writeJSONResponse(w, result)
+}
+
 func (h *Handlers) HandleUsageSummary(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters for pagination
 	page := 1
@@ -724,11 +877,32 @@ func (h *Handlers) broadcastUpdate(message models.WebSocketMessage) {
 	}
 }
 
+// broadcastLocation sends a live map update, reduced under the key's privacy policy like its response
+func (h *Handlers) broadcastLocation(apiKey *models.APIKey, message models.WebSocketMessage) {
+	privacy.ApplyMessage(apiKey.Privacy, apiKey.ID, &message)
+	message.Lat, message.Lng = h.wsRedactor.Point(message.Lat, message.Lng)
+	message.Address = h.wsRedactor.Query(message.Address)
+	message.IP = h.wsRedactor.IP(message.IP)
+	h.broadcastUpdate(message)
+}
+
+// logActivity stores a request in the activity log with its query text and client IP redacted
+func (h *Handlers) logActivity(apiKeyName, endpoint, queryText string, resultCount, responseTimeMs int, apiSource string, cacheHit bool, ipAddress, userAgent string) {
+	queryText = h.logRedactor.Query(queryText)
+	ipAddress = h.logRedactor.IP(ipAddress)
+	_ = h.db.LogActivity(apiKeyName, endpoint, queryText, resultCount, responseTimeMs, apiSource, cacheHit, ipAddress, userAgent)
+}
+
 func (h *Handlers) broadcastActivity(activity *models.ActivityLog) {
+	// Redact a copy; callers may still be using the entry
+	redacted := *activity
+	redacted.QueryText = h.wsRedactor.Query(redacted.QueryText)
+	redacted.IPAddress = h.wsRedactor.IP(redacted.IPAddress)
+
 	message := models.WebSocketMessage{
 		Type:      "activity_update",
 		Timestamp: time.Now(),
-		Activity:  activity,
+		Activity:  &redacted,
 	}
 
 	select {
@@ -803,15 +977,237 @@ func (h *Handlers) writeErrorResponse(w http.ResponseWriter, statusCode int, err
 
 // writeJSONResponse writes a JSON response with nice formatting
 func (h *Handlers) writeJSONResponse(w http.ResponseWriter, data interface{}) {
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "  ")
-	encoder.Encode(data)
+	jsonEncoder{}.Encode(w, data)
+}
+
+// apiError describes an error that a shared lookup helper hands back to the calling handler to write
+type apiError struct {
+	statusCode int
+	code       string
+	message    string
+	err        error
+}
+
+func (h *Handlers) writeAPIError(w http.ResponseWriter, apiErr *apiError) {
+	h.writeErrorResponse(w, apiErr.statusCode, apiErr.code, apiErr.message)
+}
+
+// geocodeSource is where a forward geocoding result came from, as recorded in the activity log
+type geocodeSource string
+
+const (
+	sourceCache     geocodeSource = "cache"
+	sourceGoogle    geocodeSource = "google"
+	sourceGazetteer geocodeSource = "gazetteer"
+	sourceOverride  geocodeSource = "override"
+)
+
+// resolveForwardGeocode answers addresses with an admin override from it, city and postal code queries
+// from the gazetteer when it has a confident match, and everything else through the cache and Google.
+// Results for a place with an override are replaced by it, whichever way they were found.
+func (h *Handlers) resolveForwardGeocode(cache *cacheUse, address string, query gazetteer.Query, simple bool, opts geocoding.GeocodeOptions) (*models.GeocodeAPIResponse, geocodeSource, *apiError) {
+	if result, ok := h.overrides.ForAddress(address); ok {
+		return result, sourceOverride, nil
+	}
+
+	if simple {
+		if result, ok := h.gazetteer.Resolve(query, opts); ok {
+			return result, sourceGazetteer, nil
+		}
+	}
+
+	result, cacheHit, apiErr := h.resolveGeocode(cache, address, opts)
+	if apiErr == nil {
+		if pinned, ok := h.overrides.ForPlaceID(result.PlaceID); ok {
+			result = pinned
+		}
+	}
+	if cacheHit {
+		return result, sourceCache, apiErr
+	}
+	return result, sourceGoogle, apiErr
+}
+
+// resolveGeocode geocodes an address through the cache, falling back to Google and caching the result,
+// as far as the request's cache mode allows
+func (h *Handlers) resolveGeocode(cache *cacheUse, address string, opts geocoding.GeocodeOptions) (*models.GeocodeAPIResponse, bool, *apiError) {
+	if cache.reads() {
+		if cached, cacheHit := h.cacheService.GetStandardGeocodeResultWithOptions(address, opts); cacheHit {
+			geocoding.BackfillAdminHierarchy(&cached.AdminHierarchy, cached.RawBackendResponse)
+			geocoding.BackfillPlaceID(cached)
+			cache.record(true)
+			return cached, true, nil
+		}
+	}
+	if apiErr := cache.fetch(); apiErr != nil {
+		return nil, false, apiErr
+	}
+
+	if !h.geocodeClient.IsConfigured() {
+		return nil, false, &apiError{http.StatusServiceUnavailable, "EXTERNAL_API_ERROR", "Google Geocoding API not configured", nil}
+	}
+
+	result, err := h.geocodeClient.GeocodeToStandardFormatWithOptions(address, opts)
+	if err != nil {
+		return nil, false, &apiError{http.StatusBadGateway, "EXTERNAL_API_ERROR", fmt.Sprintf("Failed to geocode address: %v", err), err}
+	}
+
+	cache.record(false)
+	if cache.writes() {
+		_ = h.cacheService.SetStandardGeocodeResultWithOptions(address, opts, result)
+	}
+
+	return result, false, nil
+}
+
+// resolveGeoIP looks up an IP address through the cache, falling back to IPinfo and caching the result,
+// as far as the request's cache mode allows
+func (h *Handlers) resolveGeoIP(cache *cacheUse, ip string) (*models.GeoIPAPIResponse, bool, *apiError) {
+	if cache.reads() {
+		if cached, cacheHit := h.cacheService.GetStandardIPResult(ip); cacheHit {
+			geoip.NormalizeResponse(cached)
+			cache.record(true)
+			return cached, true, nil
+		}
+	}
+	if apiErr := cache.fetch(); apiErr != nil {
+		return nil, false, apiErr
+	}
+
+	result, err := h.geoipClient.GetIPInfoToStandardFormat(ip)
+	if err != nil {
+		return nil, false, &apiError{http.StatusBadGateway, "EXTERNAL_API_ERROR", fmt.Sprintf("Failed to get IP info: %v", err), err}
+	}
+
+	cache.record(false)
+	if cache.writes() {
+		_ = h.cacheService.SetStandardIPResult(ip, result)
+	}
+
+	return result, false, nil
+}
+
+// logLocalRequest records usage and activity for an endpoint answered from embedded data, with no upstream cost
+func (h *Handlers) logLocalRequest(r *http.Request, apiKey *models.APIKey, endpoint, queryText string, resultCount int, startTime time.Time) {
+	h.logLocalSource(r, apiKey, endpoint, queryText, resultCount, "local", startTime)
+}
+
+// logLocalSource is logLocalRequest with a more specific source than "local" in the activity log
+func (h *Handlers) logLocalSource(r *http.Request, apiKey *models.APIKey, endpoint, queryText string, resultCount int, apiSource string, startTime time.Time) {
+	responseTime := int(time.Since(startTime).Milliseconds())
+
+	_ = h.db.LogUsage(apiKey.ID, endpoint, false, responseTime)
+	h.logActivity(apiKey.Name, endpoint, queryText, resultCount, responseTime, apiSource, false, middleware.GetClientIP(r), r.UserAgent())
+
+	h.broadcastActivity(&models.ActivityLog{
+		Timestamp:      time.Now(),
+		APIKeyName:     apiKey.Name,
+		Endpoint:       endpoint,
+		QueryText:      queryText,
+		ResultCount:    resultCount,
+		ResponseTimeMs: responseTime,
+		APISource:      apiSource,
+		CacheHit:       false,
+		IPAddress:      middleware.GetClientIP(r),
+		UserAgent:      r.UserAgent(),
+	})
+	h.broadcastStats()
+}
+
+// logGeocodeRequest records usage, activity and cost for an endpoint answered through the geocoding cache or Google
+func (h *Handlers) logGeocodeRequest(r *http.Request, apiKey *models.APIKey, endpoint, queryText string, resultCount int, source geocodeSource, startTime time.Time) {
+	responseTime := int(time.Since(startTime).Milliseconds())
+	cacheHit := source == sourceCache
+
+	_ = h.db.LogUsage(apiKey.ID, endpoint, cacheHit, responseTime)
+	h.logActivity(apiKey.Name, endpoint, queryText, resultCount, responseTime, string(source), cacheHit, middleware.GetClientIP(r), r.UserAgent())
+
+	h.broadcastActivity(&models.ActivityLog{
+		Timestamp:      time.Now(),
+		APIKeyName:     apiKey.Name,
+		Endpoint:       endpoint,
+		QueryText:      queryText,
+		ResultCount:    resultCount,
+		ResponseTimeMs: responseTime,
+		APISource:      string(source),
+		CacheHit:       cacheHit,
+		IPAddress:      middleware.GetClientIP(r),
+		UserAgent:      r.UserAgent(),
+	})
+	h.trackGeocodeCost(source)
+	h.broadcastStats()
+}
+
+// trackGeocodeCost records a Google geocoding call, a cache hit or a free gazetteer answer in the daily cost tracking
+func (h *Handlers) trackGeocodeCost(source geocodeSource) {
+	today := time.Now().Truncate(24 * time.Hour)
+	switch source {
+	case sourceGoogle:
+		_ = h.db.UpdateCostTracking(today, 1, 0, 0, 0, 0.005) // $0.005 per Google API call
+	case sourceCache:
+		_ = h.db.UpdateCostTracking(today, 0, 1, 0, 0, 0)
+	case sourceGazetteer, sourceOverride:
+		_ = h.db.UpdateOfflineCostTracking(today, 1)
+	}
+}
+
+// logIPinfoRequest records usage, activity and cost for an endpoint answered through a cache or IPinfo
+func (h *Handlers) logIPinfoRequest(r *http.Request, apiKey *models.APIKey, endpoint, queryText string, resultCount int, cacheHit bool, startTime time.Time) {
+	responseTime := int(time.Since(startTime).Milliseconds())
+	apiSource := "cache"
+	if !cacheHit {
+		apiSource = "ipinfo"
+	}
+
+	_ = h.db.LogUsage(apiKey.ID, endpoint, cacheHit, responseTime)
+	h.logActivity(apiKey.Name, endpoint, queryText, resultCount, responseTime, apiSource, cacheHit, middleware.GetClientIP(r), r.UserAgent())
+
+	h.broadcastActivity(&models.ActivityLog{
+		Timestamp:      time.Now(),
+		APIKeyName:     apiKey.Name,
+		Endpoint:       endpoint,
+		QueryText:      queryText,
+		ResultCount:    resultCount,
+		ResponseTimeMs: responseTime,
+		APISource:      apiSource,
+		CacheHit:       cacheHit,
+		IPAddress:      middleware.GetClientIP(r),
+		UserAgent:      r.UserAgent(),
+	})
+
+	h.trackIPinfoCost(cacheHit)
+	h.broadcastStats()
+}
+
+// trackIPinfoCost records an IPinfo call or an IP cache hit in the daily cost tracking
+func (h *Handlers) trackIPinfoCost(cacheHit bool) {
+	today := time.Now().Truncate(24 * time.Hour)
+	if cacheHit {
+		_ = h.db.UpdateCostTracking(today, 0, 0, 0, 1, 0)
+	} else {
+		_ = h.db.UpdateCostTracking(today, 0, 0, 1, 0, 0.001) // $0.001 per IPinfo API call
+	}
+}
+
+// parseLanguage reads and validates the optional language parameter
+func parseLanguage(params url.Values) (string, error) {
+	opts, err := geocoding.ParseGeocodeOptions(params.Get("language"), "", "", "")
+	return opts.Language, err
+}
+
+// parseGeocodeOptions reads the language, region, bounds and components parameters of a forward geocoding request
+func parseGeocodeOptions(params url.Values) (geocoding.GeocodeOptions, error) {
+	return geocoding.ParseGeocodeOptions(params.Get("language"), params.Get("region"), params.Get("bounds"), params.Get("components"))
 }
 
-// extractIP extracts the IP address from a RemoteAddr string (removes port if present)
-func extractIP(remoteAddr string) string {
-	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
-		return host
+// parseStructuredAddress reads the structured address fields from the request parameters
+func parseStructuredAddress(params url.Values) models.StructuredAddress {
+	return models.StructuredAddress{
+		AddressLine1: params.Get("address_line_1"),
+		AddressLine2: params.Get("address_line_2"),
+		City:         params.Get("city"),
+		State:        params.Get("state"),
+		PostalCode:   params.Get("postal_code"),
+		Country:      params.Get("country"),
 	}
-	return remoteAddr // fallback if not in host:port format
 }
@@ -3,18 +3,30 @@ package api
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/mux"
+
 	"github.com/hackclub/geocoder/internal/cache"
+	"github.com/hackclub/geocoder/internal/database"
 	"github.com/hackclub/geocoder/internal/geocoding"
 	"github.com/hackclub/geocoder/internal/geoip"
+	"github.com/hackclub/geocoder/internal/iso3166"
 	"github.com/hackclub/geocoder/internal/middleware"
 	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/offline"
+	"github.com/hackclub/geocoder/internal/redact"
 )
 
 func TestHandleHealth(t *testing.T) {
@@ -169,6 +181,38 @@ func TestHandleGeoIP_InvalidIP(t *testing.T) {
 	}
 }
 
+func TestHandleGeoIP_Bogon(t *testing.T) {
+	db := &activityMockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	// The mock's cache returns nil, so reaching the cache or IPinfo would fail the request
+	req := httptest.NewRequest("GET", "/v1/geoip?ip=10.1.2.3", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleGeoIP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result models.GeoIPAPIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if !result.Bogon || result.BogonReason != geoip.BogonPrivate || result.Network != "10.0.0.0/8" || result.Backend != "offline" {
+		t.Errorf("Unexpected bogon response %+v", result)
+	}
+	if len(db.sources) != 1 || db.sources[0] != "bogon (private)" {
+		t.Errorf("Expected the bogon reason as the activity source, got %v", db.sources)
+	}
+}
+
 func TestHandleAdminKeys_GET(t *testing.T) {
 	db := &mockDB{}
 	geocodeClient := geocoding.NewClient("")
@@ -342,7 +386,16 @@ func (m *mockDB) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
 func (m *mockDB) UpdateAPIKeyUsage(keyID string) error                             { return nil }
 func (m *mockDB) GetAllAPIKeys() ([]models.APIKey, error)                          { return []models.APIKey{}, nil }
 func (m *mockDB) UpdateAPIKeyRateLimit(keyID string, rateLimitPerSecond int) error { return nil }
-func (m *mockDB) DeactivateAPIKey(keyID string) error                              { return nil }
+func (m *mockDB) UpdateAPIKeyRequireHeaderAuth(keyID string, requireHeaderAuth bool) error {
+	return nil
+}
+func (m *mockDB) UpdateAPIKeyCacheControl(keyID string, allowCacheControl bool) error {
+	return nil
+}
+func (m *mockDB) UpdateAPIKeyPrivacyPolicy(keyID string, policy models.PrivacyPolicy) error {
+	return nil
+}
+func (m *mockDB) DeactivateAPIKey(keyID string) error { return nil }
 func (m *mockDB) GetAddressCache(queryHash string) (*models.AddressCache, error) {
 	return nil, nil
 }
@@ -364,9 +417,100 @@ func (m *mockDB) GetStats() (*models.Stats, error) {
 func (m *mockDB) UpdateCostTracking(date time.Time, geocodeRequests, geocodeCacheHits, geoipRequests, geoipCacheHits int, estimatedCost float64) error {
 	return nil
 }
+func (m *mockDB) UpdateOfflineCostTracking(date time.Time, offlineRequests int) error {
+	return nil
+}
+func (m *mockDB) GetASNCache(asn int) (*models.ASNCache, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockDB) SetASNCache(asn int, responseData string) error {
+	return nil
+}
+func (m *mockDB) GetPlaceCache(placeID, language string, maxAge time.Duration) (*models.PlaceCache, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockDB) SetPlaceCache(placeID, language, responseData string, maxAge time.Duration) error {
+	return nil
+}
+func (m *mockDB) FindGazetteerPlaces(name, countryCode string, limit int) ([]models.GazetteerPlace, error) {
+	return nil, nil
+}
+func (m *mockDB) FindGazetteerPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error) {
+	return nil, nil
+}
+func (m *mockDB) FindGazetteerLocalizedName(geonameID int64, name, countryCode string, languages []string) (string, error) {
+	return "", nil
+}
+func (m *mockDB) CreateGeofence(apiKeyID, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	return &models.Geofence{ID: "fence-id", APIKeyID: apiKeyID, Collection: collection, Name: name, Geometry: geometry, RadiusM: radiusM, Properties: properties}, nil
+}
+func (m *mockDB) GetGeofences(apiKeyID, collection string) ([]models.Geofence, error) {
+	return []models.Geofence{}, nil
+}
+func (m *mockDB) GetGeofence(id string) (*models.Geofence, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockDB) UpdateGeofence(id, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockDB) DeleteGeofence(id string) error {
+	return sql.ErrNoRows
+}
+func (m *mockDB) CreateGeocodeOverride(override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	override.ID = "override-id"
+	return &override, nil
+}
+func (m *mockDB) GetGeocodeOverrides() ([]models.GeocodeOverride, error) {
+	return []models.GeocodeOverride{}, nil
+}
+func (m *mockDB) GetGeocodeOverride(id string) (*models.GeocodeOverride, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockDB) UpdateGeocodeOverride(id string, override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockDB) DeleteGeocodeOverride(id string) error {
+	return sql.ErrNoRows
+}
+func (m *mockDB) CreatePlaceCollection(apiKeyID, name string) (*models.PlaceCollection, error) {
+	return &models.PlaceCollection{ID: "collection-id", APIKeyID: apiKeyID, Name: name}, nil
+}
+func (m *mockDB) GetPlaceCollections(apiKeyID string) ([]models.PlaceCollection, error) {
+	return []models.PlaceCollection{}, nil
+}
+func (m *mockDB) GetPlaceCollection(id string) (*models.PlaceCollection, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockDB) DeletePlaceCollection(id string) error {
+	return sql.ErrNoRows
+}
+func (m *mockDB) AddCollectionPlaces(collectionID string, places []models.CollectionPlace) ([]models.CollectionPlace, error) {
+	return places, nil
+}
+func (m *mockDB) GetCollectionPlaces(collectionID string) ([]models.CollectionPlace, error) {
+	return []models.CollectionPlace{}, nil
+}
+func (m *mockDB) DeleteCollectionPlace(collectionID, placeID string) error {
+	return sql.ErrNoRows
+}
+func (m *mockDB) FindCollectionPlacesInBox(collectionID string, minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error) {
+	return []models.CollectionPlace{}, nil
+}
 func (m *mockDB) GetRecentActivity() ([]models.ActivityLog, error) {
 	return nil, nil
 }
+func (m *mockDB) DeleteActivityBefore(cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+func (m *mockDB) DeleteActivityMatching(values []string) (int64, error) {
+	return 0, nil
+}
+func (m *mockDB) DeleteAddressCache(queryHash, queryText string) (int64, error) {
+	return 0, nil
+}
+func (m *mockDB) DeleteIPCache(ipAddress string) (int64, error) {
+	return 0, nil
+}
 func (m *mockDB) LogActivity(apiKeyName, endpoint, queryText string, resultCount, responseTimeMs int, apiSource string, cacheHit bool, ipAddress, userAgent string) error {
 	return nil
 }
@@ -476,3 +620,1780 @@ func TestHandleReverseGeocode_NoAPIKey(t *testing.T) {
 		t.Errorf("Expected status 401, got %d", w.Code)
 	}
 }
+
+func TestHandleValidateAddress_MissingFields(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("test-key")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+
+	// Create request without any address fields
+	req := httptest.NewRequest("GET", "/v1/validate_address", nil)
+
+	// Add mock API key to context
+	apiKey := &models.APIKey{
+		ID:                 "test-id",
+		Name:               "test-key",
+		IsActive:           true,
+		RateLimitPerSecond: 10,
+	}
+	ctx := context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey)
+	req = req.WithContext(ctx)
+
+	w := httptest.NewRecorder()
+
+	handlers.HandleValidateAddress(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	var errorResp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+		t.Errorf("Failed to parse error response: %v", err)
+	}
+
+	if errorResp.Error.Code != "INVALID_ADDRESS" {
+		t.Errorf("Expected error code 'INVALID_ADDRESS', got '%s'", errorResp.Error.Code)
+	}
+}
+
+func TestHandleGeocode_JSONBody(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("test-key")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+
+	apiKey := &models.APIKey{
+		ID:                 "test-id",
+		Name:               "test-key",
+		IsActive:           true,
+		RateLimitPerSecond: 10,
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode string
+	}{
+		{"Invalid JSON", "{not json", "INVALID_REQUEST"},
+		{"Missing address", `{"address": ""}`, "INVALID_ADDRESS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/v1/geocode", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleGeocode(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+
+			var errorResp models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+				t.Errorf("Failed to parse error response: %v", err)
+			}
+
+			if errorResp.Error.Code != tt.expectedCode {
+				t.Errorf("Expected error code '%s', got '%s'", tt.expectedCode, errorResp.Error.Code)
+			}
+		})
+	}
+}
+
+func TestRequestParams_JSONBody(t *testing.T) {
+	body := `{"lat": 37.422476, "lng": -122.08425, "address_line_1": "1600 Amphitheatre Parkway", "ignored": null}`
+	req := httptest.NewRequest("POST", "/v1/reverse_geocode?lat=1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	params, err := requestParams(w, req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if params.Get("lat") != "37.422476" {
+		t.Errorf("Expected body lat to override query lat, got '%s'", params.Get("lat"))
+	}
+	if params.Get("lng") != "-122.08425" {
+		t.Errorf("Expected lng '-122.08425', got '%s'", params.Get("lng"))
+	}
+	if params.Get("address_line_1") != "1600 Amphitheatre Parkway" {
+		t.Errorf("Unexpected address_line_1 '%s'", params.Get("address_line_1"))
+	}
+	if params.Has("ignored") {
+		t.Error("Expected null values to be skipped")
+	}
+}
+
+func TestHandleGeoIPMe_EmptyPOST(t *testing.T) {
+	db := &mockDB{}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	// A private caller address is answered without IPinfo, so only the body handling is under test
+	req := httptest.NewRequest("POST", "/v1/geoip/me", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "192.168.1.20:51234"
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleGeoIPMe(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected a POST without a body to be accepted, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		accept   string
+		expected string
+		wantErr  bool
+	}{
+		{"Default", "", "", formatJSON, false},
+		{"Explicit format", "GeoJSON", "application/json", formatGeoJSON, false},
+		{"Accept header", "", "text/csv", formatCSV, false},
+		{"Accept header quality", "", "application/json;q=0.5, application/geo+json", formatGeoJSON, false},
+		{"Unknown accept type", "", "text/html", formatJSON, false},
+		{"Unknown format", "xml", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := negotiateFormat(tt.format, tt.accept)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if format != tt.expected {
+				t.Errorf("Expected format %q, got %q", tt.expected, format)
+			}
+		})
+	}
+}
+
+func TestGeoJSONEncoder(t *testing.T) {
+	result := &models.GeocodeAPIResponse{
+		Lat:              40.7128,
+		Lng:              -74.0060,
+		FormattedAddress: "New York, NY, USA",
+		Backend:          "google",
+		RawBackendResponse: map[string]interface{}{
+			"status": "OK",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := (geoJSONEncoder{}).Encode(&buf, result); err != nil {
+		t.Fatalf("Failed to encode feature: %v", err)
+	}
+
+	var feature geoJSONFeature
+	if err := json.Unmarshal(buf.Bytes(), &feature); err != nil {
+		t.Fatalf("Failed to parse feature: %v", err)
+	}
+
+	if feature.Type != "Feature" || feature.Geometry == nil {
+		t.Fatalf("Expected a Feature with geometry, got %+v", feature)
+	}
+	if feature.Geometry.Coordinates[0] != -74.0060 || feature.Geometry.Coordinates[1] != 40.7128 {
+		t.Errorf("Expected [lng, lat] coordinates, got %v", feature.Geometry.Coordinates)
+	}
+	if feature.Properties["formatted_address"] != "New York, NY, USA" {
+		t.Errorf("Expected formatted_address property, got %v", feature.Properties)
+	}
+	if _, exists := feature.Properties["raw_backend_response"]; exists {
+		t.Error("Expected raw_backend_response to be left out of properties")
+	}
+
+	buf.Reset()
+	if err := (geoJSONEncoder{compact: true}).Encode(&buf, []*models.GeocodeAPIResponse{result, result}); err != nil {
+		t.Fatalf("Failed to encode feature collection: %v", err)
+	}
+
+	var collection geoJSONFeatureCollection
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatalf("Failed to parse feature collection: %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+		t.Errorf("Expected a FeatureCollection with 2 features, got %+v", collection)
+	}
+}
+
+func TestCSVEncoder(t *testing.T) {
+	validation := &models.AddressValidationResponse{
+		Valid:       true,
+		Granularity: "rooftop",
+		Components: models.AddressComponentVerdicts{
+			City: &models.ComponentVerdict{Status: "matched", Input: "Shelburne", Geocoded: "Shelburne"},
+		},
+		Lat: 44.3923,
+		Lng: -73.2290,
+	}
+
+	var buf bytes.Buffer
+	if err := (csvEncoder{}).Encode(&buf, validation); err != nil {
+		t.Fatalf("Failed to encode CSV: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected header and one row, got %d lines", len(lines))
+	}
+
+	header := strings.Split(lines[0], ",")
+	row := strings.Split(lines[1], ",")
+	if len(header) != len(row) {
+		t.Fatalf("Expected %d cells per row, got %d", len(header), len(row))
+	}
+
+	values := map[string]string{}
+	for i, column := range header {
+		values[column] = row[i]
+	}
+	if values["components.city.status"] != "matched" {
+		t.Errorf("Expected components.city.status to be matched, got %q", values["components.city.status"])
+	}
+	if _, exists := values["components.state.status"]; !exists {
+		t.Error("Expected columns for components that were not supplied")
+	}
+	if values["lat"] != "44.3923" {
+		t.Errorf("Expected lat 44.3923, got %q", values["lat"])
+	}
+}
+
+func TestEncoders_ListResponse(t *testing.T) {
+	nearby := &models.NearbyResponse{
+		Lat:    44.4759,
+		Lng:    -73.2121,
+		Source: "coordinates",
+		Places: []models.NearbyPlace{
+			{CollectionPlace: models.CollectionPlace{Name: "HQ", Lat: 44.4760, Lng: -73.2120}, DistanceM: 14},
+			{CollectionPlace: models.CollectionPlace{Name: "Annex", Lat: 44.4800, Lng: -73.2100}, DistanceM: 490},
+		},
+	}
+
+	var geoJSON bytes.Buffer
+	if err := (geoJSONEncoder{}).Encode(&geoJSON, nearby); err != nil {
+		t.Fatalf("Failed to encode GeoJSON: %v", err)
+	}
+	var collection geoJSONFeatureCollection
+	if err := json.Unmarshal(geoJSON.Bytes(), &collection); err != nil {
+		t.Fatalf("Failed to parse feature collection: %v", err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+		t.Fatalf("Expected a FeatureCollection of the places, got %s", geoJSON.String())
+	}
+	if collection.Features[1].Properties["name"] != "Annex" || collection.Features[1].Geometry == nil {
+		t.Errorf("Expected each place as a point feature, got %+v", collection.Features[1])
+	}
+
+	var csvOut bytes.Buffer
+	if err := (csvEncoder{}).Encode(&csvOut, nearby); err != nil {
+		t.Fatalf("Failed to encode CSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and a row per place, got %q", csvOut.String())
+	}
+	// Embedded structs are inlined rather than prefixed with their type name
+	if !strings.HasPrefix(lines[0], "id,collection_id,name,") || !strings.HasSuffix(lines[0], ",distance_m") {
+		t.Errorf("Unexpected CSV header %q", lines[0])
+	}
+}
+
+func TestHandleGeocode_UnsupportedFormat(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+
+	req := httptest.NewRequest("GET", "/v1/geocode?address=test&format=xml", nil)
+	w := httptest.NewRecorder()
+
+	handlers.HandleGeocode(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	var errorResp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+		t.Fatalf("Failed to parse error response: %v", err)
+	}
+
+	if errorResp.Error.Code != "INVALID_REQUEST" {
+		t.Errorf("Expected error code INVALID_REQUEST, got %s", errorResp.Error.Code)
+	}
+}
+
+func TestHandleCountrySubdivisions(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	req := httptest.NewRequest("GET", "/v1/countries/CA/subdivisions", nil)
+	req = mux.SetURLVars(req, map[string]string{"code": "CA"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleCountrySubdivisions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var subdivisions []iso3166.Subdivision
+	if err := json.Unmarshal(w.Body.Bytes(), &subdivisions); err != nil {
+		t.Fatalf("Failed to parse subdivisions: %v", err)
+	}
+	if len(subdivisions) != 13 {
+		t.Errorf("Expected 13 Canadian provinces and territories, got %d", len(subdivisions))
+	}
+
+	req = httptest.NewRequest("GET", "/v1/countries/XX/subdivisions", nil)
+	req = mux.SetURLVars(req, map[string]string{"code": "XX"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w = httptest.NewRecorder()
+
+	handlers.HandleCountrySubdivisions(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown country, got %d", w.Code)
+	}
+}
+
+func TestHandleCountries_Language(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	req := httptest.NewRequest("GET", "/v1/countries?language=de", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleCountries(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var countries []iso3166.Country
+	if err := json.Unmarshal(w.Body.Bytes(), &countries); err != nil {
+		t.Fatalf("Failed to parse countries: %v", err)
+	}
+	for _, country := range countries {
+		if country.Alpha2 == "DE" && country.Name != "Deutschland" {
+			t.Errorf("Expected German name 'Deutschland', got '%s'", country.Name)
+		}
+	}
+
+	req = httptest.NewRequest("GET", "/v1/countries?language=not_a_language!", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w = httptest.NewRecorder()
+
+	handlers.HandleCountries(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid language, got %d", w.Code)
+	}
+}
+
+func TestHandleReverseGeocode_OfflinePrecision(t *testing.T) {
+	db := &mockDB{}
+	// No Google key: offline lookups must not need one
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+		errorCode    string
+	}{
+		{"lat=52.52&lng=13.405&precision=country", http.StatusOK, ""},
+		{"lat=52.52&lng=13.405&precision=street", http.StatusBadRequest, "INVALID_REQUEST"},
+		{"lat=52.52&lng=13.405&precision=city", http.StatusServiceUnavailable, "OFFLINE_DATA_UNAVAILABLE"},
+		{"lat=30&lng=-40&precision=country", http.StatusNotFound, "NO_RESULTS"},
+		{"lat=52.52&lng=13.405&precision=country&encodings=geohash:3,h3:2", http.StatusOK, ""},
+		{"lat=52.52&lng=13.405&precision=country&encodings=s2", http.StatusBadRequest, "INVALID_ENCODING"},
+		{"mgrs=33UUU9177920072&precision=country", http.StatusOK, ""},
+		{"utm=33N+391779+5820072&precision=country", http.StatusOK, ""},
+		{"epsg3857=1492237.8,6894699.8&precision=country", http.StatusOK, ""},
+		{"mgrs=33UUU9177920072&lat=52.52&precision=country", http.StatusBadRequest, "INVALID_COORDINATES"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/reverse_geocode?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleReverseGeocode(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.errorCode != "" {
+				var errorResp models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil || errorResp.Error.Code != tt.errorCode {
+					t.Errorf("Expected error code %s, got %s", tt.errorCode, w.Body.String())
+				}
+				return
+			}
+
+			var result models.ReverseGeocodeAPIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if result.CountryCode != "DE" || result.Precision != "country" {
+				t.Errorf("Expected Germany at country precision, got %+v", result)
+			}
+			if strings.Contains(tt.query, "encodings") {
+				if result.Encodings == nil || len(result.Encodings.Geohash) != 3 || result.Encodings.H3 == "" || result.Encodings.PlusCode != "" {
+					t.Errorf("Expected a geohash and an H3 cell of the result, got %+v", result.Encodings)
+				}
+			} else if result.Encodings != nil {
+				t.Errorf("Expected no encodings unless asked for, got %+v", result.Encodings)
+			}
+		})
+	}
+}
+
+func TestHandleReverseGeocode_OfflineCityLocalized(t *testing.T) {
+	citiesPath := filepath.Join(t.TempDir(), "cities15000.txt")
+	cities := "2950159\tBerlin\tBerlin\t\t52.52437\t13.41053\tP\tPPLC\tDE\t\t16\t00\t11000\t11000000\t3426354\t74\t43\tEurope/Berlin\t2022-10-03\n"
+	if err := os.WriteFile(citiesPath, []byte(cities), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	geocoder, err := offline.New("", "", citiesPath)
+	if err != nil {
+		t.Fatalf("offline.New() error = %v", err)
+	}
+
+	db := &gazetteerMockDB{localizedNames: map[int64]map[string]string{2950159: {"it": "Berlino"}}}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+	handlers.SetOfflineGeocoder(geocoder)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		language          string
+		expectedCity      string
+		expectedFormatted string
+	}{
+		{"it", "Berlino", "Berlino, Germania"},
+		{"pt-BR", "Berlin", "Berlin, Alemanha"},
+		{"", "Berlin", "Berlin, Germany"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/reverse_geocode?lat=52.52&lng=13.405&precision=city&language="+tt.language, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleReverseGeocode(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var result models.ReverseGeocodeAPIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if result.City != tt.expectedCity || result.AdminHierarchy.City != tt.expectedCity || result.FormattedAddress != tt.expectedFormatted {
+				t.Errorf("Expected %q in %q, got %q, %q in %q", tt.expectedCity, tt.expectedFormatted, result.City, result.AdminHierarchy.City, result.FormattedAddress)
+			}
+		})
+	}
+}
+
+func TestHandleReverseGeocode_PrivacyPolicy(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key", Privacy: models.PrivacyPolicy{CityLevel: true}}
+
+	req := httptest.NewRequest("GET", "/v1/reverse_geocode?lat=52.520008&lng=13.404954&precision=country&encodings=geohash:9", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleReverseGeocode(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result models.ReverseGeocodeAPIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.Lat != 52.5 || result.Lng != 13.4 || result.CountryCode != "DE" {
+		t.Errorf("Expected Germany at city-level coordinates, got %+v", result)
+	}
+	// Encodings at full precision would give the exact point away
+	if result.Encodings == nil || !strings.HasPrefix(result.Encodings.Geohash, "u33d8") {
+		t.Errorf("Expected the geohash of the rounded point, got %+v", result.Encodings)
+	}
+}
+
+func TestHandleUpdateAPIKeyPrivacy(t *testing.T) {
+	db := &mockDB{}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+
+	tests := []struct {
+		body         string
+		expectedCode int
+	}{
+		{`{"coordinate_decimals": 2, "jitter_meters": 500, "suppress_street": true}`, http.StatusOK},
+		{`{"city_level": true}`, http.StatusOK},
+		{`{}`, http.StatusOK},
+		{`{"coordinate_decimals": 9}`, http.StatusBadRequest},
+		{`{"jitter_meters": -5}`, http.StatusBadRequest},
+		{`{"city_level": "yes"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/admin/keys/test-id/privacy", strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"key_id": "test-id"})
+			w := httptest.NewRecorder()
+
+			handlers.HandleUpdateAPIKeyPrivacy(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// activityMockDB is a mockDB that records what is written to and erased from the activity log
+type activityMockDB struct {
+	mockDB
+	queryTexts  []string
+	ipAddresses []string
+	sources     []string
+	erased      []string
+}
+
+func (m *activityMockDB) LogActivity(apiKeyName, endpoint, queryText string, resultCount, responseTimeMs int, apiSource string, cacheHit bool, ipAddress, userAgent string) error {
+	m.queryTexts = append(m.queryTexts, queryText)
+	m.ipAddresses = append(m.ipAddresses, ipAddress)
+	m.sources = append(m.sources, apiSource)
+	return nil
+}
+
+func (m *activityMockDB) DeleteActivityMatching(values []string) (int64, error) {
+	m.erased = append(m.erased, values...)
+	return int64(len(values)), nil
+}
+
+func TestHandleReverseGeocode_ActivityRedaction(t *testing.T) {
+	db := &activityMockDB{}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+	handlers.SetActivityRedaction(redact.New(redact.City, "secret"), redact.New(redact.Hashed, "secret"))
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	req := httptest.NewRequest("GET", "/v1/reverse_geocode?lat=52.520008&lng=13.404954&precision=country", nil)
+	req.RemoteAddr = "203.0.113.42:51234"
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleReverseGeocode(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(db.queryTexts) != 1 || db.queryTexts[0] != "52.5,13.4" {
+		t.Errorf("Expected city-level coordinates in the activity log, got %v", db.queryTexts)
+	}
+	if len(db.ipAddresses) != 1 || db.ipAddresses[0] != "203.0.113.0" {
+		t.Errorf("Expected the client IP truncated to its network, got %v", db.ipAddresses)
+	}
+	// The response itself is not redacted
+	if !strings.Contains(w.Body.String(), "52.520008") {
+		t.Errorf("Expected full coordinates in the response, got %s", w.Body.String())
+	}
+}
+
+func TestHandleAdminErase(t *testing.T) {
+	redactor := redact.New(redact.Hashed, "secret")
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedCode   int
+		expectedErased []string
+	}{
+		{"address", `{"address": " 1600 Amphitheatre Parkway, Mountain View, CA "}`, http.StatusOK,
+			[]string{"1600 Amphitheatre Parkway, Mountain View, CA", redactor.Hash("1600 amphitheatre parkway, mountain view, ca")}},
+		{"ip", `{"ip": "2001:DB8::1"}`, http.StatusOK,
+			[]string{"2001:DB8::1", "2001:db8::1", redactor.Hash("2001:db8::1")}},
+		{"both", `{"address": "Berlin", "ip": "192.0.2.1"}`, http.StatusBadRequest, nil},
+		{"neither", `{}`, http.StatusBadRequest, nil},
+		{"invalid ip", `{"ip": "not-an-ip"}`, http.StatusBadRequest, nil},
+		{"invalid json", `{"ip":`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &activityMockDB{}
+			handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+			handlers.SetActivityRedaction(redactor, nil)
+
+			req := httptest.NewRequest("POST", "/admin/erase", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handlers.HandleAdminErase(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+			if strings.Join(db.erased, "|") != strings.Join(tt.expectedErased, "|") {
+				t.Errorf("Expected activity matching %v to be erased, got %v", tt.expectedErased, db.erased)
+			}
+			var result models.EraseResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if result.ActivityEntries != int64(len(tt.expectedErased)) {
+				t.Errorf("Expected %d activity entries erased, got %d", len(tt.expectedErased), result.ActivityEntries)
+			}
+		})
+	}
+}
+
+// gazetteerMockDB is a mockDB with gazetteer data
+type gazetteerMockDB struct {
+	mockDB
+	places         []models.GazetteerPlace
+	postalCodes    []models.GazetteerPostalCode
+	localizedNames map[int64]map[string]string // By geonameid and language
+}
+
+func (m *gazetteerMockDB) FindGazetteerPlaces(name, countryCode string, limit int) ([]models.GazetteerPlace, error) {
+	return m.places, nil
+}
+
+func (m *gazetteerMockDB) FindGazetteerPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error) {
+	var codes []models.GazetteerPostalCode
+	for _, code := range m.postalCodes {
+		if code.CountryCode == countryCode && code.PostalCode == postalCode {
+			codes = append(codes, code)
+		}
+	}
+	return codes, nil
+}
+
+func (m *gazetteerMockDB) FindGazetteerLocalizedName(geonameID int64, name, countryCode string, languages []string) (string, error) {
+	for _, language := range languages {
+		if localized, ok := m.localizedNames[geonameID][language]; ok {
+			return localized, nil
+		}
+	}
+	return "", nil
+}
+
+func TestHandleGeocode_Gazetteer(t *testing.T) {
+	db := &gazetteerMockDB{places: []models.GazetteerPlace{
+		{GeonameID: 5234372, Name: "Burlington", Lat: 44.47588, Lng: -73.21207, CountryCode: "US", Admin1Code: "VT", Admin1Name: "Vermont", Population: 42239},
+	}}
+	// No Google key: city-level queries the gazetteer answers must not need one
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		name    string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"free-form", "/v1/geocode?address=Burlington,+VT", handlers.HandleGeocode},
+		{"structured", "/v1/geocode_structured?city=Burlington&state=Vermont&country=US", handlers.HandleGeocodeStructured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var result models.GeocodeAPIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if result.Backend != "geonames_gazetteer" || result.StateCode != "VT" || result.Lat != 44.47588 {
+				t.Errorf("Expected Burlington, VT from the gazetteer, got %+v", result)
+			}
+		})
+	}
+}
+
+// overrideMockDB is a mockDB with geocode overrides, and a cached Google result for every address
+type overrideMockDB struct {
+	mockDB
+	overrides []models.GeocodeOverride
+	cached    models.GeocodeAPIResponse
+}
+
+func (m *overrideMockDB) GetGeocodeOverrides() ([]models.GeocodeOverride, error) {
+	return m.overrides, nil
+}
+
+func (m *overrideMockDB) GetAddressCache(queryHash string) (*models.AddressCache, error) {
+	data, _ := json.Marshal(m.cached)
+	return &models.AddressCache{QueryHash: queryHash, ResponseData: string(data)}, nil
+}
+
+func TestHandleGeocode_Overrides(t *testing.T) {
+	db := &overrideMockDB{
+		overrides: []models.GeocodeOverride{
+			{ID: "hq", AddressPattern: "15 falls rd shelburne*", Lat: 44.3806, Lng: -73.2273, FormattedAddress: "15 Falls Rd, Shelburne, VT 05482, USA",
+				AdminHierarchy: models.AdminHierarchy{City: "Shelburne", State: "Vermont", StateCode: "VT", Country: "United States", CountryCode: "US"}},
+			{ID: "venue", PlaceID: "ChIJvenue", Lat: 40.7505, Lng: -73.9934, FormattedAddress: "Madison Square Garden, New York, NY 10001, USA"},
+		},
+		cached: models.GeocodeAPIResponse{Lat: 40.7, Lng: -74, FormattedAddress: "Somewhere else", PlaceID: "ChIJvenue", Backend: "google_maps_platform_geocoding"},
+	}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		name    string
+		path    string
+		handler http.HandlerFunc
+		wantLat float64
+	}{
+		{"free-form", "/v1/geocode?address=15+Falls+Rd.,+Shelburne,+VT", handlers.HandleGeocode, 44.3806},
+		{"structured", "/v1/geocode_structured?address_line_1=15+Falls+Rd&city=Shelburne&state=VT&country=US", handlers.HandleGeocodeStructured, 44.3806},
+		{"place ID", "/v1/geocode?address=4+Pennsylvania+Plaza", handlers.HandleGeocode, 40.7505},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var result models.GeocodeAPIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if result.Backend != "override" || result.Lat != tt.wantLat {
+				t.Errorf("Expected the pinned result at %v, got %+v", tt.wantLat, result)
+			}
+		})
+	}
+}
+
+// cacheMissMockDB is a mockDB with nothing cached
+type cacheMissMockDB struct {
+	mockDB
+}
+
+func (m *cacheMissMockDB) GetAddressCache(queryHash string) (*models.AddressCache, error) {
+	return nil, sql.ErrNoRows
+}
+
+func TestHandleGeocode_CacheModes(t *testing.T) {
+	cached := &overrideMockDB{cached: models.GeocodeAPIResponse{Lat: 37.4223, Lng: -122.0844, Backend: "google_maps_platform_geocoding"}}
+	empty := &cacheMissMockDB{}
+
+	tests := []struct {
+		name           string
+		db             database.DatabaseInterface
+		query          string
+		allowControl   bool
+		expectedCode   int
+		expectedError  string
+		expectedHeader string
+	}{
+		{"default hit", cached, "", false, http.StatusOK, "", "HIT"},
+		{"only hit", cached, "&cache=only", false, http.StatusOK, "", "HIT"},
+		{"only miss", empty, "&cache=only", false, http.StatusNotFound, "CACHE_MISS", ""},
+		// Without a Google key a fresh lookup fails, which shows the cached result was skipped
+		{"refresh skips the cache", cached, "&cache=refresh", true, http.StatusServiceUnavailable, "EXTERNAL_API_ERROR", ""},
+		{"bypass skips the cache", cached, "&cache=bypass", true, http.StatusServiceUnavailable, "EXTERNAL_API_ERROR", ""},
+		{"refresh not allowed", cached, "&cache=refresh", false, http.StatusForbidden, "CACHE_MODE_NOT_ALLOWED", ""},
+		{"bypass not allowed", cached, "&cache=bypass", false, http.StatusForbidden, "CACHE_MODE_NOT_ALLOWED", ""},
+		{"unknown mode", cached, "&cache=never", true, http.StatusBadRequest, "INVALID_REQUEST", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers := NewHandlers(tt.db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(tt.db, 1000, 1000))
+			apiKey := &models.APIKey{ID: "test-id", Name: "test-key", AllowCacheControl: tt.allowControl}
+
+			req := httptest.NewRequest("GET", "/v1/geocode?address=1600+Amphitheatre+Parkway"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleGeocode(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" && !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("Expected error %s, got %s", tt.expectedError, w.Body.String())
+			}
+			if got := w.Header().Get("X-Cache"); got != tt.expectedHeader {
+				t.Errorf("Expected X-Cache %q, got %q", tt.expectedHeader, got)
+			}
+		})
+	}
+}
+
+func TestHandleGeoIP_CacheOnlyPrivateAddress(t *testing.T) {
+	db := &mockDB{}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	// Private addresses are answered without IPinfo, so cache=only doesn't stop them
+	req := httptest.NewRequest("GET", "/v1/geoip?ip=192.168.1.10&cache=only", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleGeoIP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("X-Cache") != "" {
+		t.Errorf("Expected an offline answer without a cache header, got %d with X-Cache %q: %s", w.Code, w.Header().Get("X-Cache"), w.Body.String())
+	}
+}
+
+func TestHandleAdminOverrides(t *testing.T) {
+	db := &mockDB{}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+
+	tests := []struct {
+		body         string
+		expectedCode int
+	}{
+		{`{"address_pattern": "15 Falls Rd., Shelburne, VT", "lat": 44.3806, "lng": -73.2273}`, http.StatusCreated},
+		{`{"place_id": "ChIJvenue", "lat": 40.7505, "lng": -73.9934, "admin_hierarchy": {"country_code": "usa"}}`, http.StatusCreated},
+		{`{"lat": 44.3806, "lng": -73.2273}`, http.StatusBadRequest},
+		{`{"address_pattern": "* *", "lat": 44.3806, "lng": -73.2273}`, http.StatusBadRequest},
+		{`{"address_pattern": "15 Falls Rd", "lat": 95, "lng": -73.2273}`, http.StatusBadRequest},
+		{`{"address_pattern": "15 Falls Rd"}`, http.StatusBadRequest},
+		{`{"place_id": "ChIJvenue", "lat": 40.7505, "lng": -73.9934, "admin_hierarchy": {"country_code": "XX"}}`, http.StatusBadRequest},
+		{`{"place_id":`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/admin/overrides", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handlers.HandleAdminOverrides(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+			var created models.GeocodeOverride
+			if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if strings.ContainsAny(created.AddressPattern, ".,") || (created.AdminHierarchy.CountryCode != "" && created.AdminHierarchy.CountryCode != "US") {
+				t.Errorf("Expected a normalized override, got %+v", created)
+			}
+		})
+	}
+}
+
+func TestHandlePostalCode(t *testing.T) {
+	db := &gazetteerMockDB{postalCodes: []models.GazetteerPostalCode{
+		{CountryCode: "US", PostalCode: "05482", PlaceName: "Shelburne", Admin1Name: "Vermont", Admin1Code: "VT", Admin2Name: "Chittenden", Lat: 44.3923, Lng: -73.2197},
+	}}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+		errorCode    string
+	}{
+		{"country=US&code=05482-1234", http.StatusOK, ""},
+		{"country=USA&code=054821234", http.StatusOK, ""},
+		{"country=US", http.StatusBadRequest, "INVALID_POSTAL_CODE"},
+		{"country=US&code=1234", http.StatusBadRequest, "INVALID_POSTAL_CODE"},
+		{"country=AE&code=12345", http.StatusBadRequest, "INVALID_POSTAL_CODE"},
+		{"country=Atlantis&code=12345", http.StatusBadRequest, "INVALID_COUNTRY"},
+		{"country=US&code=05482&fallback=maybe", http.StatusBadRequest, "INVALID_REQUEST"},
+		{"country=US&code=90210", http.StatusNotFound, "NO_RESULTS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/postal_code?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandlePostalCode(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.errorCode != "" {
+				var errorResp models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil || errorResp.Error.Code != tt.errorCode {
+					t.Errorf("Expected error code %s, got %s", tt.errorCode, w.Body.String())
+				}
+				return
+			}
+
+			var result models.PostalCodeResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if result.PostalCode != "05482-1234" || len(result.Places) != 1 || result.Places[0].StateISOCode != "US-VT" || result.AdminHierarchy.City != "Shelburne" {
+				t.Errorf("Expected Shelburne, VT from offline data, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestHandleParseAddress(t *testing.T) {
+	mockDB := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(mockDB, 1000, 1000)
+
+	handlers := NewHandlers(mockDB, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	req := httptest.NewRequest("GET", "/v1/parse_address?address="+url.QueryEscape("123 Main St Apt 4, Springfield, IL 62701"), nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleParseAddress(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result models.ParsedAddressResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.AddressLine1 != "123 Main St" || result.AddressLine2 != "Apt 4" || result.City != "Springfield" || result.State != "IL" || result.PostalCode != "62701" || result.Country != "US" {
+		t.Errorf("Unexpected parse %+v", result)
+	}
+
+	req = httptest.NewRequest("GET", "/v1/parse_address", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w = httptest.NewRecorder()
+
+	handlers.HandleParseAddress(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without an address, got %d", w.Code)
+	}
+}
+
+func TestHandleFormatAddress(t *testing.T) {
+	mockDB := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(mockDB, 1000, 1000)
+
+	handlers := NewHandlers(mockDB, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	req := httptest.NewRequest("GET", "/v1/format_address?address_line_1=Unter+den+Linden+77&city=Berlin&postal_code=10117&country=DE", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleFormatAddress(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result models.FormattedAddressResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.FormattedAddress != "Unter den Linden 77\n10117 Berlin\nGERMANY" || len(result.Lines) != 3 || result.CountryCode != "DE" {
+		t.Errorf("Unexpected formatted address %+v", result)
+	}
+
+	req = httptest.NewRequest("GET", "/v1/format_address", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w = httptest.NewRecorder()
+
+	handlers.HandleFormatAddress(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without any address fields, got %d", w.Code)
+	}
+}
+
+func TestHandleEncode(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+		expected     models.Encodings
+	}{
+		{"lat=47.365590&lng=8.524997", http.StatusOK, models.Encodings{Geohash: "u0qj3yxsw", PlusCode: "8FVC9G8F+6X", H3: "891f8ed909bffff"}},
+		{"lat=47.365590&lng=8.524997&encodings=pluscode:11", http.StatusOK, models.Encodings{PlusCode: "8FVC9G8F+6XQ"}},
+		{"lat=47.365590&lng=8.524997&encodings=geohash:13", http.StatusBadRequest, models.Encodings{}},
+		{"lat=47.365590", http.StatusBadRequest, models.Encodings{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/encode?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleEncode(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var result models.EncodeResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if result.Encodings != tt.expected {
+				t.Errorf("Expected encodings %+v, got %+v", tt.expected, result.Encodings)
+			}
+		})
+	}
+}
+
+func TestHandleConvert(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+		source       string
+	}{
+		{"lat=52.52&lng=13.405", http.StatusOK, "wgs84"},
+		{"mgrs=33UUU9177920072", http.StatusOK, "mgrs"},
+		{"utm=33N+391779.259+5820072.159", http.StatusOK, "utm"},
+		{"epsg3857=1492237.774,6894699.801", http.StatusOK, "epsg3857"},
+		{"lat=52.52&lng=13.405&to=utm,bng", http.StatusBadRequest, ""},
+		{"lat=52.52&lng=13.405&mgrs_precision=6", http.StatusBadRequest, ""},
+		{"utm=33N+391779", http.StatusBadRequest, ""},
+		{"lat=89&lng=0&to=utm", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/convert?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleConvert(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var result models.ConvertResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			// Every input above is the same point in Berlin, to within a metre
+			if result.Source != tt.source || math.Abs(result.Lat-52.52) > 1e-5 || math.Abs(result.Lng-13.405) > 1e-5 {
+				t.Errorf("Expected (52.52, 13.405) from %s, got %+v", tt.source, result)
+			}
+			if result.UTM == nil || result.UTM.Zone != 33 || result.UTM.Hemisphere != "N" || result.UTM.Band != "U" || math.Abs(result.UTM.Easting-391779.259) > 1 {
+				t.Errorf("Unexpected UTM coordinate %+v", result.UTM)
+			}
+			if result.MGRS[:5] != "33UUU" || result.EPSG3857 == nil || math.Abs(result.EPSG3857.X-1492237.774) > 1 {
+				t.Errorf("Unexpected MGRS %q or Web Mercator %+v", result.MGRS, result.EPSG3857)
+			}
+		})
+	}
+
+	// Systems that don't reach the point are left out unless asked for
+	req := httptest.NewRequest("GET", "/v1/convert?lat=89&lng=0", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+	handlers.HandleConvert(w, req)
+
+	var result models.ConvertResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 near the pole, got %d: %s", w.Code, w.Body.String())
+	}
+	if result.UTM != nil || result.MGRS != "" || result.EPSG3857 != nil {
+		t.Errorf("Expected no projected coordinates near the pole, got %+v", result)
+	}
+}
+
+func TestHandleDecode(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+		errorCode    string
+		expectedType string
+	}{
+		{"code=u0qj3yxsw", http.StatusOK, "", "geohash"},
+		{"code=8FVC9G8F%2B6X", http.StatusOK, "", "pluscode"},
+		{"code=891f8ed909bffff", http.StatusOK, "", "h3"},
+		{"code=9G8F%2B6X", http.StatusBadRequest, "INVALID_CODE", ""},
+		{"code=u0qj3yxsw&type=s2", http.StatusBadRequest, "INVALID_ENCODING", ""},
+		{"", http.StatusBadRequest, "INVALID_CODE", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/decode?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleDecode(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.errorCode != "" {
+				var errorResp models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil || errorResp.Error.Code != tt.errorCode {
+					t.Errorf("Expected error code %s, got %s", tt.errorCode, w.Body.String())
+				}
+				return
+			}
+
+			var result models.DecodeResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			// Every code above covers the same building in Zurich
+			if result.Type != tt.expectedType || math.Abs(result.Lat-47.3656) > 0.002 || math.Abs(result.Lng-8.525) > 0.002 {
+				t.Errorf("Expected a %s cell around (47.3656, 8.525), got %+v", tt.expectedType, result)
+			}
+		})
+	}
+}
+
+func TestHandleASN(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("") // No token, so only the hosting list answers
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		asn          string
+		expectedCode int
+	}{
+		{"AS14061", http.StatusOK},
+		{"14061", http.StatusOK},
+		{"AS7922", http.StatusServiceUnavailable},
+		{"Google", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.asn, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/asn/"+tt.asn, nil)
+			req = mux.SetURLVars(req, map[string]string{"asn": tt.asn})
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleASN(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var result models.ASNAPIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if result.ASN != 14061 || result.Name != "DigitalOcean" || !result.Hosting || result.Backend != geoip.PrivacySourceHostingList {
+				t.Errorf("Unexpected ASN response %+v", result)
+			}
+		})
+	}
+}
+
+// consistencyMockDB is an activityMockDB with a cached geocode that records usage cache hits
+type consistencyMockDB struct {
+	activityMockDB
+	cached    *models.GeocodeAPIResponse
+	cacheHits []bool
+}
+
+func (m *consistencyMockDB) GetAddressCache(queryHash string) (*models.AddressCache, error) {
+	data, _ := json.Marshal(m.cached)
+	return &models.AddressCache{QueryHash: queryHash, ResponseData: string(data)}, nil
+}
+
+func (m *consistencyMockDB) LogUsage(apiKeyID, endpoint string, cacheHit bool, responseTimeMs int) error {
+	m.cacheHits = append(m.cacheHits, cacheHit)
+	return nil
+}
+
+func TestHandleConsistency_CachedAddressPrivateIP(t *testing.T) {
+	db := &consistencyMockDB{cached: &models.GeocodeAPIResponse{Lat: 44.476, Lng: -73.212, CountryCode: "US", Backend: "google"}}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	req := httptest.NewRequest("GET", "/v1/consistency?address=Burlington,+VT&ip=192.168.1.20", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleConsistency(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !reflect.DeepEqual(db.cacheHits, []bool{true}) || !reflect.DeepEqual(db.sources, []string{"cache"}) {
+		t.Errorf("Expected a cache hit logged with the cache source, got %v and %v", db.cacheHits, db.sources)
+	}
+}
+
+func TestHandleConsistency_InvalidParams(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		query        string
+		expectedCode string
+	}{
+		{"ip=8.8.8.8", "INVALID_ADDRESS"},
+		{"address=Shelburne+VT", "INVALID_IP"},
+		{"address=Shelburne+VT&ip=not-an-ip", "INVALID_IP"},
+		{"address=Shelburne+VT&ip=8.8.8.8&region=usa", "INVALID_REQUEST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/consistency?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleConsistency(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var errorResp models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+				t.Fatalf("Failed to parse error response: %v", err)
+			}
+			if errorResp.Error.Code != tt.expectedCode {
+				t.Errorf("Expected error code %s, got %s", tt.expectedCode, errorResp.Error.Code)
+			}
+		})
+	}
+}
+
+func TestHandlePlace(t *testing.T) {
+	db := &mockDB{}
+	geocodeClient := geocoding.NewClient("") // Not configured, so a cache miss can't reach Google
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		placeID        string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"ChIJ2eUgeAK6j4ARbn5u_wAGqWA", http.StatusServiceUnavailable, "EXTERNAL_API_ERROR"},
+		{"not a place id", http.StatusBadRequest, "INVALID_PLACE_ID"},
+		{"", http.StatusBadRequest, "INVALID_PLACE_ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.placeID, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/place/x", nil)
+			req = mux.SetURLVars(req, map[string]string{"place_id": tt.placeID})
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandlePlace(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			var errorResp models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+				t.Fatalf("Failed to parse error response: %v", err)
+			}
+			if errorResp.Error.Code != tt.expectedCode {
+				t.Errorf("Expected error code %s, got %s", tt.expectedCode, errorResp.Error.Code)
+			}
+		})
+	}
+}
+
+// geofenceMockDB is a mockDB with stored geofences
+type geofenceMockDB struct {
+	mockDB
+	fences []models.Geofence
+}
+
+func (m *geofenceMockDB) GetGeofences(apiKeyID, collection string) ([]models.Geofence, error) {
+	var fences []models.Geofence
+	for _, fence := range m.fences {
+		if fence.APIKeyID == apiKeyID && (collection == "" || fence.Collection == collection) {
+			fences = append(fences, fence)
+		}
+	}
+	return fences, nil
+}
+
+func (m *geofenceMockDB) GetGeofence(id string) (*models.Geofence, error) {
+	for _, fence := range m.fences {
+		if fence.ID == id {
+			return &fence, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func TestHandleGeofenceCheck(t *testing.T) {
+	db := &geofenceMockDB{fences: []models.Geofence{
+		{ID: "vt", APIKeyID: "test-id", Collection: "states", Name: "Vermont", Geometry: json.RawMessage(`{"type":"Polygon","coordinates":[[[-73.44,42.73],[-71.46,42.73],[-71.46,45.02],[-73.44,45.02],[-73.44,42.73]]]}`)},
+		{ID: "hq", APIKeyID: "test-id", Collection: "offices", Name: "HQ", Geometry: json.RawMessage(`{"type":"Point","coordinates":[-73.2121,44.4759]}`), RadiusM: 1000},
+		{ID: "other", APIKeyID: "other-id", Collection: "states", Name: "Vermont", Geometry: json.RawMessage(`{"type":"Polygon","coordinates":[[[-73.44,42.73],[-71.46,42.73],[-71.46,45.02],[-73.44,45.02],[-73.44,42.73]]]}`)},
+	}}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedMatches []string
+		expectedChecked int
+	}{
+		{"Inside both", "lat=44.4760&lng=-73.2120", []string{"vt", "hq"}, 2},
+		{"Collection filter", "lat=44.4760&lng=-73.2120&collection=states", []string{"vt"}, 1},
+		{"Outside", "lat=42.36&lng=-71.06", []string{}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/geofence/check?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleGeofenceCheck(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var result models.GeofenceCheckResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			ids := []string{}
+			for _, match := range result.Matches {
+				ids = append(ids, match.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedMatches) || result.Checked != tt.expectedChecked {
+				t.Errorf("Expected matches %v of %d fences, got %v of %d", tt.expectedMatches, tt.expectedChecked, ids, result.Checked)
+			}
+			if result.Inside != (len(tt.expectedMatches) > 0) || result.Source != "coordinates" {
+				t.Errorf("Unexpected inside %v or source %q", result.Inside, result.Source)
+			}
+		})
+	}
+}
+
+// geofenceActivityMockDB is a geofenceMockDB with a cached IP location that records the result counts
+// written to the activity log
+type geofenceActivityMockDB struct {
+	geofenceMockDB
+	cachedIP     string
+	resultCounts []int
+}
+
+func (m *geofenceActivityMockDB) GetIPCache(ipAddress string) (*models.IPCache, error) {
+	return &models.IPCache{IPAddress: ipAddress, ResponseData: m.cachedIP}, nil
+}
+
+func (m *geofenceActivityMockDB) LogActivity(apiKeyName, endpoint, queryText string, resultCount, responseTimeMs int, apiSource string, cacheHit bool, ipAddress, userAgent string) error {
+	m.resultCounts = append(m.resultCounts, resultCount)
+	return nil
+}
+
+func TestHandleGeofenceCheck_IPLogsMatches(t *testing.T) {
+	db := &geofenceActivityMockDB{
+		geofenceMockDB: geofenceMockDB{fences: []models.Geofence{
+			{ID: "vt", APIKeyID: "test-id", Collection: "states", Name: "Vermont", Geometry: json.RawMessage(`{"type":"Polygon","coordinates":[[[-73.44,42.73],[-71.46,42.73],[-71.46,45.02],[-73.44,45.02],[-73.44,42.73]]]}`)},
+		}},
+		// A cached location keeps IPinfo out of the test
+		cachedIP: `{"ip":"8.8.8.8","lat":44.476,"lng":-73.212,"country_code":"US"}`,
+	}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	req := httptest.NewRequest("GET", "/v1/geofence/check?ip=8.8.8.8", nil)
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleGeofenceCheck(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !reflect.DeepEqual(db.resultCounts, []int{1}) {
+		t.Errorf("Expected the check to be logged with its one match, got %v", db.resultCounts)
+	}
+}
+
+func TestHandleGeofenceCheck_InvalidParams(t *testing.T) {
+	db := &geofenceMockDB{}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"Nothing", "", http.StatusBadRequest, "INVALID_REQUEST"},
+		{"Two inputs", "lat=1&lng=2&ip=8.8.8.8", http.StatusBadRequest, "INVALID_REQUEST"},
+		{"Missing lng", "lat=1", http.StatusBadRequest, "INVALID_COORDINATES"},
+		{"Invalid IP", "ip=not-an-ip", http.StatusBadRequest, "INVALID_IP"},
+		{"Private IP", "ip=10.0.0.1", http.StatusNotFound, "NO_RESULTS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/geofence/check?"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleGeofenceCheck(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			var errorResp models.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &errorResp); err != nil {
+				t.Fatalf("Failed to parse error response: %v", err)
+			}
+			if errorResp.Error.Code != tt.expectedCode {
+				t.Errorf("Expected error code %s, got %s", tt.expectedCode, errorResp.Error.Code)
+			}
+		})
+	}
+}
+
+func TestHandleGeofence_OtherKey(t *testing.T) {
+	db := &geofenceMockDB{fences: []models.Geofence{
+		{ID: "7c9e6679-7425-40de-944b-e07fc1f90ae7", APIKeyID: "other-id", Geometry: json.RawMessage(`{"type":"Point","coordinates":[0,0]}`), RadiusM: 10},
+	}}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	req := httptest.NewRequest("GET", "/v1/geofences/x", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "7c9e6679-7425-40de-944b-e07fc1f90ae7"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleGeofence(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404 for another key's geofence, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// collectionMockDB is a mockDB with one place collection, and optionally one cached geocode that
+// answers every address
+type collectionMockDB struct {
+	mockDB
+	collection models.PlaceCollection
+	places     []models.CollectionPlace
+	added      []models.CollectionPlace
+	cached     *models.GeocodeAPIResponse
+}
+
+func (m *collectionMockDB) GetAddressCache(queryHash string) (*models.AddressCache, error) {
+	if m.cached == nil {
+		return nil, sql.ErrNoRows
+	}
+	data, _ := json.Marshal(m.cached)
+	return &models.AddressCache{QueryHash: queryHash, ResponseData: string(data)}, nil
+}
+
+func (m *collectionMockDB) GetCollectionPlaces(collectionID string) ([]models.CollectionPlace, error) {
+	return m.places, nil
+}
+
+func (m *collectionMockDB) GetPlaceCollection(id string) (*models.PlaceCollection, error) {
+	if id != m.collection.ID {
+		return nil, sql.ErrNoRows
+	}
+	return &m.collection, nil
+}
+
+func (m *collectionMockDB) AddCollectionPlaces(collectionID string, places []models.CollectionPlace) ([]models.CollectionPlace, error) {
+	m.added = append(m.added, places...)
+	return places, nil
+}
+
+func (m *collectionMockDB) FindCollectionPlacesInBox(collectionID string, minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error) {
+	var places []models.CollectionPlace
+	for _, place := range m.places {
+		if place.Lat >= minLat && place.Lat <= maxLat && place.Lng >= minLng && place.Lng <= maxLng {
+			places = append(places, place)
+		}
+	}
+	return places, nil
+}
+
+func TestHandleCollectionNearby(t *testing.T) {
+	db := &collectionMockDB{
+		collection: models.PlaceCollection{ID: "3f333df6-90a4-4fda-8dd3-9485d27cee36", APIKeyID: "test-id", Name: "Clubs"},
+		places: []models.CollectionPlace{
+			{ID: "burlington", Lat: 44.4759, Lng: -73.2121},
+			{ID: "montpelier", Lat: 44.2601, Lng: -72.5754},
+			{ID: "boston", Lat: 42.3601, Lng: -71.0589},
+		},
+	}
+	geocodeClient := geocoding.NewClient("")
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+
+	tests := []struct {
+		name           string
+		query          string
+		apiKeyID       string
+		expectedStatus int
+		expectedPlaces []string
+	}{
+		{"Nearest", "lat=44.4780&lng=-73.2100&limit=2", "test-id", http.StatusOK, []string{"burlington", "montpelier"}},
+		{"Within radius", "lat=44.4780&lng=-73.2100&radius=1000", "test-id", http.StatusOK, []string{"burlington"}},
+		{"Invalid radius", "lat=44.4780&lng=-73.2100&radius=-5", "test-id", http.StatusBadRequest, nil},
+		{"Invalid limit", "lat=44.4780&lng=-73.2100&limit=1000", "test-id", http.StatusBadRequest, nil},
+		{"No location", "radius=1000", "test-id", http.StatusBadRequest, nil},
+		{"Another key", "lat=44.4780&lng=-73.2100", "other-id", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKey := &models.APIKey{ID: tt.apiKeyID, Name: "test-key"}
+			req := httptest.NewRequest("GET", "/v1/collections/x/nearby?"+tt.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": db.collection.ID})
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			handlers.HandleCollectionNearby(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var result models.NearbyResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			var ids []string
+			for _, place := range result.Places {
+				ids = append(ids, place.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedPlaces) {
+				t.Errorf("Expected places %v, got %v", tt.expectedPlaces, ids)
+			}
+		})
+	}
+}
+
+func TestHandleCollectionImport(t *testing.T) {
+	db := &collectionMockDB{
+		collection: models.PlaceCollection{ID: "3f333df6-90a4-4fda-8dd3-9485d27cee36", APIKeyID: "test-id", Name: "Clubs"},
+	}
+	geocodeClient := geocoding.NewClient("") // Not configured, so rows with only an address fail
+	geoipClient := geoip.NewClient("")
+	cacheService := cache.NewService(db, 1000, 1000)
+
+	handlers := NewHandlers(db, geocodeClient, geoipClient, cacheService)
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key"}
+
+	csv := "name,address,lat,lng\nBurlington Club,,44.4759,-73.2121\nShelburne Club,15 Falls Rd,,\n"
+	req := httptest.NewRequest("POST", "/v1/collections/x/import", strings.NewReader(csv))
+	req.Header.Set("Content-Type", "text/csv")
+	req = mux.SetURLVars(req, map[string]string{"id": db.collection.ID})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+	w := httptest.NewRecorder()
+
+	handlers.HandleCollectionImport(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var result models.CollectionImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.Added != 1 || len(db.added) != 1 || db.added[0].Name != "Burlington Club" {
+		t.Errorf("Expected only Burlington Club to be added, got %+v", db.added)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Row != 3 {
+		t.Errorf("Expected row 3 to fail geocoding, got %+v", result.Errors)
+	}
+}
+
+func TestHandleCollectionPlaces_PrivacyPolicy(t *testing.T) {
+	db := &collectionMockDB{
+		collection: models.PlaceCollection{ID: "3f333df6-90a4-4fda-8dd3-9485d27cee36", APIKeyID: "test-id", Name: "Clubs"},
+		places: []models.CollectionPlace{
+			{ID: "shelburne", Name: "Shelburne Club", Address: "15 Falls Rd, Shelburne, VT", FormattedAddress: "15 Falls Rd, Shelburne, VT 05482, USA", Lat: 44.380612, Lng: -73.227341},
+		},
+		cached: &models.GeocodeAPIResponse{Lat: 44.380612, Lng: -73.227341, FormattedAddress: "15 Falls Rd, Shelburne, VT 05482, USA", Backend: "google_maps_platform_geocoding"},
+	}
+	handlers := NewHandlers(db, geocoding.NewClient(""), geoip.NewClient(""), cache.NewService(db, 1000, 1000))
+	apiKey := &models.APIKey{ID: "test-id", Name: "test-key", Privacy: models.PrivacyPolicy{CityLevel: true}}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		handler http.HandlerFunc
+	}{
+		{"add by address", "POST", "/v1/collections/x/places", `{"places": [{"name": "Shelburne Club", "address": "15 Falls Rd, Shelburne, VT"}]}`, handlers.HandleCollectionPlaces},
+		{"list", "GET", "/v1/collections/x/places", "", handlers.HandleCollectionPlaces},
+		{"nearby", "GET", "/v1/collections/x/nearby?lat=44.38&lng=-73.22", "", handlers.HandleCollectionNearby},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req = mux.SetURLVars(req, map[string]string{"id": db.collection.ID})
+			req = req.WithContext(context.WithValue(req.Context(), middleware.APIKeyContextKey, apiKey))
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+				t.Fatalf("Expected success, got %d: %s", w.Code, w.Body.String())
+			}
+			var result struct {
+				Places []models.NearbyPlace `json:"places"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if len(result.Places) != 1 {
+				t.Fatalf("Expected one place, got %s", w.Body.String())
+			}
+			place := result.Places[0]
+			if place.Lat != 44.4 || place.Lng != -73.2 {
+				t.Errorf("Expected coordinates rounded to 0.1°, got %v, %v", place.Lat, place.Lng)
+			}
+			if place.Address != "" || place.FormattedAddress != "" {
+				t.Errorf("Expected the street address to be suppressed, got %q and %q", place.Address, place.FormattedAddress)
+			}
+		})
+	}
+
+	// The collection itself keeps full precision
+	if len(db.added) != 1 || db.added[0].Lat != 44.380612 || db.added[0].FormattedAddress == "" {
+		t.Errorf("Expected the stored place at full precision, got %+v", db.added)
+	}
+}
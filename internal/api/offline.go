@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hackclub/geocoder/internal/geoencode"
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/offline"
+)
+
+// serveOfflineReverseGeocode answers a reverse geocoding request with precision set from offline data,
+// which costs nothing and so is tracked separately from Google calls
+func (h *Handlers) serveOfflineReverseGeocode(w http.ResponseWriter, r *http.Request, apiReq *v1Request, startTime time.Time, apiKey *models.APIKey, lat, lng float64, precision offline.Precision, language string, encodings []geoencode.Spec) {
+	result, err := h.offlineGeocoder.ReverseGeocode(lat, lng, precision, language)
+	switch {
+	case errors.Is(err, offline.ErrUnavailable):
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "OFFLINE_DATA_UNAVAILABLE", fmt.Sprintf("Offline %s data is not loaded on this server", precision))
+		return
+	case errors.Is(err, offline.ErrNoResults):
+		h.writeErrorResponse(w, http.StatusNotFound, "NO_RESULTS", fmt.Sprintf("No %s found at these coordinates", offlineSubject(precision)))
+		return
+	case err != nil:
+		h.writeErrorResponse(w, http.StatusInternalServerError, "OFFLINE_DATA_UNAVAILABLE", fmt.Sprintf("Failed to reverse geocode coordinates: %v", err))
+		return
+	}
+
+	h.localizeOfflineCity(result, language)
+
+	queryText := fmt.Sprintf("%f,%f", lat, lng)
+	h.logLocalRequest(r, apiKey, "v1/reverse_geocode", queryText, 1, startTime)
+
+	today := time.Now().Truncate(24 * time.Hour)
+	_ = h.db.UpdateOfflineCostTracking(today, 1)
+
+	result.Encodings = encodeLocation(result.Lat, result.Lng, encodings)
+	h.writeResponse(w, r, apiReq, result)
+}
+
+// offlineSubject names what an offline lookup at a precision looks for. Coarser levels are still answered
+// when a finer one is missing, so nothing is found only when all of them are.
+func offlineSubject(precision offline.Precision) string {
+	switch precision {
+	case offline.PrecisionAdmin1:
+		return "state or country"
+	case offline.PrecisionCity:
+		return "city, state or country"
+	}
+	return "country"
+}
+
+// localizeOfflineCity translates the city of an offline result from the gazetteer's GeoNames alternate
+// names, since the cities file the offline geocoder loads only has them without languages
+func (h *Handlers) localizeOfflineCity(result *models.ReverseGeocodeAPIResponse, language string) {
+	match, ok := result.RawBackendResponse.(*offline.Match)
+	if !ok || match.City == nil || language == "" {
+		return
+	}
+
+	geonameID, _ := strconv.ParseInt(match.City.GeoNameID, 10, 64)
+	city := h.gazetteer.LocalizeCity(geonameID, result.City, result.CountryCode, language)
+	if city == result.City {
+		return
+	}
+	// The formatted address starts with the city, followed by the already localized state and country
+	result.FormattedAddress = city + strings.TrimPrefix(result.FormattedAddress, result.City)
+	result.City = city
+	result.AdminHierarchy.City = city
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/overrides"
+)
+
+// HandleAdminOverrides lists (GET) or creates (POST) geocode overrides
+func (h *Handlers) HandleAdminOverrides(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		list, err := h.db.GetGeocodeOverrides()
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to retrieve overrides")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		h.writeJSONResponse(w, models.GeocodeOverrideListResponse{Overrides: list})
+	case "POST":
+		override, ok := h.readOverride(w, r)
+		if !ok {
+			return
+		}
+		created, err := h.db.CreateGeocodeOverride(override)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to store override")
+			return
+		}
+		h.overrides.Invalidate()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		h.writeJSONResponse(w, created)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleAdminOverride reads, replaces or deletes a geocode override
+func (h *Handlers) HandleAdminOverride(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if !uuidPattern.MatchString(id) {
+		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Override not found")
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		override, err := h.db.GetGeocodeOverride(id)
+		if !h.checkOverrideResult(w, err) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		h.writeJSONResponse(w, override)
+	case "PUT":
+		override, ok := h.readOverride(w, r)
+		if !ok {
+			return
+		}
+		updated, err := h.db.UpdateGeocodeOverride(id, override)
+		if !h.checkOverrideResult(w, err) {
+			return
+		}
+		h.overrides.Invalidate()
+
+		w.Header().Set("Content-Type", "application/json")
+		h.writeJSONResponse(w, updated)
+	case "DELETE":
+		if !h.checkOverrideResult(w, h.db.DeleteGeocodeOverride(id)) {
+			return
+		}
+		h.overrides.Invalidate()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// readOverride decodes and validates an override from the request body
+func (h *Handlers) readOverride(w http.ResponseWriter, r *http.Request) (models.GeocodeOverride, bool) {
+	var override models.GeocodeOverride
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)).Decode(&override); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid JSON")
+		return override, false
+	}
+	if err := overrides.Validate(&override); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return override, false
+	}
+	return override, true
+}
+
+// checkOverrideResult writes the error response for a failed override lookup or change
+func (h *Handlers) checkOverrideResult(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		h.writeErrorResponse(w, http.StatusNotFound, "NOT_FOUND", "Override not found")
+		return false
+	case err != nil:
+		h.writeErrorResponse(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to access override")
+		return false
+	}
+	return true
+}
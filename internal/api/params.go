@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/crs"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/privacy"
+)
+
+// v1Request holds the parameters, output preferences and cache mode of a v1 API request
+type v1Request struct {
+	params  url.Values
+	encoder responseEncoder
+	cache   *cacheUse
+}
+
+// parseRequest reads the parameters and negotiates the output format of a v1 request.
+// It writes the error response itself and returns false when the request is malformed.
+func (h *Handlers) parseRequest(w http.ResponseWriter, r *http.Request) (*v1Request, bool) {
+	params, err := requestParams(w, r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return nil, false
+	}
+
+	format, err := negotiateFormat(params.Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return nil, false
+	}
+
+	compact, _ := strconv.ParseBool(params.Get("compact"))
+
+	cache, ok := h.parseCacheMode(w, r, params)
+	if !ok {
+		return nil, false
+	}
+
+	return &v1Request{
+		params:  params,
+		encoder: newResponseEncoder(format, compact),
+		cache:   cache,
+	}, true
+}
+
+// writeResponse writes a successful v1 response in the negotiated output format, reduced under the
+// key's privacy policy and saying whether it came from the cache
+func (h *Handlers) writeResponse(w http.ResponseWriter, r *http.Request, apiReq *v1Request, data interface{}) {
+	apiReq.cache.setHeader(w)
+	applyPrivacy(r, data)
+	if err := writeEncoded(w, r, apiReq.encoder, data); err != nil {
+		log.Printf("Failed to encode response: %v", err)
+	}
+}
+
+// applyPrivacy reduces a response under the requesting key's privacy policy. v1 responses written
+// as plain JSON, such as collection places, need it as much as those going through writeResponse.
+func applyPrivacy(r *http.Request, data interface{}) {
+	if apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey); ok {
+		privacy.Apply(apiKey.Privacy, apiKey.ID, data)
+	}
+}
+
+// maxRequestBodyBytes caps JSON request bodies; addresses and coordinates are tiny
+const maxRequestBodyBytes = 1 << 20
+
+// requestParams returns the parameters of a v1 request. GET requests use the query string;
+// POST requests may carry the same parameters as a flat JSON object, which keeps addresses
+// out of URLs and therefore out of proxy and access logs. Body values override query values. An
+// empty body is no parameters, as for POST /v1/geoip/me.
+func requestParams(w http.ResponseWriter, r *http.Request) (url.Values, error) {
+	params := r.URL.Query()
+	if r.Method != http.MethodPost {
+		return params, nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+		return nil, fmt.Errorf("unsupported content type %q, expected application/json", contentType)
+	}
+
+	var body map[string]interface{}
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON body: %w", err)
+	}
+
+	for name, value := range body {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			params.Set(name, v)
+		case json.Number:
+			params.Set(name, v.String())
+		case bool:
+			params.Set(name, strconv.FormatBool(v))
+		default:
+			// Nested objects and arrays are passed through as JSON for the handler to interpret
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %w", name, err)
+			}
+			params.Set(name, string(encoded))
+		}
+	}
+
+	return params, nil
+}
+
+// parseCoordinates reads and range-checks the lat and lng parameters. Errors are worded for an
+// INVALID_COORDINATES response.
+func parseCoordinates(latStr, lngStr string) (float64, float64, error) {
+	if latStr == "" || lngStr == "" {
+		return 0, 0, errors.New("Both lat and lng parameters are required")
+	}
+
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil {
+		return 0, 0, errors.New("Invalid latitude value")
+	}
+	lng, err := strconv.ParseFloat(lngStr, 64)
+	if err != nil {
+		return 0, 0, errors.New("Invalid longitude value")
+	}
+
+	if lat < -90 || lat > 90 {
+		return 0, 0, errors.New("Latitude must be between -90 and 90")
+	}
+	if lng < -180 || lng > 180 {
+		return 0, 0, errors.New("Longitude must be between -180 and 180")
+	}
+	return lat, lng, nil
+}
+
+// parseLocation reads a point given as lat and lng, or as one of the utm, mgrs or epsg3857
+// parameters, and returns it in WGS84 with the system it was given in. Errors are worded for an
+// INVALID_COORDINATES response.
+func parseLocation(params url.Values) (float64, float64, string, error) {
+	var given []string
+	for _, name := range []string{crs.UTM, crs.MGRS, crs.EPSG3857} {
+		if params.Get(name) != "" {
+			given = append(given, name)
+		}
+	}
+	if len(given) > 1 || (len(given) == 1 && (params.Get("lat") != "" || params.Get("lng") != "")) {
+		return 0, 0, "", errors.New("Give only one of lat and lng, utm, mgrs or epsg3857")
+	}
+	if len(given) == 0 {
+		lat, lng, err := parseCoordinates(params.Get("lat"), params.Get("lng"))
+		return lat, lng, crs.WGS84, err
+	}
+
+	var lat, lng float64
+	var err error
+	switch source := given[0]; source {
+	case crs.UTM:
+		var c crs.UTMCoordinate
+		if c, err = crs.ParseUTM(params.Get(source)); err == nil {
+			lat, lng, err = crs.FromUTM(c)
+		}
+	case crs.MGRS:
+		lat, lng, err = crs.FromMGRS(params.Get(source))
+	case crs.EPSG3857:
+		x, y, found := strings.Cut(params.Get(source), ",")
+		xValue, xErr := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		yValue, yErr := strconv.ParseFloat(strings.TrimSpace(y), 64)
+		if !found || xErr != nil || yErr != nil {
+			return 0, 0, "", errors.New("epsg3857 must be x,y in metres")
+		}
+		lat, lng, err = crs.FromWebMercator(xValue, yValue)
+	}
+	if err != nil {
+		return 0, 0, "", err
+	}
+	return lat, lng, given[0], nil
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hackclub/geocoder/internal/addressparser"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// v1/parse_address endpoint
+func (h *Handlers) HandleParseAddress(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	address := params.Get("address")
+	if strings.TrimSpace(address) == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ADDRESS", "Address parameter is required")
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	result := addressparser.Parse(address)
+
+	h.logLocalRequest(r, apiKey, "v1/parse_address", address, 1, startTime)
+
+	h.writeResponse(w, r, apiReq, result)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hackclub/geocoder/internal/geocoding"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// placeIDPattern matches the URL-safe base64 alphabet Google's place IDs use, so malformed IDs are
+// rejected without spending a Google call
+var placeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,1000}$`)
+
+// v1/place/{place_id} endpoint, which resolves a place ID from an earlier geocoding result
+func (h *Handlers) HandlePlace(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	placeID := mux.Vars(r)["place_id"]
+	if !placeIDPattern.MatchString(placeID) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_PLACE_ID", "Place ID must be a Google place ID such as ChIJ2eUgeAK6j4ARbn5u_wAGqWA")
+		return
+	}
+
+	language, err := parseLanguage(apiReq.params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	source := sourceCache
+	var result *models.GeocodeAPIResponse
+	var cacheHit bool
+	if apiReq.cache.reads() {
+		result, cacheHit = h.cacheService.GetPlaceResult(placeID, language)
+	}
+	if !cacheHit {
+		if apiErr := apiReq.cache.fetch(); apiErr != nil {
+			h.writeAPIError(w, apiErr)
+			return
+		}
+		if !h.geocodeClient.IsConfigured() {
+			h.writeErrorResponse(w, http.StatusServiceUnavailable, "EXTERNAL_API_ERROR", "Google Geocoding API not configured")
+			return
+		}
+
+		result, err = h.geocodeClient.PlaceDetailsToStandardFormat(placeID, language)
+		switch {
+		case err == nil && apiReq.cache.writes():
+			_ = h.cacheService.SetPlaceResult(placeID, language, result)
+		case err == nil:
+		case errors.Is(err, geocoding.ErrInvalidPlaceID):
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_PLACE_ID", "Google rejected the place ID")
+			return
+		case errors.Is(err, geocoding.ErrNoResults):
+			h.writeErrorResponse(w, http.StatusNotFound, "NO_RESULTS", "Place not found; place IDs can expire, so geocode the address again")
+			return
+		default:
+			h.writeErrorResponse(w, http.StatusBadGateway, "EXTERNAL_API_ERROR", fmt.Sprintf("Failed to get place details: %v", err))
+			return
+		}
+		source = sourceGoogle
+	}
+	apiReq.cache.record(cacheHit)
+
+	h.logGeocodeRequest(r, apiKey, "v1/place", placeID, 1, source, startTime)
+
+	h.writeResponse(w, r, apiReq, result)
+}
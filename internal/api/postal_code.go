@@ -0,0 +1,133 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hackclub/geocoder/internal/gazetteer"
+	"github.com/hackclub/geocoder/internal/geocoding"
+	"github.com/hackclub/geocoder/internal/iso3166"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/postalcode"
+)
+
+// v1/postal_code endpoint
+func (h *Handlers) HandlePostalCode(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	if params.Get("country") == "" || params.Get("code") == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_POSTAL_CODE", "Both country and code parameters are required")
+		return
+	}
+
+	country, found := iso3166.FindCountry(params.Get("country"))
+	if !found {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_COUNTRY", "Unknown country; use an ISO 3166-1 code or English name")
+		return
+	}
+
+	code, err := postalcode.Normalize(country.Alpha2, params.Get("code"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_POSTAL_CODE", err.Error())
+		return
+	}
+
+	language, err := parseLanguage(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	fallback := false
+	if value := params.Get("fallback"); value != "" {
+		if fallback, err = strconv.ParseBool(value); err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", "fallback must be true or false")
+			return
+		}
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	queryText := country.Alpha2 + " " + code
+
+	result, err := h.gazetteer.LookupPostalCode(country.Alpha2, code, language)
+	switch {
+	case err == nil:
+		h.logLocalRequest(r, apiKey, "v1/postal_code", queryText, len(result.Places), startTime)
+
+		today := time.Now().Truncate(24 * time.Hour)
+		_ = h.db.UpdateOfflineCostTracking(today, 1)
+
+		h.writeResponse(w, r, apiReq, result)
+		return
+	case fallback:
+		// Ask Google below
+	case errors.Is(err, gazetteer.ErrNoResults):
+		h.writeErrorResponse(w, http.StatusNotFound, "NO_RESULTS", "Postal code not found in offline data; set fallback=true to look it up with Google")
+		return
+	default:
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "OFFLINE_DATA_UNAVAILABLE", "Postal code data is unavailable on this server")
+		return
+	}
+
+	// The fallback goes through the geocoding cache, so each code only costs one Google call
+	opts := geocoding.GeocodeOptions{
+		Language:   language,
+		Components: map[string]string{"country": country.Alpha2, "postal_code": code},
+	}
+	geocoded, cacheHit, apiErr := h.resolveGeocode(apiReq.cache, code, opts)
+	if apiErr != nil {
+		if errors.Is(apiErr.err, geocoding.ErrNoResults) {
+			h.writeErrorResponse(w, http.StatusNotFound, "NO_RESULTS", "Postal code not found")
+			return
+		}
+		h.writeAPIError(w, apiErr)
+		return
+	}
+	if geocoded.AdminHierarchy.PostalCode == "" {
+		h.writeErrorResponse(w, http.StatusNotFound, "NO_RESULTS", "Postal code not found")
+		return
+	}
+
+	source := sourceGoogle
+	if cacheHit {
+		source = sourceCache
+	}
+	h.logGeocodeRequest(r, apiKey, "v1/postal_code", queryText, 1, source, startTime)
+
+	h.writeResponse(w, r, apiReq, postalCodeFromGeocode(country.Alpha2, code, geocoded))
+}
+
+// postalCodeFromGeocode presents a Google postal code result like an offline one, with a single place
+func postalCodeFromGeocode(countryCode, code string, result *models.GeocodeAPIResponse) *models.PostalCodeResponse {
+	hierarchy := result.AdminHierarchy
+	return &models.PostalCodeResponse{
+		PostalCode:  code,
+		CountryCode: countryCode,
+		Lat:         result.Lat,
+		Lng:         result.Lng,
+		Places: []models.PostalCodePlace{{
+			Name:         hierarchy.City,
+			State:        hierarchy.State,
+			StateISOCode: hierarchy.StateISOCode,
+			County:       hierarchy.County,
+			Lat:          result.Lat,
+			Lng:          result.Lng,
+		}},
+		AdminHierarchy:     hierarchy,
+		Backend:            result.Backend,
+		RawBackendResponse: result.RawBackendResponse,
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/hackclub/geocoder/internal/addressformat"
+	"github.com/hackclub/geocoder/internal/geocoding"
+	"github.com/hackclub/geocoder/internal/middleware"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// v1/validate_address endpoint
+func (h *Handlers) HandleValidateAddress(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	apiReq, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+	params := apiReq.params
+
+	structuredAddr := parseStructuredAddress(params)
+	if structuredAddr.IsEmpty() {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_ADDRESS", "At least one address field is required")
+		return
+	}
+
+	opts, err := parseGeocodeOptions(params)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	apiKey, ok := r.Context().Value(middleware.APIKeyContextKey).(*models.APIKey)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key required")
+		return
+	}
+
+	// Validation shares the forward geocoding cache, so validating an address that was already geocoded is free
+	address := addressformat.Query(structuredAddr)
+	result, cacheHit, apiErr := h.resolveGeocode(apiReq.cache, address, opts)
+
+	var validation *models.AddressValidationResponse
+	switch {
+	case apiErr != nil && errors.Is(apiErr.err, geocoding.ErrNoResults):
+		// Google was called and found nothing, which is itself a verdict on the address
+		validation = geocoding.ValidateStructuredAddress(structuredAddr, nil)
+	case apiErr != nil:
+		h.writeAPIError(w, apiErr)
+		return
+	default:
+		googleResp, err := geocoding.DecodeRawResponse(result.RawBackendResponse)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusInternalServerError, "CACHE_ERROR", "Failed to read geocoding result")
+			return
+		}
+		validation = geocoding.ValidateStructuredAddress(structuredAddr, googleResp)
+	}
+
+	responseTime := int(time.Since(startTime).Milliseconds())
+
+	// Log usage
+	_ = h.db.LogUsage(apiKey.ID, "v1/validate_address", cacheHit, responseTime)
+
+	// Log activity
+	apiSource := sourceCache
+	if !cacheHit {
+		apiSource = sourceGoogle
+	}
+	resultCount := 1
+	if validation.Granularity == geocoding.GranularityNone {
+		resultCount = 0
+	}
+	h.logActivity(apiKey.Name, "v1/validate_address", address, resultCount, responseTime, string(apiSource), cacheHit, middleware.GetClientIP(r), r.UserAgent())
+
+	// Broadcast activity update
+	activity := &models.ActivityLog{
+		Timestamp:      time.Now(),
+		APIKeyName:     apiKey.Name,
+		Endpoint:       "v1/validate_address",
+		QueryText:      address,
+		ResultCount:    resultCount,
+		ResponseTimeMs: responseTime,
+		APISource:      string(apiSource),
+		CacheHit:       cacheHit,
+		IPAddress:      middleware.GetClientIP(r),
+		UserAgent:      r.UserAgent(),
+	}
+	h.broadcastActivity(activity)
+
+	// Update cost tracking
+	h.trackGeocodeCost(apiSource)
+
+	// Broadcast updated stats
+	h.broadcastStats()
+
+	h.writeResponse(w, r, apiReq, validation)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/hackclub/geocoder/internal/database"
 	"github.com/hackclub/geocoder/internal/geocoding"
@@ -14,10 +15,15 @@ import (
 	"github.com/hackclub/geocoder/internal/models"
 )
 
+// DefaultPlaceCacheTTL is how long place ID lookups are kept, matching the 30 days Google allows
+// coordinates to be cached
+const DefaultPlaceCacheTTL = 30 * 24 * time.Hour
+
 type CacheService struct {
 	db                  database.DatabaseInterface
 	maxAddressCacheSize int
 	maxIPCacheSize      int
+	placeCacheTTL       time.Duration
 }
 
 func NewService(db database.DatabaseInterface, maxAddressCacheSize, maxIPCacheSize int) *CacheService {
@@ -25,6 +31,14 @@ func NewService(db database.DatabaseInterface, maxAddressCacheSize, maxIPCacheSi
 		db:                  db,
 		maxAddressCacheSize: maxAddressCacheSize,
 		maxIPCacheSize:      maxIPCacheSize,
+		placeCacheTTL:       DefaultPlaceCacheTTL,
+	}
+}
+
+// SetPlaceCacheTTL changes how long place ID lookups are kept
+func (c *CacheService) SetPlaceCacheTTL(ttl time.Duration) {
+	if ttl > 0 {
+		c.placeCacheTTL = ttl
 	}
 }
 
@@ -86,7 +100,12 @@ func (c *CacheService) SetIPResult(ip string, result *geoip.IPInfoResponse) erro
 
 // GetStandardGeocodeResult retrieves a cached standard geocoding response
 func (c *CacheService) GetStandardGeocodeResult(address string) (*models.GeocodeAPIResponse, bool) {
-	queryHash := c.hashQuery(address)
+	return c.GetStandardGeocodeResultWithOptions(address, geocoding.GeocodeOptions{})
+}
+
+// GetStandardGeocodeResultWithOptions retrieves a cached standard geocoding response for an address and options
+func (c *CacheService) GetStandardGeocodeResultWithOptions(address string, opts geocoding.GeocodeOptions) (*models.GeocodeAPIResponse, bool) {
+	queryHash := c.hashGeocodeQuery(address, opts)
 
 	cached, err := c.db.GetAddressCache(queryHash)
 	if err != nil {
@@ -106,14 +125,23 @@ func (c *CacheService) GetStandardGeocodeResult(address string) (*models.Geocode
 
 // SetStandardGeocodeResult caches a standard geocoding response
 func (c *CacheService) SetStandardGeocodeResult(address string, result *models.GeocodeAPIResponse) error {
-	queryHash := c.hashQuery(address)
+	return c.SetStandardGeocodeResultWithOptions(address, geocoding.GeocodeOptions{}, result)
+}
+
+// SetStandardGeocodeResultWithOptions caches a standard geocoding response for an address and options
+func (c *CacheService) SetStandardGeocodeResultWithOptions(address string, opts geocoding.GeocodeOptions, result *models.GeocodeAPIResponse) error {
+	queryHash := c.hashGeocodeQuery(address, opts)
+	queryText := address
+	if optionsKey := opts.CacheKey(); optionsKey != "" {
+		queryText = address + " [" + optionsKey + "]"
+	}
 
 	resultJSON, err := json.Marshal(result)
 	if err != nil {
 		return fmt.Errorf("failed to marshal standard geocode result: %w", err)
 	}
 
-	return c.db.SetAddressCache(queryHash, address, string(resultJSON), c.maxAddressCacheSize)
+	return c.db.SetAddressCache(queryHash, queryText, string(resultJSON), c.maxAddressCacheSize)
 }
 
 // GetStandardIPResult retrieves a cached standard IP geolocation response
@@ -144,9 +172,64 @@ func (c *CacheService) SetStandardIPResult(ip string, result *models.GeoIPAPIRes
 	return c.db.SetIPCache(ip, string(resultJSON), c.maxIPCacheSize)
 }
 
+// GetASNResult retrieves a cached autonomous system lookup
+func (c *CacheService) GetASNResult(asn int) (*models.ASNAPIResponse, bool) {
+	cached, err := c.db.GetASNCache(asn)
+	if err != nil {
+		return nil, false // Cache miss or error
+	}
+
+	var result models.ASNAPIResponse
+	if err := json.Unmarshal([]byte(cached.ResponseData), &result); err != nil {
+		return nil, false // Invalid cached data, treat as cache miss
+	}
+
+	return &result, true
+}
+
+// SetASNResult caches an autonomous system lookup
+func (c *CacheService) SetASNResult(asn int, result *models.ASNAPIResponse) error {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ASN result: %w", err)
+	}
+
+	return c.db.SetASNCache(asn, string(resultJSON))
+}
+
+// GetPlaceResult retrieves a place ID lookup that hasn't expired
+func (c *CacheService) GetPlaceResult(placeID, language string) (*models.GeocodeAPIResponse, bool) {
+	cached, err := c.db.GetPlaceCache(placeID, language, c.placeCacheTTL)
+	if err != nil {
+		return nil, false // Cache miss, expired or error
+	}
+
+	var result models.GeocodeAPIResponse
+	if err := json.Unmarshal([]byte(cached.ResponseData), &result); err != nil {
+		return nil, false // Invalid cached data, treat as cache miss
+	}
+
+	return &result, true
+}
+
+// SetPlaceResult caches a place ID lookup
+func (c *CacheService) SetPlaceResult(placeID, language string, result *models.GeocodeAPIResponse) error {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal place result: %w", err)
+	}
+
+	return c.db.SetPlaceCache(placeID, language, string(resultJSON), c.placeCacheTTL)
+}
+
 // GetStandardReverseGeocodeResult retrieves a cached standard reverse geocoding response
 func (c *CacheService) GetStandardReverseGeocodeResult(lat, lng float64) (*models.ReverseGeocodeAPIResponse, bool) {
-	queryHash := c.hashCoordinates(lat, lng)
+	return c.GetStandardReverseGeocodeResultWithLanguage(lat, lng, "")
+}
+
+// GetStandardReverseGeocodeResultWithLanguage retrieves a cached standard reverse geocoding response in the given language
+func (c *CacheService) GetStandardReverseGeocodeResultWithLanguage(lat, lng float64, language string) (*models.ReverseGeocodeAPIResponse, bool) {
+	queryHash := c.hashReverseGeocodeQuery(lat, lng, language)
 
 	cached, err := c.db.GetReverseGeocodeCache(queryHash)
 	if err != nil {
@@ -166,8 +249,16 @@ func (c *CacheService) GetStandardReverseGeocodeResult(lat, lng float64) (*model
 
 // SetStandardReverseGeocodeResult caches a standard reverse geocoding response
 func (c *CacheService) SetStandardReverseGeocodeResult(lat, lng float64, result *models.ReverseGeocodeAPIResponse) error {
-	queryHash := c.hashCoordinates(lat, lng)
+	return c.SetStandardReverseGeocodeResultWithLanguage(lat, lng, "", result)
+}
+
+// SetStandardReverseGeocodeResultWithLanguage caches a standard reverse geocoding response in the given language
+func (c *CacheService) SetStandardReverseGeocodeResultWithLanguage(lat, lng float64, language string, result *models.ReverseGeocodeAPIResponse) error {
+	queryHash := c.hashReverseGeocodeQuery(lat, lng, language)
 	queryText := fmt.Sprintf("%f,%f", lat, lng)
+	if language != "" {
+		queryText = fmt.Sprintf("%s [language=%s]", queryText, strings.ToLower(language))
+	}
 
 	resultJSON, err := json.Marshal(result)
 	if err != nil {
@@ -222,6 +313,18 @@ func (c *CacheService) hashQuery(query string) string {
 	return fmt.Sprintf("%x", hash)
 }
 
+// hashGeocodeQuery hashes an address together with its options. Queries without options hash
+// exactly as before, so existing cache entries stay valid.
+func (c *CacheService) hashGeocodeQuery(address string, opts geocoding.GeocodeOptions) string {
+	optionsKey := opts.CacheKey()
+	if optionsKey == "" {
+		return c.hashQuery(address)
+	}
+
+	hash := sha256.Sum256([]byte(c.normalizeAddress(address) + "\x00" + optionsKey))
+	return fmt.Sprintf("%x", hash)
+}
+
 func (c *CacheService) hashCoordinates(lat, lng float64) string {
 	// Round coordinates to 5 decimal places for consistent caching
 	// This provides ~1.1m precision which is reasonable for caching
@@ -230,3 +333,24 @@ func (c *CacheService) hashCoordinates(lat, lng float64) string {
 	hash := sha256.Sum256([]byte(normalized))
 	return fmt.Sprintf("%x", hash)
 }
+
+// hashReverseGeocodeQuery hashes coordinates together with the response language. Requests without
+// a language hash exactly as before, so existing cache entries stay valid.
+func (c *CacheService) hashReverseGeocodeQuery(lat, lng float64, language string) string {
+	if language == "" {
+		return c.hashCoordinates(lat, lng)
+	}
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%.5f,%.5f\x00language=%s", lat, lng, strings.ToLower(language))))
+	return fmt.Sprintf("%x", hash)
+}
+
+// EraseAddress removes every cached geocoding result for an address and returns how many there were
+func (c *CacheService) EraseAddress(address string) (int64, error) {
+	return c.db.DeleteAddressCache(c.hashQuery(address), strings.TrimSpace(address))
+}
+
+// EraseIP removes the cached lookup of an IP address and returns how many entries there were
+func (c *CacheService) EraseIP(ip string) (int64, error) {
+	return c.db.DeleteIPCache(ip)
+}
@@ -38,6 +38,16 @@ func (m *mockCacheDB) UpdateAPIKeyRateLimit(keyID string, rateLimitPerSecond int
 	return nil
 }
 func (m *mockCacheDB) DeactivateAPIKey(keyID string) error { return nil }
+func (m *mockCacheDB) UpdateAPIKeyRequireHeaderAuth(keyID string, requireHeaderAuth bool) error {
+	return nil
+}
+func (m *mockCacheDB) UpdateAPIKeyCacheControl(keyID string, allowCacheControl bool) error {
+	return nil
+}
+
+func (m *mockCacheDB) UpdateAPIKeyPrivacyPolicy(keyID string, policy models.PrivacyPolicy) error {
+	return nil
+}
 
 func (m *mockCacheDB) GetAddressCache(queryHash string) (*models.AddressCache, error) {
 	if cache, exists := m.addressCache[queryHash]; exists {
@@ -99,9 +109,108 @@ func (m *mockCacheDB) GetStats() (*models.Stats, error) { return nil, nil }
 func (m *mockCacheDB) UpdateCostTracking(date time.Time, geocodeRequests, geocodeCacheHits, geoipRequests, geoipCacheHits int, estimatedCost float64) error {
 	return nil
 }
+func (m *mockCacheDB) UpdateOfflineCostTracking(date time.Time, offlineRequests int) error {
+	return nil
+}
+func (m *mockCacheDB) GetASNCache(asn int) (*models.ASNCache, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockCacheDB) SetASNCache(asn int, responseData string) error {
+	return nil
+}
+func (m *mockCacheDB) GetPlaceCache(placeID, language string, maxAge time.Duration) (*models.PlaceCache, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockCacheDB) SetPlaceCache(placeID, language, responseData string, maxAge time.Duration) error {
+	return nil
+}
+func (m *mockCacheDB) FindGazetteerPlaces(name, countryCode string, limit int) ([]models.GazetteerPlace, error) {
+	return nil, nil
+}
+func (m *mockCacheDB) FindGazetteerPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error) {
+	return nil, nil
+}
+func (m *mockCacheDB) FindGazetteerLocalizedName(geonameID int64, name, countryCode string, languages []string) (string, error) {
+	return "", nil
+}
+func (m *mockCacheDB) CreateGeofence(apiKeyID, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	return &models.Geofence{ID: "fence-id", APIKeyID: apiKeyID, Collection: collection, Name: name, Geometry: geometry, RadiusM: radiusM, Properties: properties}, nil
+}
+func (m *mockCacheDB) GetGeofences(apiKeyID, collection string) ([]models.Geofence, error) {
+	return []models.Geofence{}, nil
+}
+func (m *mockCacheDB) GetGeofence(id string) (*models.Geofence, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockCacheDB) UpdateGeofence(id, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockCacheDB) DeleteGeofence(id string) error {
+	return sql.ErrNoRows
+}
+func (m *mockCacheDB) CreateGeocodeOverride(override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	override.ID = "override-id"
+	return &override, nil
+}
+func (m *mockCacheDB) GetGeocodeOverrides() ([]models.GeocodeOverride, error) {
+	return []models.GeocodeOverride{}, nil
+}
+func (m *mockCacheDB) GetGeocodeOverride(id string) (*models.GeocodeOverride, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockCacheDB) UpdateGeocodeOverride(id string, override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockCacheDB) DeleteGeocodeOverride(id string) error {
+	return sql.ErrNoRows
+}
+func (m *mockCacheDB) CreatePlaceCollection(apiKeyID, name string) (*models.PlaceCollection, error) {
+	return &models.PlaceCollection{ID: "collection-id", APIKeyID: apiKeyID, Name: name}, nil
+}
+func (m *mockCacheDB) GetPlaceCollections(apiKeyID string) ([]models.PlaceCollection, error) {
+	return []models.PlaceCollection{}, nil
+}
+func (m *mockCacheDB) GetPlaceCollection(id string) (*models.PlaceCollection, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockCacheDB) DeletePlaceCollection(id string) error {
+	return sql.ErrNoRows
+}
+func (m *mockCacheDB) AddCollectionPlaces(collectionID string, places []models.CollectionPlace) ([]models.CollectionPlace, error) {
+	return places, nil
+}
+func (m *mockCacheDB) GetCollectionPlaces(collectionID string) ([]models.CollectionPlace, error) {
+	return []models.CollectionPlace{}, nil
+}
+func (m *mockCacheDB) DeleteCollectionPlace(collectionID, placeID string) error {
+	return sql.ErrNoRows
+}
+func (m *mockCacheDB) FindCollectionPlacesInBox(collectionID string, minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error) {
+	return []models.CollectionPlace{}, nil
+}
 func (m *mockCacheDB) GetRecentActivity() ([]models.ActivityLog, error) {
 	return nil, nil
 }
+func (m *mockCacheDB) DeleteActivityBefore(cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+func (m *mockCacheDB) DeleteActivityMatching(values []string) (int64, error) {
+	return 0, nil
+}
+func (m *mockCacheDB) DeleteAddressCache(queryHash, queryText string) (int64, error) {
+	if _, ok := m.addressCache[queryHash]; ok {
+		delete(m.addressCache, queryHash)
+		return 1, nil
+	}
+	return 0, nil
+}
+func (m *mockCacheDB) DeleteIPCache(ipAddress string) (int64, error) {
+	if _, ok := m.ipCache[ipAddress]; ok {
+		delete(m.ipCache, ipAddress)
+		return 1, nil
+	}
+	return 0, nil
+}
 func (m *mockCacheDB) LogActivity(apiKeyName, endpoint, queryText string, resultCount, responseTimeMs int, apiSource string, cacheHit bool, ipAddress, userAgent string) error {
 	return nil
 }
@@ -356,3 +465,66 @@ func TestCacheService_ReverseGeocodeCoordinateNormalization(t *testing.T) {
 		}
 	}
 }
+
+func TestCacheService_GeocodeOptionsInCacheKey(t *testing.T) {
+	db := newMockCacheDB()
+	cache := NewService(db, 1000, 1000)
+
+	address := "Springfield"
+	spanish := geocoding.GeocodeOptions{Language: "es", Components: map[string]string{"country": "US"}}
+
+	err := cache.SetStandardGeocodeResult(address, &models.GeocodeAPIResponse{FormattedAddress: "Springfield, IL, USA"})
+	if err != nil {
+		t.Fatalf("Failed to set geocode cache: %v", err)
+	}
+
+	if _, hit := cache.GetStandardGeocodeResultWithOptions(address, spanish); hit {
+		t.Error("Expected cache miss for the same address with different options")
+	}
+
+	err = cache.SetStandardGeocodeResultWithOptions(address, spanish, &models.GeocodeAPIResponse{FormattedAddress: "Springfield, Illinois, EE. UU."})
+	if err != nil {
+		t.Fatalf("Failed to set geocode cache with options: %v", err)
+	}
+
+	result, hit := cache.GetStandardGeocodeResultWithOptions(address, spanish)
+	if !hit || result.FormattedAddress != "Springfield, Illinois, EE. UU." {
+		t.Errorf("Expected cached result for options, got hit=%v result=%+v", hit, result)
+	}
+
+	result, hit = cache.GetStandardGeocodeResult(address)
+	if !hit || result.FormattedAddress != "Springfield, IL, USA" {
+		t.Errorf("Expected the plain entry to be unchanged, got hit=%v result=%+v", hit, result)
+	}
+}
+
+func TestCacheService_ReverseGeocodeLanguageInCacheKey(t *testing.T) {
+	db := newMockCacheDB()
+	cache := NewService(db, 1000, 1000)
+
+	lat, lng := 48.137154, 11.576124
+
+	err := cache.SetStandardReverseGeocodeResult(lat, lng, &models.ReverseGeocodeAPIResponse{CountryName: "Germany"})
+	if err != nil {
+		t.Fatalf("Failed to set reverse geocode cache: %v", err)
+	}
+
+	if _, hit := cache.GetStandardReverseGeocodeResultWithLanguage(lat, lng, "fr"); hit {
+		t.Error("Expected cache miss for the same coordinates in another language")
+	}
+
+	err = cache.SetStandardReverseGeocodeResultWithLanguage(lat, lng, "fr", &models.ReverseGeocodeAPIResponse{CountryName: "Allemagne"})
+	if err != nil {
+		t.Fatalf("Failed to set reverse geocode cache with language: %v", err)
+	}
+
+	result, hit := cache.GetStandardReverseGeocodeResultWithLanguage(lat, lng, "FR")
+	if !hit || result.CountryName != "Allemagne" {
+		t.Errorf("Expected cached French result, got hit=%v result=%+v", hit, result)
+	}
+
+	result, hit = cache.GetStandardReverseGeocodeResult(lat, lng)
+	if !hit || result.CountryName != "Germany" {
+		t.Errorf("Expected the plain entry to be unchanged, got hit=%v result=%+v", hit, result)
+	}
+}
@@ -0,0 +1,255 @@
+// Package collections validates saved places, reads them from CSV, and finds the ones nearest a
+// point. Nearest-neighbour searches query growing bounding boxes through the database's spatial
+// index and rank the candidates by great-circle distance.
+package collections
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/offline"
+)
+
+const (
+	// MaxPlacesPerUpload caps the places added by one request, since each may need geocoding
+	MaxPlacesPerUpload = 1000
+	// MaxPlacesPerCollection keeps every bounding box query small enough to rank in memory
+	MaxPlacesPerCollection = 10000
+	// DefaultLimit and MaxLimit bound the places returned by a nearby search
+	DefaultLimit = 10
+	MaxLimit     = 100
+
+	maxNameLength = 255
+	kmPerDegree   = 111.195
+	// initialSearchKm is the first radius of a search without one; it grows fourfold until enough
+	// places are found
+	initialSearchKm = 25
+	// maxSearchKm is half the Earth's circumference, so every place is within it
+	maxSearchKm = 20016
+)
+
+// ErrInvalidPlace is returned for a place without a usable address or coordinates
+var ErrInvalidPlace = errors.New("invalid place")
+
+// PlaceInput is a place to save. Places with coordinates are stored as given; others are geocoded
+// from their address.
+type PlaceInput struct {
+	Name       string          `json:"name"`
+	Address    string          `json:"address"`
+	Lat        *float64        `json:"lat"`
+	Lng        *float64        `json:"lng"`
+	Properties json.RawMessage `json:"properties"`
+}
+
+// Row is a place from an upload with its row number, for reporting errors
+type Row struct {
+	Number int
+	Place  PlaceInput
+}
+
+// HasCoordinates reports whether the place needs no geocoding
+func (p PlaceInput) HasCoordinates() bool {
+	return p.Lat != nil && p.Lng != nil
+}
+
+// Validate checks a place before it is geocoded or stored
+func (p PlaceInput) Validate() error {
+	if len(p.Name) > maxNameLength {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidPlace, maxNameLength)
+	}
+	if (p.Lat == nil) != (p.Lng == nil) {
+		return fmt.Errorf("%w: lat and lng must be given together", ErrInvalidPlace)
+	}
+	if p.HasCoordinates() {
+		if *p.Lat < -90 || *p.Lat > 90 || *p.Lng < -180 || *p.Lng > 180 {
+			return fmt.Errorf("%w: coordinates out of range", ErrInvalidPlace)
+		}
+		return nil
+	}
+	if strings.TrimSpace(p.Address) == "" {
+		return fmt.Errorf("%w: an address or lat and lng are required", ErrInvalidPlace)
+	}
+	return nil
+}
+
+// csvColumns maps recognised header names, compared case-insensitively, to PlaceInput fields
+var csvColumns = map[string]string{
+	"name":      "name",
+	"address":   "address",
+	"lat":       "lat",
+	"latitude":  "lat",
+	"lng":       "lng",
+	"lon":       "lng",
+	"long":      "lng",
+	"longitude": "lng",
+}
+
+// ParseCSV reads places from CSV with a header row. name, address, lat and lng columns (or
+// latitude, lon and longitude) are recognised, and any other columns become string properties.
+// Invalid rows are returned as import errors rather than failing the whole file.
+func ParseCSV(r io.Reader) ([]Row, []models.CollectionImportError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("%w: the CSV is empty", ErrInvalidPlace)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidPlace, err)
+	}
+
+	fields := make([]string, len(header))
+	known := false
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")) // Excel writes a byte order mark
+		header[i] = name
+		fields[i] = csvColumns[strings.ToLower(name)]
+		known = known || fields[i] == "address" || fields[i] == "lat"
+	}
+	if !known {
+		return nil, nil, fmt.Errorf("%w: the header needs an address column, or lat and lng columns", ErrInvalidPlace)
+	}
+
+	var rows []Row
+	var rowErrors []models.CollectionImportError
+	for number := 2; ; number++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: row %d: %v", ErrInvalidPlace, number, err)
+		}
+		if len(rows)+len(rowErrors) >= MaxPlacesPerUpload {
+			return nil, nil, fmt.Errorf("%w: at most %d rows per upload", ErrInvalidPlace, MaxPlacesPerUpload)
+		}
+
+		place, err := parseRecord(header, fields, record)
+		if err == nil {
+			err = place.Validate()
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, models.CollectionImportError{Row: number, Message: err.Error()})
+			continue
+		}
+		rows = append(rows, Row{Number: number, Place: place})
+	}
+
+	return rows, rowErrors, nil
+}
+
+func parseRecord(header, fields, record []string) (PlaceInput, error) {
+	var place PlaceInput
+	properties := map[string]string{}
+
+	for i, value := range record {
+		if i >= len(header) {
+			break
+		}
+		value = strings.TrimSpace(value)
+		switch fields[i] {
+		case "name":
+			place.Name = value
+		case "address":
+			place.Address = value
+		case "lat", "lng":
+			if value == "" {
+				continue
+			}
+			coord, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return place, fmt.Errorf("%w: %s is not a number", ErrInvalidPlace, header[i])
+			}
+			if fields[i] == "lat" {
+				place.Lat = &coord
+			} else {
+				place.Lng = &coord
+			}
+		default:
+			if header[i] != "" && value != "" {
+				properties[header[i]] = value
+			}
+		}
+	}
+
+	if len(properties) > 0 {
+		place.Properties, _ = json.Marshal(properties)
+	}
+	return place, nil
+}
+
+// BoundingBox returns a box containing every point within radiusKm of the centre. Boxes that
+// reach a pole or cross the antimeridian span every longitude.
+func BoundingBox(lat, lng, radiusKm float64) (minLat, minLng, maxLat, maxLng float64) {
+	dLat := radiusKm / kmPerDegree
+	minLat, maxLat = lat-dLat, lat+dLat
+	if minLat <= -90 || maxLat >= 90 {
+		return math.Max(minLat, -90), -180, math.Min(maxLat, 90), 180
+	}
+
+	// Longitude degrees are shortest at the box's highest latitude
+	dLng := dLat / math.Cos(math.Max(math.Abs(minLat), math.Abs(maxLat))*math.Pi/180)
+	minLng, maxLng = lng-dLng, lng+dLng
+	if minLng < -180 || maxLng > 180 {
+		return minLat, -180, maxLat, 180
+	}
+	return minLat, minLng, maxLat, maxLng
+}
+
+// BoxFinder returns the places inside a bounding box
+type BoxFinder func(minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error)
+
+// Nearby returns up to limit places closest to the point, nearest first. With a radius only places
+// within it are returned; without one the search widens until limit places are found or the whole
+// collection has been searched.
+func Nearby(find BoxFinder, lat, lng, radiusM float64, limit int) ([]models.NearbyPlace, error) {
+	if radiusM > 0 {
+		return search(find, lat, lng, radiusM/1000, limit)
+	}
+
+	for radiusKm := float64(initialSearchKm); ; radiusKm *= 4 {
+		radiusKm = math.Min(radiusKm, maxSearchKm)
+		places, err := search(find, lat, lng, radiusKm, limit)
+		// Every place within the radius was a candidate, so once there are enough of them no
+		// place outside it can be nearer
+		if err != nil || len(places) >= limit || radiusKm == maxSearchKm {
+			return places, err
+		}
+	}
+}
+
+func search(find BoxFinder, lat, lng, radiusKm float64, limit int) ([]models.NearbyPlace, error) {
+	candidates, err := find(BoundingBox(lat, lng, radiusKm))
+	if err != nil {
+		return nil, err
+	}
+
+	places := []models.NearbyPlace{}
+	for _, candidate := range candidates {
+		distanceKm := offline.DistanceKm(lat, lng, candidate.Lat, candidate.Lng)
+		if distanceKm <= radiusKm {
+			places = append(places, models.NearbyPlace{CollectionPlace: candidate, DistanceM: distanceKm * 1000})
+		}
+	}
+
+	sort.SliceStable(places, func(i, j int) bool {
+		return places[i].DistanceM < places[j].DistanceM
+	})
+	if len(places) > limit {
+		places = places[:limit]
+	}
+	for i := range places {
+		places[i].DistanceM = math.Round(places[i].DistanceM)
+	}
+	return places, nil
+}
@@ -0,0 +1,137 @@
+package collections
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+func TestParseCSV(t *testing.T) {
+	csv := "\ufeffName,Address,Latitude,Longitude,Contact\n" +
+		"Burlington Club,,44.4759,-73.2121,alex@example.com\n" +
+		"Shelburne Club,\"15 Falls Rd, Shelburne, VT\",,,\n" +
+		"Nowhere Club,,,,\n" +
+		"Bad Club,,north,-73,\n" +
+		"Half Club,,44,,\n"
+
+	rows, rowErrors, err := ParseCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ParseCSV() error = %v", err)
+	}
+
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	first := rows[0]
+	if first.Number != 2 || first.Place.Name != "Burlington Club" || !first.Place.HasCoordinates() || *first.Place.Lat != 44.4759 {
+		t.Errorf("unexpected first row %+v", first)
+	}
+	if string(first.Place.Properties) != `{"Contact":"alex@example.com"}` {
+		t.Errorf("expected other columns as properties, got %s", first.Place.Properties)
+	}
+	second := rows[1]
+	if second.Number != 3 || second.Place.HasCoordinates() || second.Place.Address != "15 Falls Rd, Shelburne, VT" || second.Place.Properties != nil {
+		t.Errorf("unexpected second row %+v", second)
+	}
+
+	var failed []int
+	for _, rowError := range rowErrors {
+		failed = append(failed, rowError.Row)
+	}
+	if len(failed) != 3 || failed[0] != 4 || failed[1] != 5 || failed[2] != 6 {
+		t.Errorf("expected rows 4, 5 and 6 to fail, got %+v", rowErrors)
+	}
+}
+
+func TestParseCSV_InvalidFile(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"Empty", ""},
+		{"No location columns", "name,phone\nClub,555\n"},
+		{"Too many rows", "address\n" + strings.Repeat("Burlington, VT\n", MaxPlacesPerUpload+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseCSV(strings.NewReader(tt.csv)); !errors.Is(err, ErrInvalidPlace) {
+				t.Errorf("expected ErrInvalidPlace, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	minLat, minLng, maxLat, maxLng := BoundingBox(44.4759, -73.2121, 100)
+	if minLat > 43.58 || maxLat < 45.37 || minLng > -74.47 || maxLng < -71.95 {
+		t.Errorf("box %v,%v to %v,%v doesn't contain a 100 km circle around Burlington", minLat, minLng, maxLat, maxLng)
+	}
+
+	// Near the antimeridian and the poles the box spans every longitude
+	if _, minLng, _, maxLng := BoundingBox(-17.7, 179.5, 100); minLng != -180 || maxLng != 180 {
+		t.Errorf("expected a box crossing the antimeridian to span every longitude, got %v to %v", minLng, maxLng)
+	}
+	if _, minLng, maxLat, maxLng := BoundingBox(89.5, 0, 100); minLng != -180 || maxLng != 180 || maxLat != 90 {
+		t.Errorf("expected a box over the pole to span every longitude, got %v to %v up to %v", minLng, maxLng, maxLat)
+	}
+}
+
+func TestNearby(t *testing.T) {
+	places := []models.CollectionPlace{
+		{ID: "burlington", Lat: 44.4759, Lng: -73.2121},
+		{ID: "montpelier", Lat: 44.2601, Lng: -72.5754},
+		{ID: "boston", Lat: 42.3601, Lng: -71.0589},
+		{ID: "sydney", Lat: -33.8688, Lng: 151.2093},
+	}
+	queries := 0
+	find := func(minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error) {
+		queries++
+		var inBox []models.CollectionPlace
+		for _, place := range places {
+			if place.Lat >= minLat && place.Lat <= maxLat && place.Lng >= minLng && place.Lng <= maxLng {
+				inBox = append(inBox, place)
+			}
+		}
+		return inBox, nil
+	}
+
+	tests := []struct {
+		name     string
+		radiusM  float64
+		limit    int
+		expected []string
+	}{
+		{"Within radius", 60000, 10, []string{"burlington", "montpelier"}},
+		{"Radius limit", 60000, 1, []string{"burlington"}},
+		{"Nearest two", 0, 2, []string{"burlington", "montpelier"}},
+		{"Everything", 0, 10, []string{"burlington", "montpelier", "boston", "sydney"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Nearby(find, 44.4780, -73.2100, tt.radiusM, tt.limit)
+			if err != nil {
+				t.Fatalf("Nearby() error = %v", err)
+			}
+			var ids []string
+			for _, place := range result {
+				ids = append(ids, place.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, ids)
+			}
+			if result[0].DistanceM < 200 || result[0].DistanceM > 400 {
+				t.Errorf("expected Burlington about 290 m away, got %v", result[0].DistanceM)
+			}
+		})
+	}
+
+	// A search without a radius stops widening once it has enough places
+	queries = 0
+	if _, err := Nearby(find, 44.4780, -73.2100, 0, 1); err != nil || queries != 1 {
+		t.Errorf("expected one query for the nearest place, got %d (err %v)", queries, err)
+	}
+}
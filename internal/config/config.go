@@ -9,16 +9,25 @@ import (
 )
 
 type Config struct {
-	GoogleGeocodingAPIKey     string
-	IPInfoAPIKey              string
-	DatabaseURL               string
-	Port                      string
-	AdminUsername             string
-	AdminPassword             string
-	MaxAddressCacheSize       int
-	MaxIPCacheSize            int
-	DefaultRateLimitPerSecond int
-	LogLevel                  string
+	GoogleGeocodingAPIKey      string
+	IPInfoAPIKey               string
+	DatabaseURL                string
+	Port                       string
+	AdminUsername              string
+	AdminPassword              string
+	MaxAddressCacheSize        int
+	MaxIPCacheSize             int
+	PlaceCacheTTLHours         int
+	DefaultRateLimitPerSecond  int
+	LogLevel                   string
+	TrustedProxies             string
+	OfflineCountriesPath       string
+	OfflineAdmin1Path          string
+	OfflineCitiesPath          string
+	ActivityLogRedaction       string
+	ActivityBroadcastRedaction string
+	ActivityLogHashSecret      string
+	ActivityLogRetentionHours  int
 }
 
 func Load() *Config {
@@ -34,9 +43,19 @@ func Load() *Config {
 		AdminPassword:             getEnv("ADMIN_PASSWORD", "admin"),
 		MaxAddressCacheSize:       getEnvInt("MAX_ADDRESS_CACHE_SIZE", 10000),
 		MaxIPCacheSize:            getEnvInt("MAX_IP_CACHE_SIZE", 5000),
+		PlaceCacheTTLHours:        getEnvInt("PLACE_CACHE_TTL_HOURS", 720),
 		DefaultRateLimitPerSecond: getEnvInt("DEFAULT_RATE_LIMIT_PER_SECOND", 10),
 		LogLevel:                  getEnv("LOG_LEVEL", "info"),
+		TrustedProxies:            getEnv("TRUSTED_PROXIES", ""),
+		OfflineCountriesPath:      getEnv("OFFLINE_COUNTRIES_PATH", ""),
+		OfflineAdmin1Path:         getEnv("OFFLINE_ADMIN1_PATH", ""),
+		OfflineCitiesPath:         getEnv("OFFLINE_CITIES_PATH", ""),
+		ActivityLogRedaction:      getEnv("ACTIVITY_LOG_REDACTION", "full"),
+		ActivityLogHashSecret:     getEnv("ACTIVITY_LOG_HASH_SECRET", ""),
+		ActivityLogRetentionHours: getEnvInt("ACTIVITY_LOG_RETENTION_HOURS", 0),
 	}
+	// Dashboard viewers see events redacted like the stored log unless told otherwise
+	config.ActivityBroadcastRedaction = getEnv("ACTIVITY_BROADCAST_REDACTION", config.ActivityLogRedaction)
 
 	if config.GoogleGeocodingAPIKey == "" {
 		log.Println("Warning: GOOGLE_GEOCODING_API_KEY not set")
@@ -18,6 +18,13 @@ func TestLoad(t *testing.T) {
 		"MAX_IP_CACHE_SIZE":             os.Getenv("MAX_IP_CACHE_SIZE"),
 		"DEFAULT_RATE_LIMIT_PER_SECOND": os.Getenv("DEFAULT_RATE_LIMIT_PER_SECOND"),
 		"LOG_LEVEL":                     os.Getenv("LOG_LEVEL"),
+		"TRUSTED_PROXIES":               os.Getenv("TRUSTED_PROXIES"),
+		"OFFLINE_COUNTRIES_PATH":        os.Getenv("OFFLINE_COUNTRIES_PATH"),
+		"OFFLINE_ADMIN1_PATH":           os.Getenv("OFFLINE_ADMIN1_PATH"),
+		"OFFLINE_CITIES_PATH":           os.Getenv("OFFLINE_CITIES_PATH"),
+		"ACTIVITY_LOG_REDACTION":        os.Getenv("ACTIVITY_LOG_REDACTION"),
+		"ACTIVITY_BROADCAST_REDACTION":  os.Getenv("ACTIVITY_BROADCAST_REDACTION"),
+		"ACTIVITY_LOG_RETENTION_HOURS":  os.Getenv("ACTIVITY_LOG_RETENTION_HOURS"),
 	}
 
 	// Clean up after test
@@ -52,6 +59,9 @@ func TestLoad(t *testing.T) {
 		if config.DefaultRateLimitPerSecond != 10 {
 			t.Errorf("Expected default rate limit 10, got %d", config.DefaultRateLimitPerSecond)
 		}
+		if config.ActivityLogRedaction != "full" || config.ActivityBroadcastRedaction != "full" || config.ActivityLogRetentionHours != 0 {
+			t.Errorf("Expected unredacted activity kept until trimmed, got %q, %q and %d hours", config.ActivityLogRedaction, config.ActivityBroadcastRedaction, config.ActivityLogRetentionHours)
+		}
 	})
 
 	// Test with custom values
@@ -66,6 +76,10 @@ func TestLoad(t *testing.T) {
 		os.Setenv("MAX_IP_CACHE_SIZE", "2500")
 		os.Setenv("DEFAULT_RATE_LIMIT_PER_SECOND", "20")
 		os.Setenv("LOG_LEVEL", "debug")
+		os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8")
+		os.Setenv("OFFLINE_CITIES_PATH", "/data/cities15000.txt")
+		os.Setenv("ACTIVITY_LOG_REDACTION", "hashed")
+		os.Setenv("ACTIVITY_LOG_RETENTION_HOURS", "72")
 
 		config := Load()
 
@@ -99,6 +113,16 @@ func TestLoad(t *testing.T) {
 		if config.LogLevel != "debug" {
 			t.Errorf("Expected log level 'debug', got '%s'", config.LogLevel)
 		}
+		if config.TrustedProxies != "10.0.0.0/8" {
+			t.Errorf("Expected trusted proxies '10.0.0.0/8', got '%s'", config.TrustedProxies)
+		}
+		if config.OfflineCitiesPath != "/data/cities15000.txt" || config.OfflineAdmin1Path != "" || config.OfflineCountriesPath != "" {
+			t.Errorf("Expected offline cities path only, got '%s', '%s' and '%s'", config.OfflineCitiesPath, config.OfflineAdmin1Path, config.OfflineCountriesPath)
+		}
+		// The broadcast mode follows the stored one when it isn't set
+		if config.ActivityLogRedaction != "hashed" || config.ActivityBroadcastRedaction != "hashed" || config.ActivityLogRetentionHours != 72 {
+			t.Errorf("Expected hashed activity kept for 72 hours, got %q, %q and %d hours", config.ActivityLogRedaction, config.ActivityBroadcastRedaction, config.ActivityLogRetentionHours)
+		}
 	})
 }
 
@@ -0,0 +1,132 @@
+// Package consistency scores how plausible it is that someone at an IP address lives at an address they
+// have given, e.g. before paying out a travel stipend.
+package consistency
+
+import (
+	"math"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/offline"
+)
+
+// Verdicts, from the score
+const (
+	VerdictConsistent   = "consistent"   // Score of 70 or more
+	VerdictPlausible    = "plausible"    // Score of 40 or more
+	VerdictInconsistent = "inconsistent" // Score under 40
+	VerdictUnknown      = "unknown"      // The IP address has no location to compare
+)
+
+// Reasons a score was lowered, and by how much. IP geolocation is usually only accurate to a city or
+// region, so distance matters much less than the country or an anonymizing network.
+var penalties = map[string]int{
+	"different_country":    40,
+	"different_region":     10,
+	"distance_over_100km":  5,
+	"distance_over_200km":  15,
+	"distance_over_1000km": 30,
+	"ip_tor":               40,
+	"ip_vpn":               30,
+	"ip_proxy":             30,
+	"ip_hosting":           20,
+}
+
+// Check compares a geocoded address with an IP lookup
+func Check(address *models.GeocodeAPIResponse, ip *models.GeoIPAPIResponse) *models.ConsistencyResponse {
+	result := &models.ConsistencyResponse{
+		Reasons: []string{},
+		Address: models.ConsistencyAddress{
+			FormattedAddress: address.FormattedAddress,
+			Lat:              address.Lat,
+			Lng:              address.Lng,
+			RegionCode:       address.AdminHierarchy.StateISOCode,
+			CountryCode:      address.CountryCode,
+		},
+		IP: models.ConsistencyIP{
+			IP:            ip.IP,
+			Lat:           ip.Lat,
+			Lng:           ip.Lng,
+			City:          ip.City,
+			RegionCode:    ip.RegionCode,
+			CountryCode:   ip.CountryCode,
+			ASN:           ip.ASN,
+			ASNName:       ip.ASNName,
+			Hosting:       ip.Hosting,
+			VPN:           ip.VPN,
+			Proxy:         ip.Proxy,
+			Tor:           ip.Tor,
+			Bogon:         ip.Bogon,
+			PrivacySource: ip.PrivacySource,
+		},
+	}
+
+	if ip.Bogon || ip.CountryCode == "" {
+		result.Verdict = VerdictUnknown
+		if ip.Bogon {
+			result.Reasons = append(result.Reasons, "ip_bogon")
+		} else {
+			result.Reasons = append(result.Reasons, "ip_not_located")
+		}
+		return result
+	}
+
+	result.DistanceKm = math.Round(offline.DistanceKm(address.Lat, address.Lng, ip.Lat, ip.Lng)*10) / 10
+	result.SameCountry = strings.EqualFold(address.CountryCode, ip.CountryCode)
+	regionKnown, sameRegion := compareRegions(address, ip)
+	result.SameRegion = result.SameCountry && sameRegion
+
+	switch {
+	case !result.SameCountry:
+		result.Reasons = append(result.Reasons, "different_country")
+	case regionKnown && !sameRegion:
+		result.Reasons = append(result.Reasons, "different_region")
+	}
+
+	switch {
+	case result.DistanceKm > 1000:
+		result.Reasons = append(result.Reasons, "distance_over_1000km")
+	case result.DistanceKm > 200:
+		result.Reasons = append(result.Reasons, "distance_over_200km")
+	case result.DistanceKm > 100:
+		result.Reasons = append(result.Reasons, "distance_over_100km")
+	}
+
+	flags := []struct {
+		reason  string
+		flagged bool
+	}{{"ip_tor", ip.Tor}, {"ip_vpn", ip.VPN}, {"ip_proxy", ip.Proxy}, {"ip_hosting", ip.Hosting}}
+	for _, flag := range flags {
+		if flag.flagged {
+			result.Reasons = append(result.Reasons, flag.reason)
+		}
+	}
+
+	result.Score = 100
+	for _, reason := range result.Reasons {
+		result.Score -= penalties[reason]
+	}
+	result.Score = max(result.Score, 0)
+
+	switch {
+	case result.Score >= 70:
+		result.Verdict = VerdictConsistent
+	case result.Score >= 40:
+		result.Verdict = VerdictPlausible
+	default:
+		result.Verdict = VerdictInconsistent
+	}
+	return result
+}
+
+// compareRegions reports whether both sides have a region, and whether it is the same one. ISO 3166-2
+// codes are compared when both have them, and names otherwise.
+func compareRegions(address *models.GeocodeAPIResponse, ip *models.GeoIPAPIResponse) (known, same bool) {
+	if address.AdminHierarchy.StateISOCode != "" && ip.RegionCode != "" {
+		return true, strings.EqualFold(address.AdminHierarchy.StateISOCode, ip.RegionCode)
+	}
+	if address.StateName != "" && ip.Region != "" {
+		return true, strings.EqualFold(address.StateName, ip.Region)
+	}
+	return false, false
+}
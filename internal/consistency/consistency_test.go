@@ -0,0 +1,99 @@
+package consistency
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+func TestCheck(t *testing.T) {
+	burlington := &models.GeocodeAPIResponse{
+		FormattedAddress: "15 Falls Rd, Shelburne, VT 05482, USA",
+		Lat:              44.3806,
+		Lng:              -73.2276,
+		StateName:        "Vermont",
+		CountryCode:      "US",
+		AdminHierarchy:   models.AdminHierarchy{StateISOCode: "US-VT"},
+	}
+
+	tests := []struct {
+		name            string
+		ip              *models.GeoIPAPIResponse
+		expectedScore   int
+		expectedVerdict string
+		expectedReasons []string
+	}{
+		{
+			name:            "Same city",
+			ip:              &models.GeoIPAPIResponse{Lat: 44.4759, Lng: -73.2121, RegionCode: "US-VT", CountryCode: "US"},
+			expectedScore:   100,
+			expectedVerdict: VerdictConsistent,
+			expectedReasons: []string{},
+		},
+		{
+			name:            "Neighbouring state",
+			ip:              &models.GeoIPAPIResponse{Lat: 43.2081, Lng: -71.5376, RegionCode: "US-NH", CountryCode: "US"},
+			expectedScore:   85,
+			expectedVerdict: VerdictConsistent,
+			expectedReasons: []string{"different_region", "distance_over_100km"},
+		},
+		{
+			name:            "Region names without ISO codes",
+			ip:              &models.GeoIPAPIResponse{Lat: 44.4759, Lng: -73.2121, Region: "vermont", CountryCode: "US"},
+			expectedScore:   100,
+			expectedVerdict: VerdictConsistent,
+			expectedReasons: []string{},
+		},
+		{
+			name:            "Other country on a VPN",
+			ip:              &models.GeoIPAPIResponse{Lat: 52.3676, Lng: 4.9041, RegionCode: "NL-NH", CountryCode: "NL", VPN: true, Hosting: true},
+			expectedScore:   0,
+			expectedVerdict: VerdictInconsistent,
+			expectedReasons: []string{"different_country", "distance_over_1000km", "ip_vpn", "ip_hosting"},
+		},
+		{
+			name:            "Same city from a cloud provider",
+			ip:              &models.GeoIPAPIResponse{Lat: 44.4759, Lng: -73.2121, RegionCode: "US-VT", CountryCode: "US", Hosting: true},
+			expectedScore:   80,
+			expectedVerdict: VerdictConsistent,
+			expectedReasons: []string{"ip_hosting"},
+		},
+		{
+			name:            "Far away in the same country",
+			ip:              &models.GeoIPAPIResponse{Lat: 37.4056, Lng: -122.0775, RegionCode: "US-CA", CountryCode: "US", Tor: true},
+			expectedScore:   20,
+			expectedVerdict: VerdictInconsistent,
+			expectedReasons: []string{"different_region", "distance_over_1000km", "ip_tor"},
+		},
+		{
+			name:            "Private address",
+			ip:              &models.GeoIPAPIResponse{IP: "10.0.0.1", Bogon: true, BogonReason: "private"},
+			expectedScore:   0,
+			expectedVerdict: VerdictUnknown,
+			expectedReasons: []string{"ip_bogon"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Check(burlington, tt.ip)
+			if result.Score != tt.expectedScore || result.Verdict != tt.expectedVerdict || !reflect.DeepEqual(result.Reasons, tt.expectedReasons) {
+				t.Errorf("Check() = %d %s %v, expected %d %s %v", result.Score, result.Verdict, result.Reasons, tt.expectedScore, tt.expectedVerdict, tt.expectedReasons)
+			}
+		})
+	}
+}
+
+func TestCheck_Distance(t *testing.T) {
+	address := &models.GeocodeAPIResponse{Lat: 51.5074, Lng: -0.1278, CountryCode: "GB"}
+	ip := &models.GeoIPAPIResponse{Lat: 48.8566, Lng: 2.3522, CountryCode: "FR"}
+
+	result := Check(address, ip)
+	if result.DistanceKm < 340 || result.DistanceKm > 345 {
+		t.Errorf("Expected London to Paris to be about 343 km, got %.1f", result.DistanceKm)
+	}
+	if result.SameCountry || result.SameRegion {
+		t.Errorf("Expected different countries, got %+v", result)
+	}
+}
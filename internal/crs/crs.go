@@ -0,0 +1,58 @@
+// Package crs converts between WGS84 latitude/longitude and the projected coordinate systems
+// partner datasets tend to use: UTM, MGRS grid references and Web Mercator (EPSG:3857). UTM uses
+// Krüger's series on the WGS84 ellipsoid, which is accurate to well under a millimetre within
+// a zone.
+package crs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Systems, as named in the to parameter of /v1/convert and the source of its response
+const (
+	WGS84    = "wgs84"
+	UTM      = "utm"
+	MGRS     = "mgrs"
+	EPSG3857 = "epsg3857"
+)
+
+const (
+	// WGS84 ellipsoid
+	semiMajorAxis = 6378137.0
+	flattening    = 1 / 298.257223563
+
+	// maxMercatorLat is where Web Mercator's square world ends
+	maxMercatorLat = 85.051128779806604
+	// maxMercatorXY is half the width of the Web Mercator world in metres
+	maxMercatorXY = math.Pi * semiMajorAxis
+)
+
+var (
+	// ErrInvalidCoordinates is returned for coordinates that can't be read or are out of range
+	ErrInvalidCoordinates = errors.New("invalid coordinates")
+	// ErrOutOfRange is returned when a point has no coordinates in a system, such as UTM near the poles
+	ErrOutOfRange = errors.New("outside the coordinate system's range")
+)
+
+// ToWebMercator projects a point to EPSG:3857 metres. Latitudes beyond about 85.05 degrees are
+// outside the projection.
+func ToWebMercator(lat, lng float64) (x, y float64, err error) {
+	if math.Abs(lat) > maxMercatorLat {
+		return 0, 0, fmt.Errorf("%w: Web Mercator only covers latitudes up to %.4f", ErrOutOfRange, maxMercatorLat)
+	}
+	x = semiMajorAxis * lng * math.Pi / 180
+	y = semiMajorAxis * math.Log(math.Tan(math.Pi/4+lat*math.Pi/360))
+	return x, y, nil
+}
+
+// FromWebMercator returns the point at EPSG:3857 metres
+func FromWebMercator(x, y float64) (lat, lng float64, err error) {
+	if math.IsNaN(x) || math.IsNaN(y) || math.Abs(x) > maxMercatorXY+1e-6 || math.Abs(y) > maxMercatorXY+1e-6 {
+		return 0, 0, fmt.Errorf("%w: Web Mercator x and y must be between %.0f and %.0f", ErrInvalidCoordinates, -maxMercatorXY, maxMercatorXY)
+	}
+	lng = x / semiMajorAxis * 180 / math.Pi
+	lat = (2*math.Atan(math.Exp(y/semiMajorAxis)) - math.Pi/2) * 180 / math.Pi
+	return lat, lng, nil
+}
@@ -0,0 +1,133 @@
+package crs
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestToUTM(t *testing.T) {
+	tests := []struct {
+		name              string
+		lat, lng          float64
+		zone              int
+		band              byte
+		easting, northing float64
+	}{
+		{"Eiffel Tower", 48.8582, 2.2945, 31, 'U', 448251.795, 5411932.678},
+		{"Sydney Opera House", -33.857, 151.215, 56, 'H', 334873.8, 6252266.9},
+		{"Equator on a central meridian", 0, 3, 31, 'N', 500000, 0},
+		{"Bergen, widened zone 32", 60.39, 5.32, 32, 'V', 0, 0},
+		{"Longyearbyen, Svalbard zone 33", 78.22, 15.65, 33, 'X', 0, 0},
+	}
+	for _, tt := range tests {
+		c, err := ToUTM(tt.lat, tt.lng)
+		if err != nil {
+			t.Errorf("%s: ToUTM() error = %v", tt.name, err)
+			continue
+		}
+		if c.Zone != tt.zone || c.Band != tt.band || c.North != (tt.lat >= 0) {
+			t.Errorf("%s: ToUTM() = zone %d%c, want %d%c", tt.name, c.Zone, c.Band, tt.zone, tt.band)
+		}
+		if tt.easting != 0 && (math.Abs(c.Easting-tt.easting) > 1 || math.Abs(c.Northing-tt.northing) > 1) {
+			t.Errorf("%s: ToUTM() = %.3f %.3f, want %.3f %.3f", tt.name, c.Easting, c.Northing, tt.easting, tt.northing)
+		}
+
+		lat, lng, err := FromUTM(c)
+		if err != nil || math.Abs(lat-tt.lat) > 1e-9 || math.Abs(lng-tt.lng) > 1e-9 {
+			t.Errorf("%s: FromUTM() = %v, %v, %v, want the original point", tt.name, lat, lng, err)
+		}
+	}
+
+	if _, err := ToUTM(84.5, 0); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange above 84°N, got %v", err)
+	}
+}
+
+func TestParseUTM(t *testing.T) {
+	tests := []struct {
+		value string
+		zone  int
+		north bool
+	}{
+		{"31N 448251.795 5411932.678", 31, true},
+		{"56 S 334873 6252266", 56, false},
+		{"31u, 448251, 5411932", 31, true},
+		{"56H 334873 6252266", 56, false},
+	}
+	for _, tt := range tests {
+		c, err := ParseUTM(tt.value)
+		if err != nil || c.Zone != tt.zone || c.North != tt.north {
+			t.Errorf("ParseUTM(%q) = %+v, %v", tt.value, c, err)
+		}
+	}
+
+	for _, value := range []string{"", "31 448251 5411932", "31I 448251 5411932", "31N east 5411932", "31N 448251"} {
+		if _, err := ParseUTM(value); !errors.Is(err, ErrInvalidCoordinates) {
+			t.Errorf("ParseUTM(%q): expected ErrInvalidCoordinates, got %v", value, err)
+		}
+	}
+	if _, _, err := FromUTM(UTMCoordinate{Zone: 61, North: true, Easting: 500000}); !errors.Is(err, ErrInvalidCoordinates) {
+		t.Errorf("expected ErrInvalidCoordinates for zone 61, got %v", err)
+	}
+}
+
+func TestMGRS(t *testing.T) {
+	reference, err := ToMGRS(48.8582, 2.2945, 5)
+	if err != nil || reference != "31UDQ4825111932" {
+		t.Errorf("ToMGRS() = %q, %v, want 31UDQ4825111932", reference, err)
+	}
+	if reference, _ := ToMGRS(48.8582, 2.2945, 2); reference != "31UDQ4811" {
+		t.Errorf("ToMGRS() at 1 km = %q, want 31UDQ4811", reference)
+	}
+
+	// A reference names a square, and its centre is within the square's half-diagonal of the point.
+	// Squares cut by a zone boundary can have their centre in the next zone.
+	for _, p := range [][2]float64{{48.8582, 2.2945}, {-33.857, 151.215}, {-79.5, -120.3}, {83.9, 40.1}, {0.0001, -0.0001}, {60.39, 5.32}} {
+		for precision := 1; precision <= MaxMGRSPrecision; precision++ {
+			reference, err := ToMGRS(p[0], p[1], precision)
+			if err != nil {
+				t.Fatalf("ToMGRS(%v, %d) error = %v", p, precision, err)
+			}
+			lat, lng, err := FromMGRS(reference)
+			if err != nil {
+				t.Fatalf("FromMGRS(%q) error = %v", reference, err)
+			}
+			size := 100000 / math.Pow(10, float64(precision))
+			dLat := (lat - p[0]) * 111320
+			dLng := (lng - p[1]) * 111320 * math.Cos(p[0]*math.Pi/180)
+			if math.Hypot(dLat, dLng) > 0.71*size+1 {
+				t.Errorf("FromMGRS(%q) = %v, %v, %.0fm from %v", reference, lat, lng, math.Hypot(dLat, dLng), p)
+			}
+		}
+	}
+
+	if lat, lng, err := FromMGRS("31U DQ 48251 11932"); err != nil || math.Abs(lat-48.8582) > 1e-4 || math.Abs(lng-2.2945) > 1e-4 {
+		t.Errorf("FromMGRS() with spaces = %v, %v, %v", lat, lng, err)
+	}
+	for _, reference := range []string{"", "31UDQ482511193", "31UDI4825111932", "61UDQ4825111932", "31UJQ4825111932", "31UDQ482511193200"} {
+		if _, _, err := FromMGRS(reference); !errors.Is(err, ErrInvalidCoordinates) {
+			t.Errorf("FromMGRS(%q): expected ErrInvalidCoordinates, got %v", reference, err)
+		}
+	}
+}
+
+func TestWebMercator(t *testing.T) {
+	x, y, err := ToWebMercator(maxMercatorLat, 180)
+	if err != nil || math.Abs(x-20037508.342789244) > 1e-6 || math.Abs(y-20037508.342789244) > 1e-3 {
+		t.Errorf("ToWebMercator() at the corner = %v, %v, %v", x, y, err)
+	}
+
+	x, y, _ = ToWebMercator(48.8582, 2.2945)
+	lat, lng, err := FromWebMercator(x, y)
+	if err != nil || math.Abs(lat-48.8582) > 1e-9 || math.Abs(lng-2.2945) > 1e-9 {
+		t.Errorf("FromWebMercator() = %v, %v, %v", lat, lng, err)
+	}
+
+	if _, _, err := ToWebMercator(86, 0); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange above the projection, got %v", err)
+	}
+	if _, _, err := FromWebMercator(0, 3e7); !errors.Is(err, ErrInvalidCoordinates) {
+		t.Errorf("expected ErrInvalidCoordinates outside the projection, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package crs
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultMGRSPrecision is five digits each of easting and northing, a 1 m square
+	DefaultMGRSPrecision = 5
+	// MaxMGRSPrecision is the finest precision MGRS defines
+	MaxMGRSPrecision = 5
+
+	squareSize = 100000.0
+)
+
+// The 100 km square letters repeat every three zones across and every 2,000 km up, with even zones
+// shifted five letters north (the WGS84 "AA" lettering)
+var (
+	columnLetters = [3]string{"ABCDEFGH", "JKLMNPQR", "STUVWXYZ"}
+	rowLetters    = [2]string{"ABCDEFGHJKLMNPQRSTUV", "FGHJKLMNPQRSTUVABCDE"}
+
+	mgrsPattern = regexp.MustCompile(`^(\d{1,2})([C-HJ-NP-X])([A-HJ-NP-Z])([A-HJ-NP-V])(\d*)$`)
+)
+
+// ToMGRS returns a point's MGRS grid reference with 1 to 5 digits each of easting and northing,
+// e.g. "33UUU8992519383". References are truncated, so they name the square the point is in.
+func ToMGRS(lat, lng float64, precision int) (string, error) {
+	if precision < 1 || precision > MaxMGRSPrecision {
+		return "", fmt.Errorf("%w: MGRS precision must be between 1 and %d digits", ErrInvalidCoordinates, MaxMGRSPrecision)
+	}
+	c, err := ToUTM(lat, lng)
+	if err != nil {
+		return "", err
+	}
+
+	column := int(math.Floor(c.Easting / squareSize))
+	row := int(math.Floor(c.Northing/squareSize)) % 20
+	divisor := math.Pow(10, float64(MaxMGRSPrecision-precision))
+	easting := int(math.Floor(math.Mod(c.Easting, squareSize) / divisor))
+	northing := int(math.Floor(math.Mod(c.Northing, squareSize) / divisor))
+
+	return fmt.Sprintf("%d%c%c%c%0*d%0*d", c.Zone, c.Band,
+		columnLetters[(c.Zone-1)%3][column-1], rowLetters[(c.Zone-1)%2][row],
+		precision, easting, precision, northing), nil
+}
+
+// FromMGRS returns the centre of the square an MGRS grid reference names. Spaces are ignored, and
+// a reference without digits names the whole 100 km square.
+func FromMGRS(reference string) (lat, lng float64, err error) {
+	reference = strings.ToUpper(strings.Join(strings.Fields(reference), ""))
+	match := mgrsPattern.FindStringSubmatch(reference)
+	if match == nil {
+		return 0, 0, fmt.Errorf("%w: MGRS references look like \"33UUU8992519383\"", ErrInvalidCoordinates)
+	}
+	digits := match[5]
+	if len(digits)%2 == 1 || len(digits) > 2*MaxMGRSPrecision {
+		return 0, 0, fmt.Errorf("%w: an MGRS reference has up to %d digits each of easting and northing", ErrInvalidCoordinates, MaxMGRSPrecision)
+	}
+
+	zone, _ := strconv.Atoi(match[1])
+	if zone < 1 || zone > 60 {
+		return 0, 0, fmt.Errorf("%w: MGRS zone must be between 1 and 60", ErrInvalidCoordinates)
+	}
+	band := match[2][0]
+
+	column := strings.Index(columnLetters[(zone-1)%3], match[3])
+	row := strings.Index(rowLetters[(zone-1)%2], match[4])
+	if column < 0 || row < 0 {
+		return 0, 0, fmt.Errorf("%w: square %s%s doesn't exist in zone %d", ErrInvalidCoordinates, match[3], match[4], zone)
+	}
+
+	// Digits are metres within the square at their precision; aim for the middle of the square they name
+	precision := len(digits) / 2
+	size := squareSize / math.Pow(10, float64(precision))
+	var eastingDigits, northingDigits int
+	if precision > 0 {
+		eastingDigits, _ = strconv.Atoi(digits[:precision])
+		northingDigits, _ = strconv.Atoi(digits[precision:])
+	}
+	easting := float64(column+1)*squareSize + float64(eastingDigits)*size + size/2
+	northing := float64(row)*squareSize + float64(northingDigits)*size + size/2
+
+	// Row letters repeat every 2,000 km, so count up from the bottom of the latitude band. The
+	// band's edge is measured on the central meridian, less a margin for parallels that curve
+	// south away from it in the southern hemisphere.
+	bandBottom := float64(strings.IndexByte(latBands, band))*8 + utmMinLat
+	bottom := toZone(bandBottom, float64((zone-1)*6-180+3), zone).Northing - 25000
+	bottom = math.Floor(bottom/squareSize) * squareSize
+	for northing < bottom {
+		northing += 20 * squareSize
+	}
+
+	return FromUTM(UTMCoordinate{Zone: zone, North: band >= 'N', Band: band, Easting: easting, Northing: northing})
+}
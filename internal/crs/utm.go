@@ -0,0 +1,231 @@
+package crs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	utmScale         = 0.9996
+	utmFalseEasting  = 500000.0
+	utmFalseNorthing = 10000000.0 // Southern hemisphere only
+	utmMinLat        = -80.0
+	utmMaxLat        = 84.0
+
+	// latBands are the 8 degree latitude bands from 80°S, with X stretched to 84°N
+	latBands = "CDEFGHJKLMNPQRSTUVWX"
+)
+
+// UTMCoordinate is a position in a UTM zone
+type UTMCoordinate struct {
+	Zone     int
+	North    bool // Hemisphere; southern northings are offset by 10,000 km
+	Band     byte // Latitude band letter, C to X; 0 when the coordinate was read without one
+	Easting  float64
+	Northing float64
+}
+
+// Krüger series coefficients for the WGS84 ellipsoid
+var (
+	eccentricity = math.Sqrt(flattening * (2 - flattening))
+	rectifyingA  float64 // Radius of the rectifying sphere
+	alpha        [7]float64
+	beta         [7]float64
+)
+
+func init() {
+	n := flattening / (2 - flattening)
+	n2, n3, n4, n5, n6 := n*n, n*n*n, n*n*n*n, n*n*n*n*n, n*n*n*n*n*n
+
+	rectifyingA = semiMajorAxis / (1 + n) * (1 + n2/4 + n4/64 + n6/256)
+	alpha = [7]float64{0,
+		n/2 - 2.0/3*n2 + 5.0/16*n3 + 41.0/180*n4 - 127.0/288*n5 + 7891.0/37800*n6,
+		13.0/48*n2 - 3.0/5*n3 + 557.0/1440*n4 + 281.0/630*n5 - 1983433.0/1935360*n6,
+		61.0/240*n3 - 103.0/140*n4 + 15061.0/26880*n5 + 167603.0/181440*n6,
+		49561.0/161280*n4 - 179.0/168*n5 + 6601661.0/7257600*n6,
+		34729.0/80640*n5 - 3418889.0/1995840*n6,
+		212378941.0 / 319334400 * n6,
+	}
+	beta = [7]float64{0,
+		n/2 - 2.0/3*n2 + 37.0/96*n3 - 1.0/360*n4 - 81.0/512*n5 + 96199.0/604800*n6,
+		1.0/48*n2 + 1.0/15*n3 - 437.0/1440*n4 + 46.0/105*n5 - 1118711.0/3870720*n6,
+		17.0/480*n3 - 37.0/840*n4 - 209.0/4480*n5 + 5569.0/90720*n6,
+		4397.0/161280*n4 - 11.0/504*n5 - 830251.0/7257600*n6,
+		4583.0/161280*n5 - 108847.0/3991680*n6,
+		20648693.0 / 638668800 * n6,
+	}
+}
+
+// ToUTM returns a point's UTM coordinate in its standard zone, including the Norway and Svalbard
+// exceptions. UTM covers 80°S to 84°N; the polar caps use UPS, which isn't supported.
+func ToUTM(lat, lng float64) (UTMCoordinate, error) {
+	if lat < utmMinLat || lat > utmMaxLat {
+		return UTMCoordinate{}, fmt.Errorf("%w: UTM only covers latitudes from 80°S to 84°N", ErrOutOfRange)
+	}
+	lng = normalizeLng(lng)
+
+	zone := int(math.Floor((lng+180)/6)) + 1
+	band := latBand(lat)
+	switch {
+	case band == 'V' && zone == 31 && lng >= 3:
+		zone = 32 // South-west Norway
+	case band == 'X' && zone == 32:
+		zone = 31 + 2*btoi(lng >= 9) // Svalbard has no zone 32
+	case band == 'X' && zone == 34:
+		zone = 33 + 2*btoi(lng >= 21)
+	case band == 'X' && zone == 36:
+		zone = 35 + 2*btoi(lng >= 33)
+	}
+
+	c := toZone(lat, lng, zone)
+	c.Band = band
+	return c, nil
+}
+
+func toZone(lat, lng float64, zone int) UTMCoordinate {
+	centralMeridian := float64((zone-1)*6 - 180 + 3)
+	phi := lat * math.Pi / 180
+	lambda := (lng - centralMeridian) * math.Pi / 180
+
+	// Conformal latitude, then Gauss-Schreiber coordinates, then the series to the ellipsoid
+	tau := math.Tan(phi)
+	sigma := math.Sinh(eccentricity * math.Atanh(eccentricity*tau/math.Sqrt(1+tau*tau)))
+	tauPrime := tau*math.Sqrt(1+sigma*sigma) - sigma*math.Sqrt(1+tau*tau)
+	xiPrime := math.Atan2(tauPrime, math.Cos(lambda))
+	etaPrime := math.Asinh(math.Sin(lambda) / math.Sqrt(tauPrime*tauPrime+math.Cos(lambda)*math.Cos(lambda)))
+
+	xi, eta := xiPrime, etaPrime
+	for j := 1; j <= 6; j++ {
+		xi += alpha[j] * math.Sin(2*float64(j)*xiPrime) * math.Cosh(2*float64(j)*etaPrime)
+		eta += alpha[j] * math.Cos(2*float64(j)*xiPrime) * math.Sinh(2*float64(j)*etaPrime)
+	}
+
+	c := UTMCoordinate{
+		Zone:     zone,
+		North:    lat >= 0,
+		Easting:  utmScale*rectifyingA*eta + utmFalseEasting,
+		Northing: utmScale * rectifyingA * xi,
+	}
+	if !c.North {
+		c.Northing += utmFalseNorthing
+	}
+	return c
+}
+
+// FromUTM returns the point at a UTM coordinate
+func FromUTM(c UTMCoordinate) (lat, lng float64, err error) {
+	if c.Zone < 1 || c.Zone > 60 {
+		return 0, 0, fmt.Errorf("%w: UTM zone must be between 1 and 60", ErrInvalidCoordinates)
+	}
+	// Easting stays within about 500 km of the central meridian even where zones are widened
+	if c.Easting < 0 || c.Easting > 1000000 || c.Northing < 0 || c.Northing > utmFalseNorthing {
+		return 0, 0, fmt.Errorf("%w: UTM easting or northing is out of range", ErrInvalidCoordinates)
+	}
+
+	northing := c.Northing
+	if !c.North {
+		northing -= utmFalseNorthing
+	}
+	xi := northing / (utmScale * rectifyingA)
+	eta := (c.Easting - utmFalseEasting) / (utmScale * rectifyingA)
+
+	xiPrime, etaPrime := xi, eta
+	for j := 1; j <= 6; j++ {
+		xiPrime -= beta[j] * math.Sin(2*float64(j)*xi) * math.Cosh(2*float64(j)*eta)
+		etaPrime -= beta[j] * math.Cos(2*float64(j)*xi) * math.Sinh(2*float64(j)*eta)
+	}
+
+	sinhEtaPrime := math.Sinh(etaPrime)
+	sinXiPrime, cosXiPrime := math.Sin(xiPrime), math.Cos(xiPrime)
+	tauPrime := sinXiPrime / math.Sqrt(sinhEtaPrime*sinhEtaPrime+cosXiPrime*cosXiPrime)
+
+	// Newton's method back from the conformal latitude
+	e2 := eccentricity * eccentricity
+	tau := tauPrime
+	for n := 0; n < 10; n++ {
+		sigma := math.Sinh(eccentricity * math.Atanh(eccentricity*tau/math.Sqrt(1+tau*tau)))
+		tauI := tau*math.Sqrt(1+sigma*sigma) - sigma*math.Sqrt(1+tau*tau)
+		delta := (tauPrime - tauI) / math.Sqrt(1+tauI*tauI) * (1 + (1-e2)*tau*tau) / ((1 - e2) * math.Sqrt(1+tau*tau))
+		tau += delta
+		if math.Abs(delta) < 1e-12 {
+			break
+		}
+	}
+
+	centralMeridian := float64((c.Zone-1)*6 - 180 + 3)
+	lat = math.Atan(tau) * 180 / math.Pi
+	lng = normalizeLng(math.Atan2(sinhEtaPrime, cosXiPrime)*180/math.Pi + centralMeridian)
+	return lat, lng, nil
+}
+
+// ParseUTM reads a coordinate such as "33N 389925 5819383". The letter after the zone is the
+// hemisphere, N or S, as in EPSG's "UTM zone 33N"; other latitude band letters (C-M south, P-X
+// north) are accepted too. Commas may separate the parts.
+func ParseUTM(value string) (UTMCoordinate, error) {
+	fields := strings.Fields(strings.ReplaceAll(value, ",", " "))
+	// Allow a space between the zone and its letter
+	if len(fields) == 4 && len(fields[1]) == 1 {
+		fields = []string{fields[0] + fields[1], fields[2], fields[3]}
+	}
+	if len(fields) != 3 {
+		return UTMCoordinate{}, fmt.Errorf("%w: UTM coordinates look like \"33N 389925 5819383\"", ErrInvalidCoordinates)
+	}
+
+	zoneField := strings.ToUpper(fields[0])
+	letter := rune(zoneField[len(zoneField)-1])
+	if !unicode.IsLetter(letter) {
+		return UTMCoordinate{}, fmt.Errorf("%w: UTM zone needs a hemisphere, e.g. 33N", ErrInvalidCoordinates)
+	}
+	zone, err := strconv.Atoi(zoneField[:len(zoneField)-1])
+	if err != nil {
+		return UTMCoordinate{}, fmt.Errorf("%w: invalid UTM zone %q", ErrInvalidCoordinates, fields[0])
+	}
+
+	c := UTMCoordinate{Zone: zone}
+	switch {
+	case letter == 'N':
+		c.North = true
+	case letter == 'S':
+		c.North = false
+	case strings.ContainsRune(latBands, letter):
+		c.Band = byte(letter)
+		c.North = letter >= 'N'
+	default:
+		return UTMCoordinate{}, fmt.Errorf("%w: %q is neither a hemisphere nor a latitude band", ErrInvalidCoordinates, letter)
+	}
+
+	c.Easting, err = strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return UTMCoordinate{}, fmt.Errorf("%w: invalid UTM easting %q", ErrInvalidCoordinates, fields[1])
+	}
+	c.Northing, err = strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return UTMCoordinate{}, fmt.Errorf("%w: invalid UTM northing %q", ErrInvalidCoordinates, fields[2])
+	}
+	return c, nil
+}
+
+// latBand returns the latitude band letter of a latitude within UTM's range
+func latBand(lat float64) byte {
+	index := int(math.Floor((lat - utmMinLat) / 8))
+	return latBands[min(index, len(latBands)-1)]
+}
+
+// normalizeLng wraps a longitude into [-180, 180)
+func normalizeLng(lng float64) float64 {
+	lng = math.Mod(lng+180, 360)
+	if lng < 0 {
+		lng += 360
+	}
+	return lng - 180
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -3,10 +3,12 @@ package database
 import (
 	"crypto/sha256"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"github.com/hackclub/geocoder/internal/models"
 )
@@ -46,11 +48,13 @@ func (db *DB) CreateAPIKey(keyHash, name, owner, appName, environment string, ra
 	query := `
 		INSERT INTO api_keys (key_hash, name, owner, app_name, environment, rate_limit_per_second)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, key_hash, name, owner, app_name, environment, is_active, rate_limit_per_second, created_at, last_used_at, request_count
+		RETURNING id, key_hash, name, owner, app_name, environment, is_active, rate_limit_per_second, created_at, last_used_at, request_count, require_header_auth, allow_cache_control,
+			privacy_coordinate_decimals, privacy_jitter_meters, privacy_suppress_street, privacy_city_level
 	`
 	err := db.conn.QueryRow(query, keyHash, name, owner, appName, environment, rateLimitPerSecond).Scan(
 		&apiKey.ID, &apiKey.KeyHash, &apiKey.Name, &apiKey.Owner, &apiKey.AppName, &apiKey.Environment, &apiKey.IsActive,
-		&apiKey.RateLimitPerSecond, &apiKey.CreatedAt, &apiKey.LastUsedAt, &apiKey.RequestCount,
+		&apiKey.RateLimitPerSecond, &apiKey.CreatedAt, &apiKey.LastUsedAt, &apiKey.RequestCount, &apiKey.RequireHeaderAuth, &apiKey.AllowCacheControl,
+		&apiKey.Privacy.CoordinateDecimals, &apiKey.Privacy.JitterMeters, &apiKey.Privacy.SuppressStreet, &apiKey.Privacy.CityLevel,
 	)
 	return &apiKey, err
 }
@@ -58,13 +62,15 @@ func (db *DB) CreateAPIKey(keyHash, name, owner, appName, environment string, ra
 func (db *DB) GetAPIKeyByHash(keyHash string) (*models.APIKey, error) {
 	var apiKey models.APIKey
 	query := `
-		SELECT id, key_hash, name, owner, app_name, environment, is_active, rate_limit_per_second, created_at, last_used_at, request_count
+		SELECT id, key_hash, name, owner, app_name, environment, is_active, rate_limit_per_second, created_at, last_used_at, request_count, require_header_auth, allow_cache_control,
+			privacy_coordinate_decimals, privacy_jitter_meters, privacy_suppress_street, privacy_city_level
 		FROM api_keys
 		WHERE key_hash = $1 AND is_active = true
 	`
 	err := db.conn.QueryRow(query, keyHash).Scan(
 		&apiKey.ID, &apiKey.KeyHash, &apiKey.Name, &apiKey.Owner, &apiKey.AppName, &apiKey.Environment, &apiKey.IsActive,
-		&apiKey.RateLimitPerSecond, &apiKey.CreatedAt, &apiKey.LastUsedAt, &apiKey.RequestCount,
+		&apiKey.RateLimitPerSecond, &apiKey.CreatedAt, &apiKey.LastUsedAt, &apiKey.RequestCount, &apiKey.RequireHeaderAuth, &apiKey.AllowCacheControl,
+		&apiKey.Privacy.CoordinateDecimals, &apiKey.Privacy.JitterMeters, &apiKey.Privacy.SuppressStreet, &apiKey.Privacy.CityLevel,
 	)
 	if err != nil {
 		return nil, err
@@ -84,7 +90,8 @@ func (db *DB) UpdateAPIKeyUsage(keyID string) error {
 
 func (db *DB) GetAllAPIKeys() ([]models.APIKey, error) {
 	query := `
-		SELECT id, key_hash, name, owner, app_name, environment, is_active, rate_limit_per_second, created_at, last_used_at, request_count
+		SELECT id, key_hash, name, owner, app_name, environment, is_active, rate_limit_per_second, created_at, last_used_at, request_count, require_header_auth, allow_cache_control,
+			privacy_coordinate_decimals, privacy_jitter_meters, privacy_suppress_street, privacy_city_level
 		FROM api_keys
 		ORDER BY created_at DESC
 	`
@@ -98,7 +105,8 @@ func (db *DB) GetAllAPIKeys() ([]models.APIKey, error) {
 	for rows.Next() {
 		var key models.APIKey
 		err := rows.Scan(&key.ID, &key.KeyHash, &key.Name, &key.Owner, &key.AppName, &key.Environment, &key.IsActive,
-			&key.RateLimitPerSecond, &key.CreatedAt, &key.LastUsedAt, &key.RequestCount)
+			&key.RateLimitPerSecond, &key.CreatedAt, &key.LastUsedAt, &key.RequestCount, &key.RequireHeaderAuth, &key.AllowCacheControl,
+			&key.Privacy.CoordinateDecimals, &key.Privacy.JitterMeters, &key.Privacy.SuppressStreet, &key.Privacy.CityLevel)
 		if err != nil {
 			return nil, err
 		}
@@ -113,6 +121,29 @@ func (db *DB) UpdateAPIKeyRateLimit(keyID string, rateLimitPerSecond int) error
 	return err
 }
 
+// UpdateAPIKeyCacheControl sets whether a key may refresh or bypass the caches
+func (db *DB) UpdateAPIKeyCacheControl(keyID string, allowCacheControl bool) error {
+	query := `UPDATE api_keys SET allow_cache_control = $1 WHERE id = $2`
+	_, err := db.conn.Exec(query, allowCacheControl, keyID)
+	return err
+}
+
+func (db *DB) UpdateAPIKeyRequireHeaderAuth(keyID string, requireHeaderAuth bool) error {
+	query := `UPDATE api_keys SET require_header_auth = $1 WHERE id = $2`
+	_, err := db.conn.Exec(query, requireHeaderAuth, keyID)
+	return err
+}
+
+func (db *DB) UpdateAPIKeyPrivacyPolicy(keyID string, policy models.PrivacyPolicy) error {
+	query := `
+		UPDATE api_keys
+		SET privacy_coordinate_decimals = $1, privacy_jitter_meters = $2, privacy_suppress_street = $3, privacy_city_level = $4
+		WHERE id = $5
+	`
+	_, err := db.conn.Exec(query, policy.CoordinateDecimals, policy.JitterMeters, policy.SuppressStreet, policy.CityLevel, keyID)
+	return err
+}
+
 func (db *DB) DeactivateAPIKey(keyID string) error {
 	query := `UPDATE api_keys SET is_active = false WHERE id = $1`
 	_, err := db.conn.Exec(query, keyID)
@@ -175,6 +206,22 @@ func (db *DB) SetAddressCache(queryHash, queryText, responseData string, maxCach
 	return err
 }
 
+// DeleteAddressCache removes an address's cache entries: the one under its hash, and those
+// stored with the same text, with or without geocoding options
+func (db *DB) DeleteAddressCache(queryHash, queryText string) (int64, error) {
+	query := `
+		DELETE FROM address_cache
+		WHERE query_hash = $1
+		   OR lower(query_text) = lower($2)
+		   OR left(lower(query_text), length($2) + 2) = lower($2) || ' ['
+	`
+	result, err := db.conn.Exec(query, queryHash, queryText)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 func (db *DB) GetIPCache(ipAddress string) (*models.IPCache, error) {
 	var cache models.IPCache
 	query := `
@@ -230,6 +277,78 @@ func (db *DB) SetIPCache(ipAddress, responseData string, maxCacheSize int) error
 	return err
 }
 
+// DeleteIPCache removes an IP address's cache entry
+func (db *DB) DeleteIPCache(ipAddress string) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM ip_cache WHERE ip_address = $1`, ipAddress)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (db *DB) GetASNCache(asn int) (*models.ASNCache, error) {
+	var cache models.ASNCache
+	query := `
+		SELECT asn, response_data, created_at
+		FROM asn_cache
+		WHERE asn = $1
+	`
+	err := db.conn.QueryRow(query, asn).Scan(&cache.ASN, &cache.ResponseData, &cache.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &cache, nil
+}
+
+// SetASNCache stores an ASN lookup. There are only around 100,000 allocated AS numbers, so the table
+// isn't size-limited like the other caches.
+func (db *DB) SetASNCache(asn int, responseData string) error {
+	query := `
+		INSERT INTO asn_cache (asn, response_data)
+		VALUES ($1, $2)
+		ON CONFLICT (asn) DO UPDATE SET
+			response_data = EXCLUDED.response_data,
+			created_at = NOW()
+	`
+	_, err := db.conn.Exec(query, asn, responseData)
+	return err
+}
+
+// GetPlaceCache returns a cached place ID lookup younger than maxAge
+func (db *DB) GetPlaceCache(placeID, language string, maxAge time.Duration) (*models.PlaceCache, error) {
+	var cache models.PlaceCache
+	query := `
+		SELECT place_id, language, response_data, created_at
+		FROM place_cache
+		WHERE place_id = $1 AND language = $2 AND created_at > NOW() - make_interval(secs => $3)
+	`
+	err := db.conn.QueryRow(query, placeID, language, maxAge.Seconds()).Scan(
+		&cache.PlaceID, &cache.Language, &cache.ResponseData, &cache.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &cache, nil
+}
+
+// SetPlaceCache stores a place ID lookup and removes entries older than maxAge. Place details go stale
+// rather than being pushed out by newer queries, so this table expires by age instead of FIFO eviction.
+func (db *DB) SetPlaceCache(placeID, language, responseData string, maxAge time.Duration) error {
+	insertQuery := `
+		INSERT INTO place_cache (place_id, language, response_data)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (place_id, language) DO UPDATE SET
+			response_data = EXCLUDED.response_data,
+			created_at = NOW()
+	`
+	if _, err := db.conn.Exec(insertQuery, placeID, language, responseData); err != nil {
+		return err
+	}
+
+	_, err := db.conn.Exec(`DELETE FROM place_cache WHERE created_at <= NOW() - make_interval(secs => $1)`, maxAge.Seconds())
+	return err
+}
+
 func (db *DB) GetReverseGeocodeCache(queryHash string) (*models.ReverseGeocodeCache, error) {
 	var cache models.ReverseGeocodeCache
 	query := `
@@ -359,6 +478,18 @@ func (db *DB) UpdateCostTracking(date time.Time, geocodeRequests, geocodeCacheHi
 	return err
 }
 
+// UpdateOfflineCostTracking counts requests answered from offline data, which add nothing to the estimated cost
+func (db *DB) UpdateOfflineCostTracking(date time.Time, offlineRequests int) error {
+	query := `
+		INSERT INTO cost_tracking (date, offline_requests)
+		VALUES ($1, $2)
+		ON CONFLICT (date) DO UPDATE SET
+			offline_requests = cost_tracking.offline_requests + EXCLUDED.offline_requests
+	`
+	_, err := db.conn.Exec(query, date, offlineRequests)
+	return err
+}
+
 // Activity logging
 func (db *DB) LogActivity(apiKeyName, endpoint, queryText string, resultCount, responseTimeMs int, apiSource string, cacheHit bool, ipAddress, userAgent string) error {
 	query := `
@@ -409,6 +540,40 @@ func (db *DB) GetRecentActivity() ([]models.ActivityLog, error) {
 	return activities, rows.Err()
 }
 
+// DeleteActivityBefore removes activity entries older than the retention period
+func (db *DB) DeleteActivityBefore(cutoff time.Time) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM activity_log WHERE timestamp < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteActivityMatching removes activity entries whose client IP or query text is exactly one of the
+// values, ignoring case and surrounding space in query texts. Query texts made of several values, such
+// as "address / ip" for consistency checks, also match on any one of them. Erasure can't be undone, so
+// values never match as substrings: erasing 1.2.3.4 must not take 11.2.3.45 with it.
+func (db *DB) DeleteActivityMatching(values []string) (int64, error) {
+	queryTexts := make([]string, len(values))
+	for i, value := range values {
+		queryTexts[i] = strings.ToLower(strings.TrimSpace(value))
+	}
+
+	query := `
+		DELETE FROM activity_log
+		WHERE ip_address = ANY($1)
+		   OR lower(btrim(query_text)) = ANY($2)
+		   OR EXISTS (
+		       SELECT 1 FROM unnest(string_to_array(lower(query_text), ' / ')) AS part
+		       WHERE btrim(part) = ANY($2))
+	`
+	result, err := db.conn.Exec(query, pq.Array(values), pq.Array(queryTexts))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // GetAPIKeyUsageSummary returns usage analytics for all API keys with pagination
 func (db *DB) GetAPIKeyUsageSummary(page, pageSize int) (*models.UsageSummaryResponse, error) {
 	offset := (page - 1) * pageSize
@@ -425,7 +590,8 @@ func (db *DB) GetAPIKeyUsageSummary(page, pageSize int) (*models.UsageSummaryRes
 	query := `
 		SELECT 
 			ak.id, ak.key_hash, ak.name, ak.owner, ak.app_name, ak.environment, 
-			ak.is_active, ak.rate_limit_per_second, ak.created_at, ak.last_used_at, ak.request_count,
+			ak.is_active, ak.rate_limit_per_second, ak.created_at, ak.last_used_at, ak.request_count, ak.require_header_auth, ak.allow_cache_control,
+			ak.privacy_coordinate_decimals, ak.privacy_jitter_meters, ak.privacy_suppress_street, ak.privacy_city_level,
 			COALESCE(SUM(CASE WHEN ul.endpoint = 'v1/geocode' THEN 1 ELSE 0 END), 0) as geocode_requests,
 			COALESCE(SUM(CASE WHEN ul.endpoint = 'v1/geoip' THEN 1 ELSE 0 END), 0) as geoip_requests,
 			COALESCE(SUM(CASE WHEN ul.cache_hit = true THEN 1 ELSE 0 END), 0) as cache_hits,
@@ -438,7 +604,8 @@ func (db *DB) GetAPIKeyUsageSummary(page, pageSize int) (*models.UsageSummaryRes
 		LEFT JOIN usage_logs ul ON ak.id = ul.api_key_id
 		WHERE ak.is_active = true
 		GROUP BY ak.id, ak.key_hash, ak.name, ak.owner, ak.app_name, ak.environment, 
-				 ak.is_active, ak.rate_limit_per_second, ak.created_at, ak.last_used_at, ak.request_count
+				 ak.is_active, ak.rate_limit_per_second, ak.created_at, ak.last_used_at, ak.request_count, ak.require_header_auth, ak.allow_cache_control,
+				 ak.privacy_coordinate_decimals, ak.privacy_jitter_meters, ak.privacy_suppress_street, ak.privacy_city_level
 		ORDER BY ak.last_used_at DESC NULLS LAST, total_requests DESC
 		LIMIT $1 OFFSET $2
 	`
@@ -459,7 +626,8 @@ func (db *DB) GetAPIKeyUsageSummary(page, pageSize int) (*models.UsageSummaryRes
 			&summary.APIKey.ID, &summary.APIKey.KeyHash, &summary.APIKey.Name,
 			&summary.APIKey.Owner, &summary.APIKey.AppName, &summary.APIKey.Environment,
 			&summary.APIKey.IsActive, &summary.APIKey.RateLimitPerSecond,
-			&summary.APIKey.CreatedAt, &summary.APIKey.LastUsedAt, &summary.APIKey.RequestCount,
+			&summary.APIKey.CreatedAt, &summary.APIKey.LastUsedAt, &summary.APIKey.RequestCount, &summary.APIKey.RequireHeaderAuth, &summary.APIKey.AllowCacheControl,
+			&summary.APIKey.Privacy.CoordinateDecimals, &summary.APIKey.Privacy.JitterMeters, &summary.APIKey.Privacy.SuppressStreet, &summary.APIKey.Privacy.CityLevel,
 			&geocodeRequests, &geoipRequests, &cacheHits, &totalRequests, &estimatedCost,
 		)
 		if err != nil {
@@ -547,6 +715,478 @@ func (db *DB) getAPIKeyDailyUsage(apiKeyID string) ([]models.DailyUsageStats, er
 	return dailyStats, nil
 }
 
+// Gazetteer operations
+
+// FindGazetteerPlaces returns populated places known by the given name, most populous first. An empty
+// countryCode searches every country.
+func (db *DB) FindGazetteerPlaces(name, countryCode string, limit int) ([]models.GazetteerPlace, error) {
+	query := `
+		SELECT p.geoname_id, p.name, p.lat, p.lng, COALESCE(p.feature_code, ''), p.country_code,
+		       COALESCE(p.admin1_code, ''), COALESCE(a.name, ''), COALESCE(p.population, 0), COALESCE(p.timezone, '')
+		FROM gazetteer_place_names n
+		JOIN gazetteer_places p ON p.geoname_id = n.geoname_id
+		LEFT JOIN gazetteer_admin1 a ON a.code = p.country_code || '.' || p.admin1_code
+		WHERE n.name_key = LOWER($1) AND ($2 = '' OR p.country_code = UPPER($2))
+		ORDER BY p.population DESC
+		LIMIT $3
+	`
+
+	rows, err := db.conn.Query(query, name, countryCode, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var places []models.GazetteerPlace
+	for rows.Next() {
+		var place models.GazetteerPlace
+		err := rows.Scan(
+			&place.GeonameID,
+			&place.Name,
+			&place.Lat,
+			&place.Lng,
+			&place.FeatureCode,
+			&place.CountryCode,
+			&place.Admin1Code,
+			&place.Admin1Name,
+			&place.Population,
+			&place.Timezone,
+		)
+		if err != nil {
+			return nil, err
+		}
+		places = append(places, place)
+	}
+
+	return places, rows.Err()
+}
+
+// FindGazetteerPostalCodes returns every place sharing a postal code in a country
+func (db *DB) FindGazetteerPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error) {
+	query := `
+		SELECT country_code, postal_code, COALESCE(place_name, ''), COALESCE(admin1_name, ''),
+		       COALESCE(admin1_code, ''), COALESCE(admin2_name, ''), lat, lng, COALESCE(accuracy, 0)
+		FROM gazetteer_postal_codes
+		WHERE country_code = UPPER($1) AND postal_code = UPPER($2)
+		ORDER BY id
+	`
+
+	rows, err := db.conn.Query(query, countryCode, postalCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []models.GazetteerPostalCode
+	for rows.Next() {
+		var code models.GazetteerPostalCode
+		err := rows.Scan(
+			&code.CountryCode,
+			&code.PostalCode,
+			&code.PlaceName,
+			&code.Admin1Name,
+			&code.Admin1Code,
+			&code.Admin2Name,
+			&code.Lat,
+			&code.Lng,
+			&code.Accuracy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+
+	return codes, rows.Err()
+}
+
+// FindGazetteerLocalizedName returns a place's name in the first of the given languages it has one in, or
+// an empty string. The place is identified by geonameid, or when that is 0 by its most populous namesake
+// in the country.
+func (db *DB) FindGazetteerLocalizedName(geonameID int64, name, countryCode string, languages []string) (string, error) {
+	query := `
+		SELECT name
+		FROM gazetteer_localized_names
+		WHERE language = ANY($4::text[])
+		  AND geoname_id = COALESCE(NULLIF($1::bigint, 0), (
+		      SELECT p.geoname_id
+		      FROM gazetteer_place_names n
+		      JOIN gazetteer_places p ON p.geoname_id = n.geoname_id
+		      WHERE n.name_key = LOWER($2) AND p.country_code = UPPER($3)
+		      ORDER BY p.population DESC
+		      LIMIT 1))
+		ORDER BY array_position($4::text[], language::text)
+		LIMIT 1
+	`
+
+	var localized string
+	err := db.conn.QueryRow(query, geonameID, name, countryCode, pq.Array(languages)).Scan(&localized)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return localized, err
+}
+
+// Geofence operations
+
+const geofenceColumns = `id, api_key_id, collection, name, geometry, radius_m, COALESCE(properties, 'null'::jsonb), created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanGeofence(row rowScanner) (*models.Geofence, error) {
+	var fence models.Geofence
+	var geometry, properties []byte
+	err := row.Scan(
+		&fence.ID, &fence.APIKeyID, &fence.Collection, &fence.Name, &geometry, &fence.RadiusM, &properties,
+		&fence.CreatedAt, &fence.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	fence.Geometry = json.RawMessage(geometry)
+	fence.Properties = json.RawMessage(properties)
+	return &fence, nil
+}
+
+// nullableJSON stores absent GeoJSON properties as NULL
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+// CreateGeofence stores a validated geofence for an API key
+func (db *DB) CreateGeofence(apiKeyID, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	query := `
+		INSERT INTO geofences (api_key_id, collection, name, geometry, radius_m, properties)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + geofenceColumns
+	return scanGeofence(db.conn.QueryRow(query, apiKeyID, collection, name, string(geometry), radiusM, nullableJSON(properties)))
+}
+
+// GetGeofences returns an API key's geofences, oldest first. An empty collection returns every
+// collection, and an empty apiKeyID every key's geofences.
+func (db *DB) GetGeofences(apiKeyID, collection string) ([]models.Geofence, error) {
+	query := `
+		SELECT ` + geofenceColumns + `
+		FROM geofences
+		WHERE ($1 = '' OR api_key_id::text = $1) AND ($2 = '' OR collection = $2)
+		ORDER BY created_at, id
+	`
+
+	rows, err := db.conn.Query(query, apiKeyID, collection)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fences := []models.Geofence{}
+	for rows.Next() {
+		fence, err := scanGeofence(rows)
+		if err != nil {
+			return nil, err
+		}
+		fences = append(fences, *fence)
+	}
+
+	return fences, rows.Err()
+}
+
+// GetGeofence returns one geofence, or sql.ErrNoRows
+func (db *DB) GetGeofence(id string) (*models.Geofence, error) {
+	query := `SELECT ` + geofenceColumns + ` FROM geofences WHERE id = $1`
+	return scanGeofence(db.conn.QueryRow(query, id))
+}
+
+// UpdateGeofence replaces a geofence's shape, name, collection and properties
+func (db *DB) UpdateGeofence(id, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	query := `
+		UPDATE geofences
+		SET collection = $2, name = $3, geometry = $4, radius_m = $5, properties = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + geofenceColumns
+	return scanGeofence(db.conn.QueryRow(query, id, collection, name, string(geometry), radiusM, nullableJSON(properties)))
+}
+
+// DeleteGeofence removes a geofence, returning sql.ErrNoRows if there was none
+func (db *DB) DeleteGeofence(id string) error {
+	result, err := db.conn.Exec(`DELETE FROM geofences WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// Geocode override operations
+
+const geocodeOverrideColumns = `id, address_pattern, place_id, lat, lng, formatted_address, admin_hierarchy, note, created_at, updated_at`
+
+func scanGeocodeOverride(row rowScanner) (*models.GeocodeOverride, error) {
+	var override models.GeocodeOverride
+	var hierarchy []byte
+	err := row.Scan(
+		&override.ID, &override.AddressPattern, &override.PlaceID, &override.Lat, &override.Lng,
+		&override.FormattedAddress, &hierarchy, &override.Note, &override.CreatedAt, &override.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(hierarchy, &override.AdminHierarchy); err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// CreateGeocodeOverride stores a validated override
+func (db *DB) CreateGeocodeOverride(override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	hierarchy, err := json.Marshal(override.AdminHierarchy)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		INSERT INTO geocode_overrides (address_pattern, place_id, lat, lng, formatted_address, admin_hierarchy, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + geocodeOverrideColumns
+	return scanGeocodeOverride(db.conn.QueryRow(query, override.AddressPattern, override.PlaceID, override.Lat, override.Lng,
+		override.FormattedAddress, string(hierarchy), override.Note))
+}
+
+// GetGeocodeOverrides returns every override, oldest first
+func (db *DB) GetGeocodeOverrides() ([]models.GeocodeOverride, error) {
+	rows, err := db.conn.Query(`SELECT ` + geocodeOverrideColumns + ` FROM geocode_overrides ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := []models.GeocodeOverride{}
+	for rows.Next() {
+		override, err := scanGeocodeOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, *override)
+	}
+
+	return overrides, rows.Err()
+}
+
+// GetGeocodeOverride returns one override, or sql.ErrNoRows
+func (db *DB) GetGeocodeOverride(id string) (*models.GeocodeOverride, error) {
+	query := `SELECT ` + geocodeOverrideColumns + ` FROM geocode_overrides WHERE id = $1`
+	return scanGeocodeOverride(db.conn.QueryRow(query, id))
+}
+
+// UpdateGeocodeOverride replaces an override's pattern, place ID and pinned result
+func (db *DB) UpdateGeocodeOverride(id string, override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	hierarchy, err := json.Marshal(override.AdminHierarchy)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		UPDATE geocode_overrides
+		SET address_pattern = $2, place_id = $3, lat = $4, lng = $5, formatted_address = $6, admin_hierarchy = $7,
+		    note = $8, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + geocodeOverrideColumns
+	return scanGeocodeOverride(db.conn.QueryRow(query, id, override.AddressPattern, override.PlaceID, override.Lat, override.Lng,
+		override.FormattedAddress, string(hierarchy), override.Note))
+}
+
+// DeleteGeocodeOverride removes an override, returning sql.ErrNoRows if there was none
+func (db *DB) DeleteGeocodeOverride(id string) error {
+	result, err := db.conn.Exec(`DELETE FROM geocode_overrides WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// Place collection operations
+
+const placeCollectionColumns = `c.id, c.api_key_id, c.name, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM collection_places p WHERE p.collection_id = c.id)`
+
+func scanPlaceCollection(row rowScanner) (*models.PlaceCollection, error) {
+	var collection models.PlaceCollection
+	err := row.Scan(&collection.ID, &collection.APIKeyID, &collection.Name, &collection.CreatedAt, &collection.UpdatedAt, &collection.PlaceCount)
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// CreatePlaceCollection creates an empty place collection for an API key
+func (db *DB) CreatePlaceCollection(apiKeyID, name string) (*models.PlaceCollection, error) {
+	query := `
+		INSERT INTO place_collections (api_key_id, name)
+		VALUES ($1, $2)
+		RETURNING id, api_key_id, name, created_at, updated_at, 0`
+	return scanPlaceCollection(db.conn.QueryRow(query, apiKeyID, name))
+}
+
+// GetPlaceCollections returns an API key's place collections, oldest first
+func (db *DB) GetPlaceCollections(apiKeyID string) ([]models.PlaceCollection, error) {
+	query := `
+		SELECT ` + placeCollectionColumns + `
+		FROM place_collections c
+		WHERE c.api_key_id = $1
+		ORDER BY c.created_at, c.id
+	`
+
+	rows, err := db.conn.Query(query, apiKeyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []models.PlaceCollection{}
+	for rows.Next() {
+		collection, err := scanPlaceCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, *collection)
+	}
+
+	return collections, rows.Err()
+}
+
+// GetPlaceCollection returns one place collection, or sql.ErrNoRows
+func (db *DB) GetPlaceCollection(id string) (*models.PlaceCollection, error) {
+	query := `SELECT ` + placeCollectionColumns + ` FROM place_collections c WHERE c.id = $1`
+	return scanPlaceCollection(db.conn.QueryRow(query, id))
+}
+
+// DeletePlaceCollection removes a place collection and its places, returning sql.ErrNoRows if there was none
+func (db *DB) DeletePlaceCollection(id string) error {
+	result, err := db.conn.Exec(`DELETE FROM place_collections WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+const collectionPlaceColumns = `id, collection_id, name, address, formatted_address, lat, lng, COALESCE(properties, 'null'::jsonb), created_at`
+
+func scanCollectionPlace(row rowScanner) (*models.CollectionPlace, error) {
+	var place models.CollectionPlace
+	var properties []byte
+	err := row.Scan(
+		&place.ID, &place.CollectionID, &place.Name, &place.Address, &place.FormattedAddress, &place.Lat, &place.Lng,
+		&properties, &place.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if string(properties) != "null" {
+		place.Properties = json.RawMessage(properties)
+	}
+	return &place, nil
+}
+
+func queryCollectionPlaces(rows *sql.Rows, err error) ([]models.CollectionPlace, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	places := []models.CollectionPlace{}
+	for rows.Next() {
+		place, err := scanCollectionPlace(rows)
+		if err != nil {
+			return nil, err
+		}
+		places = append(places, *place)
+	}
+
+	return places, rows.Err()
+}
+
+// AddCollectionPlaces stores places in a collection in one transaction, so an upload is added
+// completely or not at all
+func (db *DB) AddCollectionPlaces(collectionID string, places []models.CollectionPlace) ([]models.CollectionPlace, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO collection_places (collection_id, name, address, formatted_address, lat, lng, location, properties)
+		VALUES ($1, $2, $3, $4, $5, $6, point($6, $5), $7)
+		RETURNING ` + collectionPlaceColumns)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	added := make([]models.CollectionPlace, 0, len(places))
+	for _, place := range places {
+		stored, err := scanCollectionPlace(stmt.QueryRow(
+			collectionID, place.Name, place.Address, place.FormattedAddress, place.Lat, place.Lng, nullableJSON(place.Properties),
+		))
+		if err != nil {
+			return nil, err
+		}
+		added = append(added, *stored)
+	}
+
+	if _, err := tx.Exec(`UPDATE place_collections SET updated_at = NOW() WHERE id = $1`, collectionID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// GetCollectionPlaces returns a collection's places, oldest first
+func (db *DB) GetCollectionPlaces(collectionID string) ([]models.CollectionPlace, error) {
+	query := `
+		SELECT ` + collectionPlaceColumns + `
+		FROM collection_places
+		WHERE collection_id = $1
+		ORDER BY created_at, id
+	`
+	return queryCollectionPlaces(db.conn.Query(query, collectionID))
+}
+
+// DeleteCollectionPlace removes a place from a collection, returning sql.ErrNoRows if it wasn't there
+func (db *DB) DeleteCollectionPlace(collectionID, placeID string) error {
+	result, err := db.conn.Exec(`DELETE FROM collection_places WHERE collection_id = $1 AND id = $2`, collectionID, placeID)
+	if err != nil {
+		return err
+	}
+	if deleted, err := result.RowsAffected(); err == nil && deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return err
+}
+
+// FindCollectionPlacesInBox returns a collection's places inside a bounding box, through the
+// GiST index on location. Callers filter by exact distance.
+func (db *DB) FindCollectionPlacesInBox(collectionID string, minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error) {
+	query := `
+		SELECT ` + collectionPlaceColumns + `
+		FROM collection_places
+		WHERE location <@ box(point($2, $3), point($4, $5)) AND collection_id = $1
+	`
+	return queryCollectionPlaces(db.conn.Query(query, collectionID, minLng, minLat, maxLng, maxLat))
+}
+
 // Helper function to hash API keys
 func HashAPIKey(key string) string {
 	hash := sha256.Sum256([]byte(key))
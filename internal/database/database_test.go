@@ -1,6 +1,7 @@
 package database
 
 import (
+	"os"
 	"testing"
 	"time"
 
@@ -226,3 +227,80 @@ func TestMockDatabase_APIKeyOperations(t *testing.T) {
 		t.Error("API key should be deactivated")
 	}
 }
+
+// TestDeleteActivityMatching runs against a real Postgres when TEST_DATABASE_URL is set. It works
+// on a temporary activity_log that shadows the real table for its session.
+func TestDeleteActivityMatching(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := New(databaseURL)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer db.Close()
+	// Temporary tables belong to one connection
+	db.conn.SetMaxOpenConns(1)
+
+	_, err = db.conn.Exec(`
+		CREATE TEMPORARY TABLE activity_log (
+			id SERIAL PRIMARY KEY,
+			timestamp TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+			api_key_name VARCHAR(255) NOT NULL,
+			endpoint VARCHAR(50) NOT NULL,
+			query_text TEXT NOT NULL,
+			result_count INTEGER NOT NULL,
+			response_time_ms INTEGER NOT NULL,
+			api_source VARCHAR(50) NOT NULL,
+			cache_hit BOOLEAN NOT NULL DEFAULT FALSE,
+			ip_address TEXT,
+			user_agent TEXT
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create activity_log: %v", err)
+	}
+
+	entries := []struct {
+		queryText, ipAddress string
+		erased               bool
+	}{
+		{"1.2.3.4", "10.0.0.1", true},
+		{"11.2.3.45", "10.0.0.1", false},
+		{"21.2.3.4", "10.0.0.1", false},
+		{"Paris, France", "1.2.3.4", true},
+		{"Paris, France", "11.2.3.45", false},
+		{"  MAIN ST ", "10.0.0.1", true},
+		{"1 Main St", "10.0.0.1", false},
+		// Consistency checks log "address / ip", redacted value by value in hashed mode
+		{"Paris, France / 1.2.3.4", "10.0.0.1", true},
+		{"Paris, France / 11.2.3.45", "10.0.0.1", false},
+		{"hash:0123456789abcdef / hash:fedcba9876543210", "10.0.0.1", true},
+		{"hash:0123456789abcdef / hash:fedcba98765432100", "10.0.0.1", false},
+	}
+	for _, e := range entries {
+		if err := db.LogActivity("test-key", "v1/geocode", e.queryText, 1, 5, "google", false, e.ipAddress, "test"); err != nil {
+			t.Fatalf("Failed to log activity: %v", err)
+		}
+	}
+
+	deleted, err := db.DeleteActivityMatching([]string{"1.2.3.4", "Main St", "hash:fedcba9876543210"})
+	if err != nil {
+		t.Fatalf("Failed to delete activity: %v", err)
+	}
+	if deleted != 5 {
+		t.Errorf("Expected 5 entries deleted, got %d", deleted)
+	}
+
+	for _, e := range entries {
+		var count int
+		if err := db.conn.QueryRow(`SELECT COUNT(*) FROM activity_log WHERE query_text = $1 AND ip_address = $2`, e.queryText, e.ipAddress).Scan(&count); err != nil {
+			t.Fatalf("Failed to count activity: %v", err)
+		}
+		if survived := count > 0; survived == e.erased {
+			t.Errorf("Entry %q from %s: expected erased=%v", e.queryText, e.ipAddress, e.erased)
+		}
+	}
+}
@@ -17,6 +17,9 @@ type DatabaseInterface interface {
 	UpdateAPIKeyUsage(keyID string) error
 	GetAllAPIKeys() ([]models.APIKey, error)
 	UpdateAPIKeyRateLimit(keyID string, rateLimitPerSecond int) error
+	UpdateAPIKeyRequireHeaderAuth(keyID string, requireHeaderAuth bool) error
+	UpdateAPIKeyCacheControl(keyID string, allowCacheControl bool) error
+	UpdateAPIKeyPrivacyPolicy(keyID string, policy models.PrivacyPolicy) error
 	DeactivateAPIKey(keyID string) error
 
 	// Cache operations
@@ -24,20 +27,58 @@ type DatabaseInterface interface {
 	SetAddressCache(queryHash, queryText, responseData string, maxCacheSize int) error
 	GetIPCache(ipAddress string) (*models.IPCache, error)
 	SetIPCache(ipAddress, responseData string, maxCacheSize int) error
+	GetASNCache(asn int) (*models.ASNCache, error)
+	SetASNCache(asn int, responseData string) error
+	GetPlaceCache(placeID, language string, maxAge time.Duration) (*models.PlaceCache, error)
+	SetPlaceCache(placeID, language, responseData string, maxAge time.Duration) error
 	GetReverseGeocodeCache(queryHash string) (*models.ReverseGeocodeCache, error)
 	SetReverseGeocodeCache(queryHash, queryText, responseData string, maxCacheSize int) error
+	DeleteAddressCache(queryHash, queryText string) (int64, error)
+	DeleteIPCache(ipAddress string) (int64, error)
 
 	// Usage tracking
 	LogUsage(apiKeyID, endpoint string, cacheHit bool, responseTimeMs int) error
 	GetStats() (*models.Stats, error)
 	UpdateCostTracking(date time.Time, geocodeRequests, geocodeCacheHits, geoipRequests, geoipCacheHits int, estimatedCost float64) error
+	UpdateOfflineCostTracking(date time.Time, offlineRequests int) error
 
 	// Activity logging
 	LogActivity(apiKeyName, endpoint, queryText string, resultCount, responseTimeMs int, apiSource string, cacheHit bool, ipAddress, userAgent string) error
 	GetRecentActivity() ([]models.ActivityLog, error)
+	DeleteActivityBefore(cutoff time.Time) (int64, error)
+	DeleteActivityMatching(values []string) (int64, error)
 
 	// Usage summary
 	GetAPIKeyUsageSummary(page, pageSize int) (*models.UsageSummaryResponse, error)
+
+	// Gazetteer lookups
+	FindGazetteerPlaces(name, countryCode string, limit int) ([]models.GazetteerPlace, error)
+	FindGazetteerPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error)
+	FindGazetteerLocalizedName(geonameID int64, name, countryCode string, languages []string) (string, error)
+
+	// Geofences
+	CreateGeofence(apiKeyID, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error)
+	GetGeofences(apiKeyID, collection string) ([]models.Geofence, error)
+	GetGeofence(id string) (*models.Geofence, error)
+	UpdateGeofence(id, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error)
+	DeleteGeofence(id string) error
+
+	// Geocode overrides
+	CreateGeocodeOverride(override models.GeocodeOverride) (*models.GeocodeOverride, error)
+	GetGeocodeOverrides() ([]models.GeocodeOverride, error)
+	GetGeocodeOverride(id string) (*models.GeocodeOverride, error)
+	UpdateGeocodeOverride(id string, override models.GeocodeOverride) (*models.GeocodeOverride, error)
+	DeleteGeocodeOverride(id string) error
+
+	// Place collections
+	CreatePlaceCollection(apiKeyID, name string) (*models.PlaceCollection, error)
+	GetPlaceCollections(apiKeyID string) ([]models.PlaceCollection, error)
+	GetPlaceCollection(id string) (*models.PlaceCollection, error)
+	DeletePlaceCollection(id string) error
+	AddCollectionPlaces(collectionID string, places []models.CollectionPlace) ([]models.CollectionPlace, error)
+	GetCollectionPlaces(collectionID string) ([]models.CollectionPlace, error)
+	DeleteCollectionPlace(collectionID, placeID string) error
+	FindCollectionPlacesInBox(collectionID string, minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error)
 }
 
 // Ensure DB implements DatabaseInterface
@@ -0,0 +1,337 @@
+package gazetteer
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/geocoding"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected Query
+		ok       bool
+	}{
+		{"Paris", Query{City: "Paris"}, true},
+		{"Paris, France", Query{City: "Paris", Country: "FR"}, true},
+		{"Burlington, VT", Query{City: "Burlington", State: "VT", Country: "US"}, true},
+		{"Atlanta, GA", Query{City: "Atlanta", State: "GA", Country: "US"}, true},
+		{"New York, NY 10001", Query{City: "New York", State: "NY", PostalCode: "10001", Country: "US"}, true},
+		{"VT 05482", Query{State: "VT", PostalCode: "05482", Country: "US"}, true},
+		{"75001 Paris, France", Query{City: "Paris", PostalCode: "75001", Country: "FR"}, true},
+		{"sw1a 1aa, UK", Query{PostalCode: "SW1A 1AA", Country: "GB"}, true},
+		{"Munich, Bavaria, Germany", Query{City: "Munich", State: "Bavaria", Country: "DE"}, true},
+		{"1600 Amphitheatre Parkway, Mountain View, CA", Query{}, false},
+		{"1600 Amphitheatre Parkway Mountain View", Query{}, false},
+		{"Apt 4, 12 Main St, Springfield, IL, USA", Query{}, false},
+		{"France", Query{}, false},
+		{" , ", Query{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			q, ok := ParseQuery(tt.address)
+			if ok != tt.ok || q != tt.expected {
+				t.Errorf("ParseQuery(%q) = %+v, %v, expected %+v, %v", tt.address, q, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestQueryFromStructured(t *testing.T) {
+	q, ok := QueryFromStructured(models.StructuredAddress{City: "Paris", Country: "France"})
+	if !ok || q != (Query{City: "Paris", Country: "FR"}) {
+		t.Errorf("Expected Paris, FR, got %+v, %v", q, ok)
+	}
+
+	if _, ok := QueryFromStructured(models.StructuredAddress{AddressLine1: "8 Rue de Rivoli", City: "Paris"}); ok {
+		t.Error("Expected a street address to need Google")
+	}
+	if _, ok := QueryFromStructured(models.StructuredAddress{City: "Paris", Country: "Atlantis"}); ok {
+		t.Error("Expected an unknown country to need Google")
+	}
+	if _, ok := QueryFromStructured(models.StructuredAddress{Country: "France"}); ok {
+		t.Error("Expected a country on its own to need Google")
+	}
+}
+
+// fakeStore answers lookups from slices, filtering like the SQL queries do
+type fakeStore struct {
+	places         []models.GazetteerPlace
+	postalCodes    []models.GazetteerPostalCode
+	localizedNames map[int64]map[string]string // By geonameid and language
+}
+
+func (s *fakeStore) FindGazetteerPlaces(name, countryCode string, limit int) ([]models.GazetteerPlace, error) {
+	var result []models.GazetteerPlace
+	for _, place := range s.places {
+		if strings.EqualFold(place.Name, name) && (countryCode == "" || place.CountryCode == countryCode) && len(result) < limit {
+			result = append(result, place)
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStore) FindGazetteerPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error) {
+	var result []models.GazetteerPostalCode
+	for _, code := range s.postalCodes {
+		if code.CountryCode == countryCode && code.PostalCode == postalCode {
+			result = append(result, code)
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStore) FindGazetteerLocalizedName(geonameID int64, name, countryCode string, languages []string) (string, error) {
+	if geonameID == 0 {
+		places, _ := s.FindGazetteerPlaces(name, countryCode, 1)
+		if len(places) == 0 {
+			return "", nil
+		}
+		geonameID = places[0].GeonameID
+	}
+	for _, language := range languages {
+		if localized, ok := s.localizedNames[geonameID][language]; ok {
+			return localized, nil
+		}
+	}
+	return "", nil
+}
+
+func newTestResolver() *Resolver {
+	// Sorted by population, as the database returns them
+	return NewResolver(&fakeStore{
+		places: []models.GazetteerPlace{
+			{GeonameID: 2988507, Name: "Paris", Lat: 48.85341, Lng: 2.3488, CountryCode: "FR", Admin1Code: "11", Admin1Name: "Île-de-France", Population: 2138551},
+			{GeonameID: 4717560, Name: "Paris", Lat: 33.66094, Lng: -95.55551, CountryCode: "US", Admin1Code: "TX", Admin1Name: "Texas", Population: 24782},
+			{GeonameID: 4409896, Name: "Springfield", Lat: 37.21533, Lng: -93.29824, CountryCode: "US", Admin1Code: "MO", Admin1Name: "Missouri", Population: 169176},
+			{GeonameID: 4951788, Name: "Springfield", Lat: 42.10148, Lng: -72.58981, CountryCode: "US", Admin1Code: "MA", Admin1Name: "Massachusetts", Population: 154341},
+			{GeonameID: 4250542, Name: "Springfield", Lat: 39.80172, Lng: -89.64371, CountryCode: "US", Admin1Code: "IL", Admin1Name: "Illinois", Population: 116565},
+			{GeonameID: 2643743, Name: "London", Lat: 51.50853, Lng: -0.12574, CountryCode: "GB", Admin1Code: "ENG", Admin1Name: "England", Population: 8961989},
+		},
+		postalCodes: []models.GazetteerPostalCode{
+			{CountryCode: "US", PostalCode: "05482", PlaceName: "Shelburne", Admin1Name: "Vermont", Admin1Code: "VT", Admin2Name: "Chittenden", Lat: 44.3923, Lng: -73.2197},
+			{CountryCode: "GB", PostalCode: "SW1A", PlaceName: "London", Admin1Name: "England", Admin1Code: "ENG", Lat: 51.5, Lng: -0.14},
+			{CountryCode: "GB", PostalCode: "SW1A", PlaceName: "London", Admin1Name: "England", Admin1Code: "ENG", Lat: 51.502, Lng: -0.12},
+		},
+		localizedNames: map[int64]map[string]string{
+			2988507: {"it": "Parigi", "ru": "Париж"},
+			2643743: {"fr": "Londres", "pt": "Londres"},
+		},
+	})
+}
+
+func TestResolver_Places(t *testing.T) {
+	resolver := newTestResolver()
+
+	result, ok := resolver.Resolve(Query{City: "Paris"}, geocoding.GeocodeOptions{})
+	if !ok {
+		t.Fatal("Expected Paris, France to dominate Paris, Texas")
+	}
+	if result.CountryCode != "FR" || result.AdminHierarchy.StateISOCode != "FR-IDF" || result.Backend != "geonames_gazetteer" {
+		t.Errorf("Expected Paris, Île-de-France, got %+v", result)
+	}
+	if result.FormattedAddress != "Paris, Île-de-France, France" {
+		t.Errorf("Unexpected formatted address %q", result.FormattedAddress)
+	}
+
+	result, ok = resolver.Resolve(Query{City: "Paris", State: "TX", Country: "US"}, geocoding.GeocodeOptions{})
+	if !ok || result.AdminHierarchy.StateISOCode != "US-TX" || result.StateCode != "TX" {
+		t.Errorf("Expected Paris, Texas, got %+v, %v", result, ok)
+	}
+
+	if _, ok := resolver.Resolve(Query{City: "Springfield", Country: "US"}, geocoding.GeocodeOptions{}); ok {
+		t.Error("Expected no confident match for Springfield")
+	}
+	result, ok = resolver.Resolve(Query{City: "Springfield", State: "Illinois", Country: "US"}, geocoding.GeocodeOptions{})
+	if !ok || result.StateName != "Illinois" {
+		t.Errorf("Expected Springfield, Illinois, got %+v, %v", result, ok)
+	}
+
+	result, ok = resolver.Resolve(Query{City: "Paris"}, geocoding.GeocodeOptions{Components: map[string]string{"country": "us"}})
+	if !ok || result.CountryCode != "US" {
+		t.Errorf("Expected the country component to pick Paris, Texas, got %+v, %v", result, ok)
+	}
+	if _, ok := resolver.Resolve(Query{City: "Paris"}, geocoding.GeocodeOptions{Components: map[string]string{"route": "Rue de Rivoli"}}); ok {
+		t.Error("Expected a route component to need Google")
+	}
+
+	result, ok = resolver.Resolve(Query{City: "Paris"}, geocoding.GeocodeOptions{Language: "de"})
+	if !ok || result.CountryName != "Frankreich" || result.AdminHierarchy.City != "Paris" {
+		t.Errorf("Expected a German country name and the city unchanged without a translation, got %+v, %v", result, ok)
+	}
+
+	result, ok = resolver.Resolve(Query{City: "Paris"}, geocoding.GeocodeOptions{Language: "it"})
+	if !ok || result.AdminHierarchy.City != "Parigi" || !strings.HasPrefix(result.FormattedAddress, "Parigi, ") {
+		t.Errorf("Expected an Italian city name, got %+v, %v", result, ok)
+	}
+	result, ok = resolver.Resolve(Query{City: "Paris", State: "TX", Country: "US"}, geocoding.GeocodeOptions{Language: "it"})
+	if !ok || result.AdminHierarchy.City != "Paris" {
+		t.Errorf("Expected Paris, Texas to keep its name, got %+v, %v", result, ok)
+	}
+}
+
+func TestResolver_LocalizeCity(t *testing.T) {
+	resolver := newTestResolver()
+
+	tests := []struct {
+		name      string
+		geonameID int64
+		city      string
+		country   string
+		language  string
+		expected  string
+	}{
+		{"by geonameid", 2988507, "Paris", "FR", "ru", "Париж"},
+		{"by name and country", 0, "London", "GB", "fr", "Londres"},
+		{"regional language falls back", 0, "London", "GB", "pt-BR", "Londres"},
+		{"no translation", 2988507, "Paris", "FR", "fr", "Paris"},
+		{"other country's namesake", 0, "Paris", "US", "it", "Paris"},
+		{"no language", 2988507, "Paris", "FR", "", "Paris"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolver.LocalizeCity(tt.geonameID, tt.city, tt.country, tt.language); got != tt.expected {
+				t.Errorf("LocalizeCity() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolver_PostalCodes(t *testing.T) {
+	resolver := newTestResolver()
+
+	result, ok := resolver.Resolve(Query{State: "VT", PostalCode: "05482", Country: "US"}, geocoding.GeocodeOptions{})
+	if !ok {
+		t.Fatal("Expected a match for 05482")
+	}
+	if result.AdminHierarchy.City != "Shelburne" || result.AdminHierarchy.County != "Chittenden" || result.FormattedAddress != "Shelburne, Vermont 05482, United States" {
+		t.Errorf("Expected Shelburne, Vermont, got %+v", result)
+	}
+
+	if _, ok := resolver.Resolve(Query{City: "Burlington", PostalCode: "05482", Country: "US"}, geocoding.GeocodeOptions{}); ok {
+		t.Error("Expected a city that doesn't match the postal code to need Google")
+	}
+	if _, ok := resolver.Resolve(Query{PostalCode: "05482"}, geocoding.GeocodeOptions{}); ok {
+		t.Error("Expected a postal code without a country to need Google")
+	}
+
+	// Only the outward code is in the data, so the result is the centroid of its rows
+	result, ok = resolver.Resolve(Query{PostalCode: "SW1A 1AA", Country: "GB"}, geocoding.GeocodeOptions{})
+	if !ok || math.Abs(result.Lat-51.501) > 1e-9 || math.Abs(result.Lng+0.13) > 1e-9 || result.AdminHierarchy.PostalCode != "SW1A" {
+		t.Errorf("Expected the SW1A centroid, got %+v, %v", result, ok)
+	}
+}
+
+func TestReadPlaces(t *testing.T) {
+	dump := "2988507\tParis\tParis\tLutetia,Paname,Parigi\t48.85341\t2.3488\tP\tPPLC\tFR\t\t11\t75\t751\t75056\t2138551\t\t42\tEurope/Paris\t2023-02-28\n" +
+		"2988500\tParis Basin\tParis Basin\t\t48.5\t2.5\tT\tDEPR\tFR\t\t\t\t\t\t0\t\t100\tEurope/Paris\t2012-01-17\n" +
+		"3024635\tVillage\tVillage\t\t45.0\t1.0\tP\tPPL\tFR\t\t75\t\t\t\t12\t\t300\tEurope/Paris\t2012-01-17\n"
+
+	var places []placeRecord
+	err := readPlaces(strings.NewReader(dump), 100, func(p placeRecord) error {
+		places = append(places, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readPlaces() error = %v", err)
+	}
+	if len(places) != 1 {
+		t.Fatalf("Expected only the populated place above the minimum population, got %+v", places)
+	}
+	if p := places[0]; p.GeonameID != 2988507 || p.Admin1Code != "11" || p.AlternateNames != "Lutetia,Paname,Parigi" || p.Timezone != "Europe/Paris" {
+		t.Errorf("Unexpected place %+v", p)
+	}
+
+	if err := readPlaces(strings.NewReader("1\tBroken\n"), 0, func(placeRecord) error { return nil }); err == nil {
+		t.Error("Expected an error for a truncated line")
+	}
+}
+
+func TestReadPostalCodes(t *testing.T) {
+	dump := "US\t05482\tShelburne\tVermont\tVT\tChittenden\t007\t\t\t44.3923\t-73.2197\t4\n" +
+		"GB\tsw1a\tLondon\tEngland\tENG\t\t\t\t\t51.5\t-0.14\n"
+
+	var codes []models.GazetteerPostalCode
+	err := readPostalCodes(strings.NewReader(dump), func(p models.GazetteerPostalCode) error {
+		codes = append(codes, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readPostalCodes() error = %v", err)
+	}
+	if len(codes) != 2 || codes[0].Admin2Name != "Chittenden" || codes[0].Accuracy != 4 || codes[1].PostalCode != "SW1A" {
+		t.Errorf("Unexpected postal codes %+v", codes)
+	}
+}
+
+func TestReadAdmin1(t *testing.T) {
+	var records []admin1Record
+	err := readAdmin1(strings.NewReader("US.VT\tVermont\tVermont\t5242283\nFR.11\tÎle-de-France\tIle-de-France\t3012874\n"), func(a admin1Record) error {
+		records = append(records, a)
+		return nil
+	})
+	if err != nil || len(records) != 2 || records[1] != (admin1Record{Code: "FR.11", Name: "Île-de-France"}) {
+		t.Errorf("Unexpected admin1 records %+v, %v", records, err)
+	}
+}
+
+func TestReadAlternateNames(t *testing.T) {
+	dump := "1\t2988507\tit\tParigi\t1\t\t\t\t\t\n" +
+		"2\t2988507\tpost\t75000\t\t\t\t\t\t\n" +
+		"3\t2988507\tlink\thttps://en.wikipedia.org/wiki/Paris\t\t\t\t\t\t\n" +
+		"4\t2988507\tfr\tLutèce\t\t\t\t1\t\t\n" +
+		"5\t2988507\ten\tCity of Light\t\t\t1\t\t\t\n" +
+		"6\t2988507\tzh-TW\t巴黎\t\t\t\t\t\t\n" +
+		"7\t2988507\t\tParis\t\t\t\t\t\t\n"
+
+	var names []alternateNameRecord
+	err := readAlternateNames(strings.NewReader(dump), func(a alternateNameRecord) error {
+		names = append(names, a)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readAlternateNames() error = %v", err)
+	}
+	expected := []alternateNameRecord{
+		{GeonameID: 2988507, Language: "it", Name: "Parigi", Preferred: true},
+		{GeonameID: 2988507, Language: "zh-tw", Name: "巴黎"},
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected only current names in real languages, got %+v", names)
+	}
+
+	short := alternateNameRecord{Name: "NYC", Short: true}
+	full := alternateNameRecord{Name: "New York City"}
+	if !betterAlternateName(full, short) || betterAlternateName(short, full) || !betterAlternateName(alternateNameRecord{Preferred: true, Short: true}, full) {
+		t.Error("Expected preferred names, then full names, to win")
+	}
+}
+
+func TestLookupPostalCode(t *testing.T) {
+	resolver := newTestResolver()
+
+	result, err := resolver.LookupPostalCode("GB", "SW1A 1AA", "fr")
+	if err != nil {
+		t.Fatalf("LookupPostalCode() error = %v", err)
+	}
+	if result.PostalCode != "SW1A 1AA" || len(result.Places) != 2 || result.AdminHierarchy.Country != "Royaume-Uni" {
+		t.Errorf("Expected both SW1A places with French names, got %+v", result)
+	}
+	if result.AdminHierarchy.City != "Londres" || result.Places[0].Name != "Londres" {
+		t.Errorf("Expected the city named in French, got %q and %q", result.AdminHierarchy.City, result.Places[0].Name)
+	}
+	if result.Places[0].StateISOCode != "GB-ENG" {
+		t.Errorf("Expected each place to carry its state code, got %+v", result.Places[0])
+	}
+
+	if _, err := resolver.LookupPostalCode("US", "90210", ""); !errors.Is(err, ErrNoResults) {
+		t.Errorf("Expected ErrNoResults for a missing code, got %v", err)
+	}
+}
@@ -0,0 +1,342 @@
+package gazetteer
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// placeRecord is a populated place read from a GeoNames dump, with the names it is searchable by
+type placeRecord struct {
+	models.GazetteerPlace
+	ASCIIName      string
+	AlternateNames string // Comma-separated, as in the dump
+}
+
+// admin1Record is a line of admin1CodesASCII.txt
+type admin1Record struct {
+	Code string // e.g. "US.VT"
+	Name string
+}
+
+// alternateNameRecord is a line of alternateNamesV2.txt naming a place in a language
+type alternateNameRecord struct {
+	GeonameID int64
+	Language  string // Lowercased, e.g. "de" or "zh-tw"
+	Name      string
+	Preferred bool
+	Short     bool
+}
+
+// languagePattern matches the ISO 639 languages of alternate names, leaving out pseudo-languages such as
+// "post", "link", "iata" and "abbr" that hold codes and URLs rather than names
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]+)*$`)
+
+// Importer replaces the gazetteer tables with the contents of GeoNames dumps, documented at
+// https://download.geonames.org/export/dump/ and https://download.geonames.org/export/zip/
+type Importer struct {
+	db *sql.DB
+}
+
+// NewImporter creates an Importer writing to the given database
+func NewImporter(db *sql.DB) *Importer {
+	return &Importer{db: db}
+}
+
+// ImportPlaces loads the populated places of a GeoNames dump such as allCountries.txt or cities500.txt,
+// skipping those with fewer than minPopulation inhabitants. It returns the number of places imported.
+func (i *Importer) ImportPlaces(r io.Reader, minPopulation int64) (int, error) {
+	count := 0
+	err := i.replace("gazetteer_places",
+		[]string{"geoname_id", "name", "ascii_name", "alternate_names", "lat", "lng", "feature_code", "country_code", "admin1_code", "population", "timezone"},
+		func(copyRow func(...interface{}) error) error {
+			return readPlaces(r, minPopulation, func(p placeRecord) error {
+				count++
+				return copyRow(p.GeonameID, p.Name, p.ASCIIName, p.AlternateNames, p.Lat, p.Lng, p.FeatureCode, p.CountryCode, p.Admin1Code, p.Population, p.Timezone)
+			})
+		},
+		// Index every name a place goes by, so lookups are a single indexed equality match
+		`INSERT INTO gazetteer_place_names (name_key, geoname_id)
+		SELECT DISTINCT LOWER(TRIM(place_name)), geoname_id
+		FROM gazetteer_places,
+		     unnest(string_to_array(COALESCE(alternate_names, ''), ',') || ARRAY[name, ascii_name]) AS place_name
+		WHERE TRIM(COALESCE(place_name, '')) <> ''`,
+	)
+	return count, err
+}
+
+// ImportAdmin1 loads admin1CodesASCII.txt, which names the first-level divisions places refer to by code
+func (i *Importer) ImportAdmin1(r io.Reader) (int, error) {
+	count := 0
+	err := i.replace("gazetteer_admin1", []string{"code", "name"}, func(copyRow func(...interface{}) error) error {
+		return readAdmin1(r, func(a admin1Record) error {
+			count++
+			return copyRow(a.Code, a.Name)
+		})
+	})
+	return count, err
+}
+
+// ImportPostalCodes loads a GeoNames postal code dump, either allCountries.txt or a single country's file
+func (i *Importer) ImportPostalCodes(r io.Reader) (int, error) {
+	count := 0
+	err := i.replace("gazetteer_postal_codes",
+		[]string{"country_code", "postal_code", "place_name", "admin1_name", "admin1_code", "admin2_name", "lat", "lng", "accuracy"},
+		func(copyRow func(...interface{}) error) error {
+			return readPostalCodes(r, func(p models.GazetteerPostalCode) error {
+				count++
+				var accuracy interface{}
+				if p.Accuracy != 0 {
+					accuracy = p.Accuracy
+				}
+				return copyRow(p.CountryCode, p.PostalCode, p.PlaceName, p.Admin1Name, p.Admin1Code, p.Admin2Name, p.Lat, p.Lng, accuracy)
+			})
+		},
+	)
+	return count, err
+}
+
+// ImportAlternateNames loads the names places go by in each language from alternateNamesV2.txt, keeping
+// one per language for the places already imported. It has to run after ImportPlaces, which clears them.
+func (i *Importer) ImportAlternateNames(r io.Reader) (int, error) {
+	places, err := i.placeIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	// The dump lists every name of every GeoNames feature, so pick the best per language before loading
+	type key struct {
+		id       int64
+		language string
+	}
+	best := make(map[key]alternateNameRecord)
+	err = readAlternateNames(r, func(a alternateNameRecord) error {
+		if !places[a.GeonameID] {
+			return nil
+		}
+		k := key{a.GeonameID, a.Language}
+		if current, ok := best[k]; !ok || betterAlternateName(a, current) {
+			best[k] = a
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = i.replace("gazetteer_localized_names", []string{"geoname_id", "language", "name"}, func(copyRow func(...interface{}) error) error {
+		for _, a := range best {
+			if err := copyRow(a.GeonameID, a.Language, a.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(best), err
+}
+
+// placeIDs returns the geonameids of the imported places
+func (i *Importer) placeIDs() (map[int64]bool, error) {
+	rows, err := i.db.Query("SELECT geoname_id FROM gazetteer_places")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read places: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// betterAlternateName reports whether a name should replace the current one for its language: GeoNames'
+// preferred name wins, then a full name over a short one such as "NYC"
+func betterAlternateName(a, current alternateNameRecord) bool {
+	if a.Preferred != current.Preferred {
+		return a.Preferred
+	}
+	return current.Short && !a.Short
+}
+
+// replace empties a table and bulk loads it in one transaction, so lookups see either the old or the new
+// data, then runs any follow-up statements in the same transaction
+func (i *Importer) replace(table string, columns []string, load func(copyRow func(...interface{}) error) error, followUp ...string) error {
+	tx, err := i.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// CASCADE also empties gazetteer_place_names, which is rebuilt from the new places, and
+	// gazetteer_localized_names, which has to be imported again
+	if _, err := tx.Exec("TRUNCATE " + pq.QuoteIdentifier(table) + " CASCADE"); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", table, err)
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return fmt.Errorf("failed to start copy into %s: %w", table, err)
+	}
+	err = load(func(values ...interface{}) error {
+		_, err := stmt.Exec(values...)
+		return err
+	})
+	if err != nil {
+		stmt.Close()
+		return err
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return fmt.Errorf("failed to copy into %s: %w", table, err)
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	for _, query := range followUp {
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("failed to update %s: %w", table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// readPlaces streams the populated places (feature class P) of a GeoNames dump
+func readPlaces(r io.Reader, minPopulation int64, fn func(placeRecord) error) error {
+	return readTSV(r, 15, func(line int, fields []string) error {
+		if fields[6] != "P" {
+			return nil
+		}
+
+		id, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid geonameid %q", line, fields[0])
+		}
+		lat, latErr := strconv.ParseFloat(fields[4], 64)
+		lng, lngErr := strconv.ParseFloat(fields[5], 64)
+		if latErr != nil || lngErr != nil {
+			return fmt.Errorf("line %d: invalid coordinates", line)
+		}
+		population, _ := strconv.ParseInt(fields[14], 10, 64)
+		if population < minPopulation {
+			return nil
+		}
+
+		p := placeRecord{
+			GazetteerPlace: models.GazetteerPlace{
+				GeonameID:   id,
+				Name:        fields[1],
+				Lat:         lat,
+				Lng:         lng,
+				FeatureCode: fields[7],
+				CountryCode: fields[8],
+				Admin1Code:  fields[10],
+				Population:  population,
+			},
+			ASCIIName:      fields[2],
+			AlternateNames: fields[3],
+		}
+		if len(fields) > 17 {
+			p.Timezone = fields[17]
+		}
+		return fn(p)
+	})
+}
+
+// readAdmin1 streams admin1CodesASCII.txt: code, name, ASCII name and geonameid
+func readAdmin1(r io.Reader, fn func(admin1Record) error) error {
+	return readTSV(r, 2, func(line int, fields []string) error {
+		return fn(admin1Record{Code: fields[0], Name: fields[1]})
+	})
+}
+
+// readAlternateNames streams the current names of alternateNamesV2.txt: alternateNameId, geonameid,
+// language, name, then the isPreferredName, isShortName, isColloquial and isHistoric flags. Colloquial
+// and historic names, and entries without a language, are skipped.
+func readAlternateNames(r io.Reader, fn func(alternateNameRecord) error) error {
+	return readTSV(r, 4, func(line int, fields []string) error {
+		language := strings.ToLower(fields[2])
+		if !languagePattern.MatchString(language) || strings.TrimSpace(fields[3]) == "" {
+			return nil
+		}
+		flag := func(column int) bool { return len(fields) > column && fields[column] == "1" }
+		if flag(6) || flag(7) {
+			return nil
+		}
+
+		id, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid geonameid %q", line, fields[1])
+		}
+		return fn(alternateNameRecord{
+			GeonameID: id,
+			Language:  language,
+			Name:      strings.TrimSpace(fields[3]),
+			Preferred: flag(4),
+			Short:     flag(5),
+		})
+	})
+}
+
+// readPostalCodes streams a GeoNames postal code dump: country code, postal code, place name, then the
+// name and code of three admin levels, latitude, longitude and accuracy
+func readPostalCodes(r io.Reader, fn func(models.GazetteerPostalCode) error) error {
+	return readTSV(r, 11, func(line int, fields []string) error {
+		lat, latErr := strconv.ParseFloat(fields[9], 64)
+		lng, lngErr := strconv.ParseFloat(fields[10], 64)
+		if latErr != nil || lngErr != nil {
+			return fmt.Errorf("line %d: invalid coordinates", line)
+		}
+
+		p := models.GazetteerPostalCode{
+			CountryCode: fields[0],
+			PostalCode:  strings.ToUpper(fields[1]),
+			PlaceName:   fields[2],
+			Admin1Name:  fields[3],
+			Admin1Code:  fields[4],
+			Admin2Name:  fields[5],
+			Lat:         lat,
+			Lng:         lng,
+		}
+		if len(fields) > 11 {
+			p.Accuracy, _ = strconv.Atoi(fields[11])
+		}
+		return fn(p)
+	})
+}
+
+// readTSV calls fn with the fields of each non-empty line, requiring at least minColumns of them
+func readTSV(r io.Reader, minColumns int, fn func(line int, fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024) // alternate names make some lines long
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < minColumns {
+			return fmt.Errorf("line %d: expected at least %d columns, got %d", line, minColumns, len(fields))
+		}
+		if err := fn(line, fields); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,159 @@
+// Package gazetteer answers city and postal code level forward geocoding queries from GeoNames data
+// imported into Postgres, so that the most common coarse queries don't need a Google API call.
+package gazetteer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/iso3166"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// Query is a forward geocoding query simple enough for the gazetteer: a city and/or postal code,
+// optionally narrowed by state and country
+type Query struct {
+	City       string
+	State      string
+	PostalCode string
+	Country    string // ISO 3166-1 alpha-2
+}
+
+var (
+	// postalTokenPattern matches a single word of a postal code, which always contains a digit
+	postalTokenPattern = regexp.MustCompile(`^[A-Z0-9-]*[0-9][A-Z0-9-]*$`)
+	usStateCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// ParseQuery splits a free-form address such as "Burlington, VT", "Paris, France", "75001 Paris" or
+// "SW1A 1AA, UK" into a Query. It reports false for anything more specific than a city or postal code,
+// such as a street address, which the gazetteer can't answer.
+func ParseQuery(address string) (Query, bool) {
+	var parts []string
+	for _, part := range strings.Split(address, ",") {
+		if part = strings.Join(strings.Fields(part), " "); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 || len(parts) > 4 {
+		return Query{}, false
+	}
+
+	var q Query
+	// "Atlanta, GA" is far more often Georgia than Gabon, so a trailing state abbreviation isn't a country
+	if len(parts) > 1 && !isUSStateCode(parts[len(parts)-1]) {
+		if country, ok := findCountry(parts[len(parts)-1]); ok {
+			q.Country = country
+			parts = parts[:len(parts)-1]
+		}
+	}
+
+	// Only the last remaining part may hold a postal code; digits anywhere else are a street address
+	for _, part := range parts[:len(parts)-1] {
+		if strings.ContainsAny(part, "0123456789") {
+			return Query{}, false
+		}
+	}
+	last := parts[len(parts)-1]
+	text, postalCode, ok := splitPostalCode(last)
+	if !ok {
+		return Query{}, false
+	}
+	q.PostalCode = postalCode
+	parts = parts[:len(parts)-1]
+	if text != "" {
+		parts = append(parts, text)
+	}
+
+	switch len(parts) {
+	case 0:
+	case 1:
+		if _, isCountry := findCountry(parts[0]); isCountry && q.PostalCode == "" && q.Country == "" {
+			return Query{}, false // A country on its own is coarser than the gazetteer goes
+		}
+		// "VT 05482" is a state and postal code, "Paris" or "75001 Paris" is a city
+		if q.PostalCode != "" && isUSStateCode(parts[0]) && (q.Country == "" || q.Country == "US") {
+			q.State = parts[0]
+		} else {
+			q.City = parts[0]
+		}
+	case 2:
+		q.City, q.State = parts[0], parts[1]
+	default:
+		return Query{}, false
+	}
+
+	if q.Country == "" && isUSStateCode(q.State) {
+		q.Country = "US"
+	}
+
+	return q, q.City != "" || q.PostalCode != ""
+}
+
+// QueryFromStructured builds a Query from a structured address with no street lines
+func QueryFromStructured(address models.StructuredAddress) (Query, bool) {
+	if strings.TrimSpace(address.AddressLine1) != "" || strings.TrimSpace(address.AddressLine2) != "" {
+		return Query{}, false
+	}
+
+	q := Query{
+		City:       strings.TrimSpace(address.City),
+		State:      strings.TrimSpace(address.State),
+		PostalCode: strings.ToUpper(strings.TrimSpace(address.PostalCode)),
+	}
+	if country := strings.TrimSpace(address.Country); country != "" {
+		code, ok := findCountry(country)
+		if !ok {
+			return Query{}, false
+		}
+		q.Country = code
+	} else if isUSStateCode(q.State) {
+		q.Country = "US"
+	}
+
+	return q, q.City != "" || q.PostalCode != ""
+}
+
+// splitPostalCode separates the postal code words of a part from the rest, e.g. "VT 05482" into "VT" and
+// "05482". It reports false when the words don't look like a single postal code, e.g. "12 Main St".
+func splitPostalCode(part string) (string, string, bool) {
+	var text, postal []string
+	for _, word := range strings.Fields(part) {
+		if postalTokenPattern.MatchString(strings.ToUpper(word)) {
+			postal = append(postal, strings.ToUpper(word))
+		} else {
+			text = append(text, word)
+		}
+	}
+
+	switch len(postal) {
+	case 0:
+		return part, "", true
+	case 1:
+	case 2:
+		// Two-word codes such as "SW1A 1AA" or "K1A 0B1" have short halves
+		if len(postal[0]) > 4 || len(postal[1]) > 4 {
+			return "", "", false
+		}
+	default:
+		return "", "", false
+	}
+	if len(text) > 3 {
+		return "", "", false
+	}
+	return strings.Join(text, " "), strings.Join(postal, " "), true
+}
+
+func findCountry(value string) (string, bool) {
+	country, ok := iso3166.FindCountryInAddress(value)
+	return country.Alpha2, ok
+}
+
+// isUSStateCode reports whether a value is written like a US state abbreviation, e.g. "VT" but not "Vt"
+func isUSStateCode(value string) bool {
+	if !usStateCodePattern.MatchString(value) {
+		return false
+	}
+	_, ok := iso3166.LookupSubdivision("US-" + value)
+	return ok
+}
@@ -0,0 +1,363 @@
+package gazetteer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/geocoding"
+	"github.com/hackclub/geocoder/internal/iso3166"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+const (
+	// candidateLimit is how many places sharing a name are compared to decide whether one is a confident match
+	candidateLimit = 10
+	// dominanceRatio is how many times more populous the top place must be than the next to be confident,
+	// so "Paris" resolves to France but "Springfield" is left to Google
+	dominanceRatio = 10
+)
+
+// ErrNoResults is returned when the gazetteer has nothing for a lookup
+var ErrNoResults = errors.New("no results found")
+
+// Store is the database access the resolver needs
+type Store interface {
+	FindGazetteerPlaces(name, countryCode string, limit int) ([]models.GazetteerPlace, error)
+	FindGazetteerPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error)
+	FindGazetteerLocalizedName(geonameID int64, name, countryCode string, languages []string) (string, error)
+}
+
+// Resolver geocodes queries against the gazetteer tables
+type Resolver struct {
+	store Store
+}
+
+// NewResolver creates a Resolver backed by the given store
+func NewResolver(store Store) *Resolver {
+	return &Resolver{store: store}
+}
+
+// Match is returned as the raw backend response, listing the gazetteer rows a result was built from
+type Match struct {
+	Places      []models.GazetteerPlace      `json:"places,omitempty"`
+	PostalCodes []models.GazetteerPostalCode `json:"postal_codes,omitempty"`
+}
+
+// Resolve geocodes a query, reporting false when the gazetteer has no confident match and the caller
+// should fall back to Google. Queries with bounds or component filters other than country are never
+// resolved, since the gazetteer can't honour them.
+func (r *Resolver) Resolve(q Query, opts geocoding.GeocodeOptions) (*models.GeocodeAPIResponse, bool) {
+	if opts.Bounds != nil {
+		return nil, false
+	}
+	for component := range opts.Components {
+		if component != "country" {
+			return nil, false
+		}
+	}
+	if country := opts.Components["country"]; country != "" {
+		code, ok := findCountry(country)
+		if !ok || (q.Country != "" && q.Country != code) {
+			return nil, false
+		}
+		q.Country = code
+	}
+
+	var (
+		result *models.GeocodeAPIResponse
+		ok     bool
+	)
+	if q.PostalCode != "" {
+		result, ok = r.resolvePostalCode(q)
+	} else {
+		result, ok = r.resolvePlace(q, opts.Region)
+	}
+	if !ok {
+		return nil, false
+	}
+
+	geocoding.LocalizeAdminHierarchy(&result.AdminHierarchy, opts.Language)
+	var geonameID int64
+	if match, ok := result.RawBackendResponse.(Match); ok && len(match.Places) == 1 {
+		geonameID = match.Places[0].GeonameID
+	}
+	result.AdminHierarchy.City = r.LocalizeCity(geonameID, result.AdminHierarchy.City, result.AdminHierarchy.CountryCode, opts.Language)
+	h := result.AdminHierarchy
+	result.StateName = h.State
+	result.StateCode = h.StateCode
+	result.CountryName = h.Country
+	result.CountryCode = h.CountryCode
+	result.FormattedAddress = joinNonEmpty(h.City, strings.TrimSpace(h.State+" "+h.PostalCode), h.Country)
+	result.Backend = "geonames_gazetteer"
+	return result, true
+}
+
+// resolvePlace picks the populated place a city name refers to, if one clearly dominates the others
+func (r *Resolver) resolvePlace(q Query, region string) (*models.GeocodeAPIResponse, bool) {
+	candidates, err := r.store.FindGazetteerPlaces(q.City, q.Country, candidateLimit)
+	if err != nil {
+		return nil, false
+	}
+
+	var places []models.GazetteerPlace
+	for _, place := range candidates {
+		if q.State == "" || matchesState(place.CountryCode, q.State, place.Admin1Code, place.Admin1Name) {
+			places = append(places, place)
+		}
+	}
+
+	// A region biases toward a country rather than filtering, so only narrow when it leaves something
+	if region != "" && q.Country == "" {
+		var inRegion []models.GazetteerPlace
+		for _, place := range places {
+			if strings.EqualFold(place.CountryCode, region) {
+				inRegion = append(inRegion, place)
+			}
+		}
+		if len(inRegion) > 0 {
+			places = inRegion
+		}
+	}
+
+	if len(places) == 0 {
+		return nil, false
+	}
+	if len(places) > 1 && places[0].Population < dominanceRatio*places[1].Population {
+		return nil, false
+	}
+
+	place := places[0]
+	hierarchy := models.AdminHierarchy{City: place.Name, CountryCode: place.CountryCode}
+	setState(&hierarchy, place.CountryCode, place.Admin1Code, place.Admin1Name)
+	normalizeCountry(&hierarchy)
+
+	return &models.GeocodeAPIResponse{
+		Lat:                place.Lat,
+		Lng:                place.Lng,
+		AdminHierarchy:     hierarchy,
+		RawBackendResponse: Match{Places: []models.GazetteerPlace{place}},
+	}, true
+}
+
+// resolvePostalCode geocodes a postal code to the centroid of the places sharing it, checking any city
+// or state given alongside it
+func (r *Resolver) resolvePostalCode(q Query) (*models.GeocodeAPIResponse, bool) {
+	if q.Country == "" {
+		return nil, false // Postal codes are only unique within a country
+	}
+
+	codes, err := r.findPostalCodes(q.Country, q.PostalCode)
+	if err != nil {
+		return nil, false
+	}
+
+	var matched []models.GazetteerPostalCode
+	for _, code := range codes {
+		if q.City != "" && !strings.EqualFold(code.PlaceName, q.City) && !strings.EqualFold(code.Admin2Name, q.City) {
+			continue
+		}
+		if q.State != "" && !matchesState(code.CountryCode, q.State, code.Admin1Code, code.Admin1Name) {
+			continue
+		}
+		matched = append(matched, code)
+	}
+	if len(matched) == 0 {
+		return nil, false
+	}
+
+	lat, lng, hierarchy := summarizePostalCodes(matched)
+	return &models.GeocodeAPIResponse{
+		Lat:                lat,
+		Lng:                lng,
+		AdminHierarchy:     hierarchy,
+		RawBackendResponse: Match{PostalCodes: matched},
+	}, true
+}
+
+// findPostalCodes returns the rows for a postal code, falling back to the part before the space or hyphen
+func (r *Resolver) findPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error) {
+	codes, err := r.store.FindGazetteerPostalCodes(countryCode, postalCode)
+	if err == nil && len(codes) == 0 {
+		// GeoNames only has the outward part of some codes, e.g. "SW1A" for the UK and "K1A" for Canada,
+		// and US ZIP+4 codes are stored as plain ZIP codes
+		if prefix := strings.FieldsFunc(postalCode, func(r rune) bool { return r == ' ' || r == '-' }); len(prefix) > 1 {
+			codes, err = r.store.FindGazetteerPostalCodes(countryCode, prefix[0])
+		}
+	}
+	return codes, err
+}
+
+// summarizePostalCodes returns the centroid of the places sharing a postal code and the admin hierarchy
+// they have in common: a postal code covering several places only names the city or state they all share
+func summarizePostalCodes(codes []models.GazetteerPostalCode) (float64, float64, models.AdminHierarchy) {
+	var lat, lng float64
+	for _, code := range codes {
+		lat += code.Lat
+		lng += code.Lng
+	}
+
+	first := codes[0]
+	hierarchy := models.AdminHierarchy{PostalCode: first.PostalCode, CountryCode: first.CountryCode}
+	if allEqual(codes, func(code models.GazetteerPostalCode) string { return code.PlaceName }) {
+		hierarchy.City = first.PlaceName
+	}
+	if allEqual(codes, func(code models.GazetteerPostalCode) string { return code.Admin1Code + "\x00" + code.Admin1Name }) {
+		setState(&hierarchy, first.CountryCode, first.Admin1Code, first.Admin1Name)
+	}
+	if allEqual(codes, func(code models.GazetteerPostalCode) string { return code.Admin2Name }) {
+		hierarchy.County = first.Admin2Name
+	}
+	normalizeCountry(&hierarchy)
+
+	return lat / float64(len(codes)), lng / float64(len(codes)), hierarchy
+}
+
+// findSubdivision maps a GeoNames admin1 division to ISO 3166-2. Names are tried first because many
+// countries use numeric GeoNames codes that collide with unrelated ISO codes (GeoNames FR.11 is
+// Île-de-France, ISO FR-11 is Aude); alphabetic codes such as US.VT match ISO.
+func findSubdivision(countryCode, admin1Code, admin1Name string) (iso3166.Subdivision, bool) {
+	if subdivision, ok := iso3166.FindSubdivision(countryCode, admin1Name); ok {
+		return subdivision, true
+	}
+	if admin1Code != "" && !strings.ContainsAny(admin1Code, "0123456789") {
+		return iso3166.FindSubdivision(countryCode, admin1Code)
+	}
+	return iso3166.Subdivision{}, false
+}
+
+// matchesState reports whether the state a caller gave, as a name or code, is a place's admin1 division
+func matchesState(countryCode, state, admin1Code, admin1Name string) bool {
+	if strings.EqualFold(state, admin1Name) || strings.EqualFold(state, admin1Code) {
+		return true
+	}
+	wanted, ok := iso3166.FindSubdivision(countryCode, state)
+	if !ok {
+		return false
+	}
+	actual, ok := findSubdivision(countryCode, admin1Code, admin1Name)
+	return ok && actual.Code == wanted.Code
+}
+
+func setState(hierarchy *models.AdminHierarchy, countryCode, admin1Code, admin1Name string) {
+	hierarchy.State = admin1Name
+	if subdivision, ok := findSubdivision(countryCode, admin1Code, admin1Name); ok {
+		hierarchy.StateISOCode = subdivision.Code
+		hierarchy.StateCode = strings.TrimPrefix(subdivision.Code, subdivision.CountryCode+"-")
+		if hierarchy.State == "" {
+			hierarchy.State = subdivision.Name
+		}
+	}
+}
+
+// normalizeCountry names the country with its ISO name and fills in the other codes
+func normalizeCountry(hierarchy *models.AdminHierarchy) {
+	country, ok := iso3166.LookupCountry(hierarchy.CountryCode)
+	if !ok {
+		return
+	}
+	hierarchy.Country = country.Name
+	hierarchy.CountryAlpha3 = country.Alpha3
+	hierarchy.CountryNumeric = country.Numeric
+}
+
+func allEqual(codes []models.GazetteerPostalCode, key func(models.GazetteerPostalCode) string) bool {
+	for _, code := range codes[1:] {
+		if key(code) != key(codes[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+func joinNonEmpty(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
+
+// LookupPostalCode returns the places a postal code covers, their centroid and the admin areas they share,
+// with names in the given language where translations exist
+func (r *Resolver) LookupPostalCode(countryCode, postalCode, language string) (*models.PostalCodeResponse, error) {
+	codes, err := r.findPostalCodes(countryCode, postalCode)
+	if err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("%w for postal code %s in %s", ErrNoResults, postalCode, countryCode)
+	}
+
+	lat, lng, hierarchy := summarizePostalCodes(codes)
+	geocoding.LocalizeAdminHierarchy(&hierarchy, language)
+
+	// Places are only named in the postal code data, so they're translated by name, once each
+	localized := make(map[string]string)
+	localizeCity := func(name string) string {
+		if _, ok := localized[name]; !ok {
+			localized[name] = r.LocalizeCity(0, name, countryCode, language)
+		}
+		return localized[name]
+	}
+	hierarchy.City = localizeCity(hierarchy.City)
+
+	places := make([]models.PostalCodePlace, 0, len(codes))
+	for _, code := range codes {
+		state := models.AdminHierarchy{State: code.Admin1Name, CountryCode: code.CountryCode}
+		setState(&state, code.CountryCode, code.Admin1Code, code.Admin1Name)
+		geocoding.LocalizeAdminHierarchy(&state, language)
+
+		places = append(places, models.PostalCodePlace{
+			Name:         localizeCity(code.PlaceName),
+			State:        state.State,
+			StateISOCode: state.StateISOCode,
+			County:       code.Admin2Name,
+			Lat:          code.Lat,
+			Lng:          code.Lng,
+		})
+	}
+
+	return &models.PostalCodeResponse{
+		PostalCode:         postalCode,
+		CountryCode:        countryCode,
+		Lat:                lat,
+		Lng:                lng,
+		Places:             places,
+		AdminHierarchy:     hierarchy,
+		Backend:            "geonames_gazetteer",
+		RawBackendResponse: Match{PostalCodes: codes},
+	}, nil
+}
+
+// LocalizeCity translates a city name from the GeoNames alternate names loaded with the gazetteer,
+// returning it unchanged when there is no translation. The city is identified by geonameid, or when
+// that is 0 by name and country.
+func (r *Resolver) LocalizeCity(geonameID int64, name, countryCode, language string) string {
+	if language == "" || (geonameID == 0 && (name == "" || countryCode == "")) {
+		return name
+	}
+	localized, err := r.store.FindGazetteerLocalizedName(geonameID, name, countryCode, languageFallbacks(language))
+	if err != nil || localized == "" {
+		return name
+	}
+	return localized
+}
+
+// languageFallbacks lists a language tag and its less specific forms, lowercased as GeoNames stores
+// them, e.g. "pt-BR" gives "pt-br" and "pt"
+func languageFallbacks(language string) []string {
+	tag := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(language), "_", "-"))
+	var tags []string
+	for tag != "" {
+		tags = append(tags, tag)
+		cut := strings.LastIndex(tag, "-")
+		if cut < 0 {
+			break
+		}
+		tag = tag[:cut]
+	}
+	return tags
+}
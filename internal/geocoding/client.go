@@ -2,6 +2,7 @@ package geocoding
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -10,8 +11,18 @@ import (
 	"github.com/hackclub/geocoder/internal/models"
 )
 
+var (
+	// ErrNoResults is returned when Google answers successfully but finds nothing for the query
+	ErrNoResults = errors.New("no results found")
+	// ErrInvalidPlaceID is returned when Google rejects a place ID as malformed
+	ErrInvalidPlaceID = errors.New("invalid place ID")
+)
+
+const geocodeBaseURL = "https://maps.googleapis.com/maps/api/geocode/json"
+
 type Client struct {
 	apiKey     string
+	baseURL    string
 	httpClient *http.Client
 }
 
@@ -26,6 +37,7 @@ type GeocodeResult struct {
 	PlaceID           string             `json:"place_id"`
 	Types             []string           `json:"types"`
 	AddressComponents []AddressComponent `json:"address_components"`
+	PartialMatch      bool               `json:"partial_match,omitempty"`
 }
 
 type GeocodeGeometry struct {
@@ -53,7 +65,8 @@ type AddressComponent struct {
 
 func NewClient(apiKey string) *Client {
 	return &Client{
-		apiKey: apiKey,
+		apiKey:  apiKey,
+		baseURL: geocodeBaseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -61,16 +74,36 @@ func NewClient(apiKey string) *Client {
 }
 
 func (c *Client) Geocode(address string) (*GeocodeResponse, error) {
+	return c.GeocodeWithOptions(address, GeocodeOptions{})
+}
+
+// GeocodeWithOptions geocodes an address with language, region, bounds and component filters applied
+func (c *Client) GeocodeWithOptions(address string, opts GeocodeOptions) (*GeocodeResponse, error) {
 	if c.apiKey == "" {
 		return nil, fmt.Errorf("Google Geocoding API key not configured")
 	}
 
-	baseURL := "https://maps.googleapis.com/maps/api/geocode/json"
 	params := url.Values{}
 	params.Set("address", address)
-	params.Set("key", c.apiKey)
+	opts.apply(params)
+
+	geocodeResp, err := c.get(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if geocodeResp.Status != "OK" && geocodeResp.Status != "ZERO_RESULTS" {
+		return nil, fmt.Errorf("Google Geocoding API returned status: %s", geocodeResp.Status)
+	}
 
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+	return geocodeResp, nil
+}
+
+// get calls the Geocoding API with the given parameters plus the API key, leaving the response status
+// for the caller to check
+func (c *Client) get(params url.Values) (*GeocodeResponse, error) {
+	params.Set("key", c.apiKey)
+	fullURL := fmt.Sprintf("%s?%s", c.baseURL, params.Encode())
 
 	resp, err := c.httpClient.Get(fullURL)
 	if err != nil {
@@ -87,10 +120,6 @@ func (c *Client) Geocode(address string) (*GeocodeResponse, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	if geocodeResp.Status != "OK" && geocodeResp.Status != "ZERO_RESULTS" {
-		return nil, fmt.Errorf("Google Geocoding API returned status: %s", geocodeResp.Status)
-	}
-
 	return &geocodeResp, nil
 }
 
@@ -98,104 +127,175 @@ func (c *Client) IsConfigured() bool {
 	return c.apiKey != ""
 }
 
+// DecodeRawResponse recovers a Google response from a standard response's RawBackendResponse,
+// which is a *GeocodeResponse when freshly fetched and a generic map when read back from the cache
+func DecodeRawResponse(raw interface{}) (*GeocodeResponse, error) {
+	if resp, ok := raw.(*GeocodeResponse); ok {
+		return resp, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal raw backend response: %w", err)
+	}
+
+	var resp GeocodeResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode raw backend response: %w", err)
+	}
+
+	return &resp, nil
+}
+
+// BackfillPlaceID fills in the place ID of a cached response from before it was part of the standard
+// format, using the Google response stored alongside it
+func BackfillPlaceID(response *models.GeocodeAPIResponse) {
+	if response.PlaceID != "" || response.RawBackendResponse == nil {
+		return
+	}
+
+	resp, err := DecodeRawResponse(response.RawBackendResponse)
+	if err != nil || len(resp.Results) == 0 {
+		return
+	}
+
+	response.PlaceID = resp.Results[0].PlaceID
+}
+
 // GeocodeToStandardFormat converts a Google Geocoding API response to our standard format
 func (c *Client) GeocodeToStandardFormat(address string) (*models.GeocodeAPIResponse, error) {
-	googleResp, err := c.Geocode(address)
+	return c.GeocodeToStandardFormatWithOptions(address, GeocodeOptions{})
+}
+
+// GeocodeToStandardFormatWithOptions is GeocodeToStandardFormat with request options applied
+func (c *Client) GeocodeToStandardFormatWithOptions(address string, opts GeocodeOptions) (*models.GeocodeAPIResponse, error) {
+	googleResp, err := c.GeocodeWithOptions(address, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(googleResp.Results) == 0 {
-		return nil, fmt.Errorf("no results found for address: %s", address)
+		return nil, fmt.Errorf("%w for address: %s", ErrNoResults, address)
 	}
 
-	// Use the first result
+	return toStandardFormat(googleResp, opts.Language), nil
+}
+
+// toStandardFormat converts the first result of a Google response to our standard forward geocoding format
+func toStandardFormat(googleResp *GeocodeResponse, language string) *models.GeocodeAPIResponse {
 	result := googleResp.Results[0]
 
-	// Extract country and state information from address components
-	var countryName, countryCode, stateName, stateCode string
-	for _, component := range result.AddressComponents {
-		for _, componentType := range component.Types {
-			if componentType == "country" {
-				countryName = component.LongName
-				countryCode = component.ShortName
-			} else if componentType == "administrative_area_level_1" {
-				stateName = component.LongName
-				stateCode = component.ShortName
-			}
-		}
-	}
+	hierarchy := ExtractAdminHierarchy(result.AddressComponents)
+	LocalizeAdminHierarchy(&hierarchy, language)
 
-	response := &models.GeocodeAPIResponse{
+	return &models.GeocodeAPIResponse{
 		Lat:                result.Geometry.Location.Lat,
 		Lng:                result.Geometry.Location.Lng,
 		FormattedAddress:   result.FormattedAddress,
-		StateName:          stateName,
-		StateCode:          stateCode,
-		CountryName:        countryName,
-		CountryCode:        countryCode,
+		PlaceID:            result.PlaceID,
+		StateName:          hierarchy.State,
+		StateCode:          hierarchy.StateCode,
+		CountryName:        hierarchy.Country,
+		CountryCode:        hierarchy.CountryCode,
+		AdminHierarchy:     hierarchy,
 		Backend:            "google_maps_platform_geocoding",
 		RawBackendResponse: googleResp,
 	}
-
-	return response, nil
 }
 
-func (c *Client) ReverseGeocode(lat, lng float64) (*GeocodeResponse, error) {
+// PlaceDetails looks up a place ID from an earlier geocoding result, asking Google for names in the given language
+func (c *Client) PlaceDetails(placeID, language string) (*GeocodeResponse, error) {
 	if c.apiKey == "" {
 		return nil, fmt.Errorf("Google Geocoding API key not configured")
 	}
 
-	baseURL := "https://maps.googleapis.com/maps/api/geocode/json"
 	params := url.Values{}
-	params.Set("latlng", fmt.Sprintf("%f,%f", lat, lng))
-	params.Set("key", c.apiKey)
+	params.Set("place_id", placeID)
+	if language != "" {
+		params.Set("language", language)
+	}
+
+	geocodeResp, err := c.get(params)
+	if err != nil {
+		return nil, err
+	}
 
-	fullURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+	switch geocodeResp.Status {
+	case "OK", "ZERO_RESULTS":
+		return geocodeResp, nil
+	case "NOT_FOUND":
+		// Place IDs can expire when Google's data changes
+		return &GeocodeResponse{Status: geocodeResp.Status}, nil
+	case "INVALID_REQUEST":
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPlaceID, placeID)
+	default:
+		return nil, fmt.Errorf("Google Geocoding API returned status: %s", geocodeResp.Status)
+	}
+}
 
-	resp, err := c.httpClient.Get(fullURL)
+// PlaceDetailsToStandardFormat converts a place ID lookup to the same standard format as forward geocoding
+func (c *Client) PlaceDetailsToStandardFormat(placeID, language string) (*models.GeocodeAPIResponse, error) {
+	googleResp, err := c.PlaceDetails(placeID, language)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request to Google Geocoding API: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Google Geocoding API returned status %d", resp.StatusCode)
+	if len(googleResp.Results) == 0 {
+		return nil, fmt.Errorf("%w for place ID: %s", ErrNoResults, placeID)
 	}
 
-	var geocodeResp GeocodeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&geocodeResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	return toStandardFormat(googleResp, language), nil
+}
+
+func (c *Client) ReverseGeocode(lat, lng float64) (*GeocodeResponse, error) {
+	return c.ReverseGeocodeWithLanguage(lat, lng, "")
+}
+
+// ReverseGeocodeWithLanguage reverse geocodes coordinates, asking Google for results in the given language
+func (c *Client) ReverseGeocodeWithLanguage(lat, lng float64, language string) (*GeocodeResponse, error) {
+	if c.apiKey == "" {
+		return nil, fmt.Errorf("Google Geocoding API key not configured")
+	}
+
+	params := url.Values{}
+	params.Set("latlng", fmt.Sprintf("%f,%f", lat, lng))
+	if language != "" {
+		params.Set("language", language)
+	}
+
+	geocodeResp, err := c.get(params)
+	if err != nil {
+		return nil, err
 	}
 
 	if geocodeResp.Status != "OK" && geocodeResp.Status != "ZERO_RESULTS" {
 		return nil, fmt.Errorf("Google Geocoding API returned status: %s", geocodeResp.Status)
 	}
 
-	return &geocodeResp, nil
+	return geocodeResp, nil
 }
 
 // ReverseGeocodeToStandardFormat converts a Google Reverse Geocoding API response to our standard format
 func (c *Client) ReverseGeocodeToStandardFormat(lat, lng float64) (*models.ReverseGeocodeAPIResponse, error) {
-	googleResp, err := c.ReverseGeocode(lat, lng)
+	return c.ReverseGeocodeToStandardFormatWithLanguage(lat, lng, "")
+}
+
+// ReverseGeocodeToStandardFormatWithLanguage is ReverseGeocodeToStandardFormat with names in the given language
+func (c *Client) ReverseGeocodeToStandardFormatWithLanguage(lat, lng float64, language string) (*models.ReverseGeocodeAPIResponse, error) {
+	googleResp, err := c.ReverseGeocodeWithLanguage(lat, lng, language)
 	if err != nil {
 		return nil, err
 	}
 
 	if len(googleResp.Results) == 0 {
-		return nil, fmt.Errorf("no results found for coordinates: %f, %f", lat, lng)
+		return nil, fmt.Errorf("%w for coordinates: %f, %f", ErrNoResults, lat, lng)
 	}
 
 	// Use the first result
 	result := googleResp.Results[0]
 
 	// Extract address components
-	var (
-		countryName, countryCode string
-		addressLine1, city, state, stateFull, postalCode string
-		streetNumber, route string
-	)
-
+	var streetNumber, route string
 	for _, component := range result.AddressComponents {
 		for _, componentType := range component.Types {
 			switch componentType {
@@ -203,21 +303,14 @@ func (c *Client) ReverseGeocodeToStandardFormat(lat, lng float64) (*models.Rever
 				streetNumber = component.LongName
 			case "route":
 				route = component.LongName
-			case "locality":
-				city = component.LongName
-			case "administrative_area_level_1":
-				state = component.ShortName      // e.g., "CA"
-				stateFull = component.LongName   // e.g., "California"
-			case "postal_code":
-				postalCode = component.LongName
-			case "country":
-				countryName = component.LongName
-				countryCode = component.ShortName
 			}
 		}
 	}
+	hierarchy := ExtractAdminHierarchy(result.AddressComponents)
+	LocalizeAdminHierarchy(&hierarchy, language)
 
 	// Construct address line 1 from street number and route
+	var addressLine1 string
 	if streetNumber != "" && route != "" {
 		addressLine1 = streetNumber + " " + route
 	} else if route != "" {
@@ -231,12 +324,13 @@ func (c *Client) ReverseGeocodeToStandardFormat(lat, lng float64) (*models.Rever
 		Lng:                lng,
 		FormattedAddress:   result.FormattedAddress,
 		AddressLine1:       addressLine1,
-		City:               city,
-		State:              state,
-		StateFull:          stateFull,
-		PostalCode:         postalCode,
-		CountryName:        countryName,
-		CountryCode:        countryCode,
+		City:               hierarchy.City,
+		State:              hierarchy.StateCode, // e.g., "CA"
+		StateFull:          hierarchy.State,     // e.g., "California"
+		PostalCode:         hierarchy.PostalCode,
+		CountryName:        hierarchy.Country,
+		CountryCode:        hierarchy.CountryCode,
+		AdminHierarchy:     hierarchy,
 		Backend:            "google_maps_platform_geocoding",
 		RawBackendResponse: googleResp,
 	}
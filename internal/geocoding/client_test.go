@@ -1,6 +1,7 @@
 package geocoding
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -276,3 +277,64 @@ func TestGeocodeClient_ExtractStateFromComponents(t *testing.T) {
 		t.Errorf("Expected StateCode 'CA', got '%s'", expectedResponse.StateCode)
 	}
 }
+
+func TestPlaceDetailsToStandardFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("place_id") {
+		case "ChIJ2eUgeAK6j4ARbn5u_wAGqWA":
+			if r.URL.Query().Get("language") != "fr" {
+				t.Errorf("Expected language=fr, got %q", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{
+				"results": [{
+					"formatted_address": "1600 Amphitheatre Pkwy, Mountain View, CA 94043, États-Unis",
+					"geometry": {"location": {"lat": 37.4224764, "lng": -122.0842499}, "location_type": "ROOFTOP"},
+					"place_id": "ChIJ2eUgeAK6j4ARbn5u_wAGqWA",
+					"address_components": [
+						{"long_name": "Californie", "short_name": "CA", "types": ["administrative_area_level_1", "political"]},
+						{"long_name": "États-Unis", "short_name": "US", "types": ["country", "political"]}
+					]
+				}],
+				"status": "OK"
+			}`))
+		case "ChIJexpired":
+			w.Write([]byte(`{"results": [], "status": "NOT_FOUND"}`))
+		default:
+			w.Write([]byte(`{"results": [], "status": "INVALID_REQUEST"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-api-key")
+	client.baseURL = server.URL
+
+	result, err := client.PlaceDetailsToStandardFormat("ChIJ2eUgeAK6j4ARbn5u_wAGqWA", "fr")
+	if err != nil {
+		t.Fatalf("PlaceDetailsToStandardFormat() error = %v", err)
+	}
+	if result.PlaceID != "ChIJ2eUgeAK6j4ARbn5u_wAGqWA" || result.CountryCode != "US" || result.StateCode != "CA" || result.Lat != 37.4224764 {
+		t.Errorf("Unexpected place details %+v", result)
+	}
+
+	if _, err := client.PlaceDetailsToStandardFormat("ChIJexpired", ""); !errors.Is(err, ErrNoResults) {
+		t.Errorf("Expected ErrNoResults for an expired place ID, got %v", err)
+	}
+	if _, err := client.PlaceDetailsToStandardFormat("garbage", ""); !errors.Is(err, ErrInvalidPlaceID) {
+		t.Errorf("Expected ErrInvalidPlaceID, got %v", err)
+	}
+}
+
+func TestBackfillPlaceID(t *testing.T) {
+	// Cached responses come back with the Google response as a generic map
+	response := &models.GeocodeAPIResponse{
+		RawBackendResponse: map[string]interface{}{
+			"results": []interface{}{map[string]interface{}{"place_id": "ChIJ2eUgeAK6j4ARbn5u_wAGqWA"}},
+			"status":  "OK",
+		},
+	}
+	BackfillPlaceID(response)
+	if response.PlaceID != "ChIJ2eUgeAK6j4ARbn5u_wAGqWA" {
+		t.Errorf("Expected the place ID from the raw response, got %q", response.PlaceID)
+	}
+}
@@ -0,0 +1,106 @@
+package geocoding
+
+import (
+	"github.com/hackclub/geocoder/internal/iso3166"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// ExtractAdminHierarchy builds a provider-neutral admin hierarchy from Google address components
+func ExtractAdminHierarchy(components []AddressComponent) models.AdminHierarchy {
+	var hierarchy models.AdminHierarchy
+	var locality, postalTown, adminLevel3 string
+
+	for _, component := range components {
+		for _, componentType := range component.Types {
+			switch componentType {
+			case "neighborhood":
+				hierarchy.Neighborhood = component.LongName
+			case "sublocality", "sublocality_level_1":
+				hierarchy.Sublocality = component.LongName
+			case "locality":
+				locality = component.LongName
+			case "postal_town":
+				postalTown = component.LongName
+			case "administrative_area_level_3":
+				adminLevel3 = component.LongName
+			case "administrative_area_level_2":
+				hierarchy.County = component.LongName
+			case "administrative_area_level_1":
+				hierarchy.State = component.LongName
+				hierarchy.StateCode = component.ShortName
+			case "postal_code":
+				hierarchy.PostalCode = component.LongName
+			case "country":
+				hierarchy.Country = component.LongName
+				hierarchy.CountryCode = component.ShortName
+			}
+		}
+	}
+
+	// Not every country has a locality: UK addresses use postal_town, and parts of NYC only have a sublocality
+	hierarchy.City = firstNonEmpty(locality, postalTown, hierarchy.Sublocality, adminLevel3)
+
+	normalizeISOCodes(&hierarchy)
+
+	return hierarchy
+}
+
+// normalizeISOCodes fills in the ISO 3166 codes of a hierarchy from its country and state
+func normalizeISOCodes(hierarchy *models.AdminHierarchy) {
+	country, ok := iso3166.FindCountry(hierarchy.CountryCode)
+	if !ok {
+		return
+	}
+	hierarchy.CountryAlpha3 = country.Alpha3
+	hierarchy.CountryNumeric = country.Numeric
+
+	// Google's short name is usually the ISO 3166-2 suffix ("CA"), but some countries only have a name ("England")
+	if subdivision, ok := iso3166.FindSubdivision(country.Alpha2, hierarchy.StateCode); ok {
+		hierarchy.StateISOCode = subdivision.Code
+	} else if subdivision, ok := iso3166.FindSubdivision(country.Alpha2, hierarchy.State); ok {
+		hierarchy.StateISOCode = subdivision.Code
+	}
+}
+
+// BackfillAdminHierarchy fills in the admin hierarchy of a cached response from before the hierarchy existed,
+// using the Google response stored alongside it
+func BackfillAdminHierarchy(hierarchy *models.AdminHierarchy, raw interface{}) {
+	if !hierarchy.IsEmpty() {
+		if hierarchy.CountryAlpha3 == "" {
+			normalizeISOCodes(hierarchy)
+		}
+		return
+	}
+	if raw == nil {
+		return
+	}
+
+	resp, err := DecodeRawResponse(raw)
+	if err != nil || len(resp.Results) == 0 {
+		return
+	}
+
+	*hierarchy = ExtractAdminHierarchy(resp.Results[0].AddressComponents)
+}
+
+// LocalizeAdminHierarchy translates the country and state of a hierarchy that the backend left in English.
+// Names the backend already localized are kept. Cities aren't in the embedded dataset; the gazetteer
+// translates the ones it resolves.
+func LocalizeAdminHierarchy(hierarchy *models.AdminHierarchy, language string) {
+	if language == "" {
+		return
+	}
+
+	if country, ok := iso3166.LookupCountry(hierarchy.CountryCode); ok {
+		if english, ok := iso3166.LookupCountryByName(hierarchy.Country); ok && english.Alpha2 == country.Alpha2 {
+			hierarchy.Country = iso3166.LocalizeCountry(country, language).Name
+		}
+	}
+
+	if subdivision, ok := iso3166.LookupSubdivision(hierarchy.StateISOCode); ok {
+		english, _ := iso3166.LocalizedSubdivisionName(subdivision.Code, "en")
+		if hierarchy.State == subdivision.Name || hierarchy.State == english {
+			hierarchy.State = iso3166.LocalizeSubdivision(subdivision, language).Name
+		}
+	}
+}
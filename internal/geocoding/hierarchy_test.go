@@ -0,0 +1,147 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+func TestExtractAdminHierarchy(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []AddressComponent
+		expected   models.AdminHierarchy
+	}{
+		{
+			name: "US address",
+			components: []AddressComponent{
+				{LongName: "Mission District", ShortName: "Mission District", Types: []string{"neighborhood", "political"}},
+				{LongName: "San Francisco", ShortName: "SF", Types: []string{"locality", "political"}},
+				{LongName: "San Francisco County", ShortName: "San Francisco County", Types: []string{"administrative_area_level_2", "political"}},
+				{LongName: "California", ShortName: "CA", Types: []string{"administrative_area_level_1", "political"}},
+				{LongName: "United States", ShortName: "US", Types: []string{"country", "political"}},
+				{LongName: "94110", ShortName: "94110", Types: []string{"postal_code"}},
+			},
+			expected: models.AdminHierarchy{
+				Neighborhood:   "Mission District",
+				City:           "San Francisco",
+				County:         "San Francisco County",
+				State:          "California",
+				StateCode:      "CA",
+				StateISOCode:   "US-CA",
+				PostalCode:     "94110",
+				Country:        "United States",
+				CountryCode:    "US",
+				CountryAlpha3:  "USA",
+				CountryNumeric: "840",
+			},
+		},
+		{
+			name: "UK postal town with a named country subdivision",
+			components: []AddressComponent{
+				{LongName: "Cambridge", ShortName: "Cambridge", Types: []string{"postal_town"}},
+				{LongName: "Cambridgeshire", ShortName: "Cambridgeshire", Types: []string{"administrative_area_level_2", "political"}},
+				{LongName: "England", ShortName: "England", Types: []string{"administrative_area_level_1", "political"}},
+				{LongName: "United Kingdom", ShortName: "GB", Types: []string{"country", "political"}},
+			},
+			expected: models.AdminHierarchy{
+				City:           "Cambridge",
+				County:         "Cambridgeshire",
+				State:          "England",
+				StateCode:      "England",
+				StateISOCode:   "GB-ENG",
+				Country:        "United Kingdom",
+				CountryCode:    "GB",
+				CountryAlpha3:  "GBR",
+				CountryNumeric: "826",
+			},
+		},
+		{
+			name: "NYC sublocality",
+			components: []AddressComponent{
+				{LongName: "Brooklyn", ShortName: "Brooklyn", Types: []string{"political", "sublocality", "sublocality_level_1"}},
+				{LongName: "Kings County", ShortName: "Kings County", Types: []string{"administrative_area_level_2", "political"}},
+				{LongName: "New York", ShortName: "NY", Types: []string{"administrative_area_level_1", "political"}},
+				{LongName: "United States", ShortName: "US", Types: []string{"country", "political"}},
+			},
+			expected: models.AdminHierarchy{
+				Sublocality:    "Brooklyn",
+				City:           "Brooklyn",
+				County:         "Kings County",
+				State:          "New York",
+				StateCode:      "NY",
+				StateISOCode:   "US-NY",
+				Country:        "United States",
+				CountryCode:    "US",
+				CountryAlpha3:  "USA",
+				CountryNumeric: "840",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractAdminHierarchy(tt.components); got != tt.expected {
+				t.Errorf("ExtractAdminHierarchy() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBackfillAdminHierarchy(t *testing.T) {
+	// Cached responses come back from JSON as generic maps
+	raw := map[string]interface{}{}
+	data, _ := json.Marshal(GeocodeResponse{
+		Status: "OK",
+		Results: []GeocodeResult{{
+			AddressComponents: []AddressComponent{
+				{LongName: "Shelburne", ShortName: "Shelburne", Types: []string{"locality"}},
+				{LongName: "Vermont", ShortName: "VT", Types: []string{"administrative_area_level_1"}},
+				{LongName: "United States", ShortName: "US", Types: []string{"country"}},
+			},
+		}},
+	})
+	_ = json.Unmarshal(data, &raw)
+
+	var hierarchy models.AdminHierarchy
+	BackfillAdminHierarchy(&hierarchy, raw)
+	if hierarchy.City != "Shelburne" || hierarchy.StateISOCode != "US-VT" {
+		t.Errorf("Expected hierarchy from raw response, got %+v", hierarchy)
+	}
+
+	// An existing hierarchy is left alone
+	existing := models.AdminHierarchy{City: "Burlington"}
+	BackfillAdminHierarchy(&existing, raw)
+	if existing.City != "Burlington" {
+		t.Errorf("Expected existing hierarchy to be kept, got %+v", existing)
+	}
+}
+
+func TestLocalizeAdminHierarchy(t *testing.T) {
+	// Google left the names in English
+	hierarchy := models.AdminHierarchy{
+		City:         "Munich",
+		State:        "Bavaria",
+		StateCode:    "BY",
+		StateISOCode: "DE-BY",
+		Country:      "Germany",
+		CountryCode:  "DE",
+	}
+	LocalizeAdminHierarchy(&hierarchy, "fr")
+	if hierarchy.Country != "Allemagne" || hierarchy.State != "Bavière" || hierarchy.City != "Munich" {
+		t.Errorf("Expected French country and state names, got %+v", hierarchy)
+	}
+
+	// Names Google already localized are kept
+	localized := models.AdminHierarchy{
+		State:        "Baviera",
+		StateISOCode: "DE-BY",
+		Country:      "Alemania",
+		CountryCode:  "DE",
+	}
+	LocalizeAdminHierarchy(&localized, "es")
+	if localized.Country != "Alemania" || localized.State != "Baviera" {
+		t.Errorf("Expected backend names to be kept, got %+v", localized)
+	}
+}
@@ -0,0 +1,189 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GeocodeOptions narrows or biases a forward geocoding query. The zero value sends the address alone.
+type GeocodeOptions struct {
+	Language   string            // BCP 47 language for results, e.g. "es" or "pt-BR"
+	Region     string            // Two-letter region code to bias results toward, e.g. "mx"
+	Bounds     *GeocodeBounds    // Viewport to bias results toward
+	Components map[string]string // Hard filters such as country or postal_code
+}
+
+// allowedComponentFilters are the component filters Google accepts for forward geocoding
+var allowedComponentFilters = map[string]bool{
+	"route":               true,
+	"locality":            true,
+	"administrative_area": true,
+	"postal_code":         true,
+	"country":             true,
+}
+
+var (
+	languagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+	regionPattern   = regexp.MustCompile(`^[A-Za-z]{2}$`)
+)
+
+// ParseGeocodeOptions validates the raw request values for language, region, bounds and components.
+// Bounds use Google's "sw_lat,sw_lng|ne_lat,ne_lng" form. Components use Google's "country:US|postal_code:94043"
+// form, or a JSON object such as {"country": "US"} when sent in a JSON request body.
+func ParseGeocodeOptions(language, region, bounds, components string) (GeocodeOptions, error) {
+	var opts GeocodeOptions
+
+	if language = strings.TrimSpace(language); language != "" {
+		if !languagePattern.MatchString(language) {
+			return opts, fmt.Errorf("invalid language %q", language)
+		}
+		opts.Language = language
+	}
+
+	if region = strings.TrimSpace(region); region != "" {
+		if !regionPattern.MatchString(region) {
+			return opts, fmt.Errorf("invalid region %q, expected a two-letter region code", region)
+		}
+		opts.Region = strings.ToLower(region)
+	}
+
+	if bounds = strings.TrimSpace(bounds); bounds != "" {
+		parsed, err := parseBounds(bounds)
+		if err != nil {
+			return opts, err
+		}
+		opts.Bounds = parsed
+	}
+
+	if components = strings.TrimSpace(components); components != "" {
+		parsed, err := parseComponents(components)
+		if err != nil {
+			return opts, err
+		}
+		opts.Components = parsed
+	}
+
+	return opts, nil
+}
+
+func parseBounds(bounds string) (*GeocodeBounds, error) {
+	corners := strings.Split(bounds, "|")
+	if len(corners) != 2 {
+		return nil, fmt.Errorf("invalid bounds %q, expected sw_lat,sw_lng|ne_lat,ne_lng", bounds)
+	}
+
+	var points [2]GeocodeLocation
+	for i, corner := range corners {
+		coords := strings.Split(corner, ",")
+		if len(coords) != 2 {
+			return nil, fmt.Errorf("invalid bounds %q, expected sw_lat,sw_lng|ne_lat,ne_lng", bounds)
+		}
+		lat, latErr := strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
+		lng, lngErr := strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
+		if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+			return nil, fmt.Errorf("invalid bounds corner %q", corner)
+		}
+		points[i] = GeocodeLocation{Lat: lat, Lng: lng}
+	}
+
+	if points[0].Lat > points[1].Lat {
+		return nil, fmt.Errorf("invalid bounds %q, southwest latitude is north of northeast latitude", bounds)
+	}
+
+	return &GeocodeBounds{Southwest: points[0], Northeast: points[1]}, nil
+}
+
+func parseComponents(components string) (map[string]string, error) {
+	filters := map[string]string{}
+
+	if strings.HasPrefix(components, "{") {
+		if err := json.Unmarshal([]byte(components), &filters); err != nil {
+			return nil, fmt.Errorf("invalid components object: %w", err)
+		}
+	} else {
+		for _, filter := range strings.Split(components, "|") {
+			name, value, ok := strings.Cut(filter, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid component filter %q, expected name:value", filter)
+			}
+			filters[strings.TrimSpace(name)] = value
+		}
+	}
+
+	for name, value := range filters {
+		if !allowedComponentFilters[name] {
+			return nil, fmt.Errorf("unsupported component filter %q", name)
+		}
+		value = strings.TrimSpace(value)
+		if value == "" || strings.ContainsAny(value, "|:") {
+			return nil, fmt.Errorf("invalid value for component filter %q", name)
+		}
+		filters[name] = value
+	}
+
+	return filters, nil
+}
+
+// IsZero reports whether no options are set
+func (o GeocodeOptions) IsZero() bool {
+	return o.Language == "" && o.Region == "" && o.Bounds == nil && len(o.Components) == 0
+}
+
+// CacheKey returns a canonical form of the options, so that equivalent requests share a cache entry
+// and differently-biased ones do not. It is empty for the zero value, which keeps existing cache keys valid.
+func (o GeocodeOptions) CacheKey() string {
+	var parts []string
+	if o.Language != "" {
+		parts = append(parts, "language="+strings.ToLower(o.Language))
+	}
+	if o.Region != "" {
+		parts = append(parts, "region="+strings.ToLower(o.Region))
+	}
+	if o.Bounds != nil {
+		parts = append(parts, "bounds="+o.boundsParam())
+	}
+	if filters := o.componentsParam(); filters != "" {
+		parts = append(parts, "components="+strings.ToLower(filters))
+	}
+	return strings.Join(parts, "&")
+}
+
+// apply adds the options to a Google Geocoding API query
+func (o GeocodeOptions) apply(params url.Values) {
+	if o.Language != "" {
+		params.Set("language", o.Language)
+	}
+	if o.Region != "" {
+		params.Set("region", o.Region)
+	}
+	if o.Bounds != nil {
+		params.Set("bounds", o.boundsParam())
+	}
+	if filters := o.componentsParam(); filters != "" {
+		params.Set("components", filters)
+	}
+}
+
+func (o GeocodeOptions) boundsParam() string {
+	return fmt.Sprintf("%.6f,%.6f|%.6f,%.6f",
+		o.Bounds.Southwest.Lat, o.Bounds.Southwest.Lng, o.Bounds.Northeast.Lat, o.Bounds.Northeast.Lng)
+}
+
+func (o GeocodeOptions) componentsParam() string {
+	names := make([]string, 0, len(o.Components))
+	for name := range o.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	filters := make([]string, 0, len(names))
+	for _, name := range names {
+		filters = append(filters, name+":"+o.Components[name])
+	}
+	return strings.Join(filters, "|")
+}
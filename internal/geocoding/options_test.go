@@ -0,0 +1,82 @@
+package geocoding
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseGeocodeOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		language   string
+		region     string
+		bounds     string
+		components string
+		wantErr    bool
+	}{
+		{"No options", "", "", "", "", false},
+		{"All options", "pt-BR", "BR", "-23.7,-46.8|-23.4,-46.3", "country:BR|postal_code:01310", false},
+		{"JSON components", "", "", "", `{"country": "US", "locality": "Burlington"}`, false},
+		{"Invalid language", "spanish!", "", "", "", true},
+		{"Invalid region", "", "USA", "", "", true},
+		{"Bounds missing corner", "", "", "40.7,-74.0", "", true},
+		{"Bounds out of range", "", "", "40.7,-74.0|95.0,-73.9", "", true},
+		{"Bounds inverted", "", "", "41.0,-74.0|40.0,-73.9", "", true},
+		{"Unknown component", "", "", "", "street_number:12", true},
+		{"Malformed component", "", "", "", "country", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGeocodeOptions(tt.language, tt.region, tt.bounds, tt.components)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseGeocodeOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeocodeOptions_CacheKey(t *testing.T) {
+	if key := (GeocodeOptions{}).CacheKey(); key != "" {
+		t.Errorf("Expected empty cache key for zero options, got %q", key)
+	}
+
+	a, err := ParseGeocodeOptions("ES", "mx", "", "country:MX|locality:Oaxaca")
+	if err != nil {
+		t.Fatalf("Failed to parse options: %v", err)
+	}
+	b, err := ParseGeocodeOptions("es", "MX", "", `{"locality": "Oaxaca", "country": "MX"}`)
+	if err != nil {
+		t.Fatalf("Failed to parse options: %v", err)
+	}
+	if a.CacheKey() != b.CacheKey() {
+		t.Errorf("Expected equivalent options to share a cache key, got %q and %q", a.CacheKey(), b.CacheKey())
+	}
+
+	c, _ := ParseGeocodeOptions("en", "mx", "", "country:MX|locality:Oaxaca")
+	if a.CacheKey() == c.CacheKey() {
+		t.Error("Expected different languages to produce different cache keys")
+	}
+}
+
+func TestGeocodeOptions_Apply(t *testing.T) {
+	opts, err := ParseGeocodeOptions("es", "mx", "19.2,-99.3|19.6,-98.9", "postal_code:06600|country:MX")
+	if err != nil {
+		t.Fatalf("Failed to parse options: %v", err)
+	}
+
+	params := url.Values{}
+	opts.apply(params)
+
+	expected := map[string]string{
+		"language":   "es",
+		"region":     "mx",
+		"bounds":     "19.200000,-99.300000|19.600000,-98.900000",
+		"components": "country:MX|postal_code:06600",
+	}
+	for name, value := range expected {
+		if got := params.Get(name); got != value {
+			t.Errorf("Expected %s=%q, got %q", name, value, got)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package geocoding
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// Component verdict statuses
+const (
+	VerdictMatched     = "matched"     // Input agrees with the geocoded component
+	VerdictCorrected   = "corrected"   // Input was provided but the geocoder found a different value
+	VerdictMissing     = "missing"     // Input was empty but the geocoder filled it in
+	VerdictUnconfirmed = "unconfirmed" // Input was provided but the geocoder returned nothing to compare against
+	VerdictSuspicious  = "suspicious"  // Input starts with the geocoded value but isn't a known longer form of it
+)
+
+var (
+	// zipCodePattern and zipPlus4Pattern match a US ZIP code and a ZIP+4 as submitted, with or without the hyphen
+	zipCodePattern  = regexp.MustCompile(`^\d{5}$`)
+	zipPlus4Pattern = regexp.MustCompile(`^(\d{5})[- ]?\d{4}$`)
+	// ukOutwardPattern and ukInwardPattern match the two halves of a UK postcode, e.g. "SW1A" and "1AA"
+	ukOutwardPattern = regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]?$`)
+	ukInwardPattern  = regexp.MustCompile(`^\d[A-Z]{2}$`)
+)
+
+// Granularity levels, from most to least precise
+const (
+	GranularityRooftop      = "rooftop"
+	GranularityInterpolated = "interpolated"
+	GranularityStreet       = "street"
+	GranularityPostalCode   = "postal_code"
+	GranularityCity         = "city"
+	GranularityRegion       = "region"
+	GranularityCountry      = "country"
+	GranularityUnknown      = "unknown"
+	GranularityNone         = "none"
+)
+
+// streetAbbreviations maps common street suffixes and directionals to the short form Google uses
+var streetAbbreviations = map[string]string{
+	"street": "st", "avenue": "ave", "boulevard": "blvd", "road": "rd", "drive": "dr",
+	"lane": "ln", "court": "ct", "place": "pl", "parkway": "pkwy", "highway": "hwy",
+	"terrace": "ter", "circle": "cir", "square": "sq", "trail": "trl", "way": "way",
+	"north": "n", "south": "s", "east": "e", "west": "w",
+	"northeast": "ne", "northwest": "nw", "southeast": "se", "southwest": "sw",
+	"suite": "ste", "apartment": "apt", "floor": "fl", "unit": "unit",
+}
+
+// geocodedComponents holds the long and short forms of the address components of a Google result
+type geocodedComponents struct {
+	addressLine1, addressLine1Short string
+	subpremise                      string
+	city                            string
+	state, stateShort               string
+	postalCode                      string
+	country, countryShort           string
+}
+
+// ValidateStructuredAddress compares a submitted structured address against the best Google result
+// and reports a verdict for every field, the precision of the match and a suggested corrected address.
+// A nil or empty response yields an unresolved verdict rather than an error.
+func ValidateStructuredAddress(input models.StructuredAddress, resp *GeocodeResponse) *models.AddressValidationResponse {
+	validation := &models.AddressValidationResponse{
+		Granularity: GranularityNone,
+		Backend:     "google_maps_platform_geocoding",
+	}
+
+	if resp == nil || len(resp.Results) == 0 {
+		validation.PartialMatch = true
+		validation.Components = models.AddressComponentVerdicts{
+			AddressLine1: compareComponent(input.AddressLine1),
+			AddressLine2: compareComponent(input.AddressLine2),
+			City:         compareComponent(input.City),
+			State:        compareComponent(input.State),
+			PostalCode:   compareComponent(input.PostalCode),
+			Country:      compareComponent(input.Country),
+		}
+		validation.SuggestedAddress = input
+		return validation
+	}
+
+	result := resp.Results[0]
+	geocoded := extractGeocodedComponents(result.AddressComponents)
+
+	validation.Lat = result.Geometry.Location.Lat
+	validation.Lng = result.Geometry.Location.Lng
+	validation.FormattedAddress = result.FormattedAddress
+	validation.Granularity = resultGranularity(result)
+	validation.AdminHierarchy = ExtractAdminHierarchy(result.AddressComponents)
+	validation.Components = models.AddressComponentVerdicts{
+		AddressLine1: compareComponent(input.AddressLine1, geocoded.addressLine1, geocoded.addressLine1Short),
+		AddressLine2: compareComponent(input.AddressLine2, geocoded.subpremise),
+		City:         compareComponent(input.City, geocoded.city),
+		State:        compareComponent(input.State, geocoded.state, geocoded.stateShort),
+		PostalCode:   comparePostalCode(input.PostalCode, geocoded.postalCode),
+		Country:      compareComponent(input.Country, geocoded.country, geocoded.countryShort),
+	}
+
+	validation.SuggestedAddress = models.StructuredAddress{
+		AddressLine1: firstNonEmpty(geocoded.addressLine1, input.AddressLine1),
+		AddressLine2: firstNonEmpty(geocoded.subpremise, input.AddressLine2),
+		City:         firstNonEmpty(geocoded.city, input.City),
+		State:        firstNonEmpty(geocoded.stateShort, input.State),
+		PostalCode:   firstNonEmpty(geocoded.postalCode, input.PostalCode),
+		Country:      firstNonEmpty(geocoded.countryShort, input.Country),
+	}
+
+	// Google flags results that only matched part of the query; a correction on our side means the same
+	validation.PartialMatch = result.PartialMatch
+	allMatched := true
+	for _, verdict := range []*models.ComponentVerdict{
+		validation.Components.AddressLine1, validation.Components.AddressLine2, validation.Components.City,
+		validation.Components.State, validation.Components.PostalCode, validation.Components.Country,
+	} {
+		if verdict == nil {
+			continue
+		}
+		if verdict.Status == VerdictCorrected || verdict.Status == VerdictUnconfirmed || verdict.Status == VerdictSuspicious {
+			validation.PartialMatch = true
+			allMatched = false
+		}
+	}
+
+	// An address is only deliverable when it resolves to a building or an interpolated street number
+	preciseEnough := validation.Granularity == GranularityRooftop || validation.Granularity == GranularityInterpolated
+	validation.Valid = allMatched && !validation.PartialMatch && preciseEnough
+
+	return validation
+}
+
+// extractGeocodedComponents pulls the fields we compare against out of Google's address components
+func extractGeocodedComponents(components []AddressComponent) geocodedComponents {
+	hierarchy := ExtractAdminHierarchy(components)
+	g := geocodedComponents{
+		city:         hierarchy.City,
+		state:        hierarchy.State,
+		stateShort:   hierarchy.StateCode,
+		postalCode:   hierarchy.PostalCode,
+		country:      hierarchy.Country,
+		countryShort: hierarchy.CountryCode,
+	}
+	var streetNumber, route, routeShort string
+
+	for _, component := range components {
+		for _, componentType := range component.Types {
+			switch componentType {
+			case "street_number":
+				streetNumber = component.LongName
+			case "route":
+				route = component.LongName
+				routeShort = component.ShortName
+			case "subpremise":
+				g.subpremise = component.LongName
+			}
+		}
+	}
+
+	g.addressLine1 = strings.TrimSpace(streetNumber + " " + route)
+	g.addressLine1Short = strings.TrimSpace(streetNumber + " " + routeShort)
+
+	return g
+}
+
+// resultGranularity reports how precisely a Google result pins down the address
+func resultGranularity(result GeocodeResult) string {
+	switch result.Geometry.LocationType {
+	case "ROOFTOP":
+		return GranularityRooftop
+	case "RANGE_INTERPOLATED":
+		return GranularityInterpolated
+	}
+
+	levels := []struct {
+		granularity string
+		types       []string
+	}{
+		{GranularityRooftop, []string{"street_address", "premise", "subpremise"}},
+		{GranularityStreet, []string{"route", "intersection"}},
+		{GranularityPostalCode, []string{"postal_code"}},
+		{GranularityCity, []string{"locality", "postal_town", "sublocality", "neighborhood", "administrative_area_level_3"}},
+		{GranularityRegion, []string{"administrative_area_level_1", "administrative_area_level_2"}},
+		{GranularityCountry, []string{"country"}},
+	}
+
+	for _, level := range levels {
+		for _, want := range level.types {
+			for _, resultType := range result.Types {
+				if resultType == want {
+					return level.granularity
+				}
+			}
+		}
+	}
+
+	return GranularityUnknown
+}
+
+// compareComponent builds a verdict for one field; candidates are the geocoded forms it may match.
+// It returns nil when the field was neither submitted nor geocoded.
+func compareComponent(input string, candidates ...string) *models.ComponentVerdict {
+	input = strings.TrimSpace(input)
+
+	geocoded := ""
+	for _, candidate := range candidates {
+		if candidate != "" {
+			geocoded = candidate
+			break
+		}
+	}
+
+	switch {
+	case input == "" && geocoded == "":
+		return nil
+	case input == "":
+		return &models.ComponentVerdict{Status: VerdictMissing, Geocoded: geocoded}
+	case geocoded == "":
+		return &models.ComponentVerdict{Status: VerdictUnconfirmed, Input: input}
+	}
+
+	normalizedInput := normalizeComponent(input)
+	for _, candidate := range candidates {
+		if candidate != "" && normalizeComponent(candidate) == normalizedInput {
+			return &models.ComponentVerdict{Status: VerdictMatched, Input: input, Geocoded: geocoded}
+		}
+	}
+
+	return &models.ComponentVerdict{Status: VerdictCorrected, Input: input, Geocoded: geocoded}
+}
+
+// comparePostalCode compares postal codes ignoring spacing. A ZIP+4 matches its ZIP, and a full UK
+// postcode matches the outward code Google sometimes returns on its own. Any other input that merely
+// starts with the geocoded code, such as 123456 for 12345, is suspicious rather than matched.
+func comparePostalCode(input, geocoded string) *models.ComponentVerdict {
+	verdict := compareComponent(input, geocoded)
+	if verdict == nil || verdict.Status != VerdictCorrected {
+		return verdict
+	}
+
+	normalizedInput, normalizedGeocoded := normalizePostalCode(input), normalizePostalCode(geocoded)
+	switch {
+	case normalizedInput == normalizedGeocoded, isExtendedPostalCode(strings.TrimSpace(input), normalizedInput, normalizedGeocoded):
+		verdict.Status = VerdictMatched
+	case strings.HasPrefix(normalizedInput, normalizedGeocoded):
+		verdict.Status = VerdictSuspicious
+	}
+
+	return verdict
+}
+
+// isExtendedPostalCode reports whether input is a longer form of the geocoded code: its ZIP+4, or
+// the full postcode for a UK outward code
+func isExtendedPostalCode(input, normalizedInput, normalizedGeocoded string) bool {
+	if zipCodePattern.MatchString(normalizedGeocoded) {
+		match := zipPlus4Pattern.FindStringSubmatch(input)
+		return match != nil && match[1] == normalizedGeocoded
+	}
+	if ukOutwardPattern.MatchString(normalizedGeocoded) {
+		inward, ok := strings.CutPrefix(normalizedInput, normalizedGeocoded)
+		return ok && ukInwardPattern.MatchString(inward)
+	}
+	return false
+}
+
+// normalizeComponent lowercases, strips punctuation and abbreviates common street words
+func normalizeComponent(value string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, value)
+
+	words := strings.Fields(cleaned)
+	for i, word := range words {
+		if abbreviation, ok := streetAbbreviations[word]; ok {
+			words[i] = abbreviation
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+func normalizePostalCode(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, value)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
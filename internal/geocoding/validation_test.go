@@ -0,0 +1,205 @@
+package geocoding
+
+import (
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+func googleplexResponse(locationType string, partialMatch bool) *GeocodeResponse {
+	return &GeocodeResponse{
+		Status: "OK",
+		Results: []GeocodeResult{
+			{
+				FormattedAddress: "1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA",
+				Geometry: GeocodeGeometry{
+					Location:     GeocodeLocation{Lat: 37.4224764, Lng: -122.0842499},
+					LocationType: locationType,
+				},
+				Types:        []string{"street_address"},
+				PartialMatch: partialMatch,
+				AddressComponents: []AddressComponent{
+					{LongName: "1600", ShortName: "1600", Types: []string{"street_number"}},
+					{LongName: "Amphitheatre Parkway", ShortName: "Amphitheatre Pkwy", Types: []string{"route"}},
+					{LongName: "Mountain View", ShortName: "Mountain View", Types: []string{"locality", "political"}},
+					{LongName: "California", ShortName: "CA", Types: []string{"administrative_area_level_1", "political"}},
+					{LongName: "United States", ShortName: "US", Types: []string{"country", "political"}},
+					{LongName: "94043", ShortName: "94043", Types: []string{"postal_code"}},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateStructuredAddress_AllMatched(t *testing.T) {
+	input := models.StructuredAddress{
+		AddressLine1: "1600 Amphitheatre Pkwy.",
+		City:         "mountain view",
+		State:        "CA",
+		PostalCode:   "94043-1351",
+		Country:      "United States",
+	}
+
+	validation := ValidateStructuredAddress(input, googleplexResponse("ROOFTOP", false))
+
+	if !validation.Valid {
+		t.Errorf("Expected address to be valid, got %+v", validation)
+	}
+	if validation.PartialMatch {
+		t.Error("Expected partial_match to be false")
+	}
+	if validation.Granularity != GranularityRooftop {
+		t.Errorf("Expected granularity %q, got %q", GranularityRooftop, validation.Granularity)
+	}
+	if validation.Components.AddressLine2 != nil {
+		t.Errorf("Expected no verdict for address_line_2, got %+v", validation.Components.AddressLine2)
+	}
+
+	for name, verdict := range map[string]*models.ComponentVerdict{
+		"address_line_1": validation.Components.AddressLine1,
+		"city":           validation.Components.City,
+		"state":          validation.Components.State,
+		"postal_code":    validation.Components.PostalCode,
+		"country":        validation.Components.Country,
+	} {
+		if verdict == nil || verdict.Status != VerdictMatched {
+			t.Errorf("Expected %s to be matched, got %+v", name, verdict)
+		}
+	}
+}
+
+func TestValidateStructuredAddress_CorrectedAndMissing(t *testing.T) {
+	input := models.StructuredAddress{
+		AddressLine1: "1600 Amphitheatre Parkway",
+		City:         "Palo Alto",
+		State:        "CA",
+	}
+
+	validation := ValidateStructuredAddress(input, googleplexResponse("ROOFTOP", false))
+
+	if validation.Valid {
+		t.Error("Expected address with a corrected city to be invalid")
+	}
+	if !validation.PartialMatch {
+		t.Error("Expected partial_match to be true")
+	}
+	if validation.Components.City.Status != VerdictCorrected {
+		t.Errorf("Expected city to be corrected, got %q", validation.Components.City.Status)
+	}
+	if validation.Components.PostalCode.Status != VerdictMissing {
+		t.Errorf("Expected postal_code to be missing, got %q", validation.Components.PostalCode.Status)
+	}
+	if validation.SuggestedAddress.City != "Mountain View" || validation.SuggestedAddress.PostalCode != "94043" {
+		t.Errorf("Unexpected suggested address: %+v", validation.SuggestedAddress)
+	}
+}
+
+func TestValidateStructuredAddress_Granularity(t *testing.T) {
+	tests := []struct {
+		name         string
+		locationType string
+		types        []string
+		expected     string
+	}{
+		{"Rooftop", "ROOFTOP", []string{"street_address"}, GranularityRooftop},
+		{"Interpolated", "RANGE_INTERPOLATED", []string{"street_address"}, GranularityInterpolated},
+		{"Street", "GEOMETRIC_CENTER", []string{"route"}, GranularityStreet},
+		{"City centroid", "APPROXIMATE", []string{"locality", "political"}, GranularityCity},
+		{"Country", "APPROXIMATE", []string{"country", "political"}, GranularityCountry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := googleplexResponse(tt.locationType, false)
+			resp.Results[0].Types = tt.types
+
+			validation := ValidateStructuredAddress(models.StructuredAddress{City: "Mountain View"}, resp)
+			if validation.Granularity != tt.expected {
+				t.Errorf("Expected granularity %q, got %q", tt.expected, validation.Granularity)
+			}
+		})
+	}
+}
+
+func TestValidateStructuredAddress_GooglePartialMatch(t *testing.T) {
+	input := models.StructuredAddress{AddressLine1: "1600 Amphitheatre Parkway", City: "Mountain View"}
+
+	validation := ValidateStructuredAddress(input, googleplexResponse("ROOFTOP", true))
+
+	if !validation.PartialMatch || validation.Valid {
+		t.Errorf("Expected Google's partial_match flag to make the address invalid, got %+v", validation)
+	}
+}
+
+func TestValidateStructuredAddress_NoResults(t *testing.T) {
+	input := models.StructuredAddress{AddressLine1: "1 Nowhere Lane", Country: "US"}
+
+	validation := ValidateStructuredAddress(input, &GeocodeResponse{Status: "ZERO_RESULTS"})
+
+	if validation.Valid {
+		t.Error("Expected unresolved address to be invalid")
+	}
+	if validation.Granularity != GranularityNone {
+		t.Errorf("Expected granularity %q, got %q", GranularityNone, validation.Granularity)
+	}
+	if validation.Components.AddressLine1.Status != VerdictUnconfirmed {
+		t.Errorf("Expected address_line_1 to be unconfirmed, got %q", validation.Components.AddressLine1.Status)
+	}
+}
+
+func TestComparePostalCode(t *testing.T) {
+	tests := []struct {
+		input, geocoded string
+		expected        string
+	}{
+		{"94043", "94043", VerdictMatched},
+		{"94043-1351", "94043", VerdictMatched},
+		{"940431351", "94043", VerdictMatched},
+		{"SW1A 1AA", "SW1A", VerdictMatched},
+		{"sw1a1aa", "SW1A 1AA", VerdictMatched},
+		{"123456", "12345", VerdictSuspicious},
+		{"94043-13", "94043", VerdictSuspicious},
+		{"SW1A 1A", "SW1A", VerdictSuspicious},
+		{"75001", "75008", VerdictCorrected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input+"/"+tt.geocoded, func(t *testing.T) {
+			verdict := comparePostalCode(tt.input, tt.geocoded)
+			if verdict == nil || verdict.Status != tt.expected {
+				t.Errorf("Expected %q, got %+v", tt.expected, verdict)
+			}
+		})
+	}
+}
+
+func TestDecodeRawResponse(t *testing.T) {
+	original := googleplexResponse("ROOFTOP", false)
+
+	// Cached responses come back as generic maps
+	raw := map[string]interface{}{
+		"status": "OK",
+		"results": []interface{}{
+			map[string]interface{}{
+				"formatted_address": original.Results[0].FormattedAddress,
+				"geometry": map[string]interface{}{
+					"location":      map[string]interface{}{"lat": 37.4224764, "lng": -122.0842499},
+					"location_type": "ROOFTOP",
+				},
+			},
+		},
+	}
+
+	decoded, err := DecodeRawResponse(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(decoded.Results) != 1 || decoded.Results[0].Geometry.LocationType != "ROOFTOP" {
+		t.Errorf("Unexpected decoded response: %+v", decoded)
+	}
+
+	same, err := DecodeRawResponse(original)
+	if err != nil || same != original {
+		t.Error("Expected a *GeocodeResponse to be returned as is")
+	}
+}
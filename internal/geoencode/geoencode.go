@@ -0,0 +1,171 @@
+// Package geoencode converts coordinates to and from geohashes, Open Location Codes (Plus Codes)
+// and H3 cell indexes, for bucketing locations without storing them exactly. Everything is
+// computed locally.
+package geoencode
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// Formats, as named in the encodings parameter and decode responses
+const (
+	Geohash  = "geohash"
+	PlusCode = "pluscode"
+	H3       = "h3"
+)
+
+// Default precisions are each format's closest fit to a building
+const (
+	DefaultGeohashPrecision = 9  // About 5m by 5m
+	DefaultPlusCodeLength   = 10 // About 14m by 14m
+	DefaultH3Resolution     = 9  // Hexagons of about 0.1 km²
+)
+
+var (
+	// ErrInvalidCode is returned for a code that isn't valid in its format
+	ErrInvalidCode = errors.New("invalid code")
+	// ErrInvalidPrecision is returned for a precision the format doesn't support
+	ErrInvalidPrecision = errors.New("invalid precision")
+	// ErrUnknownFormat is returned for a format other than geohash, pluscode or h3
+	ErrUnknownFormat = errors.New("unknown encoding")
+)
+
+// Spec asks for one format at a precision
+type Spec struct {
+	Format    string
+	Precision int
+}
+
+// DefaultSpecs asks for every format at its default precision
+func DefaultSpecs() []Spec {
+	return []Spec{{Geohash, DefaultGeohashPrecision}, {PlusCode, DefaultPlusCodeLength}, {H3, DefaultH3Resolution}}
+}
+
+// ParseSpecs reads a comma-separated list of formats, each optionally followed by a colon and a
+// precision, e.g. "geohash:7,h3". An empty list asks for nothing.
+func ParseSpecs(value string) ([]Spec, error) {
+	var specs []Spec
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, precision, hasPrecision := strings.Cut(part, ":")
+		spec := Spec{Format: strings.ToLower(strings.TrimSpace(name))}
+		switch spec.Format {
+		case Geohash:
+			spec.Precision = DefaultGeohashPrecision
+		case PlusCode:
+			spec.Precision = DefaultPlusCodeLength
+		case H3:
+			spec.Precision = DefaultH3Resolution
+		default:
+			return nil, fmt.Errorf("%w %q: use geohash, pluscode or h3", ErrUnknownFormat, name)
+		}
+		if seen[spec.Format] {
+			return nil, fmt.Errorf("%w: %s is listed twice", ErrUnknownFormat, spec.Format)
+		}
+		seen[spec.Format] = true
+
+		if hasPrecision {
+			p, err := strconv.Atoi(strings.TrimSpace(precision))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s precision must be a whole number", ErrInvalidPrecision, spec.Format)
+			}
+			spec.Precision = p
+		}
+		// Encode a point to check the precision now, rather than after an upstream lookup
+		if _, err := Encode(0, 0, []Spec{spec}); err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// Encode returns a point in each of the formats asked for
+func Encode(lat, lng float64, specs []Spec) (*models.Encodings, error) {
+	var encodings models.Encodings
+	var err error
+	for _, spec := range specs {
+		switch spec.Format {
+		case Geohash:
+			encodings.Geohash, err = EncodeGeohash(lat, lng, spec.Precision)
+		case PlusCode:
+			encodings.PlusCode, err = EncodePlusCode(lat, lng, spec.Precision)
+		case H3:
+			encodings.H3, err = LatLngToH3(lat, lng, spec.Precision)
+		default:
+			err = fmt.Errorf("%w %q", ErrUnknownFormat, spec.Format)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &encodings, nil
+}
+
+// DetectFormat guesses a code's format: Plus Codes have a separator and H3 indexes are 15
+// hexadecimal digits, which is longer than any geohash
+func DetectFormat(code string) string {
+	if strings.ContainsRune(code, plusCodeSeparator) {
+		return PlusCode
+	}
+	if _, err := strconv.ParseUint(code, 16, 64); err == nil && len(code) == 15 {
+		return H3
+	}
+	return Geohash
+}
+
+// Decode returns the cell a code stands for. An empty format is detected from the code.
+func Decode(code, format string) (*models.DecodeResponse, error) {
+	code = strings.TrimSpace(code)
+	if format == "" {
+		format = DetectFormat(code)
+	}
+
+	result := &models.DecodeResponse{Type: format}
+	switch format {
+	case Geohash:
+		bounds, err := DecodeGeohash(code)
+		if err != nil {
+			return nil, err
+		}
+		result.Code = strings.ToLower(code)
+		result.Precision = len(code)
+		result.Bounds = &bounds
+	case PlusCode:
+		bounds, length, err := DecodePlusCode(code)
+		if err != nil {
+			return nil, err
+		}
+		// The northernmost cells are clipped at the pole
+		bounds.North = math.Min(bounds.North, 90)
+		result.Code = strings.ToUpper(code)
+		result.Precision = length
+		result.Bounds = &bounds
+	case H3:
+		lat, lng, res, err := H3ToLatLng(strings.ToLower(code))
+		if err != nil {
+			return nil, err
+		}
+		result.Code = strings.ToLower(code)
+		result.Precision = res
+		result.Lat, result.Lng = lat, lng
+		return result, nil
+	default:
+		return nil, fmt.Errorf("%w %q: use geohash, pluscode or h3", ErrUnknownFormat, format)
+	}
+
+	result.Lat = (result.Bounds.South + result.Bounds.North) / 2
+	result.Lng = (result.Bounds.West + result.Bounds.East) / 2
+	return result, nil
+}
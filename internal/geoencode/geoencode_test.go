@@ -0,0 +1,237 @@
+package geoencode
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeGeohash(t *testing.T) {
+	code, err := EncodeGeohash(57.64911, 10.40744, 11)
+	if err != nil || code != "u4pruydqqvj" {
+		t.Errorf("EncodeGeohash() = %q, %v, want u4pruydqqvj", code, err)
+	}
+
+	bounds, err := DecodeGeohash("U4PRUYDQQVJ")
+	if err != nil {
+		t.Fatalf("DecodeGeohash() error = %v", err)
+	}
+	if bounds.South > 57.64911 || bounds.North < 57.64911 || bounds.West > 10.40744 || bounds.East < 10.40744 {
+		t.Errorf("expected bounds around the point, got %+v", bounds)
+	}
+
+	for _, precision := range []int{0, 13} {
+		if _, err := EncodeGeohash(0, 0, precision); !errors.Is(err, ErrInvalidPrecision) {
+			t.Errorf("precision %d: expected ErrInvalidPrecision, got %v", precision, err)
+		}
+	}
+	for _, code := range []string{"", "u4pa", "u4pruydqqvjxx"} {
+		if _, err := DecodeGeohash(code); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("%q: expected ErrInvalidCode, got %v", code, err)
+		}
+	}
+}
+
+func TestEncodePlusCode(t *testing.T) {
+	// From the Open Location Code test data
+	tests := []struct {
+		lat, lng float64
+		length   int
+		want     string
+	}{
+		{47.365590, 8.524997, 10, "8FVC9G8F+6X"},
+		{47.365590, 8.524997, 11, "8FVC9G8F+6XQ"},
+		{47.365590, 8.524997, 4, "8FVC0000+"},
+		{20.3700625, 2.7821875, 10, "7FG49QCJ+2V"},
+		{47.0000625, 8.0000625, 15, "8FVC2222+22GCCCC"},
+		{-41.2730625, 174.7859375, 10, "4VCPPQGP+Q9"},
+		{90, 1, 10, "CFX3X2X2+X2"},
+		{1, 180, 10, "62H22222+22"},
+	}
+	for _, tt := range tests {
+		got, err := EncodePlusCode(tt.lat, tt.lng, tt.length)
+		if err != nil || got != tt.want {
+			t.Errorf("EncodePlusCode(%v, %v, %d) = %q, %v, want %q", tt.lat, tt.lng, tt.length, got, err, tt.want)
+			continue
+		}
+
+		bounds, length, err := DecodePlusCode(got)
+		if err != nil || length != tt.length {
+			t.Errorf("DecodePlusCode(%q) length = %d, %v", got, length, err)
+		}
+		if tt.lat < 90 && (bounds.South > tt.lat || bounds.North <= tt.lat) {
+			t.Errorf("DecodePlusCode(%q) = %+v, expected it to contain latitude %v", got, bounds, tt.lat)
+		}
+	}
+
+	for _, length := range []int{1, 3, 9, 16} {
+		if _, err := EncodePlusCode(0, 0, length); !errors.Is(err, ErrInvalidPrecision) {
+			t.Errorf("length %d: expected ErrInvalidPrecision, got %v", length, err)
+		}
+	}
+}
+
+func TestDecodePlusCode_Invalid(t *testing.T) {
+	codes := []string{
+		"8FVC9G8F",         // No separator
+		"9G8F+6X",          // Short code
+		"8FVC9G8F6+X",      // Separator too late
+		"8FVC9G8F+6",       // One digit after the separator
+		"8FVC0000+6X",      // Digits after padding
+		"8F0C0000+",        // Padding in the middle of a pair
+		"8FVC9G8F+6XA",     // Not in the alphabet
+		"XFVC9G8F+6X",      // Beyond the poles
+		"8FVC9G8F+6XQQQQ1", // Too long
+	}
+	for _, code := range codes {
+		if _, _, err := DecodePlusCode(code); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("%q: expected ErrInvalidCode, got %v", code, err)
+		}
+	}
+}
+
+func TestLatLngToH3(t *testing.T) {
+	// From the H3 documentation and examples
+	tests := []struct {
+		lat, lng float64
+		res      int
+		want     string
+	}{
+		{37.3615593, -122.0553238, 7, "87283472bffffff"},
+		{37.769377, -122.388903, 9, "89283082e73ffff"},
+		{40.689167, -74.044444, 10, "8a2a1072b59ffff"},
+		{90, 0, 0, "8001fffffffffff"},
+	}
+	for _, tt := range tests {
+		got, err := LatLngToH3(tt.lat, tt.lng, tt.res)
+		if err != nil || got != tt.want {
+			t.Errorf("LatLngToH3(%v, %v, %d) = %q, %v, want %q", tt.lat, tt.lng, tt.res, got, err, tt.want)
+		}
+	}
+
+	lat, lng, res, err := H3ToLatLng("87283472bffffff")
+	if err != nil || res != 7 || math.Abs(lat-37.35171820183272) > 1e-9 || math.Abs(lng+122.05032565263946) > 1e-9 {
+		t.Errorf("H3ToLatLng() = %v, %v, %d, %v", lat, lng, res, err)
+	}
+
+	for _, cell := range []string{"", "87283472bfffff", "87283472b000fff", "8009fffffffffff0", "8f0000000000fff", "80f5fffffffffff"} {
+		if _, _, _, err := H3ToLatLng(cell); !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("%q: expected ErrInvalidCode, got %v", cell, err)
+		}
+	}
+}
+
+// TestH3RoundTrip checks that every cell's centre is indexed back to the same cell, around the
+// pentagons, where the faces' coordinate systems meet, and across the globe
+func TestH3RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var points [][2]float64
+	for _, info := range baseCells {
+		if !info.isPentagon {
+			continue
+		}
+		lat, lng := faceIJKToGeo(info.home, 0)
+		for n := 0; n < 50; n++ {
+			pLat, pLng := destination(lat, lng, rng.Float64()*2*math.Pi, rng.Float64()*0.2)
+			points = append(points, [2]float64{pLat * 180 / math.Pi, pLng * 180 / math.Pi})
+		}
+	}
+	for n := 0; n < 1000; n++ {
+		points = append(points, [2]float64{math.Asin(2*rng.Float64()-1) * 180 / math.Pi, rng.Float64()*360 - 180})
+	}
+
+	for _, p := range points {
+		for res := 0; res <= MaxH3Resolution; res++ {
+			cell, err := LatLngToH3(p[0], p[1], res)
+			if err != nil {
+				t.Fatalf("LatLngToH3(%v, %v, %d) error = %v", p[0], p[1], res, err)
+			}
+			lat, lng, gotRes, err := H3ToLatLng(cell)
+			if err != nil || gotRes != res {
+				t.Fatalf("H3ToLatLng(%q) = %d, %v", cell, gotRes, err)
+			}
+			if again, _ := LatLngToH3(lat, lng, res); again != cell {
+				t.Fatalf("centre of %s at (%v, %v) indexes to %s", cell, lat, lng, again)
+			}
+
+			// A point is never further from its cell's centre than about one edge length
+			edgeKm := 1107.7 / math.Pow(sqrt7, float64(res))
+			distanceKm := chordDistance(geoToVec3(p[0]*math.Pi/180, p[1]*math.Pi/180), geoToVec3(lat*math.Pi/180, lng*math.Pi/180)) * 6371
+			if distanceKm > 1.3*edgeKm {
+				t.Fatalf("%s: point (%v, %v) is %.3fkm from the centre", cell, p[0], p[1], distanceKm)
+			}
+		}
+	}
+}
+
+func TestParseSpecs(t *testing.T) {
+	specs, err := ParseSpecs(" geohash:7, H3 ,pluscode:11")
+	if err != nil {
+		t.Fatalf("ParseSpecs() error = %v", err)
+	}
+	want := []Spec{{Geohash, 7}, {H3, DefaultH3Resolution}, {PlusCode, 11}}
+	if len(specs) != len(want) {
+		t.Fatalf("ParseSpecs() = %+v, want %+v", specs, want)
+	}
+	for i := range want {
+		if specs[i] != want[i] {
+			t.Errorf("spec %d = %+v, want %+v", i, specs[i], want[i])
+		}
+	}
+
+	if specs, err := ParseSpecs(""); err != nil || specs != nil {
+		t.Errorf("ParseSpecs(\"\") = %+v, %v, want nothing", specs, err)
+	}
+
+	invalid := map[string]error{
+		"s2":           ErrUnknownFormat,
+		"h3,h3:5":      ErrUnknownFormat,
+		"h3:16":        ErrInvalidPrecision,
+		"geohash:fine": ErrInvalidPrecision,
+		"pluscode:9":   ErrInvalidPrecision,
+	}
+	for value, wantErr := range invalid {
+		if _, err := ParseSpecs(value); !errors.Is(err, wantErr) {
+			t.Errorf("ParseSpecs(%q) error = %v, want %v", value, err, wantErr)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		code, format, wantType string
+		wantPrecision          int
+	}{
+		{"u4pruydqqvj", "", Geohash, 11},
+		{"8fvc9g8f+6x", "", PlusCode, 10},
+		{"87283472bffffff", "", H3, 7},
+		{"87283472b", Geohash, Geohash, 9},
+	}
+	for _, tt := range tests {
+		result, err := Decode(tt.code, tt.format)
+		if err != nil {
+			t.Errorf("Decode(%q) error = %v", tt.code, err)
+			continue
+		}
+		if result.Type != tt.wantType || result.Precision != tt.wantPrecision {
+			t.Errorf("Decode(%q) = %+v, want type %s precision %d", tt.code, result, tt.wantType, tt.wantPrecision)
+		}
+		if tt.wantType == H3 && result.Bounds != nil {
+			t.Errorf("Decode(%q) should have no bounds for an H3 cell", tt.code)
+		}
+	}
+
+	result, _ := Decode("8FVC9G8F+6X", "")
+	if result.Code != "8FVC9G8F+6X" || math.Abs(result.Lat-47.3655625) > 1e-9 || math.Abs(result.Lng-8.5249375) > 1e-9 {
+		t.Errorf("expected the centre of the Plus Code cell, got %+v", result)
+	}
+
+	// Too long for a geohash once the type is forced
+	if _, err := Decode("87283472bffffff", Geohash); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expected ErrInvalidCode decoding an H3 index as a geohash, got %v", err)
+	}
+	if _, err := Decode("u4pruydqqvj", "s2"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package geoencode
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+const (
+	// MaxGeohashPrecision is 12 characters, a cell of a few centimetres
+	MaxGeohashPrecision = 12
+
+	geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+)
+
+// EncodeGeohash returns the geohash of a point with 1 to 12 characters
+func EncodeGeohash(lat, lng float64, precision int) (string, error) {
+	if precision < 1 || precision > MaxGeohashPrecision {
+		return "", fmt.Errorf("%w: geohash precision must be between 1 and %d", ErrInvalidPrecision, MaxGeohashPrecision)
+	}
+
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+	lat = math.Max(-90, math.Min(90, lat))
+	lng = normalizeLng(lng)
+
+	// Bits alternate between longitude and latitude, starting with longitude, five to a character
+	var code strings.Builder
+	even := true
+	for code.Len() < precision {
+		index := 0
+		for bit := 4; bit >= 0; bit-- {
+			r, v := &latRange, lat
+			if even {
+				r, v = &lngRange, lng
+			}
+			mid := (r[0] + r[1]) / 2
+			if v >= mid {
+				index |= 1 << bit
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+		code.WriteByte(geohashAlphabet[index])
+	}
+	return code.String(), nil
+}
+
+// DecodeGeohash returns the cell a geohash covers. Upper case is accepted.
+func DecodeGeohash(code string) (models.CellBounds, error) {
+	if code == "" || len(code) > MaxGeohashPrecision {
+		return models.CellBounds{}, fmt.Errorf("%w: a geohash has 1 to %d characters", ErrInvalidCode, MaxGeohashPrecision)
+	}
+
+	bounds := models.CellBounds{South: -90, West: -180, North: 90, East: 180}
+	even := true
+	for _, c := range strings.ToLower(code) {
+		index := strings.IndexRune(geohashAlphabet, c)
+		if index < 0 {
+			return models.CellBounds{}, fmt.Errorf("%w: %q is not a geohash character", ErrInvalidCode, c)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			set := index&(1<<bit) != 0
+			if even {
+				mid := (bounds.West + bounds.East) / 2
+				if set {
+					bounds.West = mid
+				} else {
+					bounds.East = mid
+				}
+			} else {
+				mid := (bounds.South + bounds.North) / 2
+				if set {
+					bounds.South = mid
+				} else {
+					bounds.North = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return bounds, nil
+}
+
+// normalizeLng wraps a longitude into [-180, 180)
+func normalizeLng(lng float64) float64 {
+	lng = math.Mod(lng+180, 360)
+	if lng < 0 {
+		lng += 360
+	}
+	return lng - 180
+}
@@ -0,0 +1,988 @@
+package geoencode
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// This is a port of the cell indexing half of Uber's H3 library (https://h3geo.org): cells are
+// found by gnomonic projection onto the faces of an icosahedron, and indexed as one of 122 base
+// cells followed by aperture-7 child digits. Only the base cell layout is kept as a table; which
+// base cell a face coordinate falls in, and the face-to-face transforms, are derived from the
+// icosahedron when the package loads.
+
+const (
+	// MaxH3Resolution is H3's finest resolution, about a square metre
+	MaxH3Resolution = 15
+
+	h3CellMode    = 1
+	h3ModeOffset  = 59
+	h3ResOffset   = 52
+	h3BaseOffset  = 45
+	h3DigitBits   = 3
+	h3DigitMask   = 7
+	h3AllDigits   = uint64(1)<<h3BaseOffset - 1
+	numBaseCells  = 122
+	numFaces      = 20
+	maxFaceCoord  = 2
+	invalidDigit  = 7
+	h3Epsilon     = 1e-16
+	sqrt7         = 2.6457513110645905905016157536392604257102
+	sin60         = 0.8660254037844386467637231707529361834714
+	ap7RotRads    = 0.333473172251832115336090755351601070065900389 // Rotation between Class II and Class III axes
+	res0UGnomonic = 0.38196601125010500003                          // Gnomonic length of a resolution 0 unit
+)
+
+// Cell digits, named for the ijk axes they step along
+const (
+	centerDigit = iota
+	kAxesDigit
+	jAxesDigit
+	jkAxesDigit
+	iAxesDigit
+	ikAxesDigit
+	ijAxesDigit
+)
+
+// Face quadrants an overage can cross into
+const (
+	quadrantIJ = 1
+	quadrantKI = 2
+	quadrantJK = 3
+)
+
+// coordIJK is a position on a face's hexagonal grid along three axes 120 degrees apart
+type coordIJK struct{ i, j, k int }
+
+type faceIJK struct {
+	face  int
+	coord coordIJK
+}
+
+type faceOrientIJK struct {
+	face      int
+	translate coordIJK
+	ccwRot60  int
+}
+
+type baseCellOrient struct {
+	baseCell int
+	ccwRot60 int
+}
+
+type baseCellInfo struct {
+	home        faceIJK
+	isPentagon  bool
+	cwOffsetPen [2]int // Faces on which a pentagon's deleted k-axis subsequence is rotated clockwise
+}
+
+// unitVecs are the ijk steps to each digit's child
+var unitVecs = [7]coordIJK{{0, 0, 0}, {0, 0, 1}, {0, 1, 0}, {0, 1, 1}, {1, 0, 0}, {1, 0, 1}, {1, 1, 0}}
+
+// faceCenterGeo holds the latitude and longitude of each icosahedron face centre, in radians
+var faceCenterGeo = [numFaces][2]float64{
+	{0.803582649718989942, 1.248397419617396099},
+	{1.307747883455638156, 2.536945009877921159},
+	{1.054751253523952054, -1.347517358900396623},
+	{0.600191595538186799, -0.450603909469755746},
+	{0.491715428198773866, 0.401988202911306943},
+	{0.172745327415618701, 1.678146885280433686},
+	{0.605929321571350690, 2.953923329812411617},
+	{0.427370518328979641, -1.888876200336285401},
+	{-0.079066118549212831, -0.733429513380867741},
+	{-0.230961644455383637, 0.506495587332349035},
+	{0.079066118549212831, 2.408163140208925497},
+	{0.230961644455383637, -2.635097066257443758},
+	{-0.172745327415618701, -1.463445768309359553},
+	{-0.605929321571350690, -0.187669323777381622},
+	{-0.427370518328979641, 1.252716453253507838},
+	{-0.600191595538186799, 2.690988744120037492},
+	{-0.491715428198773866, -2.739604450678486295},
+	{-0.803582649718989942, -1.893195233972397139},
+	{-1.307747883455638156, -0.604647643711872080},
+	{-1.054751253523952054, 1.794075294689396615},
+}
+
+// faceAxisAz holds the azimuth of each face's Class II i-axis from the face centre, in radians
+var faceAxisAz = [numFaces]float64{
+	5.619958268523939882,
+	5.760339081714187279,
+	0.780213654393430055,
+	0.430469363979999913,
+	6.130269123335111400,
+	2.692877706530642877,
+	2.982963003477243874,
+	3.532912002790141181,
+	3.494305004259568154,
+	3.003214169499538391,
+	5.930472956509811562,
+	0.138378484090254847,
+	0.448714947059150361,
+	0.158629650112549365,
+	5.891865957979238535,
+	2.711123289609793325,
+	3.294508837434268316,
+	3.804819692245439833,
+	3.664438879055192436,
+	2.361378999196363184,
+}
+
+// baseCells gives each base cell's home face and coordinates, which fix its digits' orientation
+var baseCells = [numBaseCells]baseCellInfo{
+	{faceIJK{1, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{2, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{1, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{2, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{0, coordIJK{2, 0, 0}}, true, [2]int{-1, -1}},
+	{faceIJK{1, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{1, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{2, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{0, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{2, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{1, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{1, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{3, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{3, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{11, coordIJK{2, 0, 0}}, true, [2]int{2, 6}},
+	{faceIJK{4, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{0, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{6, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{0, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{2, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{7, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{2, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{0, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{6, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{10, coordIJK{2, 0, 0}}, true, [2]int{1, 5}},
+	{faceIJK{6, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{3, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{11, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{4, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{3, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{0, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{4, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{5, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{0, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{7, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{11, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{7, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{10, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{12, coordIJK{2, 0, 0}}, true, [2]int{3, 7}},
+	{faceIJK{6, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{7, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{4, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{3, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{3, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{4, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{6, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{11, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{8, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{5, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{14, coordIJK{2, 0, 0}}, true, [2]int{0, 9}},
+	{faceIJK{5, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{12, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{10, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{4, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{12, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{7, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{11, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{10, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{13, coordIJK{2, 0, 0}}, true, [2]int{4, 8}},
+	{faceIJK{10, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{11, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{9, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{8, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{6, coordIJK{2, 0, 0}}, true, [2]int{11, 15}},
+	{faceIJK{8, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{9, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{14, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{5, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{16, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{8, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{5, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{12, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{7, coordIJK{2, 0, 0}}, true, [2]int{12, 16}},
+	{faceIJK{12, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{10, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{9, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{13, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{16, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{15, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{15, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{16, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{14, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{13, coordIJK{1, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{5, coordIJK{2, 0, 0}}, true, [2]int{10, 19}},
+	{faceIJK{8, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{14, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{9, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{14, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{17, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{12, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{16, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{17, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{15, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{16, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{9, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{15, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{13, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{8, coordIJK{2, 0, 0}}, true, [2]int{13, 17}},
+	{faceIJK{13, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{17, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{19, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{14, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{19, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{17, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{13, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{17, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{16, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{9, coordIJK{2, 0, 0}}, true, [2]int{14, 18}},
+	{faceIJK{15, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{15, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{18, coordIJK{0, 1, 1}}, false, [2]int{0, 0}},
+	{faceIJK{18, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{19, coordIJK{0, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{17, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{19, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{18, coordIJK{0, 1, 0}}, false, [2]int{0, 0}},
+	{faceIJK{18, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{19, coordIJK{2, 0, 0}}, true, [2]int{-1, -1}},
+	{faceIJK{19, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{18, coordIJK{0, 0, 0}}, false, [2]int{0, 0}},
+	{faceIJK{19, coordIJK{1, 0, 1}}, false, [2]int{0, 0}},
+	{faceIJK{18, coordIJK{1, 0, 0}}, false, [2]int{0, 0}},
+}
+
+var (
+	faceCenterPoint  [numFaces][3]float64
+	faceNeighbors    [numFaces][4]faceOrientIJK
+	faceIjkBaseCells [numFaces][3][3][3]baseCellOrient
+)
+
+func init() {
+	for f, center := range faceCenterGeo {
+		faceCenterPoint[f] = geoToVec3(center[0], center[1])
+	}
+	deriveFaceNeighbors()
+	deriveFaceIjkBaseCells()
+}
+
+// LatLngToH3 returns the H3 cell containing a point at a resolution from 0 to 15
+func LatLngToH3(lat, lng float64, res int) (string, error) {
+	if res < 0 || res > MaxH3Resolution {
+		return "", fmt.Errorf("%w: H3 resolution must be between 0 and %d", ErrInvalidPrecision, MaxH3Resolution)
+	}
+	if math.IsNaN(lat) || math.IsNaN(lng) || math.IsInf(lat, 0) || math.IsInf(lng, 0) {
+		return "", fmt.Errorf("%w: coordinates must be finite", ErrInvalidCode)
+	}
+
+	fijk := geoToFaceIJK(lat*math.Pi/180, lng*math.Pi/180, res)
+	h, ok := faceIJKToH3(fijk, res)
+	if !ok {
+		return "", fmt.Errorf("%w: no H3 cell at these coordinates", ErrInvalidCode)
+	}
+	return strconv.FormatUint(h, 16), nil
+}
+
+// H3ToLatLng returns the centre and resolution of an H3 cell
+func H3ToLatLng(cell string) (lat, lng float64, res int, err error) {
+	h, err := strconv.ParseUint(cell, 16, 64)
+	if err != nil || !isValidH3Cell(h) {
+		return 0, 0, 0, fmt.Errorf("%w: %q is not an H3 cell", ErrInvalidCode, cell)
+	}
+
+	latRad, lngRad := faceIJKToGeo(h3ToFaceIJK(h), h3Resolution(h))
+	return latRad * 180 / math.Pi, lngRad * 180 / math.Pi, h3Resolution(h), nil
+}
+
+func isValidH3Cell(h uint64) bool {
+	if h>>63 != 0 || int(h>>h3ModeOffset&0xf) != h3CellMode || h>>56&7 != 0 {
+		return false
+	}
+	baseCell := h3BaseCell(h)
+	if baseCell >= numBaseCells {
+		return false
+	}
+
+	res := h3Resolution(h)
+	foundFirstNonZero := false
+	for r := 1; r <= MaxH3Resolution; r++ {
+		digit := h3Digit(h, r)
+		if r > res {
+			if digit != invalidDigit {
+				return false
+			}
+			continue
+		}
+		if digit == invalidDigit {
+			return false
+		}
+		// Pentagons have no k-axis child, so a leading k digit doesn't exist
+		if !foundFirstNonZero && digit != centerDigit {
+			foundFirstNonZero = true
+			if baseCells[baseCell].isPentagon && digit == kAxesDigit {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func h3Resolution(h uint64) int {
+	return int(h >> h3ResOffset & 0xf)
+}
+
+func h3BaseCell(h uint64) int {
+	return int(h >> h3BaseOffset & 0x7f)
+}
+
+func h3Digit(h uint64, r int) int {
+	return int(h >> ((MaxH3Resolution - r) * h3DigitBits) & h3DigitMask)
+}
+
+func setH3Digit(h uint64, r, digit int) uint64 {
+	shift := (MaxH3Resolution - r) * h3DigitBits
+	return h&^(uint64(h3DigitMask)<<shift) | uint64(digit)<<shift
+}
+
+func leadingNonZeroDigit(h uint64) int {
+	for r := 1; r <= h3Resolution(h); r++ {
+		if digit := h3Digit(h, r); digit != centerDigit {
+			return digit
+		}
+	}
+	return centerDigit
+}
+
+func isClassIII(res int) bool {
+	return res%2 == 1
+}
+
+// Indexing
+
+func faceIJKToH3(fijk faceIJK, res int) (uint64, bool) {
+	h := uint64(h3CellMode)<<h3ModeOffset | uint64(res)<<h3ResOffset | h3AllDigits
+
+	// Walk up the hierarchy from the finest resolution, recording the digit of each child
+	ijk := fijk.coord
+	for r := res - 1; r >= 0; r-- {
+		last := ijk
+		var lastCenter coordIJK
+		if isClassIII(r + 1) {
+			ijk = upAp7(ijk)
+			lastCenter = downAp7(ijk)
+		} else {
+			ijk = upAp7r(ijk)
+			lastCenter = downAp7r(ijk)
+		}
+		h = setH3Digit(h, r+1, unitIJKToDigit(ijkSub(last, lastCenter)))
+	}
+
+	if ijk.i > maxFaceCoord || ijk.j > maxFaceCoord || ijk.k > maxFaceCoord {
+		return 0, false
+	}
+
+	orient := faceIjkBaseCells[fijk.face][ijk.i][ijk.j][ijk.k]
+	h = h&^(uint64(0x7f)<<h3BaseOffset) | uint64(orient.baseCell)<<h3BaseOffset
+
+	// Rotate the digits into the base cell's home orientation
+	if baseCells[orient.baseCell].isPentagon {
+		// Pentagons have no k-axis child, so move out of that subsequence
+		if leadingNonZeroDigit(h) == kAxesDigit {
+			if isCwOffset(orient.baseCell, fijk.face) {
+				h = rotateH3Cw(h)
+			} else {
+				h = rotateH3Ccw(h)
+			}
+		}
+		for n := 0; n < orient.ccwRot60; n++ {
+			h = rotatePentH3Ccw(h)
+		}
+	} else {
+		for n := 0; n < orient.ccwRot60; n++ {
+			h = rotateH3Ccw(h)
+		}
+	}
+	return h, true
+}
+
+func isCwOffset(baseCell, face int) bool {
+	offsets := baseCells[baseCell].cwOffsetPen
+	return offsets[0] == face || offsets[1] == face
+}
+
+func h3ToFaceIJK(h uint64) faceIJK {
+	baseCell := h3BaseCell(h)
+	isPentagon := baseCells[baseCell].isPentagon
+	if isPentagon && leadingNonZeroDigit(h) == ikAxesDigit {
+		h = rotateH3Cw(h)
+	}
+
+	fijk := baseCells[baseCell].home
+	res := h3Resolution(h)
+
+	// Walk down from the base cell. Only pentagons, and cells away from their base cell's centre,
+	// can land on a neighbouring face.
+	possibleOverage := isPentagon || (res != 0 && fijk.coord != coordIJK{})
+	for r := 1; r <= res; r++ {
+		if isClassIII(r) {
+			fijk.coord = downAp7(fijk.coord)
+		} else {
+			fijk.coord = downAp7r(fijk.coord)
+		}
+		fijk.coord = ijkNeighbor(fijk.coord, h3Digit(h, r))
+	}
+	if !possibleOverage {
+		return fijk
+	}
+
+	original := fijk.coord
+	adjustedRes := res
+	if isClassIII(res) {
+		fijk.coord = downAp7r(fijk.coord)
+		adjustedRes++
+	}
+
+	pentLeading4 := isPentagon && leadingNonZeroDigit(h) == iAxesDigit
+	if adjustOverageClassII(&fijk, adjustedRes, pentLeading4) {
+		// Pentagons can overflow onto a second face
+		if isPentagon {
+			for adjustOverageClassII(&fijk, adjustedRes, false) {
+			}
+		}
+		if adjustedRes != res {
+			fijk.coord = upAp7r(fijk.coord)
+		}
+	} else if adjustedRes != res {
+		fijk.coord = original
+	}
+	return fijk
+}
+
+// adjustOverageClassII moves Class II coordinates that lie beyond their face onto the
+// neighbouring face, reporting whether it did
+func adjustOverageClassII(fijk *faceIJK, res int, pentLeading4 bool) bool {
+	unitScale := int(math.Round(math.Pow(7, float64(res/2))))
+	maxDim := maxFaceCoord * unitScale
+
+	ijk := fijk.coord
+	if ijk.i+ijk.j+ijk.k <= maxDim {
+		return false
+	}
+
+	var orient faceOrientIJK
+	switch {
+	case ijk.k > 0 && ijk.j > 0:
+		orient = faceNeighbors[fijk.face][quadrantJK]
+	case ijk.k > 0:
+		orient = faceNeighbors[fijk.face][quadrantKI]
+		// Pentagons have no k-axis child, so rotate around the pentagon's centre to skip it
+		if pentLeading4 {
+			origin := coordIJK{maxDim, 0, 0}
+			ijk = ijkAdd(ijkRotate60Cw(ijkSub(ijk, origin)), origin)
+		}
+	default:
+		orient = faceNeighbors[fijk.face][quadrantIJ]
+	}
+
+	for n := 0; n < orient.ccwRot60; n++ {
+		ijk = ijkRotate60Ccw(ijk)
+	}
+	translate := orient.translate
+	ijk = ijkNormalize(coordIJK{
+		ijk.i + translate.i*unitScale,
+		ijk.j + translate.j*unitScale,
+		ijk.k + translate.k*unitScale,
+	})
+
+	fijk.face = orient.face
+	fijk.coord = ijk
+	return true
+}
+
+// Digit rotations
+
+func rotate60Ccw(digit int) int {
+	switch digit {
+	case kAxesDigit:
+		return ikAxesDigit
+	case ikAxesDigit:
+		return iAxesDigit
+	case iAxesDigit:
+		return ijAxesDigit
+	case ijAxesDigit:
+		return jAxesDigit
+	case jAxesDigit:
+		return jkAxesDigit
+	case jkAxesDigit:
+		return kAxesDigit
+	}
+	return digit
+}
+
+func rotate60Cw(digit int) int {
+	switch digit {
+	case kAxesDigit:
+		return jkAxesDigit
+	case jkAxesDigit:
+		return jAxesDigit
+	case jAxesDigit:
+		return ijAxesDigit
+	case ijAxesDigit:
+		return iAxesDigit
+	case iAxesDigit:
+		return ikAxesDigit
+	case ikAxesDigit:
+		return kAxesDigit
+	}
+	return digit
+}
+
+func rotateH3Ccw(h uint64) uint64 {
+	for r := 1; r <= h3Resolution(h); r++ {
+		h = setH3Digit(h, r, rotate60Ccw(h3Digit(h, r)))
+	}
+	return h
+}
+
+func rotateH3Cw(h uint64) uint64 {
+	for r := 1; r <= h3Resolution(h); r++ {
+		h = setH3Digit(h, r, rotate60Cw(h3Digit(h, r)))
+	}
+	return h
+}
+
+// rotatePentH3Ccw rotates a pentagon's digits, skipping past the deleted k-axis subsequence
+func rotatePentH3Ccw(h uint64) uint64 {
+	foundFirstNonZero := false
+	for r := 1; r <= h3Resolution(h); r++ {
+		h = setH3Digit(h, r, rotate60Ccw(h3Digit(h, r)))
+		if !foundFirstNonZero && h3Digit(h, r) != centerDigit {
+			foundFirstNonZero = true
+			if leadingNonZeroDigit(h) == kAxesDigit {
+				h = rotateH3Ccw(h)
+			}
+		}
+	}
+	return h
+}
+
+// IJK coordinates
+
+func ijkAdd(a, b coordIJK) coordIJK {
+	return coordIJK{a.i + b.i, a.j + b.j, a.k + b.k}
+}
+
+func ijkSub(a, b coordIJK) coordIJK {
+	return coordIJK{a.i - b.i, a.j - b.j, a.k - b.k}
+}
+
+func ijkScale(c coordIJK, factor int) coordIJK {
+	return coordIJK{c.i * factor, c.j * factor, c.k * factor}
+}
+
+// ijkNormalize makes every coordinate non-negative with at least one zero
+func ijkNormalize(c coordIJK) coordIJK {
+	if c.i < 0 {
+		c.j -= c.i
+		c.k -= c.i
+		c.i = 0
+	}
+	if c.j < 0 {
+		c.i -= c.j
+		c.k -= c.j
+		c.j = 0
+	}
+	if c.k < 0 {
+		c.i -= c.k
+		c.j -= c.k
+		c.k = 0
+	}
+	least := min(c.i, c.j, c.k)
+	return coordIJK{c.i - least, c.j - least, c.k - least}
+}
+
+func unitIJKToDigit(c coordIJK) int {
+	c = ijkNormalize(c)
+	for digit, unit := range unitVecs {
+		if c == unit {
+			return digit
+		}
+	}
+	return invalidDigit
+}
+
+func ijkNeighbor(c coordIJK, digit int) coordIJK {
+	if digit > centerDigit && digit < invalidDigit {
+		return ijkNormalize(ijkAdd(c, unitVecs[digit]))
+	}
+	return c
+}
+
+// combineAxes returns i*iVec + j*jVec + k*kVec, normalized
+func combineAxes(c coordIJK, iVec, jVec, kVec coordIJK) coordIJK {
+	return ijkNormalize(ijkAdd(ijkAdd(ijkScale(iVec, c.i), ijkScale(jVec, c.j)), ijkScale(kVec, c.k)))
+}
+
+func ijkRotate60Ccw(c coordIJK) coordIJK {
+	return combineAxes(c, coordIJK{1, 1, 0}, coordIJK{0, 1, 1}, coordIJK{1, 0, 1})
+}
+
+func ijkRotate60Cw(c coordIJK) coordIJK {
+	return combineAxes(c, coordIJK{1, 0, 1}, coordIJK{1, 1, 0}, coordIJK{0, 1, 1})
+}
+
+// upAp7 returns the parent of a Class III cell, in the next coarser Class II grid
+func upAp7(c coordIJK) coordIJK {
+	i, j := c.i-c.k, c.j-c.k
+	return ijkNormalize(coordIJK{int(math.Round(float64(3*i-j) / 7)), int(math.Round(float64(i+2*j) / 7)), 0})
+}
+
+// upAp7r returns the parent of a Class II cell, in the next coarser Class III grid
+func upAp7r(c coordIJK) coordIJK {
+	i, j := c.i-c.k, c.j-c.k
+	return ijkNormalize(coordIJK{int(math.Round(float64(2*i+j) / 7)), int(math.Round(float64(3*j-i) / 7)), 0})
+}
+
+// downAp7 returns the centre child of a Class II cell, in the next finer Class III grid
+func downAp7(c coordIJK) coordIJK {
+	return combineAxes(c, coordIJK{3, 0, 1}, coordIJK{1, 3, 0}, coordIJK{0, 1, 3})
+}
+
+// downAp7r returns the centre child of a Class III cell, in the next finer Class II grid
+func downAp7r(c coordIJK) coordIJK {
+	return combineAxes(c, coordIJK{3, 1, 0}, coordIJK{0, 3, 1}, coordIJK{1, 0, 3})
+}
+
+// Projection
+
+func geoToVec3(lat, lng float64) [3]float64 {
+	return [3]float64{math.Cos(lat) * math.Cos(lng), math.Cos(lat) * math.Sin(lng), math.Sin(lat)}
+}
+
+func closestFace(lat, lng float64) (int, float64) {
+	point := geoToVec3(lat, lng)
+	face, best := 0, math.Inf(1)
+	for f, center := range faceCenterPoint {
+		dx, dy, dz := center[0]-point[0], center[1]-point[1], center[2]-point[2]
+		if sqd := dx*dx + dy*dy + dz*dz; sqd < best {
+			face, best = f, sqd
+		}
+	}
+	return face, best
+}
+
+func geoToFaceIJK(lat, lng float64, res int) faceIJK {
+	face, sqd := closestFace(lat, lng)
+	x, y := geoToHex2d(lat, lng, face, math.Acos(1-sqd/2), res)
+	return faceIJK{face, hex2dToIJK(x, y)}
+}
+
+// geoToHex2d projects a point onto a face's plane, r radians from its centre, in units of the
+// resolution's cells
+func geoToHex2d(lat, lng float64, face int, r float64, res int) (float64, float64) {
+	if r < h3Epsilon {
+		return 0, 0
+	}
+
+	center := faceCenterGeo[face]
+	theta := posAngle(faceAxisAz[face] - posAngle(azimuth(center[0], center[1], lat, lng)))
+	if isClassIII(res) {
+		theta = posAngle(theta - ap7RotRads)
+	}
+
+	r = math.Tan(r) / res0UGnomonic
+	for n := 0; n < res; n++ {
+		r *= sqrt7
+	}
+	return r * math.Cos(theta), r * math.Sin(theta)
+}
+
+func hex2dToGeo(x, y float64, face, res int) (float64, float64) {
+	center := faceCenterGeo[face]
+	r := math.Hypot(x, y)
+	if r < h3Epsilon {
+		return center[0], center[1]
+	}
+
+	theta := math.Atan2(y, x)
+	for n := 0; n < res; n++ {
+		r /= sqrt7
+	}
+	r = math.Atan(r * res0UGnomonic)
+	if isClassIII(res) {
+		theta = posAngle(theta + ap7RotRads)
+	}
+	return destination(center[0], center[1], posAngle(faceAxisAz[face]-theta), r)
+}
+
+// faceIJKToGeo returns the centre of a cell, in radians
+func faceIJKToGeo(fijk faceIJK, res int) (float64, float64) {
+	x, y := ijkToHex2d(fijk.coord)
+	return hex2dToGeo(x, y, fijk.face, res)
+}
+
+func ijkToHex2d(c coordIJK) (float64, float64) {
+	i, j := float64(c.i-c.k), float64(c.j-c.k)
+	return i - 0.5*j, j * sin60
+}
+
+// hex2dToIJK returns the cell containing a point on a face's plane
+func hex2dToIJK(x, y float64) coordIJK {
+	a1, a2 := math.Abs(x), math.Abs(y)
+
+	// Reverse the conversion to find the candidate cells, then round to the right one
+	x2 := a2 / sin60
+	x1 := a1 + x2/2
+	m1, m2 := int(x1), int(x2)
+	r1, r2 := x1-float64(m1), x2-float64(m2)
+
+	var c coordIJK
+	if r1 < 0.5 {
+		if r1 < 1.0/3 {
+			c.i = m1
+			if r2 < (1+r1)/2 {
+				c.j = m2
+			} else {
+				c.j = m2 + 1
+			}
+		} else {
+			if r2 < 1-r1 {
+				c.j = m2
+			} else {
+				c.j = m2 + 1
+			}
+			if 1-r1 <= r2 && r2 < 2*r1 {
+				c.i = m1 + 1
+			} else {
+				c.i = m1
+			}
+		}
+	} else {
+		if r1 < 2.0/3 {
+			if r2 < 1-r1 {
+				c.j = m2
+			} else {
+				c.j = m2 + 1
+			}
+			if 2*r1-1 < r2 && r2 < 1-r1 {
+				c.i = m1
+			} else {
+				c.i = m1 + 1
+			}
+		} else {
+			c.i = m1 + 1
+			if r2 < r1/2 {
+				c.j = m2
+			} else {
+				c.j = m2 + 1
+			}
+		}
+	}
+
+	// Fold back across the axes
+	if x < 0 {
+		if c.j%2 == 0 {
+			c.i -= 2 * (c.i - c.j/2)
+		} else {
+			c.i -= 2*(c.i-(c.j+1)/2) + 1
+		}
+	}
+	if y < 0 {
+		c.i -= (2*c.j + 1) / 2
+		c.j = -c.j
+	}
+	return ijkNormalize(c)
+}
+
+func posAngle(a float64) float64 {
+	a = math.Mod(a, 2*math.Pi)
+	if a < 0 {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+// azimuth returns the initial bearing from one point to another, in radians
+func azimuth(lat1, lng1, lat2, lng2 float64) float64 {
+	return math.Atan2(
+		math.Cos(lat2)*math.Sin(lng2-lng1),
+		math.Cos(lat1)*math.Sin(lat2)-math.Sin(lat1)*math.Cos(lat2)*math.Cos(lng2-lng1),
+	)
+}
+
+// destination returns the point a distance (in radians) along a bearing from a start point
+func destination(lat, lng, az, distance float64) (float64, float64) {
+	if distance < h3Epsilon {
+		return lat, lng
+	}
+	sinLat := math.Sin(lat)*math.Cos(distance) + math.Cos(lat)*math.Sin(distance)*math.Cos(az)
+	lat2 := math.Asin(math.Max(-1, math.Min(1, sinLat)))
+	if math.Abs(math.Abs(lat2)-math.Pi/2) < h3Epsilon {
+		return lat2, 0
+	}
+	dLng := math.Atan2(math.Sin(az)*math.Sin(distance)*math.Cos(lat), math.Cos(distance)-math.Sin(lat)*sinLat)
+	return lat2, constrainLng(lng + dLng)
+}
+
+func constrainLng(lng float64) float64 {
+	for lng > math.Pi {
+		lng -= 2 * math.Pi
+	}
+	for lng < -math.Pi {
+		lng += 2 * math.Pi
+	}
+	return lng
+}
+
+// Derived tables
+
+// deriveFaceNeighbors finds, for each edge of each face, the face across it and the rotation and
+// translation that carry Class II coordinates over the edge. It projects lattice points just past
+// the edge onto the neighbouring face and solves for the transform that maps them.
+func deriveFaceNeighbors() {
+	const res = 4
+	unitScale := 49
+	half := unitScale*maxFaceCoord/2 + 1
+	samples := [4][]coordIJK{
+		quadrantIJ: {{half, half, 0}, {half + 10, half - 8, 0}, {half - 12, half + 14, 0}},
+		quadrantKI: {{half, 0, half}, {half + 10, 0, half - 8}, {half - 12, 0, half + 14}},
+		quadrantJK: {{0, half, half}, {0, half + 10, half - 8}, {0, half - 12, half + 14}},
+	}
+
+	for f := range faceNeighbors {
+		faceNeighbors[f][0] = faceOrientIJK{face: f}
+		for quadrant := quadrantIJ; quadrant <= quadrantJK; quadrant++ {
+			neighbor := -1
+			mapped := make([]coordIJK, len(samples[quadrant]))
+			for n, p := range samples[quadrant] {
+				lat, lng := faceIJKToGeo(faceIJK{f, p}, res)
+				g, _ := closestFace(lat, lng)
+				if neighbor != -1 && g != neighbor {
+					panic("geoencode: inconsistent H3 face neighbour")
+				}
+				neighbor = g
+				mapped[n] = hex2dToIJK(projectToFace(lat, lng, g, res))
+			}
+			faceNeighbors[f][quadrant] = solveFaceTransform(samples[quadrant], mapped, neighbor, unitScale)
+		}
+	}
+}
+
+func solveFaceTransform(from, to []coordIJK, face, unitScale int) faceOrientIJK {
+	for rot := 0; rot < 6; rot++ {
+		var translate coordIJK
+		ok := true
+		for n := range from {
+			p := from[n]
+			for r := 0; r < rot; r++ {
+				p = ijkRotate60Ccw(p)
+			}
+			diff := ijkNormalize(ijkSub(to[n], p))
+			if diff.i%unitScale != 0 || diff.j%unitScale != 0 || diff.k%unitScale != 0 ||
+				(n > 0 && diff != ijkScale(translate, unitScale)) {
+				ok = false
+				break
+			}
+			translate = coordIJK{diff.i / unitScale, diff.j / unitScale, diff.k / unitScale}
+		}
+		if ok {
+			return faceOrientIJK{face: face, translate: translate, ccwRot60: rot}
+		}
+	}
+	panic("geoencode: no H3 face transform")
+}
+
+// deriveFaceIjkBaseCells finds which base cell each resolution 0 coordinate on each face falls in,
+// and how many 60 degree turns take the face's axes to the base cell's home axes. Hexagons share
+// the rotation of the edge to their home face; pentagons, whose five faces don't tile the plane,
+// take the rotation that makes their cells decode back to where they were encoded.
+func deriveFaceIjkBaseCells() {
+	var centers [numBaseCells][3]float64
+	for b, info := range baseCells {
+		lat, lng := faceIJKToGeo(info.home, 0)
+		centers[b] = geoToVec3(lat, lng)
+	}
+
+	for f := range faceIjkBaseCells {
+		for i := 0; i <= maxFaceCoord; i++ {
+			for j := 0; j <= maxFaceCoord; j++ {
+				for k := 0; k <= maxFaceCoord; k++ {
+					c := coordIJK{i, j, k}
+					if ijkNormalize(c) != c {
+						continue
+					}
+
+					fijk := faceIJK{f, c}
+					adjustOverageClassII(&fijk, 0, false)
+					lat, lng := faceIJKToGeo(fijk, 0)
+					baseCell := nearestPoint(centers[:], geoToVec3(lat, lng))
+
+					orient := baseCellOrient{baseCell: baseCell}
+					home := baseCells[baseCell].home
+					switch {
+					case home.face == f:
+					case baseCells[baseCell].isPentagon:
+						orient.ccwRot60 = pentagonRotation(f, c, baseCell, lat, lng)
+					default:
+						for quadrant := quadrantIJ; quadrant <= quadrantJK; quadrant++ {
+							if faceNeighbors[f][quadrant].face == home.face {
+								orient.ccwRot60 = faceNeighbors[f][quadrant].ccwRot60
+							}
+						}
+					}
+					faceIjkBaseCells[f][i][j][k] = orient
+				}
+			}
+		}
+	}
+}
+
+func pentagonRotation(face int, c coordIJK, baseCell int, lat, lng float64) int {
+	const res = 2
+	center := faceCenterGeo[face]
+	toFace := azimuth(lat, lng, center[0], center[1])
+
+	for rot := 0; rot < 6; rot++ {
+		faceIjkBaseCells[face][c.i][c.j][c.k] = baseCellOrient{baseCell, rot}
+		ok := true
+		for _, offset := range []float64{-0.4, 0, 0.4} {
+			for _, distance := range []float64{0.05, 0.1} {
+				pLat, pLng := destination(lat, lng, toFace+offset, distance)
+				f, _ := closestFace(pLat, pLng)
+				h, valid := faceIJKToH3(faceIJK{f, hex2dToIJK(projectToFace(pLat, pLng, f, res))}, res)
+				if f != face || !valid || !isValidH3Cell(h) {
+					continue
+				}
+				cLat, cLng := faceIJKToGeo(h3ToFaceIJK(h), res)
+				if chordDistance(geoToVec3(cLat, cLng), geoToVec3(pLat, pLng)) > 0.03 {
+					ok = false
+				}
+			}
+		}
+		if ok {
+			return rot
+		}
+	}
+	panic("geoencode: no H3 pentagon rotation")
+}
+
+func projectToFace(lat, lng float64, face, res int) (float64, float64) {
+	sqd := chordDistance(faceCenterPoint[face], geoToVec3(lat, lng))
+	return geoToHex2d(lat, lng, face, math.Acos(1-sqd*sqd/2), res)
+}
+
+func nearestPoint(points [][3]float64, p [3]float64) int {
+	nearest, best := 0, math.Inf(1)
+	for n, q := range points {
+		if d := chordDistance(p, q); d < best {
+			nearest, best = n, d
+		}
+	}
+	return nearest
+}
+
+func chordDistance(a, b [3]float64) float64 {
+	return math.Sqrt((a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2]))
+}
@@ -0,0 +1,144 @@
+package geoencode
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// Plus Codes follow the Open Location Code specification (https://github.com/google/open-location-code).
+// The first ten digits are five base 20 latitude/longitude pairs; any further digits each split
+// the cell into a grid of 5 rows and 4 columns.
+const (
+	// MaxPlusCodeLength is the longest code in digits, a cell of about 14cm by 11cm at the equator
+	MaxPlusCodeLength = 15
+
+	plusCodeAlphabet  = "23456789CFGHJMPQRVWX"
+	plusCodeSeparator = '+'
+	plusCodePadding   = '0'
+	separatorPosition = 8
+	pairCodeLength    = 10
+	gridRows          = 5
+	gridColumns       = 4
+	encodingBase      = 20
+	pairPrecision     = encodingBase * encodingBase * encodingBase
+	// finalLatPrecision and finalLngPrecision are the cells per degree of a 15 digit code
+	finalLatPrecision = pairPrecision * gridRows * gridRows * gridRows * gridRows * gridRows
+	finalLngPrecision = pairPrecision * gridColumns * gridColumns * gridColumns * gridColumns * gridColumns
+)
+
+// EncodePlusCode returns the full Plus Code of a point. The length is the number of digits: 2, 4,
+// 6, 8 or 10 for pair codes, or 11 to 15 for grid refinements.
+func EncodePlusCode(lat, lng float64, length int) (string, error) {
+	if length < 2 || length > MaxPlusCodeLength || (length < pairCodeLength && length%2 == 1) {
+		return "", fmt.Errorf("%w: Plus Code length must be 2, 4, 6, 8 or 10 to %d", ErrInvalidPrecision, MaxPlusCodeLength)
+	}
+
+	// Work in integers of the finest cell to avoid floating point drift between digits
+	latVal := toCells(lat, finalLatPrecision) + 90*finalLatPrecision
+	latVal = max(0, min(latVal, 180*finalLatPrecision-1))
+	lngVal := toCells(normalizeLng(lng), finalLngPrecision) + 180*finalLngPrecision
+	lngVal = min(lngVal, 360*finalLngPrecision-1)
+
+	digits := make([]byte, MaxPlusCodeLength)
+	for n := MaxPlusCodeLength - 1; n >= pairCodeLength; n-- {
+		digits[n] = plusCodeAlphabet[latVal%gridRows*gridColumns+lngVal%gridColumns]
+		latVal /= gridRows
+		lngVal /= gridColumns
+	}
+	for n := pairCodeLength - 2; n >= 0; n -= 2 {
+		digits[n] = plusCodeAlphabet[latVal%encodingBase]
+		digits[n+1] = plusCodeAlphabet[lngVal%encodingBase]
+		latVal /= encodingBase
+		lngVal /= encodingBase
+	}
+
+	code := string(digits[:length])
+	if length < separatorPosition {
+		code += strings.Repeat(string(plusCodePadding), separatorPosition-length)
+	}
+	return code[:separatorPosition] + string(plusCodeSeparator) + code[separatorPosition:], nil
+}
+
+// toCells floors degrees to whole cells, after rounding away the error in the multiplication
+// the way the reference implementations do
+func toCells(degrees float64, cellsPerDegree int64) int64 {
+	return int64(math.Floor(math.Round(degrees*float64(cellsPerDegree)*1e6) / 1e6))
+}
+
+// DecodePlusCode returns the cell a full Plus Code covers and its length in digits. Short codes,
+// which are relative to a nearby place, are rejected.
+func DecodePlusCode(code string) (models.CellBounds, int, error) {
+	digits, err := plusCodeDigits(code)
+	if err != nil {
+		return models.CellBounds{}, 0, err
+	}
+
+	var latVal, lngVal int64
+	// Place values are in cells of a 15 digit code; the first pair's digits are 20 degrees
+	latPlace := int64(encodingBase * encodingBase * finalLatPrecision)
+	lngPlace := int64(encodingBase * encodingBase * finalLngPrecision)
+	for n := 0; n < min(len(digits), pairCodeLength); n += 2 {
+		latPlace /= encodingBase
+		lngPlace /= encodingBase
+		latVal += int64(strings.IndexByte(plusCodeAlphabet, digits[n])) * latPlace
+		lngVal += int64(strings.IndexByte(plusCodeAlphabet, digits[n+1])) * lngPlace
+	}
+	for n := pairCodeLength; n < len(digits); n++ {
+		latPlace /= gridRows
+		lngPlace /= gridColumns
+		index := int64(strings.IndexByte(plusCodeAlphabet, digits[n]))
+		latVal += index / gridColumns * latPlace
+		lngVal += index % gridColumns * lngPlace
+	}
+
+	bounds := models.CellBounds{
+		South: float64(latVal)/finalLatPrecision - 90,
+		West:  float64(lngVal)/finalLngPrecision - 180,
+		North: float64(latVal+latPlace)/finalLatPrecision - 90,
+		East:  float64(lngVal+lngPlace)/finalLngPrecision - 180,
+	}
+	return bounds, len(digits), nil
+}
+
+// plusCodeDigits validates a full Plus Code and returns its digits, upper case and without the
+// separator or padding
+func plusCodeDigits(code string) (string, error) {
+	code = strings.ToUpper(code)
+	sep := strings.IndexByte(code, plusCodeSeparator)
+	switch {
+	case sep < 0 || strings.Count(code, string(plusCodeSeparator)) > 1:
+		return "", fmt.Errorf("%w: a Plus Code has one %q separator", ErrInvalidCode, plusCodeSeparator)
+	case sep < separatorPosition:
+		return "", fmt.Errorf("%w: short Plus Codes need a reference location; send the full code", ErrInvalidCode)
+	case sep > separatorPosition:
+		return "", fmt.Errorf("%w: the separator must follow the eighth character", ErrInvalidCode)
+	case len(code)-sep-1 == 1:
+		return "", fmt.Errorf("%w: a Plus Code can't have a single digit after the separator", ErrInvalidCode)
+	case len(code)-sep-1 > MaxPlusCodeLength-separatorPosition:
+		return "", fmt.Errorf("%w: a Plus Code has at most %d digits", ErrInvalidCode, MaxPlusCodeLength)
+	}
+
+	digits := code[:sep]
+	if pad := strings.IndexByte(digits, plusCodePadding); pad >= 0 {
+		if pad == 0 || pad%2 == 1 || strings.Trim(digits[pad:], string(plusCodePadding)) != "" || sep != len(code)-1 {
+			return "", fmt.Errorf("%w: Plus Code padding is misplaced", ErrInvalidCode)
+		}
+		digits = digits[:pad]
+	}
+	digits += code[sep+1:]
+
+	for _, c := range digits {
+		if !strings.ContainsRune(plusCodeAlphabet, c) {
+			return "", fmt.Errorf("%w: %q is not a Plus Code character", ErrInvalidCode, c)
+		}
+	}
+	// The first pair can't reach past the poles or the antimeridian
+	if strings.IndexByte(plusCodeAlphabet, digits[0])*encodingBase >= 180 ||
+		strings.IndexByte(plusCodeAlphabet, digits[1])*encodingBase >= 360 {
+		return "", fmt.Errorf("%w: Plus Code is outside the globe", ErrInvalidCode)
+	}
+	return digits, nil
+}
@@ -0,0 +1,268 @@
+// Package geofence parses GeoJSON geofences and checks which of them contain a point. Polygons and
+// multipolygons are stored as given; circles are a GeoJSON Point with a radius in metres, since GeoJSON
+// has no circle type.
+package geofence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/offline"
+)
+
+const (
+	// DefaultCollection holds fences uploaded without a collection name
+	DefaultCollection = "default"
+	// MaxFeatures caps the fences in one upload
+	MaxFeatures = 1000
+	// maxRadiusM keeps circles smaller than a hemisphere, where "inside" stops meaning much
+	maxRadiusM = 10000 * 1000
+	// maxVertices caps the positions in one fence, which is plenty for a simplified state border
+	maxVertices = 100000
+	// maxCollectionLength and maxNameLength match the geofences table columns
+	maxCollectionLength = 100
+	maxNameLength       = 255
+)
+
+// ErrInvalidGeometry is returned for GeoJSON that isn't a valid polygon, multipolygon or circle
+var ErrInvalidGeometry = errors.New("invalid geofence geometry")
+
+// Input is one fence from an upload, before it is stored
+type Input struct {
+	Name       string
+	Collection string
+	Geometry   json.RawMessage
+	RadiusM    float64
+	Properties json.RawMessage
+}
+
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Geometry    json.RawMessage `json:"geometry"`
+	Properties  json.RawMessage `json:"properties"`
+	Features    []geoJSON       `json:"features"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type featureProperties struct {
+	Name       string  `json:"name"`
+	Collection string  `json:"collection"`
+	RadiusM    float64 `json:"radius_m"`
+}
+
+// ParseFeatures reads a GeoJSON Feature or FeatureCollection. Each feature's name, collection and
+// radius_m come from its properties, and collection falls back to the one given.
+func ParseFeatures(body []byte, collection string) ([]Input, error) {
+	var doc geoJSON
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+	}
+
+	var features []geoJSON
+	switch doc.Type {
+	case "Feature":
+		features = []geoJSON{doc}
+	case "FeatureCollection":
+		features = doc.Features
+	default:
+		return nil, fmt.Errorf("%w: expected a GeoJSON Feature or FeatureCollection, got %q", ErrInvalidGeometry, doc.Type)
+	}
+	if len(features) == 0 {
+		return nil, fmt.Errorf("%w: no features", ErrInvalidGeometry)
+	}
+	if len(features) > MaxFeatures {
+		return nil, fmt.Errorf("%w: at most %d features per upload", ErrInvalidGeometry, MaxFeatures)
+	}
+
+	inputs := make([]Input, 0, len(features))
+	for i, feature := range features {
+		input, err := parseFeature(feature, collection)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+func parseFeature(feature geoJSON, collection string) (Input, error) {
+	if feature.Type != "Feature" {
+		return Input{}, fmt.Errorf("%w: expected a Feature, got %q", ErrInvalidGeometry, feature.Type)
+	}
+
+	var props featureProperties
+	if len(feature.Properties) > 0 && string(feature.Properties) != "null" {
+		if err := json.Unmarshal(feature.Properties, &props); err != nil {
+			return Input{}, fmt.Errorf("%w: properties: %v", ErrInvalidGeometry, err)
+		}
+	} else {
+		feature.Properties = nil
+	}
+	if props.Collection == "" {
+		props.Collection = collection
+	}
+	if props.Collection == "" {
+		props.Collection = DefaultCollection
+	}
+	if len(props.Collection) > maxCollectionLength {
+		return Input{}, fmt.Errorf("%w: collection is longer than %d characters", ErrInvalidGeometry, maxCollectionLength)
+	}
+	if len(props.Name) > maxNameLength {
+		return Input{}, fmt.Errorf("%w: name is longer than %d characters", ErrInvalidGeometry, maxNameLength)
+	}
+
+	if _, err := Compile(feature.Geometry, props.RadiusM); err != nil {
+		return Input{}, err
+	}
+
+	return Input{
+		Name:       props.Name,
+		Collection: props.Collection,
+		Geometry:   feature.Geometry,
+		RadiusM:    props.RadiusM,
+		Properties: feature.Properties,
+	}, nil
+}
+
+// ring is a closed polygon ring of [lng, lat] positions, as in GeoJSON
+type ring [][2]float64
+
+// Shape is a compiled fence that can answer containment queries
+type Shape struct {
+	polygons                       [][]ring
+	centerLat, centerLng, radiusKm float64
+	minLng, minLat, maxLng, maxLat float64
+}
+
+// Compile validates a GeoJSON Polygon, MultiPolygon, or Point with a radius
+func Compile(geometry json.RawMessage, radiusM float64) (*Shape, error) {
+	var g geoJSON
+	if err := json.Unmarshal(geometry, &g); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+	}
+
+	s := &Shape{minLng: 180, minLat: 90, maxLng: -180, maxLat: -90}
+	switch g.Type {
+	case "Point":
+		var position []float64
+		if err := json.Unmarshal(g.Coordinates, &position); err != nil || len(position) < 2 || !validPosition(position[0], position[1]) {
+			return nil, fmt.Errorf("%w: Point needs a [lng, lat] position", ErrInvalidGeometry)
+		}
+		if radiusM <= 0 || radiusM > maxRadiusM {
+			return nil, fmt.Errorf("%w: a Point fence needs radius_m between 0 and %d", ErrInvalidGeometry, maxRadiusM)
+		}
+		s.centerLng, s.centerLat, s.radiusKm = position[0], position[1], radiusM/1000
+		return s, nil
+	case "Polygon":
+		var polygon []ring
+		if err := json.Unmarshal(g.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+		s.polygons = [][]ring{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &s.polygons); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported type %q, expected Polygon, MultiPolygon or Point", ErrInvalidGeometry, g.Type)
+	}
+
+	if radiusM != 0 {
+		return nil, fmt.Errorf("%w: radius_m only applies to Point fences", ErrInvalidGeometry)
+	}
+	if err := s.validatePolygons(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Shape) validatePolygons() error {
+	if len(s.polygons) == 0 {
+		return fmt.Errorf("%w: no polygons", ErrInvalidGeometry)
+	}
+
+	vertices := 0
+	for _, polygon := range s.polygons {
+		if len(polygon) == 0 {
+			return fmt.Errorf("%w: polygon without rings", ErrInvalidGeometry)
+		}
+		for _, r := range polygon {
+			if len(r) < 4 || r[0] != r[len(r)-1] {
+				return fmt.Errorf("%w: rings need at least four positions and must end where they start", ErrInvalidGeometry)
+			}
+			for _, position := range r {
+				if !validPosition(position[0], position[1]) {
+					return fmt.Errorf("%w: position [%g, %g] is out of range", ErrInvalidGeometry, position[0], position[1])
+				}
+			}
+			vertices += len(r)
+		}
+		for _, position := range polygon[0] {
+			s.minLng = math.Min(s.minLng, position[0])
+			s.maxLng = math.Max(s.maxLng, position[0])
+			s.minLat = math.Min(s.minLat, position[1])
+			s.maxLat = math.Max(s.maxLat, position[1])
+		}
+	}
+	if vertices > maxVertices {
+		return fmt.Errorf("%w: more than %d positions", ErrInvalidGeometry, maxVertices)
+	}
+	return nil
+}
+
+func validPosition(lng, lat float64) bool {
+	return lng >= -180 && lng <= 180 && lat >= -90 && lat <= 90
+}
+
+// Contains reports whether the point is inside the fence
+func (s *Shape) Contains(lat, lng float64) bool {
+	if s.radiusKm > 0 {
+		return offline.DistanceKm(s.centerLat, s.centerLng, lat, lng) <= s.radiusKm
+	}
+	if lng < s.minLng || lng > s.maxLng || lat < s.minLat || lat > s.maxLat {
+		return false
+	}
+	for _, polygon := range s.polygons {
+		if polygonContains(polygon, lat, lng) {
+			return true
+		}
+	}
+	return false
+}
+
+// polygonContains applies the even-odd rule across every ring, so holes are excluded
+func polygonContains(polygon []ring, lat, lng float64) bool {
+	inside := false
+	for _, r := range polygon {
+		for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+			xi, yi := r[i][0], r[i][1]
+			xj, yj := r[j][0], r[j][1]
+			if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Check returns the fences that contain the point. Fences whose stored geometry no longer compiles
+// are skipped rather than failing the whole check.
+func Check(fences []models.Geofence, lat, lng float64) []models.GeofenceMatch {
+	matches := []models.GeofenceMatch{}
+	for _, fence := range fences {
+		shape, err := Compile(fence.Geometry, fence.RadiusM)
+		if err != nil || !shape.Contains(lat, lng) {
+			continue
+		}
+		matches = append(matches, models.GeofenceMatch{
+			ID:         fence.ID,
+			Collection: fence.Collection,
+			Name:       fence.Name,
+			Properties: fence.Properties,
+		})
+	}
+	return matches
+}
@@ -0,0 +1,126 @@
+package geofence
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// Vermont, roughly, with a hole around Burlington
+const vermont = `{"type":"Polygon","coordinates":[
+	[[-73.44,42.73],[-71.46,42.73],[-71.46,45.02],[-73.44,45.02],[-73.44,42.73]],
+	[[-73.30,44.40],[-73.10,44.40],[-73.10,44.55],[-73.30,44.55],[-73.30,44.40]]
+]}`
+
+func TestParseFeatures(t *testing.T) {
+	body := `{"type":"FeatureCollection","features":[
+		{"type":"Feature","properties":{"name":"Vermont"},"geometry":` + vermont + `},
+		{"type":"Feature","properties":{"name":"HQ","collection":"offices","radius_m":500},"geometry":{"type":"Point","coordinates":[-73.2121,44.4759]}},
+		{"type":"Feature","properties":null,"geometry":{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]]]]}}
+	]}`
+
+	inputs, err := ParseFeatures([]byte(body), "states")
+	if err != nil {
+		t.Fatalf("ParseFeatures() error = %v", err)
+	}
+	if len(inputs) != 3 {
+		t.Fatalf("expected 3 inputs, got %d", len(inputs))
+	}
+
+	if inputs[0].Name != "Vermont" || inputs[0].Collection != "states" {
+		t.Errorf("first input = %q in %q, want Vermont in states", inputs[0].Name, inputs[0].Collection)
+	}
+	if inputs[1].Collection != "offices" || inputs[1].RadiusM != 500 {
+		t.Errorf("second input = %q with radius %v, want offices with radius 500", inputs[1].Collection, inputs[1].RadiusM)
+	}
+	if inputs[2].Properties != nil {
+		t.Errorf("expected null properties to be dropped, got %s", inputs[2].Properties)
+	}
+
+	inputs, err = ParseFeatures([]byte(`{"type":"Feature","geometry":`+vermont+`}`), "")
+	if err != nil {
+		t.Fatalf("ParseFeatures() error = %v", err)
+	}
+	if inputs[0].Collection != DefaultCollection {
+		t.Errorf("expected collection %q, got %q", DefaultCollection, inputs[0].Collection)
+	}
+}
+
+func TestParseFeatures_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Not JSON", `not json`},
+		{"Bare geometry", vermont},
+		{"Empty collection", `{"type":"FeatureCollection","features":[]}`},
+		{"Point without radius", `{"type":"Feature","geometry":{"type":"Point","coordinates":[0,0]}}`},
+		{"Radius too large", `{"type":"Feature","properties":{"radius_m":20000000},"geometry":{"type":"Point","coordinates":[0,0]}}`},
+		{"LineString", `{"type":"Feature","geometry":{"type":"LineString","coordinates":[[0,0],[1,1]]}}`},
+		{"Open ring", `{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1]]]}}`},
+		{"Short ring", `{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}}`},
+		{"Out of range", `{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[0,0],[200,0],[1,1],[0,0]]]}}`},
+		{"Long name", `{"type":"Feature","properties":{"name":"` + strings.Repeat("a", 256) + `"},"geometry":` + vermont + `}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFeatures([]byte(tt.body), "")
+			if !errors.Is(err, ErrInvalidGeometry) {
+				t.Errorf("expected ErrInvalidGeometry, got %v", err)
+			}
+		})
+	}
+}
+
+func TestShapeContains(t *testing.T) {
+	polygon, err := Compile(json.RawMessage(vermont), 0)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	circle, err := Compile(json.RawMessage(`{"type":"Point","coordinates":[-73.2121,44.4759]}`), 1000)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		shape    *Shape
+		lat, lng float64
+		expected bool
+	}{
+		{"Inside polygon", polygon, 44.26, -72.58, true},
+		{"Outside polygon", polygon, 42.36, -71.06, false},
+		{"Inside hole", polygon, 44.4759, -73.2121, false},
+		{"Inside circle", circle, 44.4780, -73.2100, true},
+		{"Outside circle", circle, 44.26, -72.58, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.shape.Contains(tt.lat, tt.lng); got != tt.expected {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lat, tt.lng, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	fences := []models.Geofence{
+		{ID: "vt", Collection: "states", Name: "Vermont", Geometry: json.RawMessage(vermont)},
+		{ID: "hq", Collection: "offices", Name: "HQ", Geometry: json.RawMessage(`{"type":"Point","coordinates":[-72.58,44.26]}`), RadiusM: 1000},
+		{ID: "broken", Geometry: json.RawMessage(`{"type":"Point","coordinates":[-72.58,44.26]}`)},
+	}
+
+	matches := Check(fences, 44.26, -72.58)
+	if len(matches) != 2 || matches[0].ID != "vt" || matches[1].ID != "hq" {
+		t.Errorf("expected matches vt and hq, got %+v", matches)
+	}
+
+	if matches := Check(fences, 0, 0); len(matches) != 0 {
+		t.Errorf("expected no matches, got %+v", matches)
+	}
+}
@@ -0,0 +1,217 @@
+package geoip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// Sources of the hosting, VPN, proxy and Tor flags in a GeoIPAPIResponse
+const (
+	PrivacySourceIPinfo      = "ipinfo_privacy"   // IPinfo's privacy detection, on plans that include it
+	PrivacySourceHostingList = "hosting_asn_list" // Only the hosting flag, from hostingASNs
+)
+
+var (
+	// ErrInvalidASN is returned for values that aren't an AS number
+	ErrInvalidASN = errors.New("invalid ASN")
+	// ErrASNNotFound is returned when IPinfo has no record of an AS number
+	ErrASNNotFound = errors.New("ASN not found")
+	// ErrTokenRequired is returned for lookups IPinfo only answers with an API token
+	ErrTokenRequired = errors.New("IPinfo API token required")
+)
+
+// hostingASNs lists the autonomous systems of large cloud, hosting and CDN providers. Traffic from
+// them is rarely a person at home, so it is flagged as hosting when IPinfo's privacy data isn't available.
+var hostingASNs = map[int]string{
+	7506:   "GMO Internet",
+	8075:   "Microsoft",
+	8560:   "IONOS",
+	9009:   "M247",
+	12876:  "Scaleway",
+	13335:  "Cloudflare",
+	14061:  "DigitalOcean",
+	14618:  "Amazon",
+	15169:  "Google",
+	16276:  "OVH",
+	16509:  "Amazon",
+	19318:  "Interserver",
+	20473:  "Vultr",
+	20940:  "Akamai",
+	24940:  "Hetzner",
+	26496:  "GoDaddy",
+	28753:  "Leaseweb",
+	30633:  "Leaseweb",
+	31898:  "Oracle Cloud",
+	32613:  "iWeb",
+	36352:  "ColoCrossing",
+	37963:  "Alibaba Cloud",
+	40676:  "Psychz Networks",
+	45102:  "Alibaba Cloud",
+	46606:  "Unified Layer",
+	51167:  "Contabo",
+	53667:  "FranTech Solutions",
+	54113:  "Fastly",
+	60781:  "Leaseweb",
+	63949:  "Akamai Connected Cloud (Linode)",
+	132203: "Tencent Cloud",
+	197540: "netcup",
+	212238: "Datacamp (CDN77)",
+	396982: "Google Cloud",
+}
+
+// IsHostingASN reports whether an AS number belongs to a known cloud, hosting or CDN provider
+func IsHostingASN(asn int) bool {
+	_, ok := hostingASNs[asn]
+	return ok
+}
+
+// ParseASN reads an AS number written as "15169" or "AS15169"
+func ParseASN(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if len(value) > 2 && strings.EqualFold(value[:2], "AS") {
+		value = value[2:]
+	}
+	asn, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || asn == 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidASN, value)
+	}
+	return int(asn), nil
+}
+
+// ParseOrg splits IPinfo's "AS15169 Google LLC" org string into the AS number and name
+func ParseOrg(org string) (int, string) {
+	prefix, name, _ := strings.Cut(strings.TrimSpace(org), " ")
+	if !strings.HasPrefix(strings.ToUpper(prefix), "AS") {
+		return 0, ""
+	}
+	asn, err := ParseASN(prefix)
+	if err != nil {
+		return 0, ""
+	}
+	return asn, strings.TrimSpace(name)
+}
+
+// applyNetworkInfo copies the ASN and privacy data IPinfo sends on paid plans
+func applyNetworkInfo(response *models.GeoIPAPIResponse, ipinfoResp *IPInfoResponse) {
+	if ipinfoResp.ASN != nil {
+		if asn, err := ParseASN(ipinfoResp.ASN.ASN); err == nil {
+			response.ASN = asn
+		}
+		response.ASNName = ipinfoResp.ASN.Name
+		response.Network = ipinfoResp.ASN.Route
+		response.Hosting = ipinfoResp.ASN.Type == "hosting"
+	}
+	if ipinfoResp.Privacy != nil {
+		response.Hosting = response.Hosting || ipinfoResp.Privacy.Hosting
+		response.VPN = ipinfoResp.Privacy.VPN
+		response.Proxy = ipinfoResp.Privacy.Proxy || ipinfoResp.Privacy.Relay
+		response.Tor = ipinfoResp.Privacy.Tor
+		response.PrivacySource = PrivacySourceIPinfo
+	}
+}
+
+// normalizeNetwork fills in the ASN from the org string and flags known hosting providers, unless
+// IPinfo's privacy detection has already decided
+func normalizeNetwork(response *models.GeoIPAPIResponse) {
+	if response.ASN == 0 && response.Org != "" {
+		response.ASN, response.ASNName = ParseOrg(response.Org)
+	}
+	if response.PrivacySource == "" && IsHostingASN(response.ASN) {
+		response.Hosting = true
+		response.PrivacySource = PrivacySourceHostingList
+	}
+}
+
+// ipinfoASNResponse is IPinfo's ASN API response
+type ipinfoASNResponse struct {
+	ASN       string         `json:"asn"`
+	Name      string         `json:"name"`
+	Country   string         `json:"country"`
+	Allocated string         `json:"allocated"`
+	Registry  string         `json:"registry"`
+	Domain    string         `json:"domain"`
+	NumIPs    int64          `json:"num_ips"`
+	Type      string         `json:"type"`
+	Prefixes  []ipinfoPrefix `json:"prefixes"`
+	Prefixes6 []ipinfoPrefix `json:"prefixes6"`
+}
+
+type ipinfoPrefix struct {
+	Netblock string `json:"netblock"`
+	Name     string `json:"name"`
+	Country  string `json:"country"`
+}
+
+// GetASNInfoToStandardFormat looks up an autonomous system with IPinfo's ASN API, which needs a token
+func (c *Client) GetASNInfoToStandardFormat(asn int) (*models.ASNAPIResponse, error) {
+	if c.apiKey == "" {
+		return nil, ErrTokenRequired
+	}
+
+	resp, err := c.httpClient.Get(fmt.Sprintf("%s/AS%d/json?token=%s", c.baseURL, asn, c.apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to IPinfo API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: AS%d", ErrASNNotFound, asn)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("IPinfo API returned status %d", resp.StatusCode)
+	}
+
+	var asnResp ipinfoASNResponse
+	if err := json.NewDecoder(resp.Body).Decode(&asnResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	response := &models.ASNAPIResponse{
+		ASN:                asn,
+		Name:               asnResp.Name,
+		CountryCode:        asnResp.Country,
+		Domain:             asnResp.Domain,
+		Registry:           asnResp.Registry,
+		Allocated:          asnResp.Allocated,
+		Type:               asnResp.Type,
+		Hosting:            asnResp.Type == "hosting" || IsHostingASN(asn),
+		NumIPs:             asnResp.NumIPs,
+		Prefixes:           convertPrefixes(asnResp.Prefixes),
+		Prefixes6:          convertPrefixes(asnResp.Prefixes6),
+		Backend:            "ipinfo_api",
+		RawBackendResponse: asnResp,
+	}
+	return response, nil
+}
+
+// HostingASNResponse answers an ASN lookup from the built-in hosting list, for servers without an
+// IPinfo token
+func HostingASNResponse(asn int) (*models.ASNAPIResponse, bool) {
+	name, ok := hostingASNs[asn]
+	if !ok {
+		return nil, false
+	}
+	return &models.ASNAPIResponse{
+		ASN:       asn,
+		Name:      name,
+		Type:      "hosting",
+		Hosting:   true,
+		Prefixes:  []models.ASNPrefix{},
+		Prefixes6: []models.ASNPrefix{},
+		Backend:   PrivacySourceHostingList,
+	}, true
+}
+
+func convertPrefixes(prefixes []ipinfoPrefix) []models.ASNPrefix {
+	result := make([]models.ASNPrefix, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		result = append(result, models.ASNPrefix{Network: prefix.Netblock, Name: prefix.Name, CountryCode: prefix.Country})
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package geoip
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+func TestParseASN(t *testing.T) {
+	for value, expected := range map[string]int{"15169": 15169, "AS15169": 15169, "as13335": 13335, " 4200000000 ": 4200000000} {
+		if asn, err := ParseASN(value); err != nil || asn != expected {
+			t.Errorf("ParseASN(%q) = %d, %v, expected %d", value, asn, err, expected)
+		}
+	}
+	for _, value := range []string{"", "AS", "0", "ASN15169", "99999999999", "-1"} {
+		if _, err := ParseASN(value); !errors.Is(err, ErrInvalidASN) {
+			t.Errorf("ParseASN(%q) error = %v, expected ErrInvalidASN", value, err)
+		}
+	}
+}
+
+func TestParseOrg(t *testing.T) {
+	asn, name := ParseOrg("AS15169 Google LLC")
+	if asn != 15169 || name != "Google LLC" {
+		t.Errorf("ParseOrg() = %d, %q", asn, name)
+	}
+	if asn, name := ParseOrg("Some ISP"); asn != 0 || name != "" {
+		t.Errorf("Expected no ASN from an org without one, got %d, %q", asn, name)
+	}
+}
+
+func TestNormalizeResponse_HostingList(t *testing.T) {
+	response := &models.GeoIPAPIResponse{Org: "AS14061 DigitalOcean, LLC"}
+	NormalizeResponse(response)
+	if response.ASN != 14061 || response.ASNName != "DigitalOcean, LLC" || !response.Hosting || response.PrivacySource != PrivacySourceHostingList {
+		t.Errorf("Expected a DigitalOcean hosting flag, got %+v", response)
+	}
+
+	// IPinfo's own privacy data wins over the list
+	response = &models.GeoIPAPIResponse{Org: "AS14061 DigitalOcean, LLC", ASN: 14061, PrivacySource: PrivacySourceIPinfo}
+	NormalizeResponse(response)
+	if response.Hosting {
+		t.Error("Expected the hosting list not to override IPinfo privacy data")
+	}
+
+	response = &models.GeoIPAPIResponse{Org: "AS7922 Comcast Cable Communications, LLC"}
+	NormalizeResponse(response)
+	if response.Hosting || response.PrivacySource != "" {
+		t.Errorf("Expected a residential ISP to have no flags, got %+v", response)
+	}
+}
+
+func TestGetIPInfoToStandardFormat_NetworkInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"ip": "185.220.101.1",
+			"hostname": "tor-exit.example.org",
+			"country": "DE",
+			"loc": "52.52,13.40",
+			"org": "AS60729 Stiftung Erneuerbare Freiheit",
+			"asn": {"asn": "AS60729", "name": "Stiftung Erneuerbare Freiheit", "route": "185.220.101.0/24", "type": "hosting"},
+			"privacy": {"vpn": false, "proxy": false, "tor": true, "relay": false, "hosting": true, "service": ""}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	response, err := client.GetIPInfoToStandardFormat("185.220.101.1")
+	if err != nil {
+		t.Fatalf("GetIPInfoToStandardFormat() error = %v", err)
+	}
+	if response.ASN != 60729 || response.Network != "185.220.101.0/24" || response.Hostname != "tor-exit.example.org" {
+		t.Errorf("Unexpected network info %+v", response)
+	}
+	if !response.Tor || !response.Hosting || response.VPN || response.PrivacySource != PrivacySourceIPinfo {
+		t.Errorf("Unexpected privacy flags %+v", response)
+	}
+}
+
+func TestGetASNInfoToStandardFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/AS15169/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{
+			"asn": "AS15169", "name": "Google LLC", "country": "US", "allocated": "2000-03-30", "registry": "arin",
+			"domain": "google.com", "num_ips": 15000000, "type": "hosting",
+			"prefixes": [{"netblock": "8.8.8.0/24", "id": "LVLT-GOGL-8-8-8", "name": "Google LLC", "country": "US"}]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("token")
+	client.baseURL = server.URL
+
+	response, err := client.GetASNInfoToStandardFormat(15169)
+	if err != nil {
+		t.Fatalf("GetASNInfoToStandardFormat() error = %v", err)
+	}
+	if response.Name != "Google LLC" || !response.Hosting || len(response.Prefixes) != 1 || response.Prefixes[0].Network != "8.8.8.0/24" {
+		t.Errorf("Unexpected ASN response %+v", response)
+	}
+
+	if _, err := client.GetASNInfoToStandardFormat(64512); !errors.Is(err, ErrASNNotFound) {
+		t.Errorf("Expected ErrASNNotFound, got %v", err)
+	}
+	if _, err := NewClient("").GetASNInfoToStandardFormat(15169); !errors.Is(err, ErrTokenRequired) {
+		t.Errorf("Expected ErrTokenRequired without a token, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package geoip
+
+import (
+	"net"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// Reasons an address is a bogon, i.e. can't be geolocated because it isn't routed on the public internet
+const (
+	BogonPrivate       = "private"       // RFC 1918 and IPv6 unique local addresses
+	BogonLoopback      = "loopback"      // 127.0.0.0/8 and ::1
+	BogonLinkLocal     = "link_local"    // 169.254.0.0/16 and fe80::/10
+	BogonCGNAT         = "cgnat"         // RFC 6598 carrier-grade NAT space
+	BogonDocumentation = "documentation" // Example ranges from RFC 5737 and RFC 3849
+	BogonMulticast     = "multicast"
+	BogonUnspecified   = "unspecified" // 0.0.0.0/8 and ::
+	BogonReserved      = "reserved"    // Other special-purpose ranges, such as benchmarking and 240.0.0.0/4
+)
+
+type bogonRange struct {
+	network *net.IPNet
+	reason  string
+}
+
+// bogonRanges are checked in order, so more specific ranges come before the ones that contain them
+var bogonRanges = parseBogonRanges([][2]string{
+	{"0.0.0.0/8", BogonUnspecified},
+	{"10.0.0.0/8", BogonPrivate},
+	{"100.64.0.0/10", BogonCGNAT},
+	{"127.0.0.0/8", BogonLoopback},
+	{"169.254.0.0/16", BogonLinkLocal},
+	{"172.16.0.0/12", BogonPrivate},
+	{"192.0.0.0/24", BogonReserved},
+	{"192.0.2.0/24", BogonDocumentation},
+	{"192.168.0.0/16", BogonPrivate},
+	{"198.18.0.0/15", BogonReserved},
+	{"198.51.100.0/24", BogonDocumentation},
+	{"203.0.113.0/24", BogonDocumentation},
+	{"224.0.0.0/4", BogonMulticast},
+	{"240.0.0.0/4", BogonReserved},
+	{"::/128", BogonUnspecified},
+	{"::1/128", BogonLoopback},
+	{"100::/64", BogonReserved},
+	{"2001:db8::/32", BogonDocumentation},
+	{"fc00::/7", BogonPrivate},
+	{"fe80::/10", BogonLinkLocal},
+	{"ff00::/8", BogonMulticast},
+})
+
+func parseBogonRanges(ranges [][2]string) []bogonRange {
+	result := make([]bogonRange, 0, len(ranges))
+	for _, r := range ranges {
+		_, network, err := net.ParseCIDR(r[0])
+		if err != nil {
+			panic(err)
+		}
+		result = append(result, bogonRange{network: network, reason: r[1]})
+	}
+	return result
+}
+
+// BogonReason reports whether an IP address is private, reserved or otherwise not publicly routed, and
+// why. IPv4-mapped IPv6 addresses such as ::ffff:10.0.0.1 are checked as IPv4.
+func BogonReason(ip net.IP) (string, bool) {
+	if r := findBogonRange(ip); r != nil {
+		return r.reason, true
+	}
+	return "", false
+}
+
+// BogonResponse answers a lookup for a bogon address without asking IPinfo, which would only return
+// {"bogon": true} and still count against the quota
+func BogonResponse(ip string) (*models.GeoIPAPIResponse, bool) {
+	r := findBogonRange(net.ParseIP(ip))
+	if r == nil {
+		return nil, false
+	}
+	return &models.GeoIPAPIResponse{
+		IP:          ip,
+		Network:     r.network.String(),
+		Bogon:       true,
+		BogonReason: r.reason,
+		Backend:     "offline",
+	}, true
+}
+
+func findBogonRange(ip net.IP) *bogonRange {
+	if ip == nil {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for i := range bogonRanges {
+		if bogonRanges[i].network.Contains(ip) {
+			return &bogonRanges[i]
+		}
+	}
+	return nil
+}
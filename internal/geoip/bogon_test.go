@@ -0,0 +1,50 @@
+package geoip
+
+import (
+	"net"
+	"testing"
+)
+
+func TestBogonReason(t *testing.T) {
+	tests := map[string]string{
+		"10.0.0.1":        BogonPrivate,
+		"172.31.255.255":  BogonPrivate,
+		"192.168.1.1":     BogonPrivate,
+		"127.0.0.1":       BogonLoopback,
+		"169.254.169.254": BogonLinkLocal,
+		"100.64.0.1":      BogonCGNAT,
+		"192.0.2.10":      BogonDocumentation,
+		"203.0.113.5":     BogonDocumentation,
+		"224.0.0.251":     BogonMulticast,
+		"0.0.0.0":         BogonUnspecified,
+		"255.255.255.255": BogonReserved,
+		"::1":             BogonLoopback,
+		"::":              BogonUnspecified,
+		"fd12:3456::1":    BogonPrivate,
+		"fe80::1":         BogonLinkLocal,
+		"2001:db8::1":     BogonDocumentation,
+		"ff02::1":         BogonMulticast,
+		"::ffff:10.0.0.1": BogonPrivate,
+	}
+	for ip, expected := range tests {
+		if reason, ok := BogonReason(net.ParseIP(ip)); !ok || reason != expected {
+			t.Errorf("BogonReason(%s) = %q, %v, expected %q", ip, reason, ok, expected)
+		}
+	}
+
+	for _, ip := range []string{"8.8.8.8", "100.128.0.1", "172.32.0.1", "2001:4860:4860::8888"} {
+		if reason, ok := BogonReason(net.ParseIP(ip)); ok {
+			t.Errorf("Expected %s to be public, got %q", ip, reason)
+		}
+	}
+}
+
+func TestBogonResponse(t *testing.T) {
+	response, ok := BogonResponse("192.168.0.10")
+	if !ok || !response.Bogon || response.BogonReason != BogonPrivate || response.Network != "192.168.0.0/16" || response.IP != "192.168.0.10" {
+		t.Errorf("Unexpected bogon response %+v", response)
+	}
+	if _, ok := BogonResponse("8.8.8.8"); ok {
+		t.Error("Expected no bogon response for a public address")
+	}
+}
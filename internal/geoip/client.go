@@ -8,28 +8,57 @@ import (
 	"strings"
 	"time"
 
+	"github.com/hackclub/geocoder/internal/iso3166"
 	"github.com/hackclub/geocoder/internal/models"
 )
 
+const ipinfoBaseURL = "https://ipinfo.io"
+
 type Client struct {
 	apiKey     string
+	baseURL    string
 	httpClient *http.Client
 }
 
 type IPInfoResponse struct {
-	IP       string `json:"ip"`
-	City     string `json:"city"`
-	Region   string `json:"region"`
-	Country  string `json:"country"`
-	Loc      string `json:"loc"` // "lat,lng" format
-	Org      string `json:"org"`
-	Postal   string `json:"postal"`
-	Timezone string `json:"timezone"`
+	IP       string         `json:"ip"`
+	Hostname string         `json:"hostname,omitempty"`
+	City     string         `json:"city"`
+	Region   string         `json:"region"`
+	Country  string         `json:"country"`
+	Loc      string         `json:"loc"` // "lat,lng" format
+	Org      string         `json:"org"` // "AS15169 Google LLC" format
+	Postal   string         `json:"postal"`
+	Timezone string         `json:"timezone"`
+	Anycast  bool           `json:"anycast,omitempty"`
+	Bogon    bool           `json:"bogon,omitempty"`
+	ASN      *IPInfoASN     `json:"asn,omitempty"`     // Only on IPinfo plans with ASN data
+	Privacy  *IPInfoPrivacy `json:"privacy,omitempty"` // Only on IPinfo plans with privacy detection
+}
+
+// IPInfoASN is the autonomous system an IP address is announced from
+type IPInfoASN struct {
+	ASN    string `json:"asn"` // "AS15169" format
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	Route  string `json:"route"` // CIDR, e.g. "8.8.8.0/24"
+	Type   string `json:"type"`  // "hosting", "isp", "business", "education" or "government"
+}
+
+// IPInfoPrivacy holds IPinfo's anonymous IP detection
+type IPInfoPrivacy struct {
+	VPN     bool   `json:"vpn"`
+	Proxy   bool   `json:"proxy"`
+	Tor     bool   `json:"tor"`
+	Relay   bool   `json:"relay"`
+	Hosting bool   `json:"hosting"`
+	Service string `json:"service"`
 }
 
 func NewClient(apiKey string) *Client {
 	return &Client{
-		apiKey: apiKey,
+		apiKey:  apiKey,
+		baseURL: ipinfoBaseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -39,10 +68,10 @@ func NewClient(apiKey string) *Client {
 func (c *Client) GetIPInfo(ip string) (*IPInfoResponse, error) {
 	var url string
 	if c.apiKey != "" {
-		url = fmt.Sprintf("https://ipinfo.io/%s?token=%s", ip, c.apiKey)
+		url = fmt.Sprintf("%s/%s?token=%s", c.baseURL, ip, c.apiKey)
 	} else {
 		// Use free tier without API key (limited to 50k/month)
-		url = fmt.Sprintf("https://ipinfo.io/%s/json", ip)
+		url = fmt.Sprintf("%s/%s/json", c.baseURL, ip)
 	}
 
 	resp, err := c.httpClient.Get(url)
@@ -69,139 +98,45 @@ func (c *Client) IsConfigured() bool {
 
 // GetCountryName converts country code to full country name
 func (c *Client) GetCountryName(countryCode string) string {
-	countryMap := map[string]string{
-		"US": "United States",
-		"CA": "Canada",
-		"GB": "United Kingdom",
-		"DE": "Germany",
-		"FR": "France",
-		"JP": "Japan",
-		"AU": "Australia",
-		"CN": "China",
-		"IN": "India",
-		"BR": "Brazil",
-		"RU": "Russia",
-		"IT": "Italy",
-		"ES": "Spain",
-		"KR": "South Korea",
-		"NL": "Netherlands",
-		"SE": "Sweden",
-		"NO": "Norway",
-		"DK": "Denmark",
-		"FI": "Finland",
-		"CH": "Switzerland",
-		"AT": "Austria",
-		"BE": "Belgium",
-		"IE": "Ireland",
-		"PT": "Portugal",
-		"GR": "Greece",
-		"PL": "Poland",
-		"CZ": "Czech Republic",
-		"HU": "Hungary",
-		"SK": "Slovakia",
-		"SI": "Slovenia",
-		"HR": "Croatia",
-		"BG": "Bulgaria",
-		"RO": "Romania",
-		"LT": "Lithuania",
-		"LV": "Latvia",
-		"EE": "Estonia",
-		"LU": "Luxembourg",
-		"MT": "Malta",
-		"CY": "Cyprus",
-		"IS": "Iceland",
-		"LI": "Liechtenstein",
-		"MC": "Monaco",
-		"SM": "San Marino",
-		"VA": "Vatican City",
-		"AD": "Andorra",
-		"MX": "Mexico",
-		"AR": "Argentina",
-		"CL": "Chile",
-		"CO": "Colombia",
-		"PE": "Peru",
-		"VE": "Venezuela",
-		"UY": "Uruguay",
-		"PY": "Paraguay",
-		"BO": "Bolivia",
-		"EC": "Ecuador",
-		"GY": "Guyana",
-		"SR": "Suriname",
-		"FK": "Falkland Islands",
-		"ZA": "South Africa",
-		"EG": "Egypt",
-		"NG": "Nigeria",
-		"KE": "Kenya",
-		"MA": "Morocco",
-		"GH": "Ghana",
-		"TN": "Tunisia",
-		"DZ": "Algeria",
-		"LY": "Libya",
-		"SD": "Sudan",
-		"ET": "Ethiopia",
-		"UG": "Uganda",
-		"TZ": "Tanzania",
-		"MZ": "Mozambique",
-		"MG": "Madagascar",
-		"ZW": "Zimbabwe",
-		"BW": "Botswana",
-		"NA": "Namibia",
-		"ZM": "Zambia",
-		"MW": "Malawi",
-		"SZ": "Eswatini",
-		"LS": "Lesotho",
-		"ID": "Indonesia",
-		"MY": "Malaysia",
-		"TH": "Thailand",
-		"VN": "Vietnam",
-		"PH": "Philippines",
-		"SG": "Singapore",
-		"MM": "Myanmar",
-		"KH": "Cambodia",
-		"LA": "Laos",
-		"BN": "Brunei",
-		"TL": "East Timor",
-		"IL": "Israel",
-		"TR": "Turkey",
-		"SA": "Saudi Arabia",
-		"AE": "United Arab Emirates",
-		"QA": "Qatar",
-		"KW": "Kuwait",
-		"BH": "Bahrain",
-		"OM": "Oman",
-		"JO": "Jordan",
-		"LB": "Lebanon",
-		"SY": "Syria",
-		"IQ": "Iraq",
-		"IR": "Iran",
-		"AF": "Afghanistan",
-		"PK": "Pakistan",
-		"BD": "Bangladesh",
-		"LK": "Sri Lanka",
-		"MV": "Maldives",
-		"NP": "Nepal",
-		"BT": "Bhutan",
-		"NZ": "New Zealand",
-		"FJ": "Fiji",
-		"PG": "Papua New Guinea",
-		"SB": "Solomon Islands",
-		"VU": "Vanuatu",
-		"NC": "New Caledonia",
-		"PF": "French Polynesia",
-		"WS": "Samoa",
-		"KI": "Kiribati",
-		"TO": "Tonga",
-		"FM": "Micronesia",
-		"MH": "Marshall Islands",
-		"PW": "Palau",
-		"NR": "Nauru",
-		"TV": "Tuvalu",
+	return iso3166.CountryName(countryCode) // Falls back to the country code if not found
+}
+
+// NormalizeResponse fills in ISO 3166 country and region codes, which IPinfo leaves out, and the ASN and
+// hosting flag from the org string. It is also applied to cached responses, so entries stored before
+// these fields existed gain them on read.
+func NormalizeResponse(response *models.GeoIPAPIResponse) {
+	normalizeNetwork(response)
+
+	country, ok := iso3166.LookupCountry(response.CountryCode)
+	if !ok {
+		return
+	}
+	if response.CountryName == "" || response.CountryName == response.CountryCode {
+		response.CountryName = country.Name
+	}
+	response.CountryAlpha3 = country.Alpha3
+	response.CountryNumeric = country.Numeric
+
+	// IPinfo's region is a free-text name such as "California" or "England"
+	if response.RegionCode == "" {
+		if subdivision, ok := iso3166.FindSubdivision(country.Alpha2, response.Region); ok {
+			response.RegionCode = subdivision.Code
+		}
+	}
+}
+
+// LocalizeResponse translates the country and region names of a normalized response. City names
+// are translated by the gazetteer, which has them.
+func LocalizeResponse(response *models.GeoIPAPIResponse, language string) {
+	if language == "" {
+		return
+	}
+	if name, ok := iso3166.LocalizedCountryName(response.CountryCode, language); ok {
+		response.CountryName = name
 	}
-	
-	if name, exists := countryMap[countryCode]; exists {
-		return name
+	if name, ok := iso3166.LocalizedSubdivisionName(response.RegionCode, language); ok {
+		response.Region = name
 	}
-	return countryCode // Fallback to country code if not found
 }
 
 // GetIPInfoToStandardFormat converts IPinfo response to our standard format
@@ -232,9 +167,14 @@ func (c *Client) GetIPInfoToStandardFormat(ip string) (*models.GeoIPAPIResponse,
 		PostalCode:         ipinfoResp.Postal,
 		Timezone:           ipinfoResp.Timezone,
 		Org:                ipinfoResp.Org,
+		Hostname:           ipinfoResp.Hostname,
+		Anycast:            ipinfoResp.Anycast,
+		Bogon:              ipinfoResp.Bogon,
 		Backend:            "ipinfo_api",
 		RawBackendResponse: ipinfoResp,
 	}
+	applyNetworkInfo(response, ipinfoResp)
+	NormalizeResponse(response)
 
 	return response, nil
 }
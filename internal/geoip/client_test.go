@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
 )
 
 func TestGeoIPClient_IsConfigured(t *testing.T) {
@@ -54,3 +56,45 @@ func TestGeoIPClient_GetIPInfo(t *testing.T) {
 		t.Error("Client should be configured")
 	}
 }
+
+func TestNormalizeResponse(t *testing.T) {
+	response := &models.GeoIPAPIResponse{
+		Region:      "England",
+		CountryName: "GB",
+		CountryCode: "GB",
+	}
+
+	NormalizeResponse(response)
+
+	if response.CountryName != "United Kingdom" {
+		t.Errorf("Expected country name 'United Kingdom', got '%s'", response.CountryName)
+	}
+	if response.CountryAlpha3 != "GBR" || response.CountryNumeric != "826" {
+		t.Errorf("Expected GBR/826, got %s/%s", response.CountryAlpha3, response.CountryNumeric)
+	}
+	if response.RegionCode != "GB-ENG" {
+		t.Errorf("Expected region code 'GB-ENG', got '%s'", response.RegionCode)
+	}
+}
+
+func TestLocalizeResponse(t *testing.T) {
+	response := &models.GeoIPAPIResponse{
+		City:        "Munich",
+		Region:      "Bavaria",
+		RegionCode:  "DE-BY",
+		CountryName: "Germany",
+		CountryCode: "DE",
+	}
+
+	LocalizeResponse(response, "fr")
+
+	if response.CountryName != "Allemagne" {
+		t.Errorf("Expected country name 'Allemagne', got '%s'", response.CountryName)
+	}
+	if response.Region != "Bavière" {
+		t.Errorf("Expected region 'Bavière', got '%s'", response.Region)
+	}
+	if response.City != "Munich" {
+		t.Errorf("Expected city to be unchanged, got '%s'", response.City)
+	}
+}
@@ -0,0 +1,161 @@
+//go:build ignore
+
+// gen_translations builds data/translations.json from the gettext catalogs that the iso-codes
+// package installs under /usr/share/locale, keyed by ISO code so lookups don't depend on English names.
+//
+// Usage: go generate ./internal/iso3166/
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const localeDir = "/usr/share/locale"
+
+// minCountryTranslations drops languages whose catalogs are too incomplete to be useful
+const minCountryTranslations = 200
+
+func main() {
+	countryCodes, countryNameKinds := namesToCodes("data/iso_3166-1.json", "3166-1", "alpha_2", "name", "common_name")
+	subdivisionCodes, _ := namesToCodes("data/iso_3166-2.json", "3166-2", "code", "name")
+
+	translations := map[string]map[string]string{}
+	// Later calls win, so the common name ("South Korea") replaces the ISO name ("Korea, Republic of").
+	// Names that translate to themselves are left out, since lookups fall back to English anyway.
+	add := func(language string, catalog map[string]string, codes map[string][]string) {
+		if translations[language] == nil {
+			translations[language] = map[string]string{}
+		}
+		for msgid, msgstr := range catalog {
+			for _, code := range codes[msgid] {
+				if msgstr == msgid {
+					delete(translations[language], code)
+					continue
+				}
+				translations[language][code] = msgstr
+			}
+		}
+	}
+
+	countryCatalogs, _ := filepath.Glob(filepath.Join(localeDir, "*", "LC_MESSAGES", "iso_3166-1.mo"))
+	for _, path := range countryCatalogs {
+		catalog := readCatalog(path)
+		if len(catalog) < minCountryTranslations {
+			continue
+		}
+		language := languageTag(path)
+		add(language, filterCatalog(catalog, countryNameKinds, "name"), countryCodes)
+		add(language, filterCatalog(catalog, countryNameKinds, "common_name"), countryCodes)
+	}
+
+	subdivisionCatalogs, _ := filepath.Glob(filepath.Join(localeDir, "*", "LC_MESSAGES", "iso_3166-2.mo"))
+	for _, path := range subdivisionCatalogs {
+		language := languageTag(path)
+		if _, ok := translations[language]; !ok && language != "en" {
+			continue
+		}
+		add(language, readCatalog(path), subdivisionCodes)
+	}
+
+	data, err := json.Marshal(translations)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("data/translations.json", data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote translations for %d languages\n", len(translations))
+}
+
+// namesToCodes maps every English name in an iso-codes JSON file to the codes that carry it,
+// and each name to the field it came from
+func namesToCodes(path, key, codeField string, nameFields ...string) (map[string][]string, map[string]string) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var entries map[string][]map[string]string
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		log.Fatal(err)
+	}
+
+	codes := map[string][]string{}
+	kinds := map[string]string{}
+	for _, entry := range entries[key] {
+		for _, field := range nameFields {
+			if name := entry[field]; name != "" {
+				codes[name] = append(codes[name], entry[codeField])
+				kinds[name] = field
+			}
+		}
+	}
+	for _, list := range codes {
+		sort.Strings(list)
+	}
+	return codes, kinds
+}
+
+// filterCatalog keeps the catalog entries whose English name came from the given field
+func filterCatalog(catalog map[string]string, kinds map[string]string, field string) map[string]string {
+	filtered := map[string]string{}
+	for msgid, msgstr := range catalog {
+		if kinds[msgid] == field {
+			filtered[msgid] = msgstr
+		}
+	}
+	return filtered
+}
+
+// languageTag turns a locale directory name such as "pt_BR" or "sr@latin" into a BCP 47 tag
+func languageTag(path string) string {
+	locale := filepath.Base(filepath.Dir(filepath.Dir(path)))
+	switch locale {
+	case "nb_NO":
+		return "nb"
+	case "bn_BD":
+		return "bn"
+	}
+	// Tatar's iqtelif and Serbian's @latin are both Latin-script variants
+	locale = strings.Replace(locale, "@latin", "-Latn", 1)
+	locale = strings.Replace(locale, "@iqtelif", "-Latn", 1)
+	return strings.Replace(locale, "_", "-", 1)
+}
+
+// readCatalog reads the translated messages of a GNU .mo file
+func readCatalog(path string) map[string]string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if binary.LittleEndian.Uint32(data) != 0x950412de {
+		order = binary.BigEndian
+	}
+	count := order.Uint32(data[8:])
+	originals := order.Uint32(data[12:])
+	translated := order.Uint32(data[16:])
+
+	str := func(table uint32, i uint32) string {
+		length := order.Uint32(data[table+8*i:])
+		offset := order.Uint32(data[table+8*i+4:])
+		return string(bytes.TrimRight(data[offset:offset+length], "\x00"))
+	}
+
+	catalog := map[string]string{}
+	for i := uint32(0); i < count; i++ {
+		msgid, msgstr := str(originals, i), str(translated, i)
+		if msgid != "" && msgstr != "" {
+			catalog[msgid] = msgstr
+		}
+	}
+	return catalog
+}
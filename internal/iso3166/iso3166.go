@@ -0,0 +1,247 @@
+// Package iso3166 provides the ISO 3166-1 country and ISO 3166-2 subdivision lists, embedded
+// from the iso-codes project, for normalizing names and codes from any backend.
+package iso3166
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed data/iso_3166-1.json
+var countriesJSON []byte
+
+//go:embed data/iso_3166-2.json
+var subdivisionsJSON []byte
+
+// Country is an ISO 3166-1 country
+type Country struct {
+	Alpha2       string `json:"alpha_2"`
+	Alpha3       string `json:"alpha_3"`
+	Numeric      string `json:"numeric"`
+	Name         string `json:"name"`          // Short name, using the common name where ISO's is formal, e.g. "South Korea"
+	ISOName      string `json:"iso_name"`      // ISO short name, e.g. "Korea, Republic of"
+	OfficialName string `json:"official_name"` // e.g. "Republic of Korea"
+	Flag         string `json:"flag"`
+}
+
+// Subdivision is an ISO 3166-2 subdivision such as a state, province or region
+type Subdivision struct {
+	Code        string `json:"code"` // e.g. "US-CA"
+	Name        string `json:"name"`
+	Type        string `json:"type"`   // e.g. "State", "Province"
+	Parent      string `json:"parent"` // Code of the enclosing subdivision, if any, e.g. "GB-ENG"
+	CountryCode string `json:"country_code"`
+}
+
+type dataset struct {
+	countries       []Country
+	byCode          map[string]*Country // alpha-2, alpha-3 and numeric codes
+	byName          map[string]*Country // lowercased short, ISO and official names
+	subdivisions    map[string][]Subdivision
+	subdivisionByID map[string]*Subdivision
+}
+
+var (
+	loadOnce sync.Once
+	data     *dataset
+)
+
+func load() *dataset {
+	loadOnce.Do(func() {
+		var rawCountries struct {
+			Countries []struct {
+				Alpha2       string `json:"alpha_2"`
+				Alpha3       string `json:"alpha_3"`
+				Numeric      string `json:"numeric"`
+				Name         string `json:"name"`
+				CommonName   string `json:"common_name"`
+				OfficialName string `json:"official_name"`
+				Flag         string `json:"flag"`
+			} `json:"3166-1"`
+		}
+		if err := json.Unmarshal(countriesJSON, &rawCountries); err != nil {
+			panic("iso3166: invalid embedded country data: " + err.Error())
+		}
+
+		var rawSubdivisions struct {
+			Subdivisions []struct {
+				Code   string `json:"code"`
+				Name   string `json:"name"`
+				Type   string `json:"type"`
+				Parent string `json:"parent"`
+			} `json:"3166-2"`
+		}
+		if err := json.Unmarshal(subdivisionsJSON, &rawSubdivisions); err != nil {
+			panic("iso3166: invalid embedded subdivision data: " + err.Error())
+		}
+
+		d := &dataset{
+			byCode:          make(map[string]*Country),
+			byName:          make(map[string]*Country),
+			subdivisions:    make(map[string][]Subdivision),
+			subdivisionByID: make(map[string]*Subdivision),
+		}
+
+		for _, raw := range rawCountries.Countries {
+			country := Country{
+				Alpha2:       raw.Alpha2,
+				Alpha3:       raw.Alpha3,
+				Numeric:      raw.Numeric,
+				Name:         raw.Name,
+				ISOName:      raw.Name,
+				OfficialName: raw.OfficialName,
+				Flag:         raw.Flag,
+			}
+			if raw.CommonName != "" {
+				country.Name = raw.CommonName
+			}
+			d.countries = append(d.countries, country)
+		}
+		sort.Slice(d.countries, func(i, j int) bool { return d.countries[i].Alpha2 < d.countries[j].Alpha2 })
+
+		for i := range d.countries {
+			country := &d.countries[i]
+			for _, code := range []string{country.Alpha2, country.Alpha3, country.Numeric} {
+				d.byCode[strings.ToUpper(code)] = country
+			}
+			for _, name := range []string{country.Name, country.ISOName, country.OfficialName} {
+				if name != "" {
+					d.byName[strings.ToLower(name)] = country
+				}
+			}
+		}
+
+		for _, raw := range rawSubdivisions.Subdivisions {
+			countryCode, _, _ := strings.Cut(raw.Code, "-")
+			parent := raw.Parent
+			if parent != "" && !strings.Contains(parent, "-") {
+				// Parents are sometimes given without the country prefix
+				parent = countryCode + "-" + parent
+			}
+			d.subdivisions[countryCode] = append(d.subdivisions[countryCode], Subdivision{
+				Code:        raw.Code,
+				Name:        raw.Name,
+				Type:        raw.Type,
+				Parent:      parent,
+				CountryCode: countryCode,
+			})
+		}
+		for countryCode, subdivisions := range d.subdivisions {
+			sort.Slice(subdivisions, func(i, j int) bool { return subdivisions[i].Code < subdivisions[j].Code })
+			for i := range subdivisions {
+				d.subdivisionByID[subdivisions[i].Code] = &d.subdivisions[countryCode][i]
+			}
+		}
+
+		data = d
+	})
+	return data
+}
+
+// Countries returns every ISO 3166-1 country, sorted by alpha-2 code
+func Countries() []Country {
+	countries := load().countries
+	result := make([]Country, len(countries))
+	copy(result, countries)
+	return result
+}
+
+// LookupCountry finds a country by alpha-2, alpha-3 or numeric code, ignoring case
+func LookupCountry(code string) (Country, bool) {
+	country, ok := load().byCode[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Country{}, false
+	}
+	return *country, true
+}
+
+// LookupCountryByName finds a country by its common, ISO or official English name, ignoring case
+func LookupCountryByName(name string) (Country, bool) {
+	country, ok := load().byName[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Country{}, false
+	}
+	return *country, true
+}
+
+// FindCountry finds a country from either a code or an English name, as backends send both
+func FindCountry(codeOrName string) (Country, bool) {
+	if country, ok := LookupCountry(codeOrName); ok {
+		return country, true
+	}
+	return LookupCountryByName(codeOrName)
+}
+
+// addressAliases are country spellings common in addresses that aren't ISO codes or names
+var addressAliases = map[string]string{
+	"uk":       "GB",
+	"usa":      "US",
+	"u.s.":     "US",
+	"u.s.a.":   "US",
+	"america":  "US",
+	"england":  "GB",
+	"scotland": "GB",
+	"wales":    "GB",
+}
+
+// FindCountryInAddress is FindCountry for the country line of a typed address, which also accepts
+// informal names such as "UK" and "England"
+func FindCountryInAddress(value string) (Country, bool) {
+	if code, ok := addressAliases[strings.ToLower(strings.TrimSpace(value))]; ok {
+		return LookupCountry(code)
+	}
+	return FindCountry(value)
+}
+
+// CountryName returns the English short name for a country code, or the code itself if it is unknown
+func CountryName(code string) string {
+	if country, ok := LookupCountry(code); ok {
+		return country.Name
+	}
+	return code
+}
+
+// Subdivisions returns the ISO 3166-2 subdivisions of a country, sorted by code. The country may be
+// given by any code LookupCountry accepts.
+func Subdivisions(countryCode string) ([]Subdivision, bool) {
+	country, ok := LookupCountry(countryCode)
+	if !ok {
+		return nil, false
+	}
+	subdivisions := load().subdivisions[country.Alpha2]
+	result := make([]Subdivision, len(subdivisions))
+	copy(result, subdivisions)
+	return result, true
+}
+
+// LookupSubdivision finds a subdivision by its full ISO 3166-2 code, e.g. "US-CA", ignoring case
+func LookupSubdivision(code string) (Subdivision, bool) {
+	subdivision, ok := load().subdivisionByID[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Subdivision{}, false
+	}
+	return *subdivision, true
+}
+
+// FindSubdivision finds a subdivision of a country from a backend's code suffix ("CA") or name ("California").
+// Codes are tried first since names vary between backends and languages.
+func FindSubdivision(countryCode, codeOrName string) (Subdivision, bool) {
+	country, ok := LookupCountry(countryCode)
+	codeOrName = strings.TrimSpace(codeOrName)
+	if !ok || codeOrName == "" {
+		return Subdivision{}, false
+	}
+
+	if subdivision, ok := LookupSubdivision(country.Alpha2 + "-" + codeOrName); ok {
+		return subdivision, true
+	}
+	for _, subdivision := range load().subdivisions[country.Alpha2] {
+		if strings.EqualFold(subdivision.Name, codeOrName) {
+			return subdivision, true
+		}
+	}
+	return Subdivision{}, false
+}
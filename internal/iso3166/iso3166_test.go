@@ -0,0 +1,173 @@
+package iso3166
+
+import "testing"
+
+func TestCountries(t *testing.T) {
+	countries := Countries()
+	if len(countries) < 249 {
+		t.Fatalf("Expected all ISO 3166-1 countries, got %d", len(countries))
+	}
+	for i := 1; i < len(countries); i++ {
+		if countries[i-1].Alpha2 >= countries[i].Alpha2 {
+			t.Fatalf("Expected countries sorted by alpha-2, got %s before %s", countries[i-1].Alpha2, countries[i].Alpha2)
+		}
+	}
+}
+
+func TestLookupCountry(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		{"US", "United States"},
+		{"us", "United States"},
+		{"USA", "United States"},
+		{"840", "United States"},
+		{"KR", "South Korea"},
+		{"TW", "Taiwan"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			country, ok := LookupCountry(tt.code)
+			if !ok || country.Name != tt.expected {
+				t.Errorf("LookupCountry(%q) = %+v, %v, want %s", tt.code, country, ok, tt.expected)
+			}
+		})
+	}
+
+	if _, ok := LookupCountry("XX"); ok {
+		t.Error("Expected unknown code to be missing")
+	}
+	if name := CountryName("XX"); name != "XX" {
+		t.Errorf("Expected CountryName to fall back to the code, got %s", name)
+	}
+}
+
+func TestFindCountry(t *testing.T) {
+	for _, query := range []string{"GB", "GBR", "United Kingdom", "united kingdom", "United Kingdom of Great Britain and Northern Ireland"} {
+		country, ok := FindCountry(query)
+		if !ok || country.Alpha2 != "GB" {
+			t.Errorf("FindCountry(%q) = %+v, %v, want GB", query, country, ok)
+		}
+	}
+}
+
+func TestFindCountryInAddress(t *testing.T) {
+	for query, expected := range map[string]string{"UK": "GB", "England": "GB", "U.S.A.": "US", "Germany": "DE", "FRA": "FR"} {
+		country, ok := FindCountryInAddress(query)
+		if !ok || country.Alpha2 != expected {
+			t.Errorf("FindCountryInAddress(%q) = %+v, %v, want %s", query, country, ok, expected)
+		}
+	}
+}
+
+func TestSubdivisions(t *testing.T) {
+	subdivisions, ok := Subdivisions("USA")
+	if !ok {
+		t.Fatal("Expected subdivisions for USA")
+	}
+	found := false
+	for _, subdivision := range subdivisions {
+		if subdivision.Code == "US-CA" && subdivision.Name == "California" && subdivision.CountryCode == "US" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected US-CA California among US subdivisions")
+	}
+
+	if _, ok := Subdivisions("XX"); ok {
+		t.Error("Expected unknown country to have no subdivisions")
+	}
+
+	// Parents given without a country prefix are expanded
+	subdivision, ok := LookupSubdivision("GB-CAM")
+	if !ok || subdivision.Parent != "GB-ENG" {
+		t.Errorf("Expected GB-CAM with parent GB-ENG, got %+v, %v", subdivision, ok)
+	}
+}
+
+func TestFindSubdivision(t *testing.T) {
+	tests := []struct {
+		country    string
+		codeOrName string
+		expected   string
+	}{
+		{"US", "CA", "US-CA"},
+		{"US", "california", "US-CA"},
+		{"GB", "England", "GB-ENG"},
+		{"DE", "BY", "DE-BY"},
+		{"CAN", "Ontario", "CA-ON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.codeOrName, func(t *testing.T) {
+			subdivision, ok := FindSubdivision(tt.country, tt.codeOrName)
+			if !ok || subdivision.Code != tt.expected {
+				t.Errorf("FindSubdivision(%q, %q) = %+v, %v, want %s", tt.country, tt.codeOrName, subdivision, ok, tt.expected)
+			}
+		})
+	}
+
+	if _, ok := FindSubdivision("US", "Atlantis"); ok {
+		t.Error("Expected unknown subdivision to be missing")
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		expected string
+		ok       bool
+	}{
+		{"es", "es", true},
+		{"es-MX", "es", true},
+		{"pt-br", "pt-BR", true},
+		{"pt_BR", "pt-BR", true},
+		{"zh", "zh-CN", true},
+		{"zh-Hant-TW", "zh-TW", true},
+		{"no", "nb", true},
+		{"xx", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			tag, ok := MatchLanguage(tt.language)
+			if tag != tt.expected || ok != tt.ok {
+				t.Errorf("MatchLanguage(%q) = %q, %v, want %q, %v", tt.language, tag, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLocalizedNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		lookup   func(code, language string) (string, bool)
+		code     string
+		language string
+		expected string
+	}{
+		{"spanish country", LocalizedCountryName, "US", "es", "Estados Unidos"},
+		{"japanese country", LocalizedCountryName, "JPN", "ja", "日本"},
+		{"french country", LocalizedCountryName, "DE", "fr-CA", "Allemagne"},
+		{"unknown language", LocalizedCountryName, "DE", "xx", "Germany"},
+		{"english subdivision", LocalizedSubdivisionName, "DE-BY", "en", "Bavaria"},
+		{"untranslated subdivision", LocalizedSubdivisionName, "US-CA", "es", "California"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, ok := tt.lookup(tt.code, tt.language)
+			if !ok || name != tt.expected {
+				t.Errorf("lookup(%q, %q) = %q, %v, want %q", tt.code, tt.language, name, ok, tt.expected)
+			}
+		})
+	}
+
+	if _, ok := LocalizedCountryName("XX", "es"); ok {
+		t.Error("Expected unknown country code to be missing")
+	}
+}
@@ -0,0 +1,122 @@
+package iso3166
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+//go:generate go run gen_translations.go
+
+//go:embed data/translations.json
+var translationsJSON []byte
+
+// languageAliases maps tags that have no catalog of their own to the closest one that does
+var languageAliases = map[string]string{
+	"zh":      "zh-CN",
+	"zh-Hans": "zh-CN",
+	"zh-SG":   "zh-CN",
+	"zh-Hant": "zh-TW",
+	"zh-MO":   "zh-HK",
+	"no":      "nb",
+	"nn-NO":   "nn",
+	"sr-Cyrl": "sr",
+	"tt-Cyrl": "tt",
+	"fil-PH":  "fil",
+	"iw":      "he",
+	"in":      "id",
+}
+
+var (
+	translationsOnce sync.Once
+	translations     map[string]map[string]string // canonical language tag -> ISO code -> name
+	languageTags     map[string]string            // lowercased tag -> canonical tag
+)
+
+func loadTranslations() map[string]map[string]string {
+	translationsOnce.Do(func() {
+		if err := json.Unmarshal(translationsJSON, &translations); err != nil {
+			panic("iso3166: invalid embedded translation data: " + err.Error())
+		}
+		languageTags = make(map[string]string, len(translations)+len(languageAliases))
+		for tag := range translations {
+			languageTags[strings.ToLower(tag)] = tag
+		}
+		for alias, tag := range languageAliases {
+			languageTags[strings.ToLower(alias)] = tag
+		}
+	})
+	return translations
+}
+
+// MatchLanguage returns the embedded language that best serves a BCP 47 tag, trying the full tag
+// ("pt-BR"), then each shorter prefix ("zh-Hant-TW" -> "zh-Hant" -> "zh"). It reports false when
+// there are no translations for the language, in which case names stay in English.
+func MatchLanguage(language string) (string, bool) {
+	loadTranslations()
+	tag := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(language), "_", "-"))
+	for tag != "" {
+		if canonical, ok := languageTags[tag]; ok {
+			return canonical, true
+		}
+		cut := strings.LastIndex(tag, "-")
+		if cut < 0 {
+			break
+		}
+		tag = tag[:cut]
+	}
+	return "", false
+}
+
+// translate looks up the name of an ISO code in a language, reporting false when there is no translation
+func translate(code, language string) (string, bool) {
+	tag, ok := MatchLanguage(language)
+	if !ok {
+		return "", false
+	}
+	name, ok := loadTranslations()[tag][strings.ToUpper(code)]
+	return name, ok
+}
+
+// LocalizedCountryName returns a country's name in the given language, falling back to the English
+// name when there is no translation. It reports false only when the code is unknown.
+func LocalizedCountryName(code, language string) (string, bool) {
+	country, ok := LookupCountry(code)
+	if !ok {
+		return "", false
+	}
+	if name, ok := translate(country.Alpha2, language); ok {
+		return name, true
+	}
+	return country.Name, true
+}
+
+// LocalizedSubdivisionName returns a subdivision's name in the given language, e.g. "Bavaria" for
+// DE-BY in English, falling back to the ISO name. It reports false only when the code is unknown.
+func LocalizedSubdivisionName(code, language string) (string, bool) {
+	subdivision, ok := LookupSubdivision(code)
+	if !ok {
+		return "", false
+	}
+	if name, ok := translate(subdivision.Code, language); ok {
+		return name, true
+	}
+	return subdivision.Name, true
+}
+
+// LocalizeCountry returns a copy of the country with Name in the given language
+func LocalizeCountry(country Country, language string) Country {
+	if name, ok := translate(country.Alpha2, language); ok {
+		country.Name = name
+	}
+	return country
+}
+
+// LocalizeSubdivision returns a copy of the subdivision with Name in the given language
+func LocalizeSubdivision(subdivision Subdivision, language string) Subdivision {
+	if name, ok := translate(subdivision.Code, language); ok {
+		subdivision.Name = name
+	}
+	return subdivision
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/hackclub/geocoder/internal/database"
@@ -17,8 +18,7 @@ const APIKeyContextKey contextKey = "api_key"
 func APIKeyAuth(db database.DatabaseInterface) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get API key from query parameter
-			apiKey := r.URL.Query().Get("key")
+			apiKey, fromQuery := extractAPIKey(r)
 			if apiKey == "" {
 				writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "API key is required")
 				return
@@ -39,6 +39,12 @@ func APIKeyAuth(db database.DatabaseInterface) func(http.Handler) http.Handler {
 				return
 			}
 
+			// Keys in URLs leak into proxy and access logs, so owners can opt out of them
+			if fromQuery && apiKeyRecord.RequireHeaderAuth {
+				writeErrorResponse(w, http.StatusUnauthorized, "INVALID_API_KEY", "This API key must be sent in the Authorization or X-API-Key header")
+				return
+			}
+
 			// Update API key usage
 			_ = db.UpdateAPIKeyUsage(apiKeyRecord.ID)
 
@@ -49,6 +55,28 @@ func APIKeyAuth(db database.DatabaseInterface) func(http.Handler) http.Handler {
 	}
 }
 
+// extractAPIKey reads the API key from the Authorization: Bearer header, the X-API-Key header or
+// the key query parameter, in that order, and reports whether it came from the query string
+func extractAPIKey(r *http.Request) (string, bool) {
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		if scheme, token, ok := strings.Cut(authorization, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			if token = strings.TrimSpace(token); token != "" {
+				return token, false
+			}
+		}
+	}
+
+	if apiKey := strings.TrimSpace(r.Header.Get("X-API-Key")); apiKey != "" {
+		return apiKey, false
+	}
+
+	if apiKey := r.URL.Query().Get("key"); apiKey != "" {
+		return apiKey, true
+	}
+
+	return "", false
+}
+
 func BasicAuth(username, password string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -68,7 +96,7 @@ func CORS() func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -112,7 +113,16 @@ func (m *mockAuthDB) UpdateAPIKeyUsage(keyID string) error {
 func (m *mockAuthDB) GetAllAPIKeys() ([]models.APIKey, error)                          { return nil, nil }
 func (m *mockAuthDB) UpdateAPIKeyRateLimit(keyID string, rateLimitPerSecond int) error { return nil }
 func (m *mockAuthDB) DeactivateAPIKey(keyID string) error                              { return nil }
-func (m *mockAuthDB) GetAddressCache(queryHash string) (*models.AddressCache, error)   { return nil, nil }
+func (m *mockAuthDB) UpdateAPIKeyRequireHeaderAuth(keyID string, requireHeaderAuth bool) error {
+	return nil
+}
+func (m *mockAuthDB) UpdateAPIKeyCacheControl(keyID string, allowCacheControl bool) error {
+	return nil
+}
+func (m *mockAuthDB) UpdateAPIKeyPrivacyPolicy(keyID string, policy models.PrivacyPolicy) error {
+	return nil
+}
+func (m *mockAuthDB) GetAddressCache(queryHash string) (*models.AddressCache, error) { return nil, nil }
 func (m *mockAuthDB) SetAddressCache(queryHash, queryText, responseData string, maxCacheSize int) error {
 	return nil
 }
@@ -125,7 +135,92 @@ func (m *mockAuthDB) GetStats() (*models.Stats, error) { return nil, nil }
 func (m *mockAuthDB) UpdateCostTracking(date time.Time, geocodeRequests, geocodeCacheHits, geoipRequests, geoipCacheHits int, estimatedCost float64) error {
 	return nil
 }
+func (m *mockAuthDB) UpdateOfflineCostTracking(date time.Time, offlineRequests int) error {
+	return nil
+}
+func (m *mockAuthDB) GetASNCache(asn int) (*models.ASNCache, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockAuthDB) SetASNCache(asn int, responseData string) error {
+	return nil
+}
+func (m *mockAuthDB) GetPlaceCache(placeID, language string, maxAge time.Duration) (*models.PlaceCache, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockAuthDB) SetPlaceCache(placeID, language, responseData string, maxAge time.Duration) error {
+	return nil
+}
+func (m *mockAuthDB) FindGazetteerPlaces(name, countryCode string, limit int) ([]models.GazetteerPlace, error) {
+	return nil, nil
+}
+func (m *mockAuthDB) FindGazetteerPostalCodes(countryCode, postalCode string) ([]models.GazetteerPostalCode, error) {
+	return nil, nil
+}
+func (m *mockAuthDB) FindGazetteerLocalizedName(geonameID int64, name, countryCode string, languages []string) (string, error) {
+	return "", nil
+}
+func (m *mockAuthDB) CreateGeofence(apiKeyID, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	return &models.Geofence{ID: "fence-id", APIKeyID: apiKeyID, Collection: collection, Name: name, Geometry: geometry, RadiusM: radiusM, Properties: properties}, nil
+}
+func (m *mockAuthDB) GetGeofences(apiKeyID, collection string) ([]models.Geofence, error) {
+	return []models.Geofence{}, nil
+}
+func (m *mockAuthDB) GetGeofence(id string) (*models.Geofence, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockAuthDB) UpdateGeofence(id, collection, name string, geometry, properties []byte, radiusM float64) (*models.Geofence, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockAuthDB) DeleteGeofence(id string) error {
+	return sql.ErrNoRows
+}
+func (m *mockAuthDB) CreateGeocodeOverride(override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	override.ID = "override-id"
+	return &override, nil
+}
+func (m *mockAuthDB) GetGeocodeOverrides() ([]models.GeocodeOverride, error) {
+	return []models.GeocodeOverride{}, nil
+}
+func (m *mockAuthDB) GetGeocodeOverride(id string) (*models.GeocodeOverride, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockAuthDB) UpdateGeocodeOverride(id string, override models.GeocodeOverride) (*models.GeocodeOverride, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockAuthDB) DeleteGeocodeOverride(id string) error {
+	return sql.ErrNoRows
+}
+func (m *mockAuthDB) CreatePlaceCollection(apiKeyID, name string) (*models.PlaceCollection, error) {
+	return &models.PlaceCollection{ID: "collection-id", APIKeyID: apiKeyID, Name: name}, nil
+}
+func (m *mockAuthDB) GetPlaceCollections(apiKeyID string) ([]models.PlaceCollection, error) {
+	return []models.PlaceCollection{}, nil
+}
+func (m *mockAuthDB) GetPlaceCollection(id string) (*models.PlaceCollection, error) {
+	return nil, sql.ErrNoRows
+}
+func (m *mockAuthDB) DeletePlaceCollection(id string) error {
+	return sql.ErrNoRows
+}
+func (m *mockAuthDB) AddCollectionPlaces(collectionID string, places []models.CollectionPlace) ([]models.CollectionPlace, error) {
+	return places, nil
+}
+func (m *mockAuthDB) GetCollectionPlaces(collectionID string) ([]models.CollectionPlace, error) {
+	return []models.CollectionPlace{}, nil
+}
+func (m *mockAuthDB) DeleteCollectionPlace(collectionID, placeID string) error {
+	return sql.ErrNoRows
+}
+func (m *mockAuthDB) FindCollectionPlacesInBox(collectionID string, minLat, minLng, maxLat, maxLng float64) ([]models.CollectionPlace, error) {
+	return []models.CollectionPlace{}, nil
+}
 func (m *mockAuthDB) GetRecentActivity() ([]models.ActivityLog, error) { return nil, nil }
+func (m *mockAuthDB) DeleteActivityBefore(cutoff time.Time) (int64, error) { return 0, nil }
+func (m *mockAuthDB) DeleteActivityMatching(values []string) (int64, error) { return 0, nil }
+func (m *mockAuthDB) DeleteAddressCache(queryHash, queryText string) (int64, error) {
+	return 0, nil
+}
+func (m *mockAuthDB) DeleteIPCache(ipAddress string) (int64, error) { return 0, nil }
 func (m *mockAuthDB) LogActivity(apiKeyName, endpoint, queryText string, resultCount, responseTimeMs int, apiSource string, cacheHit bool, ipAddress, userAgent string) error {
 	return nil
 }
@@ -245,3 +340,90 @@ func TestAPIKeyAuth_InactiveKey(t *testing.T) {
 		t.Errorf("Expected status 401, got %d", w.Code)
 	}
 }
+
+func TestAPIKeyAuth_HeaderKey(t *testing.T) {
+	db := newMockAuthDB()
+
+	apiKey := &models.APIKey{
+		ID:                 "test-id",
+		KeyHash:            database.HashAPIKey("header-key"),
+		Name:               "test-key",
+		IsActive:           true,
+		RateLimitPerSecond: 10,
+	}
+	db.apiKeys[apiKey.KeyHash] = apiKey
+
+	middleware := APIKeyAuth(db)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	wrappedHandler := middleware(handler)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{"Bearer token", "Authorization", "Bearer header-key"},
+		{"Lowercase bearer scheme", "Authorization", "bearer header-key"},
+		{"X-API-Key header", "X-API-Key", "header-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/geocode", nil)
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+
+			wrappedHandler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuth_RequireHeaderAuth(t *testing.T) {
+	db := newMockAuthDB()
+
+	// Add an API key that refuses query string authentication
+	apiKey := &models.APIKey{
+		ID:                 "test-id",
+		KeyHash:            database.HashAPIKey("strict-key"),
+		Name:               "test-key",
+		IsActive:           true,
+		RateLimitPerSecond: 10,
+		RequireHeaderAuth:  true,
+	}
+	db.apiKeys[apiKey.KeyHash] = apiKey
+
+	middleware := APIKeyAuth(db)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	wrappedHandler := middleware(handler)
+
+	req := httptest.NewRequest("GET", "/v1/geocode?key=strict-key", nil)
+	w := httptest.NewRecorder()
+
+	wrappedHandler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for query string key, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/v1/geocode", nil)
+	req.Header.Set("Authorization", "Bearer strict-key")
+	w = httptest.NewRecorder()
+
+	wrappedHandler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for header key, got %d", w.Code)
+	}
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const ClientIPContextKey contextKey = "client_ip"
+
+// ParseTrustedProxies parses a comma-separated list of CIDRs or single IPs, e.g. "10.0.0.0/8, 192.168.1.5"
+func ParseTrustedProxies(spec string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ClientIP resolves the address of the caller and stores it in the request context. Forwarding headers
+// are only believed when the connection comes from a trusted proxy, and are read right to left so a
+// client cannot spoof its address by sending its own X-Forwarded-For.
+func ClientIP(trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolveClientIP(r, trustedProxies)
+			ctx := context.WithValue(r.Context(), ClientIPContextKey, ip)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetClientIP returns the resolved caller address, falling back to the connection's address
+// when the ClientIP middleware has not run
+func GetClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ClientIPContextKey).(string); ok {
+		return ip
+	}
+	return remoteIP(r.RemoteAddr)
+}
+
+func resolveClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	clientIP := remoteIP(r.RemoteAddr)
+	if !isTrustedProxy(clientIP, trustedProxies) {
+		return clientIP
+	}
+
+	// Prefer the standard Forwarded header, falling back to X-Forwarded-For
+	hops := forwardedFor(r.Header.Values("Forwarded"))
+	if len(hops) == 0 {
+		hops = xForwardedFor(r.Header.Values("X-Forwarded-For"))
+	}
+
+	// Walk back from the proxy closest to us until we reach an address we don't trust
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := hops[i]
+		if net.ParseIP(hop) == nil {
+			// Obfuscated or malformed entries ("unknown", "_hidden") end the chain we can vouch for
+			break
+		}
+		clientIP = hop
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+
+	return clientIP
+}
+
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// xForwardedFor returns the addresses of every X-Forwarded-For header, in order
+func xForwardedFor(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, stripPort(strings.TrimSpace(hop)))
+		}
+	}
+	return hops
+}
+
+// forwardedFor returns the for= addresses of every RFC 7239 Forwarded header, in order
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, node, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(name), "for") {
+					continue
+				}
+				hops = append(hops, stripPort(strings.Trim(strings.TrimSpace(node), `"`)))
+			}
+		}
+	}
+	return hops
+}
+
+// stripPort removes an optional port and IPv6 brackets, e.g. "[2001:db8::1]:4711" or "192.0.2.60:80"
+func stripPort(node string) string {
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+}
+
+// remoteIP extracts the IP address from a RemoteAddr string (removes port if present)
+func remoteIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr // fallback if not in host:port format
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.5,2001:db8::/32")
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	if len(networks) != 3 {
+		t.Fatalf("Expected 3 networks, got %d", len(networks))
+	}
+
+	if networks, err := ParseTrustedProxies(""); err != nil || len(networks) != 0 {
+		t.Errorf("Expected no networks for empty list, got %v, %v", networks, err)
+	}
+
+	if _, err := ParseTrustedProxies("10.0.0.0/8,not-an-ip"); err == nil {
+		t.Error("Expected error for invalid entry")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, _ := ParseTrustedProxies("10.0.0.0/8")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{"Direct connection", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"Untrusted peer ignores headers", "203.0.113.7:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.7"},
+		{"Trusted proxy", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"Spoofed leftmost entry", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"Chain of trusted proxies", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "198.51.100.1, 10.1.1.1"}, "198.51.100.1"},
+		{"Forwarded header", "10.0.0.2:5000", map[string]string{"Forwarded": `for=192.0.2.60;proto=http;by=203.0.113.43`}, "192.0.2.60"},
+		{"Forwarded IPv6 with port", "10.0.0.2:5000", map[string]string{"Forwarded": `for="[2001:db8:cafe::17]:4711"`}, "2001:db8:cafe::17"},
+		{"Forwarded preferred over X-Forwarded-For", "10.0.0.2:5000", map[string]string{"Forwarded": "for=192.0.2.60", "X-Forwarded-For": "198.51.100.1"}, "192.0.2.60"},
+		{"Obfuscated hop", "10.0.0.2:5000", map[string]string{"Forwarded": "for=unknown"}, "10.0.0.2"},
+		{"Trusted proxy without headers", "10.0.0.2:5000", nil, "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resolved string
+			handler := ClientIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				resolved = GetClientIP(r)
+			}))
+
+			req := httptest.NewRequest("GET", "/v1/geoip/me", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if resolved != tt.expected {
+				t.Errorf("Expected client IP %s, got %s", tt.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestGetClientIP_WithoutMiddleware(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/geoip/me", nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+
+	if ip := GetClientIP(req); ip != "203.0.113.7" {
+		t.Errorf("Expected 203.0.113.7, got %s", ip)
+	}
+}
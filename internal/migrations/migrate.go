@@ -11,6 +11,11 @@ import (
 
 // RunMigrations automatically runs all pending migrations
 func RunMigrations(db *sql.DB) error {
+	return applyMigrations(db, "migrations")
+}
+
+// applyMigrations runs the migration files in dir that haven't been applied yet, in sorted order
+func applyMigrations(db *sql.DB, dir string) error {
 	// Create migrations table if it doesn't exist
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS schema_migrations (
@@ -23,7 +28,7 @@ func RunMigrations(db *sql.DB) error {
 	}
 
 	// Get list of migration files
-	files, err := os.ReadDir("migrations")
+	files, err := os.ReadDir(dir)
 	if err != nil {
 		// If migrations directory doesn't exist, create tables directly
 		log.Println("Migrations directory not found, creating tables...")
@@ -60,7 +65,7 @@ func RunMigrations(db *sql.DB) error {
 		}
 
 		// Read and execute migration
-		content, err := os.ReadFile(filepath.Join("migrations", file))
+		content, err := os.ReadFile(filepath.Join(dir, file))
 		if err != nil {
 			return err
 		}
@@ -1,9 +1,15 @@
 package migrations
 
 import (
+	"database/sql"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
 )
 
 func TestMigrationSchemaContent(t *testing.T) {
@@ -72,3 +78,75 @@ func TestCreateTablesSQL(t *testing.T) {
 		}
 	}
 }
+
+// TestApplyMigrations applies the migrations directory in order, as the server does at startup, to a
+// scratch schema of the Postgres at TEST_DATABASE_URL. It stops before 017 first to check that an
+// existing database's activity log keeps its IP addresses, since each .down.sql file sorts before and
+// runs ahead of its .up.sql file.
+func TestApplyMigrations(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer db.Close()
+	// The search path belongs to one connection
+	db.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err := db.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Exec("DROP SCHEMA " + schema + " CASCADE")
+	if _, err := db.Exec("SET search_path TO " + schema + ", public"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join("..", "..", "migrations")
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := t.TempDir()
+	for _, file := range files {
+		if file.Name() < "017" {
+			content, err := os.ReadFile(filepath.Join(dir, file.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(before, file.Name()), content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := applyMigrations(db, before); err != nil {
+		t.Fatalf("Failed to apply migrations before 017: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO activity_log (api_key_name, endpoint, query_text, result_count, response_time_ms, api_source, ip_address)
+		VALUES ('test', 'v1/geoip', '203.0.113.7', 1, 5, 'ipinfo', '203.0.113.7')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyMigrations(db, dir); err != nil {
+		t.Fatalf("Failed to apply migrations: %v", err)
+	}
+	if err := applyMigrations(db, dir); err != nil {
+		t.Fatalf("Expected a second run to skip applied migrations, got %v", err)
+	}
+
+	var ip, columnType string
+	if err := db.QueryRow("SELECT ip_address FROM activity_log").Scan(&ip); err != nil || ip != "203.0.113.7" {
+		t.Errorf("Expected the IP address to survive, got %q, %v", ip, err)
+	}
+	err = db.QueryRow(`SELECT data_type FROM information_schema.columns
+		WHERE table_schema = $1 AND table_name = 'activity_log' AND column_name = 'ip_address'`, schema).Scan(&columnType)
+	if err != nil || columnType != "text" {
+		t.Errorf("Expected ip_address to be text, got %q, %v", columnType, err)
+	}
+}
@@ -1,23 +1,40 @@
 package models
 
 import (
-	"strings"
+	"encoding/json"
 	"time"
 )
 
 // APIKey represents an API key in the database
 type APIKey struct {
-	ID                 string     `json:"id" db:"id"`
-	KeyHash            string     `json:"-" db:"key_hash"`
-	Name               string     `json:"name" db:"name"`
-	Owner              string     `json:"owner" db:"owner"`
-	AppName            string     `json:"app_name" db:"app_name"`
-	Environment        string     `json:"environment" db:"environment"`
-	IsActive           bool       `json:"is_active" db:"is_active"`
-	RateLimitPerSecond int        `json:"rate_limit_per_second" db:"rate_limit_per_second"`
-	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
-	LastUsedAt         *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
-	RequestCount       int        `json:"request_count" db:"request_count"`
+	ID                 string        `json:"id" db:"id"`
+	KeyHash            string        `json:"-" db:"key_hash"`
+	Name               string        `json:"name" db:"name"`
+	Owner              string        `json:"owner" db:"owner"`
+	AppName            string        `json:"app_name" db:"app_name"`
+	Environment        string        `json:"environment" db:"environment"`
+	IsActive           bool          `json:"is_active" db:"is_active"`
+	RateLimitPerSecond int           `json:"rate_limit_per_second" db:"rate_limit_per_second"`
+	CreatedAt          time.Time     `json:"created_at" db:"created_at"`
+	LastUsedAt         *time.Time    `json:"last_used_at,omitempty" db:"last_used_at"`
+	RequestCount       int           `json:"request_count" db:"request_count"`
+	RequireHeaderAuth  bool          `json:"require_header_auth" db:"require_header_auth"`
+	AllowCacheControl  bool          `json:"allow_cache_control" db:"allow_cache_control"` // May send cache=refresh or cache=bypass
+	Privacy            PrivacyPolicy `json:"privacy"`
+}
+
+// PrivacyPolicy limits how precisely an API key's responses can locate someone. The zero value
+// returns everything at full precision.
+type PrivacyPolicy struct {
+	CoordinateDecimals int  `json:"coordinate_decimals" db:"privacy_coordinate_decimals"` // Round lat/lng to this many decimal places, 0 to leave them
+	JitterMeters       int  `json:"jitter_meters" db:"privacy_jitter_meters"`             // Move points up to this far, the same way every time for a point
+	SuppressStreet     bool `json:"suppress_street" db:"privacy_suppress_street"`         // Drop street lines, postal codes and place IDs
+	CityLevel          bool `json:"city_level" db:"privacy_city_level"`                   // Drop everything below city and round to 0.1°
+}
+
+// IsEmpty returns true if the policy leaves responses unchanged
+func (p PrivacyPolicy) IsEmpty() bool {
+	return p == PrivacyPolicy{}
 }
 
 // AddressCache represents a cached geocoding result
@@ -37,6 +54,21 @@ type IPCache struct {
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
+// ASNCache represents a cached autonomous system lookup
+type ASNCache struct {
+	ASN          int       `json:"asn" db:"asn"`
+	ResponseData string    `json:"response_data" db:"response_data"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// PlaceCache represents a cached place ID lookup
+type PlaceCache struct {
+	PlaceID      string    `json:"place_id" db:"place_id"`
+	Language     string    `json:"language" db:"language"`
+	ResponseData string    `json:"response_data" db:"response_data"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
 // ReverseGeocodeCache represents a cached reverse geocoding result
 type ReverseGeocodeCache struct {
 	ID           int       `json:"id" db:"id"`
@@ -63,9 +95,59 @@ type CostTracking struct {
 	GeocodeCacheHits int       `json:"geocode_cache_hits" db:"geocode_cache_hits"`
 	GeoipRequests    int       `json:"geoip_requests" db:"geoip_requests"`
 	GeoipCacheHits   int       `json:"geoip_cache_hits" db:"geoip_cache_hits"`
+	OfflineRequests  int       `json:"offline_requests" db:"offline_requests"`
 	EstimatedCostUSD float64   `json:"estimated_cost_usd" db:"estimated_cost_usd"`
 }
 
+// GazetteerPlace represents a GeoNames populated place
+type GazetteerPlace struct {
+	GeonameID   int64   `json:"geoname_id" db:"geoname_id"`
+	Name        string  `json:"name" db:"name"`
+	Lat         float64 `json:"lat" db:"lat"`
+	Lng         float64 `json:"lng" db:"lng"`
+	FeatureCode string  `json:"feature_code" db:"feature_code"`
+	CountryCode string  `json:"country_code" db:"country_code"`
+	Admin1Code  string  `json:"admin1_code" db:"admin1_code"`
+	Admin1Name  string  `json:"admin1_name" db:"admin1_name"`
+	Population  int64   `json:"population" db:"population"`
+	Timezone    string  `json:"timezone" db:"timezone"`
+}
+
+// GazetteerPostalCode represents a GeoNames postal code centroid
+type GazetteerPostalCode struct {
+	CountryCode string  `json:"country_code" db:"country_code"`
+	PostalCode  string  `json:"postal_code" db:"postal_code"`
+	PlaceName   string  `json:"place_name" db:"place_name"`
+	Admin1Name  string  `json:"admin1_name" db:"admin1_name"`
+	Admin1Code  string  `json:"admin1_code" db:"admin1_code"`
+	Admin2Name  string  `json:"admin2_name" db:"admin2_name"`
+	Lat         float64 `json:"lat" db:"lat"`
+	Lng         float64 `json:"lng" db:"lng"`
+	Accuracy    int     `json:"accuracy" db:"accuracy"` // 1 estimated, 4 GeoNames id, 6 centroid of addresses or shape
+}
+
+// PostalCodePlace is one of the places a postal code covers
+type PostalCodePlace struct {
+	Name         string  `json:"name"`
+	State        string  `json:"state"`
+	StateISOCode string  `json:"state_iso_code"`
+	County       string  `json:"county"`
+	Lat          float64 `json:"lat"`
+	Lng          float64 `json:"lng"`
+}
+
+// PostalCodeResponse represents our standardized postal code lookup response
+type PostalCodeResponse struct {
+	PostalCode         string            `json:"postal_code"`
+	CountryCode        string            `json:"country_code"`
+	Lat                float64           `json:"lat"` // Centroid of the places
+	Lng                float64           `json:"lng"`
+	Places             []PostalCodePlace `json:"places"`
+	AdminHierarchy     AdminHierarchy    `json:"admin_hierarchy"` // Only the levels every place shares
+	Backend            string            `json:"backend"`
+	RawBackendResponse interface{}       `json:"raw_backend_response"`
+}
+
 // ErrorResponse represents a standard error response
 type ErrorResponse struct {
 	Error ErrorDetail `json:"error"`
@@ -77,6 +159,185 @@ type ErrorDetail struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// Geofence is a polygon or circle owned by an API key, grouped into named collections
+type Geofence struct {
+	ID         string          `json:"id" db:"id"`
+	APIKeyID   string          `json:"api_key_id" db:"api_key_id"`
+	Collection string          `json:"collection" db:"collection"`
+	Name       string          `json:"name" db:"name"`
+	Geometry   json.RawMessage `json:"geometry" db:"geometry"`     // GeoJSON Polygon or MultiPolygon, or a Point for circles
+	RadiusM    float64         `json:"radius_m" db:"radius_m"`     // Circle radius around a Point, 0 for polygons
+	Properties json.RawMessage `json:"properties" db:"properties"` // The uploaded feature's properties, returned with matches
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// GeofenceListResponse lists an API key's geofences
+type GeofenceListResponse struct {
+	Geofences []Geofence `json:"geofences"`
+}
+
+// GeocodeOverride pins the geocoding result for addresses matching a pattern or for a provider
+// place ID, for places the provider gets wrong
+type GeocodeOverride struct {
+	ID               string         `json:"id" db:"id"`
+	AddressPattern   string         `json:"address_pattern" db:"address_pattern"` // Normalized address; * matches any run of characters
+	PlaceID          string         `json:"place_id" db:"place_id"`
+	Lat              float64        `json:"lat" db:"lat"`
+	Lng              float64        `json:"lng" db:"lng"`
+	FormattedAddress string         `json:"formatted_address" db:"formatted_address"`
+	AdminHierarchy   AdminHierarchy `json:"admin_hierarchy" db:"admin_hierarchy"`
+	Note             string         `json:"note" db:"note"`
+	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// GeocodeOverrideListResponse lists geocode overrides
+type GeocodeOverrideListResponse struct {
+	Overrides []GeocodeOverride `json:"overrides"`
+}
+
+// GeofenceMatch is a geofence that contains the checked point
+type GeofenceMatch struct {
+	ID         string          `json:"id"`
+	Collection string          `json:"collection"`
+	Name       string          `json:"name"`
+	Properties json.RawMessage `json:"properties"`
+}
+
+// GeofenceCheckResponse lists the geofences containing a point, address or IP address
+type GeofenceCheckResponse struct {
+	Lat     float64         `json:"lat"`
+	Lng     float64         `json:"lng"`
+	Source  string          `json:"source"` // "coordinates", "address" or "ip"
+	Inside  bool            `json:"inside"` // Whether any fence contains the point
+	Checked int             `json:"checked"`
+	Matches []GeofenceMatch `json:"matches"`
+}
+
+// PlaceCollection is a named list of saved places owned by an API key
+type PlaceCollection struct {
+	ID         string    `json:"id" db:"id"`
+	APIKeyID   string    `json:"api_key_id" db:"api_key_id"`
+	Name       string    `json:"name" db:"name"`
+	PlaceCount int       `json:"place_count"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PlaceCollectionListResponse lists an API key's place collections
+type PlaceCollectionListResponse struct {
+	Collections []PlaceCollection `json:"collections"`
+}
+
+// CollectionPlace is a saved place. Address and FormattedAddress are only set for places that were geocoded.
+type CollectionPlace struct {
+	ID               string          `json:"id" db:"id"`
+	CollectionID     string          `json:"collection_id" db:"collection_id"`
+	Name             string          `json:"name" db:"name"`
+	Address          string          `json:"address,omitempty" db:"address"`
+	FormattedAddress string          `json:"formatted_address,omitempty" db:"formatted_address"`
+	Lat              float64         `json:"lat" db:"lat"`
+	Lng              float64         `json:"lng" db:"lng"`
+	Properties       json.RawMessage `json:"properties,omitempty" db:"properties"`
+	CreatedAt        time.Time       `json:"created_at" db:"created_at"`
+}
+
+// CollectionPlaceListResponse lists a collection's places
+type CollectionPlaceListResponse struct {
+	Places []CollectionPlace `json:"places"`
+}
+
+// CollectionImportResponse reports the places added by an upload, and the rows that couldn't be
+type CollectionImportResponse struct {
+	Added  int                     `json:"added"`
+	Places []CollectionPlace       `json:"places"`
+	Errors []CollectionImportError `json:"errors"`
+}
+
+// CollectionImportError is a rejected row of an upload. Rows count from 1, and for CSV the header is row 1.
+type CollectionImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// NearbyPlace is a saved place with its distance from the query point
+type NearbyPlace struct {
+	CollectionPlace
+	DistanceM float64 `json:"distance_m"`
+}
+
+// NearbyResponse lists a collection's places nearest the query point, closest first
+type NearbyResponse struct {
+	Lat     float64       `json:"lat"`
+	Lng     float64       `json:"lng"`
+	Source  string        `json:"source"`             // "coordinates" or "address"
+	RadiusM float64       `json:"radius_m,omitempty"` // Omitted for plain k-nearest queries
+	Places  []NearbyPlace `json:"places"`
+}
+
+// ListItems returns the places, which are what GeoJSON and CSV output lists
+func (r NearbyResponse) ListItems() interface{} {
+	return r.Places
+}
+
+// Encodings are a point's cells in the formats asked for with the encodings parameter
+type Encodings struct {
+	Geohash  string `json:"geohash,omitempty"`
+	PlusCode string `json:"pluscode,omitempty"`
+	H3       string `json:"h3,omitempty"`
+}
+
+// EncodeResponse is a point and its encodings
+type EncodeResponse struct {
+	Lat       float64   `json:"lat"`
+	Lng       float64   `json:"lng"`
+	Encodings Encodings `json:"encodings"`
+}
+
+// CellBounds is the rectangle a geohash or Plus Code covers
+type CellBounds struct {
+	South float64 `json:"south"`
+	West  float64 `json:"west"`
+	North float64 `json:"north"`
+	East  float64 `json:"east"`
+}
+
+// DecodeResponse is the cell a geohash, Plus Code or H3 index stands for
+type DecodeResponse struct {
+	Code      string      `json:"code"`
+	Type      string      `json:"type"`      // "geohash", "pluscode" or "h3"
+	Precision int         `json:"precision"` // Characters for a geohash, digits for a Plus Code, resolution for H3
+	Lat       float64     `json:"lat"`       // Centre of the cell
+	Lng       float64     `json:"lng"`
+	Bounds    *CellBounds `json:"bounds,omitempty"` // Not set for H3, whose cells are hexagons
+}
+
+// ConvertResponse is a point in WGS84 and in each projected coordinate system asked for
+type ConvertResponse struct {
+	Lat      float64                `json:"lat"`
+	Lng      float64                `json:"lng"`
+	Source   string                 `json:"source"` // The input's system: "wgs84", "utm", "mgrs" or "epsg3857"
+	UTM      *UTMCoordinate         `json:"utm,omitempty"`
+	MGRS     string                 `json:"mgrs,omitempty"`
+	EPSG3857 *WebMercatorCoordinate `json:"epsg3857,omitempty"`
+}
+
+// UTMCoordinate is a position in a UTM zone, in metres
+type UTMCoordinate struct {
+	Zone       int     `json:"zone"`
+	Hemisphere string  `json:"hemisphere"` // "N" or "S"
+	Band       string  `json:"band"`       // Latitude band letter, C to X
+	Easting    float64 `json:"easting"`
+	Northing   float64 `json:"northing"`
+}
+
+// WebMercatorCoordinate is a position in EPSG:3857, in metres
+type WebMercatorCoordinate struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
 // CreateAPIKeyRequest represents the request to create a new API key
 type CreateAPIKeyRequest struct {
 	Name               string `json:"name"`
@@ -103,6 +364,16 @@ type UpdateRateLimitRequest struct {
 	RateLimitPerSecond int `json:"rate_limit_per_second"`
 }
 
+// UpdateAuthSettingsRequest represents an API key authentication settings update request
+type UpdateAuthSettingsRequest struct {
+	RequireHeaderAuth bool `json:"require_header_auth"`
+}
+
+// UpdateCacheSettingsRequest represents an API key cache settings update request
+type UpdateCacheSettingsRequest struct {
+	AllowCacheControl bool `json:"allow_cache_control"`
+}
+
 // WebSocketMessage represents a real-time update message
 type WebSocketMessage struct {
 	Type      string       `json:"type"`
@@ -140,6 +411,18 @@ type ActivityLog struct {
 	UserAgent      string    `json:"user_agent,omitempty" db:"user_agent"`
 }
 
+// EraseRequest names an address or IP address to remove from the activity log and caches
+type EraseRequest struct {
+	Address string `json:"address,omitempty"`
+	IP      string `json:"ip,omitempty"`
+}
+
+// EraseResponse counts what an erase request removed
+type EraseResponse struct {
+	ActivityEntries int64 `json:"activity_entries"`
+	CacheEntries    int64 `json:"cache_entries"`
+}
+
 // Stats represents usage statistics
 type Stats struct {
 	TotalRequests       int64   `json:"total_requests"`
@@ -189,17 +472,41 @@ type UsageSummaryResponse struct {
 	TotalPages int                  `json:"total_pages"`
 }
 
+// AdminHierarchy is a provider-neutral breakdown of where a result sits, from neighborhood up to country
+type AdminHierarchy struct {
+	Neighborhood   string `json:"neighborhood"`
+	Sublocality    string `json:"sublocality"`
+	City           string `json:"city"`
+	County         string `json:"county"`
+	State          string `json:"state"`
+	StateCode      string `json:"state_code"`
+	StateISOCode   string `json:"state_iso_code"` // ISO 3166-2, e.g. "US-CA"
+	PostalCode     string `json:"postal_code"`
+	Country        string `json:"country"`
+	CountryCode    string `json:"country_code"`
+	CountryAlpha3  string `json:"country_alpha3"`
+	CountryNumeric string `json:"country_numeric"`
+}
+
+// IsEmpty returns true if no level of the hierarchy is known
+func (ah AdminHierarchy) IsEmpty() bool {
+	return ah == AdminHierarchy{}
+}
+
 // GeocodeAPIResponse represents our standardized geocoding API response
 type GeocodeAPIResponse struct {
-	Lat                  float64     `json:"lat"`
-	Lng                  float64     `json:"lng"`
-	FormattedAddress     string      `json:"formatted_address"`
-	StateName            string      `json:"state_name"`
-	StateCode            string      `json:"state_code"`
-	CountryName          string      `json:"country_name"`
-	CountryCode          string      `json:"country_code"`
-	Backend              string      `json:"backend"`
-	RawBackendResponse   interface{} `json:"raw_backend_response"`
+	Lat                float64        `json:"lat"`
+	Lng                float64        `json:"lng"`
+	FormattedAddress   string         `json:"formatted_address"`
+	PlaceID            string         `json:"place_id"` // Google place ID, which /v1/place/{place_id} resolves later
+	StateName          string         `json:"state_name"`
+	StateCode          string         `json:"state_code"`
+	CountryName        string         `json:"country_name"`
+	CountryCode        string         `json:"country_code"`
+	AdminHierarchy     AdminHierarchy `json:"admin_hierarchy"`
+	Encodings          *Encodings     `json:"encodings,omitempty"` // Set when asked for; never cached
+	Backend            string         `json:"backend"`
+	RawBackendResponse interface{}    `json:"raw_backend_response"`
 }
 
 // GeoIPAPIResponse represents our standardized IP geolocation API response
@@ -209,29 +516,73 @@ type GeoIPAPIResponse struct {
 	IP                 string      `json:"ip"`
 	City               string      `json:"city"`
 	Region             string      `json:"region"`
+	RegionCode         string      `json:"region_code"` // ISO 3166-2, e.g. "US-CA"
 	CountryName        string      `json:"country_name"`
 	CountryCode        string      `json:"country_code"`
+	CountryAlpha3      string      `json:"country_alpha3"`
+	CountryNumeric     string      `json:"country_numeric"`
 	PostalCode         string      `json:"postal_code"`
 	Timezone           string      `json:"timezone"`
 	Org                string      `json:"org"`
+	ASN                int         `json:"asn"` // e.g. 15169, parsed from org when IPinfo has no ASN data
+	ASNName            string      `json:"asn_name"`
+	Network            string      `json:"network"` // CIDR of the announced route, on IPinfo plans with ASN data
+	Hostname           string      `json:"hostname"`
+	Hosting            bool        `json:"hosting"`
+	VPN                bool        `json:"vpn"`
+	Proxy              bool        `json:"proxy"`
+	Tor                bool        `json:"tor"`
+	Anycast            bool        `json:"anycast"`
+	Bogon              bool        `json:"bogon"`
+	BogonReason        string      `json:"bogon_reason"`        // e.g. "private" or "loopback", for addresses answered without IPinfo
+	PrivacySource      string      `json:"privacy_source"`      // "ipinfo_privacy", "hosting_asn_list" or empty when the flags are unknown
+	Encodings          *Encodings  `json:"encodings,omitempty"` // Set when asked for; never cached
+	Backend            string      `json:"backend"`
+	RawBackendResponse interface{} `json:"raw_backend_response"`
+}
+
+// ASNPrefix is a network announced by an autonomous system
+type ASNPrefix struct {
+	Network     string `json:"network"` // CIDR
+	Name        string `json:"name"`
+	CountryCode string `json:"country_code"`
+}
+
+// ASNAPIResponse represents our standardized autonomous system lookup response
+type ASNAPIResponse struct {
+	ASN                int         `json:"asn"`
+	Name               string      `json:"name"`
+	CountryCode        string      `json:"country_code"`
+	Domain             string      `json:"domain"`
+	Registry           string      `json:"registry"`
+	Allocated          string      `json:"allocated"`
+	Type               string      `json:"type"` // "hosting", "isp", "business", "education" or "government"
+	Hosting            bool        `json:"hosting"`
+	NumIPs             int64       `json:"num_ips"`
+	Prefixes           []ASNPrefix `json:"prefixes"`
+	Prefixes6          []ASNPrefix `json:"prefixes6"`
 	Backend            string      `json:"backend"`
 	RawBackendResponse interface{} `json:"raw_backend_response"`
 }
 
 // ReverseGeocodeAPIResponse represents our standardized reverse geocoding API response
 type ReverseGeocodeAPIResponse struct {
-	Lat                  float64     `json:"lat"`
-	Lng                  float64     `json:"lng"`
-	FormattedAddress     string      `json:"formatted_address"`
-	AddressLine1         string      `json:"address_line_1"`
-	City                 string      `json:"city"`
-	State                string      `json:"state"`
-	StateFull            string      `json:"state_full"`
-	PostalCode           string      `json:"postal_code"`
-	CountryName          string      `json:"country_name"`
-	CountryCode          string      `json:"country_code"`
-	Backend              string      `json:"backend"`
-	RawBackendResponse   interface{} `json:"raw_backend_response"`
+	Lat                float64        `json:"lat"`
+	Lng                float64        `json:"lng"`
+	FormattedAddress   string         `json:"formatted_address"`
+	AddressLine1       string         `json:"address_line_1"`
+	City               string         `json:"city"`
+	State              string         `json:"state"`
+	StateFull          string         `json:"state_full"`
+	PostalCode         string         `json:"postal_code"`
+	CountryName        string         `json:"country_name"`
+	CountryCode        string         `json:"country_code"`
+	AdminHierarchy     AdminHierarchy `json:"admin_hierarchy"`
+	Precision          string         `json:"precision,omitempty"`  // Set for offline lookups: country, admin1 or city
+	Confidence         string         `json:"confidence,omitempty"` // Set for offline lookups: high, or low when matched by proximity or the nearest city
+	Encodings          *Encodings     `json:"encodings,omitempty"`  // Set when asked for; never cached
+	Backend            string         `json:"backend"`
+	RawBackendResponse interface{}    `json:"raw_backend_response"`
 }
 
 // StructuredAddress represents a structured address for geocoding
@@ -244,28 +595,119 @@ type StructuredAddress struct {
 	Country      string `json:"country"`
 }
 
-// ToFormattedString converts the structured address to a single formatted string for geocoding
-func (sa *StructuredAddress) ToFormattedString() string {
-	var parts []string
-	
-	if sa.AddressLine1 != "" {
-		parts = append(parts, sa.AddressLine1)
-	}
-	if sa.AddressLine2 != "" {
-		parts = append(parts, sa.AddressLine2)
-	}
-	if sa.City != "" {
-		parts = append(parts, sa.City)
-	}
-	if sa.State != "" {
-		parts = append(parts, sa.State)
-	}
-	if sa.PostalCode != "" {
-		parts = append(parts, sa.PostalCode)
-	}
-	if sa.Country != "" {
-		parts = append(parts, sa.Country)
+// AddressFieldConfidence holds how confident the address parser is in each field, from 0 to 1
+type AddressFieldConfidence struct {
+	AddressLine1 float64 `json:"address_line_1"`
+	AddressLine2 float64 `json:"address_line_2"`
+	City         float64 `json:"city"`
+	State        float64 `json:"state"`
+	PostalCode   float64 `json:"postal_code"`
+	Country      float64 `json:"country"`
+}
+
+// ParsedAddressResponse represents a free-text address split into the structured geocoding fields, so
+// it can be passed straight to /v1/geocode_structured
+type ParsedAddressResponse struct {
+	AddressLine1 string                 `json:"address_line_1"`
+	AddressLine2 string                 `json:"address_line_2"`
+	City         string                 `json:"city"`
+	State        string                 `json:"state"`
+	PostalCode   string                 `json:"postal_code"`
+	Country      string                 `json:"country"` // ISO 3166-1 alpha-2
+	Confidence   AddressFieldConfidence `json:"confidence"`
+	Format       string                 `json:"format"` // "us", "ca", "uk", "eu", "other" or "unknown"
+}
+
+// ToStructuredAddress returns the parsed fields as a StructuredAddress
+func (p ParsedAddressResponse) ToStructuredAddress() StructuredAddress {
+	return StructuredAddress{
+		AddressLine1: p.AddressLine1,
+		AddressLine2: p.AddressLine2,
+		City:         p.City,
+		State:        p.State,
+		PostalCode:   p.PostalCode,
+		Country:      p.Country,
 	}
-	
-	return strings.Join(parts, ", ")
+}
+
+// FormattedAddressResponse represents a structured address laid out for a postal label in its country's format
+type FormattedAddressResponse struct {
+	FormattedAddress string   `json:"formatted_address"` // Lines separated by newlines
+	Lines            []string `json:"lines"`
+	CountryCode      string   `json:"country_code"` // ISO 3166-1 alpha-2, empty if the country wasn't recognized
+}
+
+// ComponentVerdict describes how a single submitted address field compares to the geocoded result
+type ComponentVerdict struct {
+	Status   string `json:"status"` // "matched", "corrected", "missing", "unconfirmed" or "suspicious"
+	Input    string `json:"input"`
+	Geocoded string `json:"geocoded"`
+}
+
+// AddressComponentVerdicts holds the per-field verdicts for a structured address
+type AddressComponentVerdicts struct {
+	AddressLine1 *ComponentVerdict `json:"address_line_1,omitempty"`
+	AddressLine2 *ComponentVerdict `json:"address_line_2,omitempty"`
+	City         *ComponentVerdict `json:"city,omitempty"`
+	State        *ComponentVerdict `json:"state,omitempty"`
+	PostalCode   *ComponentVerdict `json:"postal_code,omitempty"`
+	Country      *ComponentVerdict `json:"country,omitempty"`
+}
+
+// AddressValidationResponse represents our standardized address validation API response
+type AddressValidationResponse struct {
+	Valid            bool                     `json:"valid"`
+	PartialMatch     bool                     `json:"partial_match"`
+	Granularity      string                   `json:"granularity"`
+	Components       AddressComponentVerdicts `json:"components"`
+	SuggestedAddress StructuredAddress        `json:"suggested_address"`
+	FormattedAddress string                   `json:"formatted_address"`
+	Lat              float64                  `json:"lat"`
+	Lng              float64                  `json:"lng"`
+	AdminHierarchy   AdminHierarchy           `json:"admin_hierarchy"`
+	Backend          string                   `json:"backend"`
+}
+
+// ConsistencyResponse compares where an address is with where an IP address appears to be
+type ConsistencyResponse struct {
+	Score       int                `json:"score"`   // 0-100, higher is more plausible
+	Verdict     string             `json:"verdict"` // "consistent", "plausible", "inconsistent" or "unknown"
+	Reasons     []string           `json:"reasons"` // What lowered the score, e.g. "different_country" or "ip_vpn"
+	DistanceKm  float64            `json:"distance_km"`
+	SameCountry bool               `json:"same_country"`
+	SameRegion  bool               `json:"same_region"` // False when either side has no region
+	Address     ConsistencyAddress `json:"address"`
+	IP          ConsistencyIP      `json:"ip"`
+}
+
+// ConsistencyAddress is the geocoded address side of a consistency check
+type ConsistencyAddress struct {
+	FormattedAddress string  `json:"formatted_address"`
+	Lat              float64 `json:"lat"`
+	Lng              float64 `json:"lng"`
+	RegionCode       string  `json:"region_code"` // ISO 3166-2, e.g. "US-CA"
+	CountryCode      string  `json:"country_code"`
+}
+
+// ConsistencyIP is the IP geolocation side of a consistency check, with its risk flags
+type ConsistencyIP struct {
+	IP            string  `json:"ip"`
+	Lat           float64 `json:"lat"`
+	Lng           float64 `json:"lng"`
+	City          string  `json:"city"`
+	RegionCode    string  `json:"region_code"`
+	CountryCode   string  `json:"country_code"`
+	ASN           int     `json:"asn"`
+	ASNName       string  `json:"asn_name"`
+	Hosting       bool    `json:"hosting"`
+	VPN           bool    `json:"vpn"`
+	Proxy         bool    `json:"proxy"`
+	Tor           bool    `json:"tor"`
+	Bogon         bool    `json:"bogon"`
+	PrivacySource string  `json:"privacy_source"`
+}
+
+// IsEmpty reports whether none of the address fields are set
+func (sa *StructuredAddress) IsEmpty() bool {
+	return sa.AddressLine1 == "" && sa.AddressLine2 == "" && sa.City == "" && sa.State == "" && sa.PostalCode == "" && sa.Country == ""
 }
@@ -0,0 +1,166 @@
+package offline
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// featureCollection is the subset of GeoJSON needed to index boundaries
+type featureCollection struct {
+	Features []struct {
+		Properties map[string]interface{} `json:"properties"`
+		Geometry   struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// regionFunc reads a region from a feature's properties, reporting false to skip the feature
+type regionFunc func(properties map[string]interface{}) (region, bool)
+
+// countryRegion reads the embedded country boundaries, or Natural Earth's full admin-0 layers such as
+// ne_10m_admin_0_countries, whose ISO_A2 is "-99" for France, Norway and a few others; ISO_A2_EH has them
+func countryRegion(properties map[string]interface{}) (region, bool) {
+	code := firstProperty(properties, "iso_a2", "ISO_A2_EH", "ISO_A2")
+	name := firstProperty(properties, "name", "NAME")
+	return region{code: code, name: name, countryCode: code}, code != ""
+}
+
+// firstProperty returns the first of the given properties that is set to something besides "-99"
+func firstProperty(properties map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value := stringProperty(properties, key); value != "" && value != "-99" {
+			return value
+		}
+	}
+	return ""
+}
+
+// admin1Region reads Natural Earth's ne_10m_admin_1_states_provinces, whose iso_3166_2 codes are
+// sometimes only a country prefix ("FR-") where ISO has no matching subdivision
+func admin1Region(properties map[string]interface{}) (region, bool) {
+	countryCode := stringProperty(properties, "iso_a2")
+	code := stringProperty(properties, "iso_3166_2")
+	if strings.HasSuffix(code, "-") {
+		code = ""
+	}
+	name := stringProperty(properties, "name")
+	return region{code: code, name: name, countryCode: countryCode}, name != "" && countryCode != "" && countryCode != "-99"
+}
+
+func stringProperty(properties map[string]interface{}, key string) string {
+	value, _ := properties[key].(string)
+	return strings.TrimSpace(value)
+}
+
+func loadBoundaries(path string, read regionFunc) (*polygonIndex, []region, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseBoundaries(data, read)
+}
+
+// parseBoundaries indexes the Polygon and MultiPolygon features of a GeoJSON FeatureCollection
+func parseBoundaries(data []byte, read regionFunc) (*polygonIndex, []region, error) {
+	var collection featureCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	index := newPolygonIndex()
+	var regions []region
+	for _, feature := range collection.Features {
+		r, ok := read(feature.Properties)
+		if !ok {
+			continue
+		}
+
+		var polygons [][][][]float64
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var polygon [][][]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &polygon); err != nil {
+				return nil, nil, fmt.Errorf("invalid polygon for %s: %w", r.name, err)
+			}
+			polygons = append(polygons, polygon)
+		case "MultiPolygon":
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &polygons); err != nil {
+				return nil, nil, fmt.Errorf("invalid multipolygon for %s: %w", r.name, err)
+			}
+		default:
+			continue
+		}
+
+		id := len(regions)
+		regions = append(regions, r)
+		for _, polygon := range polygons {
+			rings := make([]ring, 0, len(polygon))
+			for _, positions := range polygon {
+				rg := make(ring, 0, len(positions))
+				for _, position := range positions {
+					if len(position) >= 2 {
+						rg = append(rg, [2]float64{position[0], position[1]})
+					}
+				}
+				rings = append(rings, rg)
+			}
+			index.add(rings, id)
+		}
+	}
+
+	return index, regions, nil
+}
+
+// loadGeoNamesCities reads a GeoNames cities dump (cities500.txt, cities15000.txt and so on), which is
+// tab-separated with the columns documented at https://download.geonames.org/export/dump/
+func loadGeoNamesCities(path string) (*pointIndex, []place, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	index := newPointIndex()
+	var places []place
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024) // alternate names make some lines long
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 15 {
+			return nil, nil, fmt.Errorf("line %d: expected at least 15 columns, got %d", line, len(fields))
+		}
+		if fields[6] != "P" {
+			continue // Only populated places
+		}
+
+		lat, latErr := strconv.ParseFloat(fields[4], 64)
+		lng, lngErr := strconv.ParseFloat(fields[5], 64)
+		if latErr != nil || lngErr != nil {
+			return nil, nil, fmt.Errorf("line %d: invalid coordinates", line)
+		}
+		population, _ := strconv.ParseInt(fields[14], 10, 64)
+
+		index.add(lat, lng, len(places))
+		places = append(places, place{
+			id:          fields[0],
+			name:        fields[1],
+			countryCode: fields[8],
+			admin1Code:  fields[10],
+			population:  population,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return index, places, nil
+}
@@ -0,0 +1,223 @@
+package offline
+
+import (
+	"math"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	kmPerDegree   = 111.195 // along a meridian, and along the equator
+	cellSize      = 1.0     // degrees per grid cell
+)
+
+// ring is a closed polygon ring of [lng, lat] positions, as in GeoJSON
+type ring [][2]float64
+
+// shape is one polygon (an outer ring and its holes) belonging to a region
+type shape struct {
+	rings                          []ring
+	minLng, minLat, maxLng, maxLat float64
+	region                         int
+}
+
+// polygonIndex answers point-in-polygon queries through a grid of one-degree cells, each listing
+// the shapes whose bounding box overlaps it
+type polygonIndex struct {
+	shapes []shape
+	cells  map[int][]int
+}
+
+func newPolygonIndex() *polygonIndex {
+	return &polygonIndex{cells: make(map[int][]int)}
+}
+
+func cellKey(x, y int) int {
+	return y*360 + x
+}
+
+func cellCoords(lat, lng float64) (int, int) {
+	x := int(math.Floor((lng + 180) / cellSize))
+	y := int(math.Floor((lat + 90) / cellSize))
+	return clamp(x, 0, 359), clamp(y, 0, 179)
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// add indexes a polygon for the region with the given index
+func (idx *polygonIndex) add(rings []ring, region int) {
+	if len(rings) == 0 || len(rings[0]) < 3 {
+		return
+	}
+
+	s := shape{rings: rings, region: region, minLng: 180, minLat: 90, maxLng: -180, maxLat: -90}
+	for _, position := range rings[0] {
+		s.minLng = math.Min(s.minLng, position[0])
+		s.maxLng = math.Max(s.maxLng, position[0])
+		s.minLat = math.Min(s.minLat, position[1])
+		s.maxLat = math.Max(s.maxLat, position[1])
+	}
+
+	id := len(idx.shapes)
+	idx.shapes = append(idx.shapes, s)
+
+	minX, minY := cellCoords(s.minLat, s.minLng)
+	maxX, maxY := cellCoords(s.maxLat, s.maxLng)
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			idx.cells[cellKey(x, y)] = append(idx.cells[cellKey(x, y)], id)
+		}
+	}
+}
+
+// locate returns the region containing the point
+func (idx *polygonIndex) locate(lat, lng float64) (int, bool) {
+	for _, id := range idx.cells[cellKey(cellCoords(lat, lng))] {
+		s := &idx.shapes[id]
+		if lng < s.minLng || lng > s.maxLng || lat < s.minLat || lat > s.maxLat {
+			continue
+		}
+		if s.contains(lat, lng) {
+			return s.region, true
+		}
+	}
+	return 0, false
+}
+
+// nearest returns the region whose border is closest to the point, if it lies within maxKm. It catches
+// points just offshore, or on a coastline that the simplified polygons cut off.
+func (idx *polygonIndex) nearest(lat, lng, maxKm float64) (int, bool) {
+	best, bestKm := 0, math.Inf(1)
+	seen := make(map[int]bool)
+
+	latSpan := maxKm / kmPerDegree
+	lngSpan := maxKm / (kmPerDegree * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+	minX, minY := cellCoords(lat-latSpan, lng-lngSpan)
+	maxX, maxY := cellCoords(lat+latSpan, lng+lngSpan)
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			for _, id := range idx.cells[cellKey(x, y)] {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				s := &idx.shapes[id]
+				if distance := s.distanceKm(lat, lng); distance < bestKm {
+					best, bestKm = s.region, distance
+				}
+			}
+		}
+	}
+
+	if bestKm > maxKm {
+		return 0, false
+	}
+	return best, true
+}
+
+// contains applies the even-odd rule across every ring, so holes are excluded
+func (s *shape) contains(lat, lng float64) bool {
+	inside := false
+	for _, r := range s.rings {
+		for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+			xi, yi := r[i][0], r[i][1]
+			xj, yj := r[j][0], r[j][1]
+			if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// distanceKm is the approximate distance from the point to the shape's outer ring, using an
+// equirectangular projection around the point, which is accurate enough over tens of kilometres
+func (s *shape) distanceKm(lat, lng float64) float64 {
+	scale := math.Cos(lat * math.Pi / 180)
+	project := func(position [2]float64) (float64, float64) {
+		return (position[0] - lng) * scale * kmPerDegree, (position[1] - lat) * kmPerDegree
+	}
+
+	best := math.Inf(1)
+	outer := s.rings[0]
+	for i := 1; i < len(outer); i++ {
+		ax, ay := project(outer[i-1])
+		bx, by := project(outer[i])
+		best = math.Min(best, distanceToSegment(ax, ay, bx, by))
+	}
+	return best
+}
+
+// distanceToSegment is the distance from the origin to the segment a-b
+func distanceToSegment(ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	t := 0.0
+	if lengthSquared := dx*dx + dy*dy; lengthSquared > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSquared))
+	}
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+// point is an indexed location belonging to a place
+type point struct {
+	lat, lng float64
+	place    int
+}
+
+// pointIndex answers nearest-neighbour queries through the same one-degree grid
+type pointIndex struct {
+	cells map[int][]point
+	size  int
+}
+
+func newPointIndex() *pointIndex {
+	return &pointIndex{cells: make(map[int][]point)}
+}
+
+func (idx *pointIndex) add(lat, lng float64, place int) {
+	key := cellKey(cellCoords(lat, lng))
+	idx.cells[key] = append(idx.cells[key], point{lat: lat, lng: lng, place: place})
+	idx.size++
+}
+
+// nearest returns the closest place within maxKm and its distance
+func (idx *pointIndex) nearest(lat, lng, maxKm float64) (int, float64, bool) {
+	best, bestKm := 0, math.Inf(1)
+
+	latSpan := maxKm / kmPerDegree
+	lngSpan := maxKm / (kmPerDegree * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+	minX, minY := cellCoords(lat-latSpan, lng-lngSpan)
+	maxX, maxY := cellCoords(lat+latSpan, lng+lngSpan)
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			for _, p := range idx.cells[cellKey(x, y)] {
+				if distance := DistanceKm(lat, lng, p.lat, p.lng); distance < bestKm {
+					best, bestKm = p.place, distance
+				}
+			}
+		}
+	}
+
+	if bestKm > maxKm {
+		return 0, 0, false
+	}
+	return best, bestKm, true
+}
+
+// DistanceKm is the great-circle distance between two points
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,327 @@
+// Package offline answers coarse reverse geocoding queries (country, state or city) from boundary
+// and place data held in memory, without calling an upstream API.
+package offline
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/hackclub/geocoder/internal/iso3166"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+//go:embed data/countries.geojson
+var countriesGeoJSON []byte
+
+// Precision is how much of the admin hierarchy an offline lookup resolves
+type Precision string
+
+const (
+	PrecisionCountry Precision = "country"
+	PrecisionAdmin1  Precision = "admin1"
+	PrecisionCity    Precision = "city"
+)
+
+const (
+	// coastalToleranceKm lets points just outside a simplified coastline still match the country
+	coastalToleranceKm = 25
+	// maxCityDistanceKm is how far the nearest populated place may be before a point has no city
+	maxCityDistanceKm = 50
+)
+
+// Confidence of an offline result: high when the point is inside the boundaries it was matched to, low
+// when they were matched by proximity or the country was taken from the nearest city
+const (
+	ConfidenceHigh = "high"
+	ConfidenceLow  = "low"
+)
+
+var (
+	// ErrNoResults is returned when the point is not in or near any known region, e.g. in open ocean
+	ErrNoResults = errors.New("no results found")
+	// ErrUnavailable is returned when the data for a precision has not been loaded
+	ErrUnavailable = errors.New("offline data not loaded")
+)
+
+// ParsePrecision validates a precision parameter
+func ParsePrecision(value string) (Precision, error) {
+	switch precision := Precision(strings.ToLower(strings.TrimSpace(value))); precision {
+	case PrecisionCountry, PrecisionAdmin1, PrecisionCity:
+		return precision, nil
+	default:
+		return "", fmt.Errorf("invalid precision %q, expected country, admin1 or city", value)
+	}
+}
+
+// region is a country or first-level subdivision with a polygon in an index
+type region struct {
+	code        string // ISO 3166-1 alpha-2 for countries, ISO 3166-2 for subdivisions
+	name        string
+	countryCode string
+}
+
+// place is a populated place
+type place struct {
+	id          string
+	name        string
+	countryCode string
+	admin1Code  string // GeoNames admin1 code, which is the ISO 3166-2 suffix for some countries only
+	population  int64
+}
+
+// Geocoder resolves coordinates against country boundaries, which are always available, and optionally
+// against first-level subdivision boundaries and populated places loaded from files
+type Geocoder struct {
+	countries      *polygonIndex
+	countryRegions []region
+	countryNames   map[string]string // By code, for every country the boundaries include
+	admin1         *polygonIndex
+	admin1Regions  []region
+	cities         *pointIndex
+	places         []place
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultGeocoder *Geocoder
+)
+
+// Default returns a Geocoder with only the embedded country boundaries
+func Default() *Geocoder {
+	defaultOnce.Do(func() {
+		geocoder, err := New("", "", "")
+		if err != nil {
+			panic("offline: invalid embedded country data: " + err.Error())
+		}
+		defaultGeocoder = geocoder
+	})
+	return defaultGeocoder
+}
+
+// New builds a Geocoder from Natural Earth admin-0 and admin-1 GeoJSON files and a GeoNames cities file
+// such as cities15000.txt. An empty countriesPath uses the embedded 1:110m boundaries; the other paths
+// may be empty to leave that precision out.
+func New(countriesPath, admin1Path, citiesPath string) (*Geocoder, error) {
+	g := &Geocoder{}
+
+	var err error
+	if countriesPath != "" {
+		g.countries, g.countryRegions, err = loadBoundaries(countriesPath, countryRegion)
+	} else {
+		g.countries, g.countryRegions, err = parseBoundaries(countriesGeoJSON, countryRegion)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load country boundaries: %w", err)
+	}
+	g.countryNames = make(map[string]string, len(g.countryRegions))
+	for _, country := range g.countryRegions {
+		g.countryNames[country.code] = country.name
+	}
+
+	if admin1Path != "" {
+		g.admin1, g.admin1Regions, err = loadBoundaries(admin1Path, admin1Region)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load admin-1 boundaries: %w", err)
+		}
+	}
+
+	if citiesPath != "" {
+		g.cities, g.places, err = loadGeoNamesCities(citiesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cities: %w", err)
+		}
+	}
+
+	return g, nil
+}
+
+// Supports reports whether the data needed for a precision is loaded
+func (g *Geocoder) Supports(precision Precision) bool {
+	switch precision {
+	case PrecisionCountry:
+		return true
+	case PrecisionAdmin1:
+		return g.admin1 != nil
+	case PrecisionCity:
+		return g.cities != nil
+	}
+	return false
+}
+
+// Match describes how an offline lookup was resolved, and is returned as the raw backend response
+type Match struct {
+	Precision Precision   `json:"precision"`
+	Country   *RegionInfo `json:"country,omitempty"`
+	Admin1    *RegionInfo `json:"admin1,omitempty"`
+	City      *PlaceInfo  `json:"city,omitempty"`
+}
+
+// RegionInfo identifies the boundary a point matched
+type RegionInfo struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Inside bool   `json:"inside"` // False when matched by proximity to the border rather than containment
+}
+
+// PlaceInfo identifies the nearest populated place
+type PlaceInfo struct {
+	GeoNameID  string  `json:"geoname_id"`
+	Name       string  `json:"name"`
+	Population int64   `json:"population"`
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// ReverseGeocode resolves coordinates to the requested precision, with names in the given language
+// where translations exist
+func (g *Geocoder) ReverseGeocode(lat, lng float64, precision Precision, language string) (*models.ReverseGeocodeAPIResponse, error) {
+	if !g.Supports(precision) {
+		return nil, fmt.Errorf("%w for precision %s", ErrUnavailable, precision)
+	}
+
+	match := &Match{Precision: precision}
+	var hierarchy models.AdminHierarchy
+	confident := true
+
+	countryInside := false
+	if id, inside, ok := lookupRegion(g.countries, lat, lng); ok {
+		country := g.countryRegions[id]
+		match.Country = &RegionInfo{Code: country.code, Name: country.name, Inside: inside}
+		hierarchy.CountryCode = country.code
+		hierarchy.Country = country.name
+		countryInside = inside
+		confident = inside
+	}
+
+	var city *place
+	countryFromCity := false
+	if g.cities != nil {
+		if id, distance, ok := g.cities.nearest(lat, lng, maxCityDistanceKm); ok {
+			city = &g.places[id]
+			match.City = &PlaceInfo{GeoNameID: city.id, Name: city.name, Population: city.population, DistanceKm: distance}
+		}
+	}
+
+	// The nearest city settles the country when the boundaries can't: for points outside every polygon,
+	// and for countries the boundaries leave out, such as Singapore, which the embedded 1:110m layer
+	// folds into Malaysia
+	if city != nil && city.countryCode != hierarchy.CountryCode {
+		if _, hasBoundary := g.countryNames[city.countryCode]; !countryInside || !hasBoundary {
+			hierarchy = models.AdminHierarchy{CountryCode: city.countryCode, Country: g.countryNames[city.countryCode]}
+			countryFromCity = true
+			confident = false
+		}
+	}
+
+	if precision == PrecisionAdmin1 || (precision == PrecisionCity && g.admin1 != nil) {
+		if id, inside, ok := lookupRegion(g.admin1, lat, lng); ok {
+			admin1 := g.admin1Regions[id]
+			match.Admin1 = &RegionInfo{Code: admin1.code, Name: admin1.name, Inside: inside}
+			if hierarchy.CountryCode == "" || admin1.countryCode == hierarchy.CountryCode {
+				setState(&hierarchy, admin1.countryCode, admin1.code, admin1.name)
+				confident = confident && inside
+			}
+		}
+	}
+
+	if precision == PrecisionCity && city != nil {
+		hierarchy.City = city.name
+		if hierarchy.State == "" && city.countryCode == hierarchy.CountryCode {
+			// GeoNames admin1 codes match ISO 3166-2 in the US, Canada, Australia and a few others
+			if subdivision, ok := iso3166.FindSubdivision(city.countryCode, city.admin1Code); ok {
+				setState(&hierarchy, city.countryCode, subdivision.Code, subdivision.Name)
+			}
+		}
+	}
+	if precision != PrecisionCity && !countryFromCity {
+		match.City = nil // Only reported at coarser precisions when it decided the country
+	}
+
+	if hierarchy.CountryCode == "" {
+		return nil, fmt.Errorf("%w for coordinates: %f, %f", ErrNoResults, lat, lng)
+	}
+
+	normalizeCountry(&hierarchy)
+	localize(&hierarchy, language)
+
+	confidence := ConfidenceHigh
+	if !confident {
+		confidence = ConfidenceLow
+	}
+
+	return &models.ReverseGeocodeAPIResponse{
+		Lat:                lat,
+		Lng:                lng,
+		FormattedAddress:   joinNonEmpty(hierarchy.City, hierarchy.State, hierarchy.Country),
+		City:               hierarchy.City,
+		State:              hierarchy.StateCode,
+		StateFull:          hierarchy.State,
+		CountryName:        hierarchy.Country,
+		CountryCode:        hierarchy.CountryCode,
+		AdminHierarchy:     hierarchy,
+		Precision:          string(precision),
+		Confidence:         confidence,
+		Backend:            "offline",
+		RawBackendResponse: match,
+	}, nil
+}
+
+// lookupRegion finds the region containing a point, or failing that the one whose border is closest
+func lookupRegion(index *polygonIndex, lat, lng float64) (int, bool, bool) {
+	if index == nil {
+		return 0, false, false
+	}
+	if id, ok := index.locate(lat, lng); ok {
+		return id, true, true
+	}
+	if id, ok := index.nearest(lat, lng, coastalToleranceKm); ok {
+		return id, false, true
+	}
+	return 0, false, false
+}
+
+func setState(hierarchy *models.AdminHierarchy, countryCode, isoCode, name string) {
+	hierarchy.State = name
+	if subdivision, ok := iso3166.LookupSubdivision(isoCode); ok {
+		hierarchy.StateISOCode = subdivision.Code
+		hierarchy.StateCode = strings.TrimPrefix(subdivision.Code, subdivision.CountryCode+"-")
+	}
+	if hierarchy.CountryCode == "" {
+		hierarchy.CountryCode = countryCode
+	}
+}
+
+// normalizeCountry replaces the boundary data's country name with the ISO one and fills in the other codes
+func normalizeCountry(hierarchy *models.AdminHierarchy) {
+	country, ok := iso3166.LookupCountry(hierarchy.CountryCode)
+	if !ok {
+		return // e.g. Kosovo, which has no ISO 3166-1 code yet
+	}
+	hierarchy.Country = country.Name
+	hierarchy.CountryAlpha3 = country.Alpha3
+	hierarchy.CountryNumeric = country.Numeric
+}
+
+func localize(hierarchy *models.AdminHierarchy, language string) {
+	if language == "" {
+		return
+	}
+	if name, ok := iso3166.LocalizedCountryName(hierarchy.CountryCode, language); ok {
+		hierarchy.Country = name
+	}
+	if name, ok := iso3166.LocalizedSubdivisionName(hierarchy.StateISOCode, language); ok {
+		hierarchy.State = name
+	}
+}
+
+func joinNonEmpty(parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
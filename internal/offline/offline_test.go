@@ -0,0 +1,183 @@
+package offline
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReverseGeocode_Country(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lng float64
+		expected string
+	}{
+		{"Berlin", 52.52, 13.405, "DE"},
+		{"Shelburne", 44.38, -73.23, "US"},
+		{"Tokyo", 35.68, 139.69, "JP"},
+		{"Nairobi", -1.29, 36.82, "KE"},
+		{"Lesotho enclave", -29.6, 28.2, "LS"},
+		{"San Francisco on the simplified coastline", 37.7749, -122.4194, "US"},
+	}
+
+	geocoder := Default()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := geocoder.ReverseGeocode(tt.lat, tt.lng, PrecisionCountry, "")
+			if err != nil {
+				t.Fatalf("ReverseGeocode() error = %v", err)
+			}
+			if result.CountryCode != tt.expected {
+				t.Errorf("Expected country %s, got %s (%s)", tt.expected, result.CountryCode, result.CountryName)
+			}
+			if result.Precision != "country" || result.Backend != "offline" {
+				t.Errorf("Expected offline country precision, got %s/%s", result.Precision, result.Backend)
+			}
+		})
+	}
+
+	result, err := geocoder.ReverseGeocode(52.52, 13.405, PrecisionCountry, "")
+	if err != nil || result.Confidence != ConfidenceHigh {
+		t.Errorf("Expected high confidence inside Germany, got %+v, %v", result, err)
+	}
+
+	result, err = geocoder.ReverseGeocode(52.52, 13.405, PrecisionCountry, "fr")
+	if err != nil || result.CountryName != "Allemagne" || result.AdminHierarchy.CountryAlpha3 != "DEU" {
+		t.Errorf("Expected localized Germany with ISO codes, got %+v, %v", result, err)
+	}
+
+	if _, err := geocoder.ReverseGeocode(30.0, -40.0, PrecisionCountry, ""); !errors.Is(err, ErrNoResults) {
+		t.Errorf("Expected ErrNoResults in the mid-Atlantic, got %v", err)
+	}
+	if _, err := geocoder.ReverseGeocode(52.52, 13.405, PrecisionCity, ""); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Expected ErrUnavailable without city data, got %v", err)
+	}
+}
+
+func TestReverseGeocode_Admin1AndCity(t *testing.T) {
+	dir := t.TempDir()
+
+	// A rough box around Vermont
+	admin1 := `{"type":"FeatureCollection","features":[{"type":"Feature",
+		"properties":{"iso_a2":"US","iso_3166_2":"US-VT","name":"Vermont"},
+		"geometry":{"type":"Polygon","coordinates":[[[-73.44,42.73],[-71.46,42.73],[-71.46,45.02],[-73.44,45.02],[-73.44,42.73]]]}}]}`
+	admin1Path := filepath.Join(dir, "admin1.geojson")
+	if err := os.WriteFile(admin1Path, []byte(admin1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cities := "5234372\tBurlington\tBurlington\t\t44.47588\t-73.21207\tP\tPPLA2\tUS\t\tVT\t007\t\t\t42239\t61\t62\tAmerica/New_York\t2019-09-19\n" +
+		"5128581\tNew York City\tNew York City\t\t40.71427\t-74.00597\tP\tPPL\tUS\t\tNY\t\t\t\t8804190\t10\t57\tAmerica/New_York\t2022-05-11\n"
+	citiesPath := filepath.Join(dir, "cities15000.txt")
+	if err := os.WriteFile(citiesPath, []byte(cities), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	geocoder, err := New("", admin1Path, citiesPath)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	result, err := geocoder.ReverseGeocode(44.38, -73.23, PrecisionAdmin1, "")
+	if err != nil {
+		t.Fatalf("ReverseGeocode() error = %v", err)
+	}
+	if result.StateFull != "Vermont" || result.State != "VT" || result.AdminHierarchy.StateISOCode != "US-VT" || result.City != "" {
+		t.Errorf("Expected Vermont without a city, got %+v", result)
+	}
+
+	result, err = geocoder.ReverseGeocode(44.38, -73.23, PrecisionCity, "")
+	if err != nil {
+		t.Fatalf("ReverseGeocode() error = %v", err)
+	}
+	if result.City != "Burlington" || result.FormattedAddress != "Burlington, Vermont, United States" {
+		t.Errorf("Expected Burlington, Vermont, got %+v", result)
+	}
+
+	// Outside the admin-1 data, the state comes from the city's GeoNames admin1 code
+	result, err = geocoder.ReverseGeocode(40.73, -73.99, PrecisionCity, "")
+	if err != nil {
+		t.Fatalf("ReverseGeocode() error = %v", err)
+	}
+	if result.City != "New York City" || result.AdminHierarchy.StateISOCode != "US-NY" {
+		t.Errorf("Expected New York City, NY, got %+v", result)
+	}
+}
+
+func TestReverseGeocode_CountriesMissingFromBoundaries(t *testing.T) {
+	dir := t.TempDir()
+	cities := "1880252\tSingapore\tSingapore\t\t1.28967\t103.85007\tP\tPPLC\tSG\t\t00\t\t\t\t5638700\t\t8\tAsia/Singapore\t2024-01-01\n" +
+		"1732752\tJohor Bahru\tJohor Bahru\t\t1.4655\t103.7578\tP\tPPLA\tMY\t\t01\t\t\t\t802489\t\t33\tAsia/Kuala_Lumpur\t2024-01-01\n" +
+		"2562305\tValletta\tValletta\t\t35.89968\t14.5148\tP\tPPLC\tMT\t\t60\t\t\t\t6794\t\t56\tEurope/Malta\t2024-01-01\n"
+	citiesPath := filepath.Join(dir, "cities15000.txt")
+	if err := os.WriteFile(citiesPath, []byte(cities), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	geocoder, err := New("", "", citiesPath)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		lat, lng   float64
+		expected   string
+		confidence string
+	}{
+		// The 1:110m boundaries put Singapore inside Malaysia and leave Malta out
+		{"Singapore", 1.29, 103.85, "SG", ConfidenceLow},
+		{"Malta", 35.9, 14.51, "MT", ConfidenceLow},
+		{"Johor Bahru", 1.48, 103.74, "MY", ConfidenceHigh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := geocoder.ReverseGeocode(tt.lat, tt.lng, PrecisionCountry, "")
+			if err != nil {
+				t.Fatalf("ReverseGeocode() error = %v", err)
+			}
+			if result.CountryCode != tt.expected || result.Confidence != tt.confidence || result.City != "" {
+				t.Errorf("Expected %s with %s confidence and no city, got %+v", tt.expected, tt.confidence, result)
+			}
+		})
+	}
+
+	if _, err := Default().ReverseGeocode(35.9, 14.51, PrecisionCountry, ""); !errors.Is(err, ErrNoResults) {
+		t.Errorf("Expected ErrNoResults for Malta without city data, got %v", err)
+	}
+}
+
+func TestNew_CountriesFile(t *testing.T) {
+	// Natural Earth's full layers have upper case properties and no ISO_A2 for France
+	countries := `{"type":"FeatureCollection","features":[{"type":"Feature",
+		"properties":{"ISO_A2":"-99","ISO_A2_EH":"FR","NAME":"France"},
+		"geometry":{"type":"Polygon","coordinates":[[[-5,42],[8,42],[8,51],[-5,51],[-5,42]]]}}]}`
+	countriesPath := filepath.Join(t.TempDir(), "countries.geojson")
+	if err := os.WriteFile(countriesPath, []byte(countries), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	geocoder, err := New(countriesPath, "", "")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	result, err := geocoder.ReverseGeocode(48.85, 2.35, PrecisionCountry, "")
+	if err != nil || result.CountryCode != "FR" || result.CountryName != "France" {
+		t.Errorf("Expected France from the countries file, got %+v, %v", result, err)
+	}
+	if _, err := geocoder.ReverseGeocode(52.52, 13.405, PrecisionCountry, ""); !errors.Is(err, ErrNoResults) {
+		t.Errorf("Expected the file to replace the embedded boundaries, got %v", err)
+	}
+}
+
+func TestParsePrecision(t *testing.T) {
+	for _, value := range []string{"country", "admin1", "CITY"} {
+		if _, err := ParsePrecision(value); err != nil {
+			t.Errorf("ParsePrecision(%q) error = %v", value, err)
+		}
+	}
+	if _, err := ParsePrecision("street"); err == nil {
+		t.Error("Expected an error for an unsupported precision")
+	}
+}
@@ -0,0 +1,201 @@
+// Package overrides pins the answer for addresses and places the geocoding provider gets wrong,
+// such as recurring venues. Overrides are managed by admins and consulted before the cache and
+// Google.
+package overrides
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/hackclub/geocoder/internal/iso3166"
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+// Backend is the backend reported for overridden results
+const Backend = "override"
+
+// refreshInterval is how long overrides are held in memory before being reloaded, so changes made
+// through another instance are picked up
+const refreshInterval = time.Minute
+
+// Store is the database access the resolver needs
+type Store interface {
+	GetGeocodeOverrides() ([]models.GeocodeOverride, error)
+}
+
+// Normalize reduces an address or pattern to lowercase letters, digits and single spaces, so that
+// "123 Main St., Suite #4" and "123 main st suite 4" match. Wildcards are kept.
+func Normalize(address string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(address) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '*' {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Validate normalizes an override's pattern and checks that it matches something and pins a valid
+// point. Errors are worded for an INVALID_REQUEST response.
+func Validate(o *models.GeocodeOverride) error {
+	o.AddressPattern = Normalize(o.AddressPattern)
+	o.PlaceID = strings.TrimSpace(o.PlaceID)
+	if o.AddressPattern == "" && o.PlaceID == "" {
+		return errors.New("address_pattern or place_id is required")
+	}
+	if o.AddressPattern != "" && strings.Trim(o.AddressPattern, "* ") == "" {
+		return errors.New("address_pattern must contain more than wildcards")
+	}
+	if o.Lat < -90 || o.Lat > 90 || o.Lng < -180 || o.Lng > 180 {
+		return errors.New("lat must be between -90 and 90 and lng between -180 and 180")
+	}
+	if o.Lat == 0 && o.Lng == 0 {
+		return errors.New("lat and lng are required")
+	}
+	if code := o.AdminHierarchy.CountryCode; code != "" {
+		country, ok := iso3166.LookupCountry(code)
+		if !ok {
+			return errors.New("admin_hierarchy.country_code must be an ISO 3166 country code")
+		}
+		o.AdminHierarchy.CountryCode = country.Alpha2
+		if o.AdminHierarchy.Country == "" {
+			o.AdminHierarchy.Country = country.Name
+		}
+	}
+	return nil
+}
+
+// Matches reports whether a normalized address matches a pattern, where * stands for any run of
+// characters
+func Matches(pattern, address string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == address
+	}
+	if !strings.HasPrefix(address, parts[0]) {
+		return false
+	}
+	address = address[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(address, part)
+		if i < 0 {
+			return false
+		}
+		address = address[i+len(part):]
+	}
+	return strings.HasSuffix(address, last)
+}
+
+// Resolver finds the override for a query. Overrides are few and read on every forward geocode, so
+// they are held in memory.
+type Resolver struct {
+	store Store
+
+	mu        sync.Mutex
+	overrides []models.GeocodeOverride
+	loadedAt  time.Time
+}
+
+// NewResolver creates a Resolver backed by the given store
+func NewResolver(store Store) *Resolver {
+	return &Resolver{store: store}
+}
+
+// Invalidate makes the next lookup reload overrides, e.g. after an admin changed them
+func (r *Resolver) Invalidate() {
+	r.mu.Lock()
+	r.loadedAt = time.Time{}
+	r.mu.Unlock()
+}
+
+// ForAddress returns the pinned result for an address. An exact pattern wins over wildcard ones,
+// and among those the most specific, i.e. the one with the most characters besides wildcards.
+func (r *Resolver) ForAddress(address string) (*models.GeocodeAPIResponse, bool) {
+	normalized := Normalize(address)
+	if normalized == "" {
+		return nil, false
+	}
+
+	overrides := r.load()
+	var best *models.GeocodeOverride
+	bestScore := -1
+	for i, o := range overrides {
+		if o.AddressPattern == "" || !Matches(o.AddressPattern, normalized) {
+			continue
+		}
+		score := len(strings.ReplaceAll(o.AddressPattern, "*", ""))
+		if !strings.Contains(o.AddressPattern, "*") {
+			score = len(normalized) + 1
+		}
+		if score > bestScore {
+			best, bestScore = &overrides[i], score
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return Response(*best), true
+}
+
+// ForPlaceID returns the pinned result for a provider place ID
+func (r *Resolver) ForPlaceID(placeID string) (*models.GeocodeAPIResponse, bool) {
+	if placeID == "" {
+		return nil, false
+	}
+	for _, o := range r.load() {
+		if o.PlaceID == placeID {
+			return Response(o), true
+		}
+	}
+	return nil, false
+}
+
+// load returns the overrides, reloading them when they are stale. If reloading fails the stale
+// overrides are kept, so a database hiccup doesn't turn pinned venues back into wrong answers.
+func (r *Resolver) load() []models.GeocodeOverride {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.loadedAt) < refreshInterval {
+		return r.overrides
+	}
+	overrides, err := r.store.GetGeocodeOverrides()
+	if err != nil {
+		log.Printf("Failed to load geocode overrides: %v", err)
+		return r.overrides
+	}
+	r.overrides = overrides
+	r.loadedAt = time.Now()
+	return r.overrides
+}
+
+// Response builds a geocoding result from an override
+func Response(o models.GeocodeOverride) *models.GeocodeAPIResponse {
+	h := o.AdminHierarchy
+	return &models.GeocodeAPIResponse{
+		Lat:              o.Lat,
+		Lng:              o.Lng,
+		FormattedAddress: o.FormattedAddress,
+		PlaceID:          o.PlaceID,
+		StateName:        h.State,
+		StateCode:        h.StateCode,
+		CountryName:      h.Country,
+		CountryCode:      h.CountryCode,
+		AdminHierarchy:   h,
+		Backend:          Backend,
+		// The override itself, so callers can tell which entry pinned the result
+		RawBackendResponse: o,
+	}
+}
@@ -0,0 +1,127 @@
+package overrides
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+type mockStore struct {
+	overrides []models.GeocodeOverride
+	err       error
+	loads     int
+}
+
+func (m *mockStore) GetGeocodeOverrides() ([]models.GeocodeOverride, error) {
+	m.loads++
+	return m.overrides, m.err
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"123 Main St., Suite #4":       "123 main st suite 4",
+		"  15 Falls Rd,Shelburne, VT ": "15 falls rd shelburne vt",
+		"Rathausstraße 15, Berlin":     "rathausstraße 15 berlin",
+		"* Madison Square Garden *":    "* madison square garden *",
+		"":                             "",
+	}
+	for address, want := range tests {
+		if got := Normalize(address); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", address, got, want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		address string
+		want    bool
+	}{
+		{"15 falls rd shelburne vt", "15 falls rd shelburne vt", true},
+		{"15 falls rd shelburne vt", "15 falls rd shelburne vt 05482", false},
+		{"15 falls rd shelburne*", "15 falls rd shelburne vt 05482", true},
+		{"*madison square garden*", "madison square garden new york", true},
+		{"*madison square garden*", "the garden", false},
+		{"4 pennsylvania plaza * ny", "4 pennsylvania plaza new york ny", true},
+		{"4 pennsylvania plaza * ny", "4 pennsylvania plaza new york nj", false},
+		{"a*a", "a", false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.pattern, tt.address); got != tt.want {
+			t.Errorf("Matches(%q, %q) = %v, want %v", tt.pattern, tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	store := &mockStore{overrides: []models.GeocodeOverride{
+		{ID: "broad", AddressPattern: "*shelburne*", Lat: 44.39, Lng: -73.22},
+		{ID: "specific", AddressPattern: "15 falls rd shelburne*", Lat: 44.3806, Lng: -73.2273},
+		{ID: "exact", AddressPattern: "15 falls rd shelburne vt", Lat: 44.38, Lng: -73.23},
+		{ID: "venue", PlaceID: "ChIJvenue", Lat: 40.7505, Lng: -73.9934, AdminHierarchy: models.AdminHierarchy{Country: "United States", CountryCode: "US"}},
+	}}
+	r := NewResolver(store)
+
+	tests := []struct {
+		address string
+		wantID  string
+	}{
+		{"15 Falls Rd., Shelburne, VT", "exact"},
+		{"15 Falls Rd, Shelburne, VT 05482", "specific"},
+		{"Shelburne Museum, Shelburne", "broad"},
+		{"Burlington, VT", ""},
+	}
+	for _, tt := range tests {
+		result, ok := r.ForAddress(tt.address)
+		gotID := ""
+		if ok {
+			gotID = result.RawBackendResponse.(models.GeocodeOverride).ID
+		}
+		if gotID != tt.wantID {
+			t.Errorf("ForAddress(%q) matched %q, want %q", tt.address, gotID, tt.wantID)
+		}
+	}
+
+	result, ok := r.ForPlaceID("ChIJvenue")
+	if !ok || result.Backend != Backend || result.CountryCode != "US" || result.PlaceID != "ChIJvenue" {
+		t.Errorf("Expected the pinned venue, got %+v", result)
+	}
+	if _, ok := r.ForPlaceID(""); ok {
+		t.Error("Expected no override for an empty place ID")
+	}
+
+	if store.loads != 1 {
+		t.Errorf("Expected overrides to be loaded once, got %d loads", store.loads)
+	}
+
+	// A failed reload keeps the overrides already loaded
+	store.err = errors.New("connection refused")
+	r.Invalidate()
+	if _, ok := r.ForPlaceID("ChIJvenue"); !ok || store.loads != 2 {
+		t.Errorf("Expected a reload that falls back to the loaded overrides, got %d loads", store.loads)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	o := models.GeocodeOverride{AddressPattern: "15 Falls Rd., Shelburne*", Lat: 44.3806, Lng: -73.2273, AdminHierarchy: models.AdminHierarchy{CountryCode: "usa"}}
+	if err := Validate(&o); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if o.AddressPattern != "15 falls rd shelburne*" || o.AdminHierarchy.CountryCode != "US" || o.AdminHierarchy.Country == "" {
+		t.Errorf("Expected a normalized override, got %+v", o)
+	}
+
+	for _, invalid := range []models.GeocodeOverride{
+		{Lat: 44.3806, Lng: -73.2273},
+		{AddressPattern: "**", Lat: 44.3806, Lng: -73.2273},
+		{PlaceID: "ChIJvenue"},
+		{PlaceID: "ChIJvenue", Lat: 44.3806, Lng: 200},
+		{PlaceID: "ChIJvenue", Lat: 44.3806, Lng: -73.2273, AdminHierarchy: models.AdminHierarchy{CountryCode: "XX"}},
+	} {
+		if err := Validate(&invalid); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Package postalcode validates and normalizes postal codes against the formats each country uses.
+package postalcode
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrNotUsed is returned for countries without a postal code system
+	ErrNotUsed = errors.New("country does not use postal codes")
+	// ErrInvalidFormat is returned when a code doesn't match its country's format
+	ErrInvalidFormat = errors.New("invalid postal code format")
+)
+
+// format is a country's postal code layout. Codes typed without their separator, such as "SW1A1AA" or
+// "00950", are accepted by inserting it before the last suffix characters.
+type format struct {
+	pattern   *regexp.Regexp
+	example   string
+	separator string
+	suffix    int
+}
+
+func newFormat(pattern, example, separator string, suffix int) format {
+	return format{pattern: regexp.MustCompile(`^(?:` + pattern + `)$`), example: example, separator: separator, suffix: suffix}
+}
+
+// formats covers the countries with postal codes in the GeoNames dataset. Where GeoNames only has the
+// first part of a code (the UK outward code, the Canadian FSA, Irish routing keys), both are accepted.
+var formats = map[string]format{
+	"AD": newFormat(`AD\d{3}`, "AD100", "", 0),
+	"AR": newFormat(`[A-Z]?\d{4}(?:[A-Z]{3})?`, "C1425", "", 0),
+	"AT": newFormat(`\d{4}`, "1010", "", 0),
+	"AU": newFormat(`\d{4}`, "2000", "", 0),
+	"BD": newFormat(`\d{4}`, "1000", "", 0),
+	"BE": newFormat(`\d{4}`, "1000", "", 0),
+	"BG": newFormat(`\d{4}`, "1000", "", 0),
+	"BR": newFormat(`\d{5}(?:-\d{3})?`, "01310-100", "-", 3),
+	"BY": newFormat(`\d{6}`, "220030", "", 0),
+	"CA": newFormat(`[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z](?: \d[ABCEGHJ-NPRSTV-Z]\d)?`, "K1A 0B1", " ", 3),
+	"CH": newFormat(`\d{4}`, "8001", "", 0),
+	"CL": newFormat(`\d{7}`, "8320000", "", 0),
+	"CN": newFormat(`\d{6}`, "100000", "", 0),
+	"CO": newFormat(`\d{6}`, "110111", "", 0),
+	"CY": newFormat(`\d{4}`, "1010", "", 0),
+	"CZ": newFormat(`\d{3} \d{2}`, "110 00", " ", 2),
+	"DE": newFormat(`\d{5}`, "10115", "", 0),
+	"DK": newFormat(`\d{4}`, "1050", "", 0),
+	"DZ": newFormat(`\d{5}`, "16000", "", 0),
+	"EE": newFormat(`\d{5}`, "10111", "", 0),
+	"ES": newFormat(`\d{5}`, "28001", "", 0),
+	"FI": newFormat(`\d{5}`, "00100", "", 0),
+	"FR": newFormat(`\d{5}`, "75001", "", 0),
+	"GB": newFormat(`[A-Z]{1,2}\d[A-Z\d]?(?: \d[A-Z]{2})?`, "SW1A 1AA", " ", 3),
+	"GR": newFormat(`\d{3} \d{2}`, "105 57", " ", 2),
+	"GT": newFormat(`\d{5}`, "01001", "", 0),
+	"HR": newFormat(`\d{5}`, "10000", "", 0),
+	"HU": newFormat(`\d{4}`, "1011", "", 0),
+	"ID": newFormat(`\d{5}`, "10110", "", 0),
+	"IE": newFormat(`(?:[AC-FHKNPRTV-Y]\d{2}|D6W)(?: [AC-FHKNPRTV-Y\d]{4})?`, "D02 X285", " ", 4),
+	"IN": newFormat(`\d{6}`, "110001", "", 0),
+	"IS": newFormat(`\d{3}`, "101", "", 0),
+	"IT": newFormat(`\d{5}`, "00118", "", 0),
+	"JP": newFormat(`\d{3}-\d{4}`, "100-0001", "-", 4),
+	"KR": newFormat(`\d{5}`, "03051", "", 0),
+	"LI": newFormat(`\d{4}`, "9490", "", 0),
+	"LK": newFormat(`\d{5}`, "00100", "", 0),
+	"LT": newFormat(`\d{5}`, "01100", "", 0),
+	"LU": newFormat(`\d{4}`, "1009", "", 0),
+	"LV": newFormat(`LV-\d{4}`, "LV-1050", "-", 4),
+	"MA": newFormat(`\d{5}`, "10000", "", 0),
+	"MC": newFormat(`980\d{2}`, "98000", "", 0),
+	"MT": newFormat(`[A-Z]{3} ?\d{2,4}`, "VLT 1117", "", 0),
+	"MX": newFormat(`\d{5}`, "06000", "", 0),
+	"MY": newFormat(`\d{5}`, "50050", "", 0),
+	"NL": newFormat(`\d{4}(?: [A-Z]{2})?`, "1012 JS", " ", 2),
+	"NO": newFormat(`\d{4}`, "0150", "", 0),
+	"NZ": newFormat(`\d{4}`, "6011", "", 0),
+	"PE": newFormat(`\d{5}`, "15001", "", 0),
+	"PH": newFormat(`\d{4}`, "1000", "", 0),
+	"PK": newFormat(`\d{5}`, "44000", "", 0),
+	"PL": newFormat(`\d{2}-\d{3}`, "00-950", "-", 3),
+	"PR": newFormat(`\d{5}(?:-\d{4})?`, "00901", "-", 4),
+	"PT": newFormat(`\d{4}(?:-\d{3})?`, "1000-001", "-", 3),
+	"RO": newFormat(`\d{6}`, "010011", "", 0),
+	"RS": newFormat(`\d{5}`, "11000", "", 0),
+	"RU": newFormat(`\d{6}`, "101000", "", 0),
+	"SE": newFormat(`\d{3} \d{2}`, "111 22", " ", 2),
+	"SG": newFormat(`\d{6}`, "018956", "", 0),
+	"SI": newFormat(`\d{4}`, "1000", "", 0),
+	"SK": newFormat(`\d{3} \d{2}`, "811 01", " ", 2),
+	"TH": newFormat(`\d{5}`, "10200", "", 0),
+	"TR": newFormat(`\d{5}`, "06100", "", 0),
+	"UA": newFormat(`\d{5}`, "01001", "", 0),
+	"US": newFormat(`\d{5}(?:-\d{4})?`, "05482", "-", 4),
+	"UY": newFormat(`\d{5}`, "11000", "", 0),
+	"ZA": newFormat(`\d{4}`, "2000", "", 0),
+}
+
+// withoutPostalCodes lists countries with no postal code system
+var withoutPostalCodes = map[string]bool{
+	"AE": true, "AG": true, "AO": true, "AW": true, "BF": true, "BI": true, "BJ": true, "BO": true, "BS": true,
+	"BW": true, "BZ": true, "CD": true, "CF": true, "CG": true, "CI": true, "CK": true, "CM": true, "DJ": true,
+	"DM": true, "ER": true, "FJ": true, "GD": true, "GM": true, "GQ": true, "GY": true, "HK": true, "KI": true,
+	"KM": true, "KN": true, "KP": true, "ML": true, "MO": true, "MR": true, "MW": true, "NR": true, "NU": true,
+	"QA": true, "RW": true, "SB": true, "SC": true, "SL": true, "SR": true, "ST": true, "SY": true, "TD": true,
+	"TF": true, "TG": true, "TK": true, "TL": true, "TO": true, "TV": true, "UG": true, "VU": true, "YE": true,
+	"ZW": true,
+}
+
+// genericPattern accepts the codes of countries without a known format
+var genericPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,9}$`)
+
+// Normalize validates a postal code for a country (ISO 3166-1 alpha-2) and returns it in the country's
+// canonical form: upper case, with single spaces, and with any separator the format needs
+func Normalize(countryCode, code string) (string, error) {
+	countryCode = strings.ToUpper(countryCode)
+	code = strings.ToUpper(strings.Join(strings.Fields(code), " "))
+
+	if withoutPostalCodes[countryCode] {
+		return "", fmt.Errorf("%w: %s", ErrNotUsed, countryCode)
+	}
+
+	f, ok := formats[countryCode]
+	if !ok {
+		if !genericPattern.MatchString(code) {
+			return "", fmt.Errorf("%w for %s", ErrInvalidFormat, countryCode)
+		}
+		return code, nil
+	}
+
+	if f.pattern.MatchString(code) {
+		return code, nil
+	}
+	if f.separator != "" {
+		compact := strings.NewReplacer(" ", "", "-", "").Replace(code)
+		if len(compact) > f.suffix {
+			candidate := compact[:len(compact)-f.suffix] + f.separator + compact[len(compact)-f.suffix:]
+			if f.pattern.MatchString(candidate) {
+				return candidate, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w for %s, expected a code like %q", ErrInvalidFormat, countryCode, f.example)
+}
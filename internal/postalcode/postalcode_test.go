@@ -0,0 +1,58 @@
+package postalcode
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		country  string
+		code     string
+		expected string
+	}{
+		{"US", "05482", "05482"},
+		{"US", "054821234", "05482-1234"},
+		{"us", " 05482-1234 ", "05482-1234"},
+		{"GB", "sw1a1aa", "SW1A 1AA"},
+		{"GB", "SW1A", "SW1A"},
+		{"CA", "k1a0b1", "K1A 0B1"},
+		{"NL", "1012js", "1012 JS"},
+		{"PL", "00950", "00-950"},
+		{"JP", "1000001", "100-0001"},
+		{"SE", "11122", "111 22"},
+		{"DE", "10115", "10115"},
+		{"IE", "d02x285", "D02 X285"},
+		{"KE", "00100", "00100"}, // No specific format, so anything plausible
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.country+" "+tt.code, func(t *testing.T) {
+			got, err := Normalize(tt.country, tt.code)
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Normalize(%q, %q) = %q, expected %q", tt.country, tt.code, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	for _, tt := range []struct{ country, code string }{
+		{"US", "1234"},
+		{"US", "ABCDE"},
+		{"DE", "101150"},
+		{"GB", "12345"},
+		{"KE", "!"},
+	} {
+		if _, err := Normalize(tt.country, tt.code); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("Normalize(%q, %q) error = %v, expected ErrInvalidFormat", tt.country, tt.code, err)
+		}
+	}
+
+	if _, err := Normalize("AE", "12345"); !errors.Is(err, ErrNotUsed) {
+		t.Errorf("Expected ErrNotUsed for the UAE, got %v", err)
+	}
+}
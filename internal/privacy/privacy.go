@@ -0,0 +1,253 @@
+// Package privacy enforces an API key's privacy policy on what the key gets back, so apps that
+// must not hold precise locations, e.g. of minors, never receive them. Policies are applied to
+// responses on the way out; caches and stored data keep full precision.
+package privacy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/geoencode"
+	"github.com/hackclub/geocoder/internal/models"
+	"github.com/hackclub/geocoder/internal/offline"
+)
+
+const (
+	// MaxCoordinateDecimals is the finest rounding a policy can ask for; 6 decimal places is about 10cm
+	MaxCoordinateDecimals = 6
+	// MaxJitterMeters caps jitter at a distance where it is still a location
+	MaxJitterMeters = 50000
+
+	// cityLevelDecimals rounds city-level coordinates to 0.1°, about 11 km
+	cityLevelDecimals = 1
+	metersPerDegree   = 111320.0
+)
+
+// Validate checks that a policy's settings are in range. Errors are worded for an INVALID_REQUEST response.
+func Validate(policy models.PrivacyPolicy) error {
+	if policy.CoordinateDecimals < 0 || policy.CoordinateDecimals > MaxCoordinateDecimals {
+		return fmt.Errorf("coordinate_decimals must be between 0 and %d", MaxCoordinateDecimals)
+	}
+	if policy.JitterMeters < 0 || policy.JitterMeters > MaxJitterMeters {
+		return fmt.Errorf("jitter_meters must be between 0 and %d", MaxJitterMeters)
+	}
+	return nil
+}
+
+// Point reduces a point's precision under a policy: it is jittered, then rounded. The jitter is
+// derived from the key and the point, so the same point always moves to the same place and
+// averaging repeated requests can't undo it. 0,0 means "no location" throughout the API and is
+// returned as is.
+func Point(policy models.PrivacyPolicy, keyID string, lat, lng float64) (float64, float64) {
+	if lat == 0 && lng == 0 {
+		return lat, lng
+	}
+
+	if policy.JitterMeters > 0 {
+		lat, lng = jitter(keyID, lat, lng, float64(policy.JitterMeters))
+	}
+
+	decimals := policy.CoordinateDecimals
+	if policy.CityLevel && (decimals == 0 || decimals > cityLevelDecimals) {
+		decimals = cityLevelDecimals
+	}
+	if decimals > 0 {
+		scale := math.Pow(10, float64(decimals))
+		lat = math.Round(lat*scale) / scale
+		lng = math.Round(lng*scale) / scale
+	}
+	return lat, lng
+}
+
+// jitter moves a point up to maxMeters in a direction and distance picked uniformly over the disc
+// by hashing the key and the point
+func jitter(keyID string, lat, lng, maxMeters float64) (float64, float64) {
+	h := fnv.New64a()
+	h.Write([]byte(keyID))
+	h.Write([]byte(strconv.FormatFloat(lat, 'f', 7, 64)))
+	h.Write([]byte(strconv.FormatFloat(lng, 'f', 7, 64)))
+	var sum [8]byte
+	seed := binary.BigEndian.Uint64(h.Sum(sum[:0]))
+
+	u1 := float64(seed>>32) / (1 << 32)
+	u2 := float64(seed&0xffffffff) / (1 << 32)
+	distance := maxMeters * math.Sqrt(u1)
+	bearing := 2 * math.Pi * u2
+
+	lat += distance * math.Cos(bearing) / metersPerDegree
+	// Longitude degrees shrink towards the poles; the clamp keeps them finite right at a pole
+	lng += distance * math.Sin(bearing) / (metersPerDegree * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+
+	lat = math.Max(-90, math.Min(90, lat))
+	if lng > 180 {
+		lng -= 360
+	} else if lng < -180 {
+		lng += 360
+	}
+	return lat, lng
+}
+
+// Apply reduces a v1 response in place under a key's policy. Responses without looked-up locations
+// or addresses, such as conversions of coordinates the caller sent, are left alone.
+func Apply(policy models.PrivacyPolicy, keyID string, data interface{}) {
+	if policy.IsEmpty() {
+		return
+	}
+	suppressStreet := policy.SuppressStreet || policy.CityLevel
+
+	switch result := data.(type) {
+	case *models.GeocodeAPIResponse:
+		result.Lat, result.Lng = Point(policy, keyID, result.Lat, result.Lng)
+		result.Encodings = reencode(result.Encodings, result.Lat, result.Lng)
+		result.RawBackendResponse = nil
+		if suppressStreet {
+			reduceHierarchy(&result.AdminHierarchy, policy.CityLevel)
+			result.FormattedAddress = locality(result.AdminHierarchy.City, firstOf(result.AdminHierarchy.State, result.StateName), result.CountryName)
+			result.PlaceID = ""
+		}
+	case *models.ReverseGeocodeAPIResponse:
+		result.Lat, result.Lng = Point(policy, keyID, result.Lat, result.Lng)
+		result.Encodings = reencode(result.Encodings, result.Lat, result.Lng)
+		result.RawBackendResponse = nil
+		if suppressStreet {
+			reduceHierarchy(&result.AdminHierarchy, policy.CityLevel)
+			result.AddressLine1 = ""
+			result.PostalCode = ""
+			result.FormattedAddress = locality(result.City, firstOf(result.StateFull, result.State), result.CountryName)
+		}
+	case *models.GeoIPAPIResponse:
+		result.Lat, result.Lng = Point(policy, keyID, result.Lat, result.Lng)
+		result.Encodings = reencode(result.Encodings, result.Lat, result.Lng)
+		result.RawBackendResponse = nil
+		if suppressStreet {
+			result.PostalCode = ""
+		}
+	case *models.AddressValidationResponse:
+		result.Lat, result.Lng = Point(policy, keyID, result.Lat, result.Lng)
+		if suppressStreet {
+			reduceHierarchy(&result.AdminHierarchy, policy.CityLevel)
+			suggested := &result.SuggestedAddress
+			suggested.AddressLine1, suggested.AddressLine2, suggested.PostalCode = "", "", ""
+			for _, verdict := range []*models.ComponentVerdict{result.Components.AddressLine1, result.Components.AddressLine2, result.Components.PostalCode} {
+				if verdict != nil {
+					verdict.Geocoded = ""
+				}
+			}
+			result.FormattedAddress = locality(suggested.City, suggested.State, result.AdminHierarchy.Country)
+		}
+	case *models.PostalCodeResponse:
+		result.Lat, result.Lng = Point(policy, keyID, result.Lat, result.Lng)
+		for i := range result.Places {
+			place := &result.Places[i]
+			place.Lat, place.Lng = Point(policy, keyID, place.Lat, place.Lng)
+		}
+		result.RawBackendResponse = nil
+	case *models.ConsistencyResponse:
+		result.Address.Lat, result.Address.Lng = Point(policy, keyID, result.Address.Lat, result.Address.Lng)
+		result.IP.Lat, result.IP.Lng = Point(policy, keyID, result.IP.Lat, result.IP.Lng)
+		if suppressStreet {
+			result.Address.FormattedAddress = ""
+		}
+	case *models.GeofenceCheckResponse:
+		result.Lat, result.Lng = Point(policy, keyID, result.Lat, result.Lng)
+	case *models.NearbyResponse:
+		result.Lat, result.Lng = Point(policy, keyID, result.Lat, result.Lng)
+		for i := range result.Places {
+			place := &result.Places[i]
+			reducePlace(policy, keyID, &place.CollectionPlace)
+			// Exact distances to a few known places would pin the query point down again
+			place.DistanceM = math.Round(offline.DistanceKm(result.Lat, result.Lng, place.Lat, place.Lng) * 1000)
+		}
+	case *models.CollectionPlaceListResponse:
+		for i := range result.Places {
+			reducePlace(policy, keyID, &result.Places[i])
+		}
+	case *models.CollectionImportResponse:
+		for i := range result.Places {
+			reducePlace(policy, keyID, &result.Places[i])
+		}
+	}
+}
+
+// reducePlace reduces a saved place. Places added by address were geocoded on insert, so they are
+// lookups like any other; saving an address must not be a way around the policy.
+func reducePlace(policy models.PrivacyPolicy, keyID string, place *models.CollectionPlace) {
+	place.Lat, place.Lng = Point(policy, keyID, place.Lat, place.Lng)
+	if policy.SuppressStreet || policy.CityLevel {
+		place.Address = ""
+		place.FormattedAddress = ""
+	}
+}
+
+// ApplyMessage reduces a live map update under a key's policy. Map updates carry the query text
+// rather than a result address, so it is dropped entirely when street lines are suppressed.
+func ApplyMessage(policy models.PrivacyPolicy, keyID string, message *models.WebSocketMessage) {
+	if policy.IsEmpty() {
+		return
+	}
+	message.Lat, message.Lng = Point(policy, keyID, message.Lat, message.Lng)
+	if policy.SuppressStreet || policy.CityLevel {
+		message.Address = ""
+	}
+}
+
+// reduceHierarchy drops a hierarchy's postal code, and for city-level policies its neighborhood
+// and sublocality
+func reduceHierarchy(hierarchy *models.AdminHierarchy, cityLevel bool) {
+	hierarchy.PostalCode = ""
+	if cityLevel {
+		hierarchy.Neighborhood = ""
+		hierarchy.Sublocality = ""
+	}
+}
+
+// reencode recomputes encodings for a reduced point at the precisions they were asked for, so
+// they can't give away more than the coordinates do
+func reencode(encodings *models.Encodings, lat, lng float64) *models.Encodings {
+	if encodings == nil {
+		return nil
+	}
+
+	var specs []geoencode.Spec
+	for format, code := range map[string]string{geoencode.Geohash: encodings.Geohash, geoencode.PlusCode: encodings.PlusCode, geoencode.H3: encodings.H3} {
+		if code == "" {
+			continue
+		}
+		decoded, err := geoencode.Decode(code, format)
+		if err != nil {
+			return nil
+		}
+		specs = append(specs, geoencode.Spec{Format: format, Precision: decoded.Precision})
+	}
+
+	reduced, err := geoencode.Encode(lat, lng, specs)
+	if err != nil {
+		return nil
+	}
+	return reduced
+}
+
+// locality joins the known parts of a city-level address, e.g. "Berlin, Germany"
+func locality(parts ...string) string {
+	var known []string
+	for _, part := range parts {
+		if part != "" {
+			known = append(known, part)
+		}
+	}
+	return strings.Join(known, ", ")
+}
+
+// firstOf returns the first non-empty value
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+package privacy
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hackclub/geocoder/internal/models"
+)
+
+func TestPoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   models.PrivacyPolicy
+		lat, lng float64
+		wantLat  float64
+		wantLng  float64
+	}{
+		{"empty policy", models.PrivacyPolicy{}, 52.520008, 13.404954, 52.520008, 13.404954},
+		{"two decimals", models.PrivacyPolicy{CoordinateDecimals: 2}, 52.520008, 13.404954, 52.52, 13.4},
+		{"city level", models.PrivacyPolicy{CityLevel: true}, 52.520008, 13.404954, 52.5, 13.4},
+		{"city level overrides finer rounding", models.PrivacyPolicy{CityLevel: true, CoordinateDecimals: 4}, -33.86882, 151.20929, -33.9, 151.2},
+		{"no location", models.PrivacyPolicy{CityLevel: true, JitterMeters: 1000}, 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lng := Point(tt.policy, "key-1", tt.lat, tt.lng)
+			if math.Abs(lat-tt.wantLat) > 1e-9 || math.Abs(lng-tt.wantLng) > 1e-9 {
+				t.Errorf("Point(%v, %v) = %v, %v, want %v, %v", tt.lat, tt.lng, lat, lng, tt.wantLat, tt.wantLng)
+			}
+		})
+	}
+}
+
+func TestPointJitter(t *testing.T) {
+	policy := models.PrivacyPolicy{JitterMeters: 500}
+
+	lat, lng := Point(policy, "key-1", 52.52, 13.405)
+	if lat == 52.52 && lng == 13.405 {
+		t.Fatal("Expected the point to move")
+	}
+	if d := distanceMeters(52.52, 13.405, lat, lng); d > 500 {
+		t.Errorf("Expected the point to move at most 500m, moved %.0fm", d)
+	}
+
+	// Repeating the request must not reveal more than one sample
+	if againLat, againLng := Point(policy, "key-1", 52.52, 13.405); againLat != lat || againLng != lng {
+		t.Errorf("Expected the same jitter for the same point, got %v, %v then %v, %v", lat, lng, againLat, againLng)
+	}
+	if otherLat, otherLng := Point(policy, "key-2", 52.52, 13.405); otherLat == lat && otherLng == lng {
+		t.Error("Expected a different key to jitter the point differently")
+	}
+
+	// Points near the antimeridian and the poles stay valid coordinates
+	for _, p := range [][2]float64{{10, 179.9999}, {-10, -179.9999}, {89.9999, 45}, {-89.9999, -45}} {
+		lat, lng := Point(models.PrivacyPolicy{JitterMeters: MaxJitterMeters}, "key-1", p[0], p[1])
+		if lat < -90 || lat > 90 || lng < -180 || lng > 180 || math.IsNaN(lat) || math.IsNaN(lng) {
+			t.Errorf("Point(%v, %v) = %v, %v, outside the valid range", p[0], p[1], lat, lng)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		policy  models.PrivacyPolicy
+		wantErr bool
+	}{
+		{models.PrivacyPolicy{}, false},
+		{models.PrivacyPolicy{CoordinateDecimals: 3, JitterMeters: 250, SuppressStreet: true}, false},
+		{models.PrivacyPolicy{CoordinateDecimals: 7}, true},
+		{models.PrivacyPolicy{CoordinateDecimals: -1}, true},
+		{models.PrivacyPolicy{JitterMeters: MaxJitterMeters + 1}, true},
+	}
+
+	for _, tt := range tests {
+		if err := Validate(tt.policy); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Run("suppress street", func(t *testing.T) {
+		result := &models.ReverseGeocodeAPIResponse{
+			Lat:              52.520008,
+			Lng:              13.404954,
+			FormattedAddress: "Rathausstraße 15, 10178 Berlin, Germany",
+			AddressLine1:     "Rathausstraße 15",
+			City:             "Berlin",
+			State:            "BE",
+			StateFull:        "Berlin",
+			PostalCode:       "10178",
+			CountryName:      "Germany",
+			AdminHierarchy:   models.AdminHierarchy{Neighborhood: "Mitte", City: "Berlin", PostalCode: "10178", Country: "Germany"},
+			Encodings:        &models.Encodings{Geohash: "u33dc1v0e"},
+			RawBackendResponse: map[string]interface{}{
+				"formatted_address": "Rathausstraße 15, 10178 Berlin, Germany",
+			},
+		}
+
+		Apply(models.PrivacyPolicy{SuppressStreet: true, CoordinateDecimals: 2}, "key-1", result)
+
+		if result.AddressLine1 != "" || result.PostalCode != "" || result.AdminHierarchy.PostalCode != "" {
+			t.Errorf("Expected street and postal code to be dropped, got %+v", result)
+		}
+		if result.FormattedAddress != "Berlin, Berlin, Germany" {
+			t.Errorf("Expected a city-level formatted address, got %q", result.FormattedAddress)
+		}
+		if result.AdminHierarchy.Neighborhood != "Mitte" {
+			t.Errorf("Expected the neighborhood to be kept below city level, got %q", result.AdminHierarchy.Neighborhood)
+		}
+		if result.RawBackendResponse != nil {
+			t.Error("Expected the raw backend response to be dropped")
+		}
+		if result.Lat != 52.52 || result.Lng != 13.4 {
+			t.Errorf("Expected rounded coordinates, got %v, %v", result.Lat, result.Lng)
+		}
+		// The geohash keeps its precision but must describe the rounded point
+		if result.Encodings == nil || len(result.Encodings.Geohash) != 9 || result.Encodings.Geohash == "u33dc1v0e" {
+			t.Errorf("Expected the geohash to be re-encoded for the rounded point, got %+v", result.Encodings)
+		}
+	})
+
+	t.Run("city level", func(t *testing.T) {
+		result := &models.GeocodeAPIResponse{
+			Lat:              40.748817,
+			Lng:              -73.985428,
+			FormattedAddress: "20 W 34th St, New York, NY 10001, USA",
+			PlaceID:          "ChIJaXQRs6lZwokRY6EFpJnhNNE",
+			StateName:        "New York",
+			CountryName:      "United States",
+			AdminHierarchy:   models.AdminHierarchy{Neighborhood: "Midtown", Sublocality: "Manhattan", City: "New York", State: "New York", PostalCode: "10001"},
+		}
+
+		Apply(models.PrivacyPolicy{CityLevel: true}, "key-1", result)
+
+		if result.FormattedAddress != "New York, New York, United States" || result.PlaceID != "" {
+			t.Errorf("Expected only city, state and country, got %q with place ID %q", result.FormattedAddress, result.PlaceID)
+		}
+		if result.AdminHierarchy.Neighborhood != "" || result.AdminHierarchy.Sublocality != "" || result.AdminHierarchy.PostalCode != "" {
+			t.Errorf("Expected levels below city to be dropped, got %+v", result.AdminHierarchy)
+		}
+		if result.Lat != 40.7 || result.Lng != -74 {
+			t.Errorf("Expected coordinates rounded to 0.1°, got %v, %v", result.Lat, result.Lng)
+		}
+	})
+
+	t.Run("nearby places", func(t *testing.T) {
+		result := &models.NearbyResponse{
+			Lat: 44.380612,
+			Lng: -73.227341,
+			Places: []models.NearbyPlace{{
+				CollectionPlace: models.CollectionPlace{Address: "15 Falls Rd", FormattedAddress: "15 Falls Rd, Shelburne, VT 05482, USA", Lat: 44.380912, Lng: -73.227141},
+				DistanceM:       37,
+			}},
+		}
+
+		Apply(models.PrivacyPolicy{CityLevel: true}, "key-1", result)
+
+		place := result.Places[0]
+		if place.Lat != 44.4 || place.Lng != -73.2 || place.Address != "" || place.FormattedAddress != "" {
+			t.Errorf("Expected a city-level place, got %+v", place)
+		}
+		// Both points round to the same cell, so the exact distance must not survive
+		if place.DistanceM != 0 {
+			t.Errorf("Expected the distance between the reduced points, got %v", place.DistanceM)
+		}
+	})
+
+	t.Run("empty policy", func(t *testing.T) {
+		result := &models.GeoIPAPIResponse{Lat: 37.386, Lng: -122.0838, PostalCode: "94035", RawBackendResponse: "raw"}
+		Apply(models.PrivacyPolicy{}, "key-1", result)
+		if result.Lat != 37.386 || result.PostalCode != "94035" || result.RawBackendResponse != "raw" {
+			t.Errorf("Expected an empty policy to leave the response alone, got %+v", result)
+		}
+	})
+}
+
+func TestApplyMessage(t *testing.T) {
+	message := &models.WebSocketMessage{Type: "geocode_request", Lat: 52.520008, Lng: 13.404954, Address: "Rathausstraße 15, Berlin"}
+	ApplyMessage(models.PrivacyPolicy{CityLevel: true}, "key-1", message)
+
+	if message.Lat != 52.5 || message.Lng != 13.4 || message.Address != "" {
+		t.Errorf("Expected a city-level map update without the address, got %+v", message)
+	}
+}
+
+// distanceMeters is the haversine distance between two points
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadius = 6371000.0
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
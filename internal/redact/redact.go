@@ -0,0 +1,204 @@
+// Package redact strips personal data from activity log entries and dashboard updates before they
+// are stored or broadcast. Query texts are addresses, coordinates or IP addresses, and each mode
+// reduces them differently; see Mode.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hackclub/geocoder/internal/addressparser"
+)
+
+// Mode is how much of a query text or IP address is kept
+type Mode string
+
+const (
+	// Full keeps everything as it was sent
+	Full Mode = "full"
+	// Truncated drops the first address line and masks digits, rounds coordinates to 0.01° and
+	// zeroes IP addresses down to their /24 or /48 network
+	Truncated Mode = "truncated"
+	// Hashed replaces query texts and IP addresses with a keyed hash, which can still be matched
+	// for erasure but not read back, and rounds live map points to 0.01°
+	Hashed Mode = "hashed"
+	// City reduces addresses to their city, state and country and coordinates to 0.1°. IP
+	// addresses have no city without a lookup, so they are truncated.
+	City Mode = "city"
+)
+
+// Placeholder stands in for a query text with nothing left to show
+const Placeholder = "[redacted]"
+
+// hashPrefix marks hashed values so they aren't mistaken for query texts
+const hashPrefix = "hash:"
+
+var coordinatesPattern = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s*,\s*(-?\d+(?:\.\d+)?)\s*$`)
+
+// ParseMode reads a mode name; an empty name is Full
+func ParseMode(name string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case "":
+		return Full, nil
+	case Full, Truncated, Hashed, City:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown redaction mode %q: use full, truncated, hashed or city", name)
+	}
+}
+
+// Redactor applies a mode. The zero value and a nil Redactor keep everything.
+type Redactor struct {
+	mode   Mode
+	secret []byte
+}
+
+// New returns a Redactor for a mode. Hashes are keyed with secret so that short values such as IPv4
+// addresses can't be found by hashing every candidate; without one, a random key is used and
+// hashes can't be matched after a restart.
+func New(mode Mode, secret string) *Redactor {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &Redactor{mode: mode, secret: key}
+}
+
+// Mode returns the redactor's mode
+func (r *Redactor) Mode() Mode {
+	if r == nil || r.mode == "" {
+		return Full
+	}
+	return r.mode
+}
+
+// Query redacts a logged query text. Texts made of several values, such as "address / ip" for
+// consistency checks, have each value redacted separately.
+func (r *Redactor) Query(text string) string {
+	if r.Mode() == Full || text == "" {
+		return text
+	}
+
+	parts := strings.Split(text, " / ")
+	for i, part := range parts {
+		parts[i] = r.value(part)
+	}
+	return strings.Join(parts, " / ")
+}
+
+// value redacts a single address, pair of coordinates or IP address
+func (r *Redactor) value(text string) string {
+	if ip := net.ParseIP(strings.TrimSpace(text)); ip != nil {
+		return r.IP(text)
+	}
+
+	if match := coordinatesPattern.FindStringSubmatch(text); match != nil && r.mode != Hashed {
+		lat, _ := strconv.ParseFloat(match[1], 64)
+		lng, _ := strconv.ParseFloat(match[2], 64)
+		lat, lng = r.Point(lat, lng)
+		return strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lng, 'f', -1, 64)
+	}
+
+	switch r.mode {
+	case Hashed:
+		return r.Hash(text)
+	case City:
+		parsed := addressparser.Parse(text)
+		return orPlaceholder(joinNonEmpty(", ", parsed.City, parsed.State, parsed.Country))
+	default:
+		return orPlaceholder(truncateAddress(text))
+	}
+}
+
+// IP redacts an IP address. Anything that isn't one is redacted as a query text.
+func (r *Redactor) IP(value string) string {
+	if r.Mode() == Full || value == "" {
+		return value
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		return r.Query(value)
+	}
+	if r.mode == Hashed {
+		return r.Hash(ip.String())
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// Point reduces a live map point: to 0.1° in City mode and to 0.01° in Truncated and Hashed modes
+func (r *Redactor) Point(lat, lng float64) (float64, float64) {
+	var scale float64
+	switch r.Mode() {
+	case City:
+		scale = 10
+	case Truncated, Hashed:
+		scale = 100
+	default:
+		return lat, lng
+	}
+	return math.Round(lat*scale) / scale, math.Round(lng*scale) / scale
+}
+
+// Hash returns the keyed hash a value is stored as in Hashed mode. Case and spacing don't change
+// it, and IP addresses are hashed in their canonical form.
+func (r *Redactor) Hash(value string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(value), " "))
+	if ip := net.ParseIP(normalized); ip != nil {
+		normalized = ip.String()
+	}
+	secret := []byte(nil)
+	if r != nil {
+		secret = r.secret
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(normalized))
+	return hashPrefix + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// truncateAddress drops the first line of an address, which holds the street and house number, and
+// masks the digits left in postal codes and unit numbers
+func truncateAddress(text string) string {
+	lines := strings.Split(text, ",")
+	if len(lines) > 1 {
+		lines = lines[1:]
+	}
+	truncated := strings.TrimSpace(strings.Join(lines, ","))
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return '#'
+		}
+		return r
+	}, truncated)
+}
+
+// joinNonEmpty joins the values that aren't empty
+func joinNonEmpty(separator string, values ...string) string {
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return strings.Join(kept, separator)
+}
+
+// orPlaceholder returns the placeholder for a value with nothing left
+func orPlaceholder(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return Placeholder
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		mode Mode
+		text string
+		want string
+	}{
+		{Full, "1600 Amphitheatre Parkway, Mountain View, CA 94043", "1600 Amphitheatre Parkway, Mountain View, CA 94043"},
+		{Truncated, "1600 Amphitheatre Parkway, Mountain View, CA 94043", "Mountain View, CA #####"},
+		{Truncated, "52.520008,13.404954", "52.52,13.4"},
+		{Truncated, "203.0.113.42", "203.0.113.0"},
+		{City, "52.520008,13.404954", "52.5,13.4"},
+		{City, "2001:db8:1234:5678::1", "2001:db8:1234::"},
+		{Truncated, "Berlin / 203.0.113.42", "Berlin / 203.0.113.0"},
+		{City, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+" "+tt.text, func(t *testing.T) {
+			if got := New(tt.mode, "secret").Query(tt.text); got != tt.want {
+				t.Errorf("Query(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryCity(t *testing.T) {
+	got := New(City, "secret").Query("1600 Amphitheatre Parkway, Mountain View, CA 94043, USA")
+	if strings.Contains(got, "1600") || strings.Contains(got, "94043") || !strings.Contains(got, "Mountain View") {
+		t.Errorf("Expected only the city and region to be kept, got %q", got)
+	}
+}
+
+func TestHash(t *testing.T) {
+	r := New(Hashed, "secret")
+
+	hashed := r.Query("1600 Amphitheatre Parkway, Mountain View")
+	if !strings.HasPrefix(hashed, "hash:") || strings.Contains(hashed, "Amphitheatre") {
+		t.Fatalf("Expected a hashed query text, got %q", hashed)
+	}
+	// Erasure hashes the address it is given, which may be spelled differently
+	if again := r.Hash("  1600 amphitheatre   PARKWAY, Mountain View "); again != hashed {
+		t.Errorf("Expected case and spacing not to change the hash, got %q and %q", hashed, again)
+	}
+	if r.IP("2001:DB8::1") != r.Hash("2001:db8:0::1") {
+		t.Error("Expected IP addresses to be hashed in canonical form")
+	}
+	if New(Hashed, "other").Hash("203.0.113.42") == r.Hash("203.0.113.42") {
+		t.Error("Expected different secrets to give different hashes")
+	}
+
+	// Each part of a composite query text is hashed on its own, so either can be erased
+	composite := r.Query("Berlin / 203.0.113.42")
+	if composite != r.Hash("Berlin")+" / "+r.Hash("203.0.113.42") {
+		t.Errorf("Expected each part hashed separately, got %q", composite)
+	}
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+	if r.Query("Berlin") != "Berlin" || r.IP("203.0.113.42") != "203.0.113.42" {
+		t.Error("Expected a nil redactor to keep everything")
+	}
+	if lat, lng := r.Point(52.520008, 13.404954); lat != 52.520008 || lng != 13.404954 {
+		t.Errorf("Expected a nil redactor to keep points, got %v, %v", lat, lng)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Mode
+		wantErr bool
+	}{
+		{"", Full, false},
+		{"full", Full, false},
+		{" Hashed ", Hashed, false},
+		{"city", City, false},
+		{"truncated", Truncated, false},
+		{"street", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMode(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseMode(%q) = %q, %v, want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,2 @@
+-- Drop header-only authentication setting
+ALTER TABLE api_keys DROP COLUMN IF EXISTS require_header_auth;
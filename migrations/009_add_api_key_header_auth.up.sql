@@ -0,0 +1,2 @@
+-- Allow API keys to refuse being sent in the query string, where they end up in access logs
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS require_header_auth BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+-- Drop offline request counts
+ALTER TABLE cost_tracking DROP COLUMN IF EXISTS offline_requests;
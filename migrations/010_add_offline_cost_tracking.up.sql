@@ -0,0 +1,2 @@
+-- Count requests answered from offline data, which cost nothing but should still show up in cost reports
+ALTER TABLE cost_tracking ADD COLUMN IF NOT EXISTS offline_requests INTEGER DEFAULT 0;
@@ -0,0 +1,5 @@
+-- Drop GeoNames gazetteer tables
+DROP TABLE IF EXISTS gazetteer_postal_codes;
+DROP TABLE IF EXISTS gazetteer_admin1;
+DROP TABLE IF EXISTS gazetteer_place_names;
+DROP TABLE IF EXISTS gazetteer_places;
@@ -0,0 +1,43 @@
+-- GeoNames gazetteer, loaded with cmd/gazetteer, for answering city and postal code queries without Google
+CREATE TABLE IF NOT EXISTS gazetteer_places (
+    geoname_id BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    ascii_name TEXT,
+    alternate_names TEXT,
+    lat DOUBLE PRECISION NOT NULL,
+    lng DOUBLE PRECISION NOT NULL,
+    feature_code VARCHAR(10),
+    country_code CHAR(2) NOT NULL,
+    admin1_code VARCHAR(20),
+    population BIGINT DEFAULT 0,
+    timezone VARCHAR(40)
+);
+
+-- Every lowercased name a place is known by, built from name, ascii_name and alternate_names on import
+CREATE TABLE IF NOT EXISTS gazetteer_place_names (
+    name_key TEXT NOT NULL,
+    geoname_id BIGINT NOT NULL REFERENCES gazetteer_places(geoname_id) ON DELETE CASCADE,
+    PRIMARY KEY (name_key, geoname_id)
+);
+
+-- First-level admin division names, keyed like GeoNames as "US.VT"
+CREATE TABLE IF NOT EXISTS gazetteer_admin1 (
+    code VARCHAR(30) PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS gazetteer_postal_codes (
+    id BIGSERIAL PRIMARY KEY,
+    country_code CHAR(2) NOT NULL,
+    postal_code VARCHAR(20) NOT NULL,
+    place_name TEXT,
+    admin1_name TEXT,
+    admin1_code VARCHAR(20),
+    admin2_name TEXT,
+    lat DOUBLE PRECISION NOT NULL,
+    lng DOUBLE PRECISION NOT NULL,
+    accuracy SMALLINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_gazetteer_places_country_code ON gazetteer_places(country_code);
+CREATE INDEX IF NOT EXISTS idx_gazetteer_postal_codes_lookup ON gazetteer_postal_codes(country_code, postal_code);
@@ -0,0 +1,2 @@
+-- Drop autonomous system lookup cache
+DROP TABLE IF EXISTS asn_cache;
@@ -0,0 +1,7 @@
+-- Autonomous system lookup cache, keyed by AS number
+CREATE TABLE asn_cache (
+    asn BIGINT PRIMARY KEY,
+    response_data JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX idx_asn_cache_created_at ON asn_cache(created_at);
@@ -0,0 +1,2 @@
+-- Drop place ID lookup cache
+DROP TABLE IF EXISTS place_cache;
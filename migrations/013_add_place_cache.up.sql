@@ -0,0 +1,9 @@
+-- Place ID lookup cache, keyed by place ID and response language. Entries expire after PLACE_CACHE_TTL_HOURS.
+CREATE TABLE place_cache (
+    place_id TEXT NOT NULL,
+    language VARCHAR(35) NOT NULL DEFAULT '',
+    response_data JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (place_id, language)
+);
+CREATE INDEX idx_place_cache_created_at ON place_cache(created_at);
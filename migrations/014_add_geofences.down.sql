@@ -0,0 +1,2 @@
+-- Drop geofences
+DROP TABLE IF EXISTS geofences;
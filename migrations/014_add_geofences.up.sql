@@ -0,0 +1,13 @@
+-- Geofences owned by API keys, grouped into named collections
+CREATE TABLE geofences (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    collection VARCHAR(100) NOT NULL DEFAULT 'default',
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    geometry JSONB NOT NULL,                -- GeoJSON Polygon or MultiPolygon, or a Point for circles
+    radius_m DOUBLE PRECISION NOT NULL DEFAULT 0,
+    properties JSONB,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX idx_geofences_api_key_collection ON geofences(api_key_id, collection);
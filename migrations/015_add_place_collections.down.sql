@@ -0,0 +1,3 @@
+-- Drop place collections
+DROP TABLE IF EXISTS collection_places;
+DROP TABLE IF EXISTS place_collections;
@@ -0,0 +1,24 @@
+-- Saved places grouped into collections owned by API keys, e.g. a team's clubs or venues
+CREATE TABLE place_collections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX idx_place_collections_api_key_id ON place_collections(api_key_id);
+
+CREATE TABLE collection_places (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    collection_id UUID NOT NULL REFERENCES place_collections(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    address TEXT NOT NULL DEFAULT '',           -- As given, when the place was geocoded
+    formatted_address TEXT NOT NULL DEFAULT '',
+    lat DOUBLE PRECISION NOT NULL,
+    lng DOUBLE PRECISION NOT NULL,
+    location POINT NOT NULL,                    -- (lng, lat), for the spatial index
+    properties JSONB,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX idx_collection_places_collection_id ON collection_places(collection_id);
+CREATE INDEX idx_collection_places_location ON collection_places USING GIST (location);
@@ -0,0 +1,5 @@
+-- Drop per-key privacy policies
+ALTER TABLE api_keys DROP COLUMN IF EXISTS privacy_city_level;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS privacy_suppress_street;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS privacy_jitter_meters;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS privacy_coordinate_decimals;
@@ -0,0 +1,5 @@
+-- Per-key limits on how precisely responses may locate someone, e.g. for apps used by minors
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS privacy_coordinate_decimals INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS privacy_jitter_meters INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS privacy_suppress_street BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS privacy_city_level BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,4 @@
+-- Restore INET IP addresses; hashed values can't be converted back and are dropped. The runner applies
+-- this before the up migration, while the column is still INET, whose text form carries a prefix length.
+ALTER TABLE activity_log ALTER COLUMN ip_address TYPE INET
+    USING CASE WHEN ip_address::text ~ '^[0-9a-fA-F.:]+(/[0-9]+)?$' THEN ip_address::text::inet ELSE NULL END;
@@ -0,0 +1,2 @@
+-- Redacted activity stores hashed or truncated IP addresses, which don't fit INET
+ALTER TABLE activity_log ALTER COLUMN ip_address TYPE TEXT USING host(ip_address);
@@ -0,0 +1,2 @@
+-- Drop geocode overrides
+DROP TABLE IF EXISTS geocode_overrides;
@@ -0,0 +1,13 @@
+-- Admin-managed geocoding results for addresses and places the provider gets wrong
+CREATE TABLE geocode_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    address_pattern TEXT NOT NULL DEFAULT '',   -- Normalized address; * matches any run of characters
+    place_id TEXT NOT NULL DEFAULT '',          -- Provider place ID whose results are replaced
+    lat DOUBLE PRECISION NOT NULL,
+    lng DOUBLE PRECISION NOT NULL,
+    formatted_address TEXT NOT NULL DEFAULT '',
+    admin_hierarchy JSONB NOT NULL DEFAULT '{}',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
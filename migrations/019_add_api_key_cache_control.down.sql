@@ -0,0 +1,2 @@
+-- Drop cache control permission
+ALTER TABLE api_keys DROP COLUMN IF EXISTS allow_cache_control;
@@ -0,0 +1,2 @@
+-- Keys allowed to force fresh lookups with cache=refresh or cache=bypass, which cost money
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allow_cache_control BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+-- Drop localized gazetteer place names
+DROP TABLE IF EXISTS gazetteer_localized_names;
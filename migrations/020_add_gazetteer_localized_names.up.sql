@@ -0,0 +1,7 @@
+-- Place names by language, loaded by cmd/gazetteer from GeoNames alternateNamesV2.txt, for localizing cities
+CREATE TABLE IF NOT EXISTS gazetteer_localized_names (
+    geoname_id BIGINT NOT NULL REFERENCES gazetteer_places(geoname_id) ON DELETE CASCADE,
+    language VARCHAR(10) NOT NULL, -- Lowercased, e.g. "de" or "zh-tw"
+    name TEXT NOT NULL,
+    PRIMARY KEY (geoname_id, language)
+);